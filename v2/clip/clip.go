@@ -0,0 +1,116 @@
+package clip
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"gitlab.com/gomidi/midi/v2/ump"
+)
+
+// Magic is the file header of a MIDI clip file.
+const Magic = "SMF2CLIP"
+
+// maxDelta is the maximal number of ticks of a delta clockstamp (20-bit)
+const maxDelta = 0xFFFFF
+
+// Event is an event of the clip sequence.
+type Event struct {
+	// Delta is the number of ticks since the previous event
+	Delta uint32
+
+	// Packet is the message
+	Packet ump.Packet
+}
+
+// Clip is a MIDI clip file.
+type Clip struct {
+	// TicksPerQuarterNote is the resolution of the delta clockstamps
+	TicksPerQuarterNote uint16
+
+	// Config contains the messages of the clip configuration header, apart from the
+	// delta clockstamps and ticks per quarter note
+	Config []ump.Packet
+
+	// Events are the events of the clip sequence, without the start of clip and end of clip messages
+	Events []Event
+
+	// EndDelta is the number of ticks between the last event and the end of the clip
+	EndDelta uint32
+}
+
+// New returns a clip with a resolution of 960 ticks per quarter note.
+func New() *Clip {
+	return &Clip{TicksPerQuarterNote: 960}
+}
+
+// Add adds the given packets to the clip. The first packet gets the given delta, the others a delta of 0.
+func (c *Clip) Add(delta uint32, packets ...ump.Packet) {
+	for _, p := range packets {
+		c.Events = append(c.Events, Event{Delta: delta, Packet: p})
+		delta = 0
+	}
+}
+
+// Ticks returns the number of ticks from the start to the end of the clip.
+func (c *Clip) Ticks() (ticks int64) {
+	for _, ev := range c.Events {
+		ticks += int64(ev.Delta)
+	}
+	return ticks + int64(c.EndDelta)
+}
+
+func (c Clip) String() string {
+	var bf bytes.Buffer
+	fmt.Fprintf(&bf, "#### MIDI clip (%v ticks per quarter note)\n", c.TicksPerQuarterNote)
+
+	for _, p := range c.Config {
+		fmt.Fprintf(&bf, "config: %s\n", p)
+	}
+
+	for _, ev := range c.Events {
+		fmt.Fprintf(&bf, "#%v %s\n", ev.Delta, ev.Packet)
+	}
+
+	fmt.Fprintf(&bf, "#%v end of clip\n", c.EndDelta)
+	return bf.String()
+}
+
+// ReadFile opens and reads the clip file with the given filename.
+func ReadFile(file string) (*Clip, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+	return ReadFrom(f)
+}
+
+// WriteFile writes the clip to the given filename.
+func (c *Clip) WriteFile(file string) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("writing clip file failed: could not create file %#v", file)
+	}
+
+	_, err = c.WriteTo(f)
+	f.Close()
+
+	if err != nil {
+		os.Remove(file)
+		return fmt.Errorf("writing to clip file %#v failed: %v", file, err)
+	}
+
+	return nil
+}
+
+// Bytes returns the bytes of the clip file.
+func (c *Clip) Bytes() ([]byte, error) {
+	var bf bytes.Buffer
+	_, err := c.WriteTo(&bf)
+	if err != nil {
+		return nil, err
+	}
+	return bf.Bytes(), nil
+}
@@ -0,0 +1,73 @@
+package clip
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/ump"
+)
+
+func TestWriteRead(t *testing.T) {
+	c := New()
+	c.TicksPerQuarterNote = 480
+	c.Config = append(c.Config, ump.MetadataText(0, ump.ProjectName, "demo")...)
+	c.Add(0, ump.Tempo(0, 120))
+	c.Add(0, ump.NoteOn(0, 1, 60, 0x8000))
+	c.Add(480, ump.NoteOff(0, 1, 60, 0))
+	c.Add(0x100010, ump.MIDI1(1, midi.ControlChange(2, 7, 100)))
+	c.EndDelta = 240
+
+	var bf bytes.Buffer
+	_, err := c.WriteTo(&bf)
+	if err != nil {
+		t.Fatalf("WriteTo returned error: %v", err)
+	}
+
+	got, err := ReadFrom(bytes.NewReader(bf.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadFrom returned error: %v", err)
+	}
+
+	if !reflect.DeepEqual(got, c) {
+		t.Errorf("ReadFrom() = \n%s\nwanted\n%s", got, c)
+	}
+
+	if got.Ticks() != 480+0x100010+240 {
+		t.Errorf("Ticks() = %v", got.Ticks())
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	var header bytes.Buffer
+	header.WriteString(Magic)
+	header.Write(ump.DeltaClockstamp(0).Bytes())
+	header.Write(ump.DeltaClockstampTPQ(96).Bytes())
+
+	noTPQ := append([]byte(Magic), ump.DeltaClockstamp(0).Bytes()...)
+	noTPQ = append(noTPQ, ump.StartOfClip().Bytes()...)
+
+	noEnd := append([]byte{}, header.Bytes()...)
+	noEnd = append(noEnd, ump.StartOfClip().Bytes()...)
+	noEnd = append(noEnd, ump.DeltaClockstamp(10).Bytes()...)
+	noEnd = append(noEnd, ump.NoteOn(0, 0, 60, 100).Bytes()...)
+
+	tests := []struct {
+		data     []byte
+		expected error
+	}{
+		{[]byte("MThd"), ErrNoClip},
+		{header.Bytes(), ErrMissingStartOfClip},
+		{noTPQ, ErrMissingTicksPerQuarterNote},
+		{noEnd, ErrMissingEndOfClip},
+	}
+
+	for i, test := range tests {
+		_, err := ReadFrom(bytes.NewReader(test.data))
+
+		if err != test.expected {
+			t.Errorf("[%v] ReadFrom() returned error %v; wanted %v", i, err, test.expected)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package clip
+
+import (
+	"bytes"
+
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/internal/utils"
+	"gitlab.com/gomidi/midi/v2/smf"
+	"gitlab.com/gomidi/midi/v2/ump"
+)
+
+// tonics of the major and minor keys, indexed by the number of sharps (positive) or flats (negative) + 7
+var (
+	majorTonics = [15]uint8{
+		ump.TonicC, ump.TonicG, ump.TonicD, ump.TonicA, ump.TonicE, ump.TonicB, ump.TonicF, ump.TonicC,
+		ump.TonicG, ump.TonicD, ump.TonicA, ump.TonicE, ump.TonicB, ump.TonicF, ump.TonicC,
+	}
+
+	minorTonics = [15]uint8{
+		ump.TonicA, ump.TonicE, ump.TonicB, ump.TonicF, ump.TonicC, ump.TonicG, ump.TonicD, ump.TonicA,
+		ump.TonicE, ump.TonicB, ump.TonicF, ump.TonicC, ump.TonicG, ump.TonicD, ump.TonicA,
+	}
+)
+
+// FromSMF converts the given SMF to a clip. The tracks of the SMF are merged into a single sequence
+// (see smf.SMF.ConvertToSMF0) and all messages are put into group 0.
+// The protocol (ump.ProtocolMIDI1 or ump.ProtocolMIDI2) defines, if the channel messages are kept as MIDI 1.0
+// channel voice messages or translated to MIDI 2.0 channel voice messages (see ump.Translator). A stream
+// configuration notification for the protocol becomes part of the clip configuration header.
+// Tempo, time signature and key signature meta messages are converted to the corresponding flex data messages,
+// copyright, track name, text and lyric meta messages to flex data text messages. Other meta messages are dropped.
+// The SMF must have a metric time format.
+func FromSMF(s *smf.SMF, protocol uint8) (*Clip, error) {
+	ticks, isMetric := s.TimeFormat.(smf.MetricTicks)
+	if !isMetric {
+		return nil, errTimeCode
+	}
+
+	c := &Clip{
+		TicksPerQuarterNote: ticks.Resolution(),
+		Config:              []ump.Packet{ump.StreamConfigNotification(protocol, false, false)},
+	}
+
+	src := s.ConvertToSMF0()
+	if len(src.Tracks) == 0 {
+		return c, nil
+	}
+
+	var tr ump.Translator
+	var delta uint32
+
+	for _, ev := range src.Tracks[0] {
+		delta += ev.Delta
+		msg := ev.Message
+
+		if msg.Is(smf.MetaEndOfTrackMsg) {
+			break
+		}
+
+		var packets []ump.Packet
+		if msg.IsMeta() {
+			packets = metaToFlex(msg)
+		} else if protocol == ump.ProtocolMIDI2 {
+			packets = tr.ToMIDI2(0, midi.Message(msg))
+		} else {
+			packets = ump.FromMIDI1(0, midi.Message(msg))
+		}
+
+		if len(packets) > 0 {
+			c.Add(delta, packets...)
+			delta = 0
+		}
+	}
+
+	c.EndDelta = delta
+	return c, nil
+}
+
+// metaToFlex returns the flex data messages for the given meta message
+func metaToFlex(msg smf.Message) []ump.Packet {
+	var bpm float64
+	var num, denom, demiSemiQuavers uint8
+	var isMajor, isFlat bool
+	var text string
+
+	switch {
+	case msg.GetMetaTempo(&bpm):
+		return []ump.Packet{ump.Tempo(0, bpm)}
+	case msg.GetMetaTimeSig(&num, &denom, nil, &demiSemiQuavers):
+		return []ump.Packet{ump.TimeSignature(0, num, denom, demiSemiQuavers)}
+	case msg.GetMetaKeySig(nil, &num, &isMajor, &isFlat):
+		sf := int8(num)
+		if isFlat {
+			sf = -sf
+		}
+
+		var tonic uint8
+		if sf >= -7 && sf <= 7 {
+			tonic = minorTonics[sf+7]
+			if isMajor {
+				tonic = majorTonics[sf+7]
+			}
+		}
+		return []ump.Packet{ump.KeySignature(0, sf, tonic)}
+	case msg.GetMetaCopyright(&text):
+		return ump.MetadataText(0, ump.CopyrightNotice, text)
+	case msg.GetMetaTrackName(&text):
+		return ump.MetadataText(0, ump.ClipName, text)
+	case msg.GetMetaText(&text):
+		return ump.MetadataText(0, ump.UnknownText, text)
+	case msg.GetMetaLyric(&text):
+		return ump.PerformanceText(0, ump.Lyrics, text)
+	default:
+		return nil
+	}
+}
+
+// ToSMF converts the clip to a SMF of format 0. The groups of the messages are ignored.
+// The MIDI 2.0 channel voice messages are translated to MIDI 1.0 messages (see ump.Translator).
+// Tempo, time signature, key signature and text flex data messages are converted to the corresponding meta messages.
+// Messages that have no representation within a SMF (e.g. system real time messages) are dropped.
+// Only the text messages of the clip configuration header are converted.
+func (c *Clip) ToSMF() *smf.SMF {
+	s := smf.New()
+	s.TimeFormat = smf.MetricTicks(c.TicksPerQuarterNote)
+
+	var conv converter
+	var tr smf.Track
+
+	for _, p := range c.Config {
+		if p.IsOneOf(ump.MetadataTextMsg, ump.PerformanceTextMsg) {
+			tr.Add(0, conv.messages(p)...)
+		}
+	}
+
+	var delta uint32
+
+	for _, ev := range c.Events {
+		delta += ev.Delta
+		msgs := conv.messages(ev.Packet)
+
+		if len(msgs) > 0 {
+			tr.Add(delta, msgs...)
+			delta = 0
+		}
+	}
+
+	tr.Close(delta + c.EndDelta)
+	s.Add(tr)
+	return s
+}
+
+// converter converts packets to SMF messages
+type converter struct {
+	translator ump.Translator
+	text       bytes.Buffer
+}
+
+// messages returns the SMF messages for the given packet
+func (c *converter) messages(p ump.Packet) (msgs [][]byte) {
+	var bpm float64
+	var num, denom, demiSemiQuavers, tonic, format, bank, status uint8
+	var sf int8
+	var text string
+
+	switch {
+	case p.GetTempo(nil, &bpm):
+		return [][]byte{smf.MetaTempo(bpm)}
+	case p.GetTimeSignature(nil, &num, &denom, &demiSemiQuavers):
+		return [][]byte{smf.MetaTimeSig(num, denom, 0, demiSemiQuavers)}
+	case p.GetKeySignature(nil, &sf, &tonic):
+		isMajor := sf < -7 || sf > 7 || minorTonics[sf+7] != tonic
+		var mode uint8
+		if !isMajor {
+			mode = 1
+		}
+
+		n := sf
+		if n < 0 {
+			n = -n
+		}
+		return [][]byte{smf.MetaKey(utils.KeyFromSharpsOrFlats(sf, mode), isMajor, uint8(n), sf < 0)}
+	case p.GetFlexText(&format, &bank, &status, &text):
+		if format == 0x0 || format == 0x1 {
+			c.text.Reset()
+		}
+		c.text.WriteString(text)
+
+		if format == 0x1 || format == 0x2 {
+			return nil
+		}
+		return [][]byte{textMessage(bank, status, c.text.String())}
+	}
+
+	for _, msg := range c.translator.ToMIDI1(p) {
+		if msg.Is(midi.ChannelMsg) || msg.Is(midi.SysExMsg) {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs
+}
+
+// textMessage returns the meta message for the given flex data text
+func textMessage(bank, status uint8, text string) smf.Message {
+	switch {
+	case bank == ump.MetadataTextBank && status == ump.CopyrightNotice:
+		return smf.MetaCopyright(text)
+	case bank == ump.MetadataTextBank && status == ump.ClipName:
+		return smf.MetaTrackSequenceName(text)
+	case bank == ump.PerformanceTextBank && status == ump.Lyrics:
+		return smf.MetaLyric(text)
+	default:
+		return smf.MetaText(text)
+	}
+}
@@ -0,0 +1,84 @@
+package clip
+
+import (
+	"reflect"
+	"testing"
+
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/smf"
+	"gitlab.com/gomidi/midi/v2/ump"
+)
+
+func TestFromSMF(t *testing.T) {
+	s := smf.NewSMF1()
+	s.TimeFormat = smf.MetricTicks(96)
+
+	var tr0, tr1 smf.Track
+	tr0.Add(0, smf.MetaTrackSequenceName("song"))
+	tr0.Add(0, smf.MetaTempo(100))
+	tr0.Add(0, smf.MetaMeter(3, 4))
+	tr0.Add(0, smf.MetaKey(0, false, 3, true))
+	tr0.Close(0)
+
+	tr1.Add(10, midi.NoteOn(2, 60, 127))
+	tr1.Add(86, midi.NoteOff(2, 60))
+	tr1.Add(0, smf.MetaLyric("la"))
+	tr1.Close(96)
+	s.Add(tr0)
+	s.Add(tr1)
+
+	c, err := FromSMF(s, ump.ProtocolMIDI2)
+	if err != nil {
+		t.Fatalf("FromSMF returned error: %v", err)
+	}
+
+	expected := &Clip{
+		TicksPerQuarterNote: 96,
+		Config:              []ump.Packet{ump.StreamConfigNotification(ump.ProtocolMIDI2, false, false)},
+		EndDelta:            96,
+	}
+	expected.Add(0, ump.MetadataText(0, ump.ClipName, "song")...)
+	expected.Add(0, ump.Tempo(0, 100), ump.TimeSignature(0, 3, 4, 8), ump.KeySignature(0, -3, ump.TonicC))
+	expected.Add(10, ump.NoteOn(0, 2, 60, 0xFFFF))
+	expected.Add(86, ump.NoteOff(0, 2, 60, 0))
+	expected.Add(0, ump.PerformanceText(0, ump.Lyrics, "la")...)
+
+	if !reflect.DeepEqual(c, expected) {
+		t.Errorf("FromSMF() = \n%s\nwanted\n%s", c, expected)
+	}
+
+	_, err = FromSMF(&smf.SMF{TimeFormat: smf.SMPTE25(40)}, ump.ProtocolMIDI1)
+	if err == nil {
+		t.Errorf("FromSMF must return an error for time code")
+	}
+}
+
+func TestToSMF(t *testing.T) {
+	c := New()
+	c.Config = ump.MetadataText(0, ump.CopyrightNotice, "a copyright that is longer than a single packet")
+	c.Add(0, ump.Tempo(0, 140), ump.KeySignature(0, 2, ump.TonicB))
+	c.Add(0, ump.ProgramChangeWithBank(0, 1, 5, 0, 1))
+	c.Add(0, ump.MIDI1(0, midi.TimingClock()))
+	c.Add(960, ump.NoteOn(0, 1, 60, 0x8000))
+	c.Add(960, ump.NoteOff(0, 1, 60, 0x8000))
+	c.Add(0, ump.PerNotePitchBend(0, 1, 60, ump.PitchCenter))
+	c.EndDelta = 10
+
+	s := c.ToSMF()
+
+	var expected smf.Track
+	expected.Add(0, smf.MetaCopyright("a copyright that is longer than a single packet"))
+	expected.Add(0, smf.MetaTempo(140), smf.MetaKey(11, false, 2, false))
+	expected.Add(0, midi.ControlChange(1, 0, 0), midi.ControlChange(1, 32, 1), midi.ProgramChange(1, 5))
+	expected.Add(960, midi.NoteOn(1, 60, 64))
+	expected.Add(960, midi.NoteOffVelocity(1, 60, 64))
+	expected.Close(10)
+
+	if s.TimeFormat != smf.MetricTicks(960) {
+		t.Errorf("TimeFormat = %v; wanted %v", s.TimeFormat, smf.MetricTicks(960))
+	}
+
+	if len(s.Tracks) != 1 || !reflect.DeepEqual(s.Tracks[0], expected) {
+		t.Errorf("ToSMF() = %v; wanted track %v", s, expected)
+	}
+}
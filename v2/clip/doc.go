@@ -0,0 +1,18 @@
+// Copyright (c) 2022 Marc René Arns. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+/*
+Package clip helps with reading and writing of MIDI Clip Files (the MIDI 2.0 file format with the "SMF2CLIP" header).
+
+A clip file consists of the file header, a clip configuration header and the clip sequence. All messages are
+Universal MIDI Packets (see package ump) and each message is preceded by a delta clockstamp. The ticks per quarter note
+of the delta clockstamps are defined in the clip configuration header.
+
+A Clip has the ticks per quarter note, the further configuration messages and the events of the sequence, each with
+its delta in ticks to the previous event. The delta clockstamps, the start of clip and end of clip messages are
+handled while reading and writing.
+
+A Clip can be converted to a SMF (ToSMF) and a SMF to a Clip (FromSMF).
+*/
+package clip
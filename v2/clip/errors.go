@@ -0,0 +1,19 @@
+package clip
+
+import "errors"
+
+var (
+	// ErrNoClip is returned, if the data does not start with the file header of a clip file
+	ErrNoClip = errors.New("missing clip file header " + Magic)
+
+	// ErrMissingTicksPerQuarterNote is returned, if the clip configuration header has no ticks per quarter note
+	ErrMissingTicksPerQuarterNote = errors.New("missing delta clockstamp ticks per quarter note in clip header")
+
+	// ErrMissingStartOfClip is returned, if the data ends before the start of clip message
+	ErrMissingStartOfClip = errors.New("missing start of clip")
+
+	// ErrMissingEndOfClip is returned, if the data ends before the end of clip message
+	ErrMissingEndOfClip = errors.New("missing end of clip")
+
+	errTimeCode = errors.New("clip files require metric ticks, time code is not supported")
+)
@@ -0,0 +1,76 @@
+package clip
+
+import (
+	"bufio"
+	"io"
+
+	"gitlab.com/gomidi/midi/v2/ump"
+)
+
+// ReadFrom reads a clip from the given reader.
+// The delta clockstamps of the clip sequence are summed up to the delta of the following event, NOOP messages
+// are skipped. Messages after the end of clip are ignored.
+func ReadFrom(rd io.Reader) (*Clip, error) {
+	r := bufio.NewReader(rd)
+
+	var magic [len(Magic)]byte
+	_, err := io.ReadFull(r, magic[:])
+	if err != nil || string(magic[:]) != Magic {
+		return nil, ErrNoClip
+	}
+
+	c := &Clip{}
+	var hasTPQ bool
+
+	// clip configuration header
+	for {
+		p, err := ump.ReadPacket(r)
+		if err == io.EOF {
+			return nil, ErrMissingStartOfClip
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case p.GetDeltaClockstampTPQ(&c.TicksPerQuarterNote):
+			hasTPQ = true
+		case p.IsOneOf(ump.DeltaClockstampMsg, ump.NOOPMsg):
+			// the delta clockstamps of the header have no meaning
+		case p.Is(ump.StartOfClipMsg):
+			if !hasTPQ {
+				return nil, ErrMissingTicksPerQuarterNote
+			}
+			return c, c.readSequence(r)
+		default:
+			c.Config = append(c.Config, p)
+		}
+	}
+}
+
+// readSequence reads the clip sequence until the end of clip
+func (c *Clip) readSequence(r io.Reader) error {
+	var delta, ticks uint32
+
+	for {
+		p, err := ump.ReadPacket(r)
+		if err == io.EOF {
+			return ErrMissingEndOfClip
+		}
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case p.GetDeltaClockstamp(&ticks):
+			delta += ticks
+		case p.Is(ump.NOOPMsg):
+		case p.Is(ump.EndOfClipMsg):
+			c.EndDelta = delta
+			return nil
+		default:
+			c.Events = append(c.Events, Event{Delta: delta, Packet: p})
+			delta = 0
+		}
+	}
+}
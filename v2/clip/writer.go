@@ -0,0 +1,64 @@
+package clip
+
+import (
+	"io"
+
+	"gitlab.com/gomidi/midi/v2/ump"
+)
+
+type writer struct {
+	output io.Writer
+	size   int64
+	err    error
+}
+
+func (w *writer) write(p ump.Packet) {
+	if w.err != nil {
+		return
+	}
+
+	var n int
+	n, w.err = w.output.Write(p.Bytes())
+	w.size += int64(n)
+}
+
+// writeDelta writes the delta clockstamps for the given delta.
+// Deltas that don't fit into a single delta clockstamp are split, using NOOP messages in between.
+func (w *writer) writeDelta(delta uint32) {
+	for delta > maxDelta {
+		w.write(ump.DeltaClockstamp(maxDelta))
+		w.write(ump.NOOP())
+		delta -= maxDelta
+	}
+	w.write(ump.DeltaClockstamp(delta))
+}
+
+// WriteTo writes the clip file to the given writer.
+func (c *Clip) WriteTo(wr io.Writer) (size int64, err error) {
+	w := &writer{output: wr}
+
+	var n int
+	n, w.err = io.WriteString(wr, Magic)
+	w.size += int64(n)
+
+	w.writeDelta(0)
+	w.write(ump.DeltaClockstampTPQ(c.TicksPerQuarterNote))
+
+	for _, p := range c.Config {
+		w.writeDelta(0)
+		w.write(p)
+	}
+
+	w.writeDelta(0)
+	w.write(ump.StartOfClip())
+
+	for _, ev := range c.Events {
+		w.writeDelta(ev.Delta)
+		w.write(ev.Packet)
+	}
+
+	w.writeDelta(c.EndDelta)
+	w.write(ump.EndOfClip())
+
+	return w.size, w.err
+}
@@ -0,0 +1,366 @@
+package clocksync
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/drivers"
+)
+
+// State is the transport state of the external clock.
+type State int
+
+const (
+	Stopped State = iota
+	Playing
+)
+
+func (s State) String() string {
+	if s == Playing {
+		return "playing"
+	}
+	return "stopped"
+}
+
+// Position is the song position in timing clocks (24 per quarter note) since the start of the song.
+type Position int64
+
+// Quarters returns the number of quarter notes since the start of the song.
+func (p Position) Quarters() int64 {
+	return int64(p) / 24
+}
+
+// Sixteenths returns the number of sixteenth notes (MIDI beats, as used by the song position pointer)
+// since the start of the song.
+func (p Position) Sixteenths() int64 {
+	return int64(p) / 6
+}
+
+// String returns the position as quarter notes, sixteenth notes within the quarter note and timing clocks within
+// the sixteenth note, e.g. 4.2.3
+func (p Position) String() string {
+	return fmt.Sprintf("%v.%v.%v", p.Quarters(), p.Sixteenths()%4, int64(p)%6)
+}
+
+// Option is an option for a Follower.
+type Option func(*Follower)
+
+// Smoothing sets the number of timing clock intervals that are averaged for the tempo estimate (default: 24, i.e.
+// a quarter note).
+func Smoothing(n int) Option {
+	return func(f *Follower) {
+		if n > 0 {
+			f.smoothing = n
+		}
+	}
+}
+
+// Jitter sets the maximal deviation of a timing clock interval from the average interval, as fraction of the average
+// interval (default: 0.5). Intervals with a larger deviation are ignored, unless 3 of them follow each other, which
+// resets the tempo estimate.
+func Jitter(tolerance float64) Option {
+	return func(f *Follower) {
+		if tolerance > 0 {
+			f.tolerance = tolerance
+		}
+	}
+}
+
+// Timeout sets the time after the last timing clock after which the clock is considered lost (default: 500ms).
+func Timeout(d time.Duration) Option {
+	return func(f *Follower) {
+		if d > 0 {
+			f.timeout = d
+		}
+	}
+}
+
+// OnBeat sets a callback that is called for each timing clock at the start of a quarter note while playing,
+// with the position and the tempo estimate in BPM (which is 0 if not yet known).
+func OnBeat(fn func(pos Position, bpm float64)) Option {
+	return func(f *Follower) {
+		f.onBeat = fn
+	}
+}
+
+// OnPosition sets a callback that is called, when the position is set by a song position pointer or a start message.
+func OnPosition(fn func(pos Position)) Option {
+	return func(f *Follower) {
+		f.onPosition = fn
+	}
+}
+
+// OnTransport sets a callback that is called, when the transport state changes.
+func OnTransport(fn func(s State)) Option {
+	return func(f *Follower) {
+		f.onTransport = fn
+	}
+}
+
+// OnDropout sets a callback that is called, when no timing clock has been received within the timeout.
+// If the state was Playing, the state changes to Stopped before.
+func OnDropout(fn func()) Option {
+	return func(f *Follower) {
+		f.onDropout = fn
+	}
+}
+
+// Follower follows an external MIDI clock.
+// The callbacks are called from different goroutines, but never concurrently. They may call the methods of the
+// Follower. Its methods may be called from different goroutines.
+type Follower struct {
+	smoothing   int
+	tolerance   float64
+	timeout     time.Duration
+	onBeat      func(Position, float64)
+	onPosition  func(Position)
+	onTransport func(State)
+	onDropout   func()
+	stop        func()
+
+	cbMu sync.Mutex
+
+	mu        sync.Mutex
+	closed    bool
+	state     State
+	pos       Position
+	waiting   bool
+	last      time.Time
+	intervals []time.Duration
+	next      int
+	sum       time.Duration
+	outliers  int
+	timer     *time.Timer
+}
+
+// NewFollower returns a Follower that listens on the given in port. The port is opened, if needed.
+func NewFollower(in drivers.In, opts ...Option) (*Follower, error) {
+	f := newFollower(opts...)
+
+	// the drivers only pass the timing clock through, if time code is used
+	stop, err := midi.ListenTo(in, func(msg midi.Message, timestampms int32) {
+		f.receive(msg, time.Now())
+	}, midi.UseTimeCode())
+	if err != nil {
+		return nil, err
+	}
+
+	f.stop = stop
+	return f, nil
+}
+
+func newFollower(opts ...Option) *Follower {
+	f := &Follower{
+		smoothing: 24,
+		tolerance: 0.5,
+		timeout:   500 * time.Millisecond,
+		waiting:   true,
+	}
+
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// Close stops listening.
+func (f *Follower) Close() {
+	f.mu.Lock()
+	f.closed = true
+	if f.timer != nil {
+		f.timer.Stop()
+	}
+	f.mu.Unlock()
+
+	if f.stop != nil {
+		f.stop()
+	}
+}
+
+// BPM returns the tempo estimate in beats (quarter notes) per minute. It returns 0, if the tempo is not known
+// (yet), e.g. after a dropout.
+func (f *Follower) BPM() float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.bpm()
+}
+
+// Position returns the position of the last timing clock or, if no timing clock has been received since the last
+// song position pointer or start message, the position that is played by the next timing clock.
+func (f *Follower) Position() Position {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.pos
+}
+
+// State returns the transport state.
+func (f *Follower) State() State {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.state
+}
+
+// bpm returns the tempo estimate. f.mu must be locked.
+func (f *Follower) bpm() float64 {
+	if len(f.intervals) == 0 || f.sum <= 0 {
+		return 0
+	}
+	avg := f.sum / time.Duration(len(f.intervals))
+	return float64(time.Minute) / float64(avg*24)
+}
+
+// resetTempo forgets the tempo estimate. f.mu must be locked.
+func (f *Follower) resetTempo() {
+	f.intervals = f.intervals[:0]
+	f.next = 0
+	f.sum = 0
+	f.outliers = 0
+}
+
+// addInterval adds a timing clock interval to the tempo estimate. f.mu must be locked.
+func (f *Follower) addInterval(d time.Duration) {
+	if len(f.intervals) < f.smoothing {
+		f.intervals = append(f.intervals, d)
+		f.sum += d
+		return
+	}
+	f.sum += d - f.intervals[f.next]
+	f.intervals[f.next] = d
+	f.next = (f.next + 1) % f.smoothing
+}
+
+// clock handles a timing clock that has been received at the given time. f.mu must be locked.
+func (f *Follower) clock(at time.Time) {
+	if !f.last.IsZero() {
+		d := at.Sub(f.last)
+		var isOutlier bool
+
+		if len(f.intervals) > 0 {
+			avg := f.sum / time.Duration(len(f.intervals))
+			dev := d - avg
+			if dev < 0 {
+				dev = -dev
+			}
+			isOutlier = float64(dev) > f.tolerance*float64(avg)
+		}
+
+		switch {
+		case !isOutlier:
+			f.outliers = 0
+			f.addInterval(d)
+		case f.outliers >= 2:
+			// the tempo has changed
+			f.resetTempo()
+			f.addInterval(d)
+		default:
+			f.outliers++
+		}
+	}
+	f.last = at
+
+	if f.timer == nil {
+		f.timer = time.AfterFunc(f.timeout, f.dropout)
+	} else {
+		f.timer.Reset(f.timeout)
+	}
+}
+
+// receive handles a message that has been received at the given time
+func (f *Follower) receive(msg midi.Message, at time.Time) {
+	var spp uint16
+	var events []func()
+
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		return
+	}
+
+	setState := func(s State) {
+		if f.state == s {
+			return
+		}
+		f.state = s
+		if f.onTransport != nil {
+			events = append(events, func() { f.onTransport(s) })
+		}
+	}
+
+	setPosition := func(p Position) {
+		f.pos = p
+		f.waiting = true
+		if f.onPosition != nil {
+			events = append(events, func() { f.onPosition(p) })
+		}
+	}
+
+	switch {
+	case msg.Is(midi.TimingClockMsg):
+		f.clock(at)
+		if f.state == Playing {
+			if f.waiting {
+				f.waiting = false
+			} else {
+				f.pos++
+			}
+			if f.pos%24 == 0 && f.onBeat != nil {
+				pos, bpm := f.pos, f.bpm()
+				events = append(events, func() { f.onBeat(pos, bpm) })
+			}
+		}
+	case msg.Is(midi.StartMsg):
+		setPosition(0)
+		setState(Playing)
+	case msg.Is(midi.ContinueMsg):
+		setState(Playing)
+	case msg.Is(midi.StopMsg):
+		setState(Stopped)
+	case msg.GetSPP(&spp):
+		setPosition(Position(spp) * 6)
+	}
+	f.mu.Unlock()
+
+	f.dispatch(events)
+}
+
+// dropout is called by the timer, if no timing clock has been received within the timeout
+func (f *Follower) dropout() {
+	var events []func()
+
+	f.mu.Lock()
+	if f.closed || f.last.IsZero() || time.Since(f.last) < f.timeout {
+		f.mu.Unlock()
+		return
+	}
+
+	f.last = time.Time{}
+	f.resetTempo()
+
+	if f.state == Playing {
+		f.state = Stopped
+		if f.onTransport != nil {
+			events = append(events, func() { f.onTransport(Stopped) })
+		}
+	}
+
+	if f.onDropout != nil {
+		events = append(events, f.onDropout)
+	}
+	f.mu.Unlock()
+
+	f.dispatch(events)
+}
+
+// dispatch calls the given callbacks
+func (f *Follower) dispatch(events []func()) {
+	if len(events) == 0 {
+		return
+	}
+	f.cbMu.Lock()
+	defer f.cbMu.Unlock()
+	for _, ev := range events {
+		ev()
+	}
+}
@@ -0,0 +1,166 @@
+package clocksync
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/drivers/testdrv"
+)
+
+// interval returns the timing clock interval for the given tempo
+func interval(bpm float64) time.Duration {
+	return time.Duration(float64(time.Minute) / (bpm * 24))
+}
+
+func TestTempo(t *testing.T) {
+	f := newFollower()
+	defer f.Close()
+
+	at := time.Now()
+	clock := func(d time.Duration) {
+		at = at.Add(d)
+		f.receive(midi.TimingClock(), at)
+	}
+
+	if got := f.BPM(); got != 0 {
+		t.Errorf("BPM() = %v before the first clocks; expected 0", got)
+	}
+
+	// 120 BPM with jitter
+	for i := 0; i < 48; i++ {
+		jitter := 2 * time.Millisecond
+		if i%2 == 0 {
+			jitter = -jitter
+		}
+		clock(interval(120) + jitter)
+	}
+
+	// a dropped timing clock is ignored
+	clock(2 * interval(120))
+	clock(interval(120))
+
+	if got := f.BPM(); math.Abs(got-120) > 0.5 {
+		t.Errorf("BPM() = %v; expected 120", got)
+	}
+
+	// a tempo change is followed after 3 intervals
+	for i := 0; i < 24; i++ {
+		clock(interval(60))
+	}
+
+	if got := f.BPM(); math.Abs(got-60) > 0.01 {
+		t.Errorf("BPM() = %v after tempo change; expected 60", got)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	var got []string
+
+	f := newFollower(
+		OnBeat(func(pos Position, bpm float64) {
+			got = append(got, fmt.Sprintf("beat %s %.0f", pos, bpm))
+		}),
+		OnPosition(func(pos Position) {
+			got = append(got, fmt.Sprintf("position %s", pos))
+		}),
+		OnTransport(func(s State) {
+			got = append(got, s.String())
+		}),
+	)
+	defer f.Close()
+
+	at := time.Now()
+	clocks := func(n int) {
+		for i := 0; i < n; i++ {
+			at = at.Add(interval(100))
+			f.receive(midi.TimingClock(), at)
+		}
+	}
+
+	// clocks while stopped only change the tempo
+	clocks(10)
+	// the song position pointer is sent LSB first: 01 00 is the first sixteenth note
+	f.receive(midi.Message{0xF2, 0x01, 0x00}, at)
+	if f.Position() != 6 {
+		t.Errorf("Position() = %v after SPP F2 01 00; expected 6", f.Position())
+	}
+
+	f.receive(midi.SPP(4), at)
+	if f.Position() != 24 {
+		t.Errorf("Position() = %v after SPP; expected 24", f.Position())
+	}
+
+	f.receive(midi.Continue(), at)
+	clocks(1 + 24 + 3)
+
+	if f.Position() != 51 {
+		t.Errorf("Position() = %v; expected 51", f.Position())
+	}
+
+	f.receive(midi.Stop(), at)
+	clocks(5)
+	f.receive(midi.Start(), at)
+	clocks(1)
+
+	expected := []string{
+		"position 0.1.0",
+		"position 1.0.0",
+		"playing",
+		"beat 1.0.0 100",
+		"beat 2.0.0 100",
+		"stopped",
+		"position 0.0.0",
+		"playing",
+		"beat 0.0.0 100",
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %q; expected %q", got, expected)
+	}
+}
+
+func TestDropout(t *testing.T) {
+	a := testdrv.New("clock")
+	ins, _ := a.Ins()
+	outs, _ := a.Outs()
+
+	dropout := make(chan State, 1)
+
+	var f *Follower
+	f, err := NewFollower(ins[0], Timeout(100*time.Millisecond), OnDropout(func() {
+		dropout <- f.State()
+	}))
+	if err != nil {
+		t.Fatalf("NewFollower() returned error: %v", err)
+	}
+	defer f.Close()
+
+	out := outs[0]
+	out.Open()
+	out.Send(midi.Start())
+	for i := 0; i < 3; i++ {
+		out.Send(midi.TimingClock())
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if f.State() != Playing || f.Position() != 2 || f.BPM() == 0 {
+		t.Errorf("state: %v position: %v bpm: %v; expected playing at 2 with a tempo", f.State(), f.Position(), f.BPM())
+	}
+
+	select {
+	case s := <-dropout:
+		if s != Stopped {
+			t.Errorf("State() = %v after dropout; expected stopped", s)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no dropout")
+	}
+
+	if f.BPM() != 0 {
+		t.Errorf("BPM() = %v after dropout; expected 0", f.BPM())
+	}
+}
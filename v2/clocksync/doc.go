@@ -0,0 +1,18 @@
+// Copyright (c) 2022 Marc René Arns. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+/*
+Package clocksync follows an external MIDI clock, e.g. of a hardware sequencer.
+
+A Follower listens on a MIDI in port for timing clock messages (24 per quarter note), song position pointers
+and the transport messages Start, Continue and Stop. It derives a smoothed tempo estimate from the intervals
+between the timing clocks and keeps track of the song position, counted in timing clocks.
+
+Intervals that deviate too much from the current estimate (jitter) are ignored, unless they repeat, which
+indicates a tempo change. If no timing clock is received within the timeout, the clock is considered lost.
+
+The callbacks (see OnBeat, OnPosition, OnTransport and OnDropout) allow to follow the external clock,
+e.g. to play or record in sync instead of using a fixed tempo.
+*/
+package clocksync
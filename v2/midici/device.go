@@ -0,0 +1,365 @@
+package midici
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/drivers"
+)
+
+// ErrTimeout is returned, if no reply has been received within the timeout.
+var ErrTimeout = errors.New("MIDI-CI reply timed out")
+
+// defaultRemoteSysExSize is the maximal system exclusive size that is assumed for devices that have not been discovered
+const defaultRemoteSysExSize = 128
+
+// Option is an option for a Device.
+type Option func(*Device)
+
+// UseMUID is an option to use the given MUID instead of a random one.
+func UseMUID(m MUID) Option {
+	return func(d *Device) {
+		d.muid = m
+	}
+}
+
+// Timeout is an option to set the time to wait for replies (default: 2 seconds).
+// Discover always waits for the whole timeout to collect the replies.
+func Timeout(t time.Duration) Option {
+	return func(d *Device) {
+		d.timeout = t
+	}
+}
+
+// MaxSysExSize is an option to set the maximal size of system exclusive messages that the device can receive (default: 1024).
+func MaxSysExSize(size uint32) Option {
+	return func(d *Device) {
+		d.maxSysExSize = size
+	}
+}
+
+// HandleProfile is an option to set a callback that is called, when a remote device wants to enable or disable a profile.
+// If the callback returns false, the request is rejected.
+func HandleProfile(fn func(p Profile, enable bool) (accept bool)) Option {
+	return func(d *Device) {
+		d.onProfile = fn
+	}
+}
+
+// HandleSetProperty is an option to set a callback that is called, when a remote device wants to set a property resource.
+// If the callback returns false, the request is rejected.
+func HandleSetProperty(fn func(resource string, data []byte) (accept bool)) Option {
+	return func(d *Device) {
+		d.onSetProperty = fn
+	}
+}
+
+type profileState struct {
+	profile Profile
+	enabled bool
+}
+
+type subscriber struct {
+	muid MUID
+	id   string
+}
+
+type subscriptionKey struct {
+	muid MUID
+	id   string
+}
+
+// waiter waits for messages that match
+type waiter struct {
+	match func(Message) bool
+	ch    chan Message
+}
+
+// Device is a MIDI-CI device that acts as initiator and responder.
+// Its methods may be called from different goroutines.
+type Device struct {
+	out           drivers.Out
+	identity      Identity
+	muid          MUID
+	maxSysExSize  uint32
+	timeout       time.Duration
+	onProfile     func(p Profile, enable bool) bool
+	onSetProperty func(resource string, data []byte) bool
+	stop          func()
+
+	sendMu sync.Mutex
+
+	mu            sync.Mutex
+	remotes       map[MUID]uint32
+	profiles      []profileState
+	resources     map[string][]byte
+	subscribers   map[string][]subscriber
+	subscriptions map[subscriptionKey]func(data []byte)
+	waiters       []*waiter
+	chunks        assembler
+	requestID     byte
+	subscribeID   int
+}
+
+// NewDevice returns a device with the given identity, that listens on the given in port and sends to the given out port.
+// The ports are opened, if needed.
+func NewDevice(in drivers.In, out drivers.Out, identity Identity, opts ...Option) (*Device, error) {
+	d := &Device{
+		out:           out,
+		identity:      identity,
+		maxSysExSize:  1024,
+		timeout:       2 * time.Second,
+		remotes:       map[MUID]uint32{},
+		resources:     map[string][]byte{},
+		subscribers:   map[string][]subscriber{},
+		subscriptions: map[subscriptionKey]func([]byte){},
+		chunks:        assembler{},
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	if d.muid == 0 {
+		d.muid = NewMUID()
+	}
+
+	if !out.IsOpen() {
+		err := out.Open()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	stop, err := midi.ListenTo(in, d.receive, midi.UseSysEx(), midi.SysExBufferSize(d.maxSysExSize))
+	if err != nil {
+		return nil, err
+	}
+
+	d.stop = stop
+	return d, nil
+}
+
+// MUID returns the MUID of the device.
+func (d *Device) MUID() MUID {
+	return d.muid
+}
+
+// Close invalidates the MUID of the device and stops listening.
+func (d *Device) Close() error {
+	err := d.send(InvalidateMUID{Header: d.header(Broadcast), Target: d.muid})
+	d.stop()
+	return err
+}
+
+// AddProfile adds the given profile to the profiles of the device.
+func (d *Device) AddProfile(p Profile, enabled bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for i, ps := range d.profiles {
+		if ps.profile == p {
+			d.profiles[i].enabled = enabled
+			return
+		}
+	}
+	d.profiles = append(d.profiles, profileState{p, enabled})
+}
+
+// ProfileEnabled returns true, if the given profile of the device is enabled.
+func (d *Device) ProfileEnabled(p Profile) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, ps := range d.profiles {
+		if ps.profile == p {
+			return ps.enabled
+		}
+	}
+	return false
+}
+
+// Resource returns the data of the given property resource of the device.
+func (d *Device) Resource(resource string) (data []byte, has bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	data, has = d.resources[resource]
+	return
+}
+
+// SetResource sets the data of the given property resource of the device and notifies the subscribers.
+func (d *Device) SetResource(resource string, data []byte) error {
+	return d.setResource(resource, data, 0)
+}
+
+// setResource sets the resource and notifies the subscribers except the given one
+func (d *Device) setResource(resource string, data []byte, except MUID) error {
+	d.mu.Lock()
+	d.resources[resource] = data
+	subscribers := append([]subscriber(nil), d.subscribers[resource]...)
+	d.mu.Unlock()
+
+	for _, s := range subscribers {
+		if s.muid == except {
+			continue
+		}
+
+		hd := PropertyHeader{Command: CommandFull, SubscribeID: s.id, Resource: resource}
+		err := d.sendChunks(Chunks(d.header(s.muid), PESubscription, d.nextRequestID(), hd, data, d.remoteSysExSize(s.muid)))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Device) header(destination MUID) Header {
+	return Header{DeviceID: FunctionBlock, Source: d.muid, Destination: destination}
+}
+
+func (d *Device) nextRequestID() byte {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.requestID = (d.requestID + 1) & 0x7F
+	return d.requestID
+}
+
+func (d *Device) remoteSysExSize(m MUID) uint32 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if size, has := d.remotes[m]; has && size > 0 {
+		return size
+	}
+	return defaultRemoteSysExSize
+}
+
+func (d *Device) send(m Message) error {
+	d.sendMu.Lock()
+	defer d.sendMu.Unlock()
+	return d.out.Send(m.SysEx())
+}
+
+func (d *Device) sendChunks(msgs []PropertyMessage) error {
+	for _, m := range msgs {
+		err := d.send(m)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addWaiter registers a waiter for messages that match
+func (d *Device) addWaiter(match func(Message) bool, buffer int) *waiter {
+	w := &waiter{match: match, ch: make(chan Message, buffer)}
+	d.mu.Lock()
+	d.waiters = append(d.waiters, w)
+	d.mu.Unlock()
+	return w
+}
+
+func (d *Device) removeWaiter(w *waiter) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for i, ww := range d.waiters {
+		if ww == w {
+			d.waiters = append(d.waiters[:i], d.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+// deliver passes the message to the first matching waiter
+func (d *Device) deliver(m Message) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, w := range d.waiters {
+		if w.match(m) {
+			select {
+			case w.ch <- m:
+			default:
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// request sends the given messages and waits for a reply that matches (or a NAK for the given sub-ID)
+func (d *Device) request(destination MUID, subID byte, match func(Message) bool, msgs ...Message) (Message, error) {
+	w := d.addWaiter(func(m Message) bool {
+		if nak, isNAK := m.(NAK); isNAK && nak.Source == destination && (nak.OriginalSubID == subID || nak.OriginalSubID == 0) {
+			return true
+		}
+		return m.CIHeader().Source == destination && match(m)
+	}, 1)
+	defer d.removeWaiter(w)
+
+	for _, m := range msgs {
+		err := d.send(m)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	select {
+	case m := <-w.ch:
+		if nak, isNAK := m.(NAK); isNAK {
+			return nil, nak
+		}
+		return m, nil
+	case <-time.After(d.timeout):
+		return nil, ErrTimeout
+	}
+}
+
+// receive handles the incoming messages
+func (d *Device) receive(msg midi.Message, timestampms int32) {
+	var data []byte
+	if !msg.GetSysEx(&data) {
+		return
+	}
+
+	m, err := Parse(data)
+	if err != nil {
+		return
+	}
+
+	h := m.CIHeader()
+	if h.Source == d.muid || (h.Destination != d.muid && h.Destination != Broadcast) {
+		return
+	}
+
+	switch v := m.(type) {
+	case PropertyMessage:
+		d.mu.Lock()
+		complete := d.chunks.add(v)
+		d.mu.Unlock()
+
+		if complete == nil {
+			return
+		}
+		m = *complete
+	case Discovery:
+		d.addRemote(v.Source, v.MaxSysExSize)
+	case DiscoveryReply:
+		d.addRemote(v.Source, v.MaxSysExSize)
+	}
+
+	if d.deliver(m) {
+		return
+	}
+
+	d.respond(m)
+}
+
+func (d *Device) addRemote(m MUID, maxSysExSize uint32) {
+	d.mu.Lock()
+	d.remotes[m] = maxSysExSize
+	d.mu.Unlock()
+}
@@ -0,0 +1,157 @@
+package midici
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"gitlab.com/gomidi/midi/v2/drivers/testdrv"
+)
+
+// newDevices returns an initiator and a responder that are connected via two test drivers
+func newDevices(t *testing.T) (initiator, responder *Device) {
+	a := testdrv.New("a")
+	b := testdrv.New("b")
+
+	aIns, _ := a.Ins()
+	aOuts, _ := a.Outs()
+	bIns, _ := b.Ins()
+	bOuts, _ := b.Outs()
+
+	var err error
+
+	// the responder listens to the messages the initiator sends to a and the other way round
+	responder, err = NewDevice(aIns[0], bOuts[0], Identity{Family: 1, Model: 2}, UseMUID(0x200), Timeout(50*time.Millisecond),
+		MaxSysExSize(128))
+	if err != nil {
+		t.Fatalf("NewDevice() returned error: %v", err)
+	}
+
+	initiator, err = NewDevice(bIns[0], aOuts[0], Identity{Family: 3}, UseMUID(0x100), Timeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewDevice() returned error: %v", err)
+	}
+
+	return
+}
+
+func TestDiscoveryAndProfiles(t *testing.T) {
+	initiator, responder := newDevices(t)
+
+	p1 := Profile{0x7E, 0x00, 0x00, 0x01, 0x01}
+	p2 := Profile{0x7E, 0x00, 0x00, 0x02, 0x01}
+	responder.AddProfile(p1, true)
+	responder.AddProfile(p2, false)
+
+	replies, err := initiator.Discover()
+	if err != nil {
+		t.Fatalf("Discover() returned error: %v", err)
+	}
+
+	if len(replies) != 1 || replies[0].Source != responder.MUID() || replies[0].Identity.Model != 2 || replies[0].MaxSysExSize != 128 {
+		t.Fatalf("Discover() = %+v", replies)
+	}
+
+	enabled, disabled, err := initiator.Profiles(responder.MUID())
+	if err != nil {
+		t.Fatalf("Profiles() returned error: %v", err)
+	}
+
+	if !reflect.DeepEqual(enabled, []Profile{p1}) || !reflect.DeepEqual(disabled, []Profile{p2}) {
+		t.Errorf("Profiles() = %v, %v", enabled, disabled)
+	}
+
+	err = initiator.SetProfile(responder.MUID(), p2, true)
+	if err != nil {
+		t.Fatalf("SetProfile() returned error: %v", err)
+	}
+
+	if !responder.ProfileEnabled(p2) {
+		t.Errorf("profile %s should be enabled", p2)
+	}
+
+	err = initiator.SetProfile(responder.MUID(), Profile{1, 2, 3, 4, 5}, true)
+	if _, isNAK := err.(NAK); !isNAK {
+		t.Errorf("SetProfile() of unknown profile returned %v; wanted NAK", err)
+	}
+
+	_, _, err = initiator.Profiles(0x300)
+	if err != ErrTimeout {
+		t.Errorf("Profiles() of unknown device returned %v; wanted %v", err, ErrTimeout)
+	}
+}
+
+func TestPropertyExchange(t *testing.T) {
+	initiator, responder := newDevices(t)
+
+	_, err := initiator.Discover()
+	if err != nil {
+		t.Fatalf("Discover() returned error: %v", err)
+	}
+
+	requests, err := initiator.PropertyExchangeCapabilities(responder.MUID())
+	if err != nil || requests != maxRequests {
+		t.Errorf("PropertyExchangeCapabilities() = %v, %v", requests, err)
+	}
+
+	// larger than the maximal sysex size of the responder
+	info := []byte(`{"manufacturer":"gomidi","family":"test","model":"responder","version":"1.0","serial":"0123456789abcdefghijklmnopqrstuvwxyz"}`)
+	responder.SetResource("DeviceInfo", info)
+
+	data, err := initiator.GetProperty(responder.MUID(), "DeviceInfo")
+	if err != nil {
+		t.Fatalf("GetProperty() returned error: %v", err)
+	}
+
+	if !bytes.Equal(data, info) {
+		t.Errorf("GetProperty() = %s; wanted %s", data, info)
+	}
+
+	_, err = initiator.GetProperty(responder.MUID(), "Unknown")
+	if err == nil {
+		t.Errorf("GetProperty() of unknown resource must return an error")
+	}
+
+	var notified [][]byte
+	unsubscribe, err := initiator.Subscribe(responder.MUID(), "ProgramList", func(data []byte) {
+		notified = append(notified, data)
+	})
+
+	if err == nil {
+		t.Errorf("Subscribe() of unknown resource must return an error")
+	}
+
+	responder.SetResource("ProgramList", []byte(`[]`))
+
+	unsubscribe, err = initiator.Subscribe(responder.MUID(), "ProgramList", func(data []byte) {
+		notified = append(notified, data)
+	})
+
+	if err != nil {
+		t.Fatalf("Subscribe() returned error: %v", err)
+	}
+
+	responder.SetResource("ProgramList", []byte(`[{"title":"piano"}]`))
+
+	err = initiator.SetProperty(responder.MUID(), "ProgramList", []byte(`[{"title":"organ"}]`))
+	if err != nil {
+		t.Fatalf("SetProperty() returned error: %v", err)
+	}
+
+	if got, _ := responder.Resource("ProgramList"); string(got) != `[{"title":"organ"}]` {
+		t.Errorf("Resource() = %s", got)
+	}
+
+	err = unsubscribe()
+	if err != nil {
+		t.Fatalf("unsubscribe() returned error: %v", err)
+	}
+
+	responder.SetResource("ProgramList", []byte(`[]`))
+
+	// the set property of the initiator is not notified to itself, changes after the unsubscription neither
+	if len(notified) != 1 || string(notified[0]) != `[{"title":"piano"}]` {
+		t.Errorf("notified = %q", notified)
+	}
+}
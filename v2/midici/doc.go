@@ -0,0 +1,18 @@
+// Copyright (c) 2022 Marc René Arns. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+/*
+Package midici implements MIDI Capability Inquiry (MIDI-CI) via Universal System Exclusive messages.
+
+The messages (discovery, profile configuration and property exchange) are typed structs that can be converted to
+system exclusive messages via their SysEx method and parsed via Parse.
+
+A Device is a MIDI-CI device that is connected to a drivers.In and drivers.Out. It acts as initiator
+(Discover, Profiles, SetProfile, GetProperty, SetProperty, Subscribe) and as responder for the profiles
+and property resources that have been added to it. Each device is identified by its MUID, which is generated randomly.
+
+Property data and headers are exchanged as JSON. Property data that is larger than the maximal system exclusive size of the
+receiver is split into chunks.
+*/
+package midici
@@ -0,0 +1,162 @@
+package midici
+
+import (
+	"time"
+)
+
+// Discover sends a discovery message to all devices and returns the replies that have been received within the timeout.
+func (d *Device) Discover() ([]DiscoveryReply, error) {
+	w := d.addWaiter(func(m Message) bool {
+		_, is := m.(DiscoveryReply)
+		return is
+	}, 128)
+	defer d.removeWaiter(w)
+
+	err := d.send(Discovery{
+		Header:       d.header(Broadcast),
+		Identity:     d.identity,
+		Categories:   CategoryProfiles | CategoryPropertyExchange,
+		MaxSysExSize: d.maxSysExSize,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	var replies []DiscoveryReply
+	timeout := time.After(d.timeout)
+
+	for {
+		select {
+		case m := <-w.ch:
+			replies = append(replies, m.(DiscoveryReply))
+		case <-timeout:
+			return replies, nil
+		}
+	}
+}
+
+// Profiles returns the enabled and disabled profiles of the given device.
+func (d *Device) Profiles(destination MUID) (enabled, disabled []Profile, err error) {
+	m, err := d.request(destination, subProfileInquiry, func(m Message) bool {
+		_, is := m.(ProfileInquiryReply)
+		return is
+	}, ProfileInquiry{Header: d.header(destination)})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reply := m.(ProfileInquiryReply)
+	return reply.Enabled, reply.Disabled, nil
+}
+
+// SetProfile enables or disables the given profile of the given device and waits for the report.
+func (d *Device) SetProfile(destination MUID, p Profile, enable bool) error {
+	var msg Message = SetProfileOff{Header: d.header(destination), Profile: p}
+	subID := subSetProfileOff
+
+	if enable {
+		msg = SetProfileOn{Header: d.header(destination), Profile: p}
+		subID = subSetProfileOn
+	}
+
+	_, err := d.request(destination, subID, func(m Message) bool {
+		switch v := m.(type) {
+		case ProfileEnabled:
+			return enable && v.Profile == p
+		case ProfileDisabled:
+			return !enable && v.Profile == p
+		default:
+			return false
+		}
+	}, msg)
+
+	return err
+}
+
+// PropertyExchangeCapabilities returns the number of simultaneous property exchange requests that are supported by the
+// given device.
+func (d *Device) PropertyExchangeCapabilities(destination MUID) (requests byte, err error) {
+	m, err := d.request(destination, subPECapabilities, func(m Message) bool {
+		_, is := m.(PropertyCapabilitiesReply)
+		return is
+	}, PropertyCapabilities{Header: d.header(destination), MaxRequests: maxRequests})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return m.(PropertyCapabilitiesReply).MaxRequests, nil
+}
+
+// propertyRequest sends the property exchange request and returns the assembled reply
+func (d *Device) propertyRequest(destination MUID, kind PropertyKind, header PropertyHeader, data []byte) (reply PropertyMessage, replyHeader PropertyHeader, err error) {
+	requestID := d.nextRequestID()
+	chunks := Chunks(d.header(destination), kind, requestID, header, data, d.remoteSysExSize(destination))
+
+	msgs := make([]Message, len(chunks))
+	for i, c := range chunks {
+		msgs[i] = c
+	}
+
+	m, err := d.request(destination, byte(kind), func(m Message) bool {
+		pm, is := m.(PropertyMessage)
+		return is && pm.Kind == kind+1 && pm.RequestID == requestID
+	}, msgs...)
+
+	if err != nil {
+		return
+	}
+
+	reply = m.(PropertyMessage)
+	replyHeader, err = ParsePropertyHeader(reply.PropertyHeader)
+	if err != nil {
+		return
+	}
+
+	err = replyHeader.Err()
+	return
+}
+
+// GetProperty returns the data of the given property resource of the given device.
+func (d *Device) GetProperty(destination MUID, resource string) ([]byte, error) {
+	reply, _, err := d.propertyRequest(destination, PEGet, PropertyHeader{Resource: resource}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return reply.Data, nil
+}
+
+// SetProperty sets the data of the given property resource of the given device.
+func (d *Device) SetProperty(destination MUID, resource string, data []byte) error {
+	_, _, err := d.propertyRequest(destination, PESet, PropertyHeader{Resource: resource}, data)
+	return err
+}
+
+// Subscribe subscribes to the given property resource of the given device. The given callback is called with the new data,
+// whenever the resource changes. The returned function ends the subscription.
+func (d *Device) Subscribe(destination MUID, resource string, fn func(data []byte)) (unsubscribe func() error, err error) {
+	_, reply, err := d.propertyRequest(destination, PESubscription, PropertyHeader{Command: CommandStart, Resource: resource}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	key := subscriptionKey{destination, reply.SubscribeID}
+
+	d.mu.Lock()
+	d.subscriptions[key] = fn
+	d.mu.Unlock()
+
+	unsubscribe = func() error {
+		d.mu.Lock()
+		delete(d.subscriptions, key)
+		d.mu.Unlock()
+
+		_, _, err := d.propertyRequest(destination, PESubscription,
+			PropertyHeader{Command: CommandEnd, Resource: resource, SubscribeID: reply.SubscribeID}, nil)
+		return err
+	}
+
+	return unsubscribe, nil
+}
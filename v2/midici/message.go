@@ -0,0 +1,330 @@
+package midici
+
+import (
+	"fmt"
+)
+
+// Version is the MIDI-CI message version that is used for sending (MIDI-CI 1.2).
+const Version byte = 0x02
+
+// FunctionBlock is the device ID that addresses the whole function block (or port) instead of a single channel.
+const FunctionBlock byte = 0x7F
+
+// subIDMIDICI is the sub-ID#1 of MIDI-CI messages within universal non-realtime system exclusive messages
+const subIDMIDICI byte = 0x0D
+
+// The sub-ID#2 of the MIDI-CI messages.
+const (
+	subProfileInquiry        byte = 0x20
+	subProfileInquiryReply   byte = 0x21
+	subSetProfileOn          byte = 0x22
+	subSetProfileOff         byte = 0x23
+	subProfileEnabled        byte = 0x24
+	subProfileDisabled       byte = 0x25
+	subPECapabilities        byte = 0x30
+	subPECapabilitiesReply   byte = 0x31
+	subDiscovery             byte = 0x70
+	subDiscoveryReply        byte = 0x71
+	subInvalidateMUID        byte = 0x7E
+	subNAK                   byte = 0x7F
+	firstPropertyMessageType byte = 0x34
+	lastPropertyMessageType  byte = 0x3F
+)
+
+// The categories (capabilities) of a device, that are reported within the discovery messages.
+const (
+	CategoryProtocolNegotiation byte = 0x02
+	CategoryProfiles            byte = 0x04
+	CategoryPropertyExchange    byte = 0x08
+	CategoryProcessInquiry      byte = 0x10
+)
+
+// Message is a MIDI-CI message.
+type Message interface {
+	// CIHeader returns the common header of the message
+	CIHeader() Header
+
+	// SysEx returns the system exclusive message, including the start and end bytes
+	SysEx() []byte
+}
+
+// Header is the common header of all MIDI-CI messages.
+type Header struct {
+	// DeviceID is the channel (0-15) or FunctionBlock
+	DeviceID byte
+
+	// Version is the MIDI-CI message version. If it is 0, Version is used for sending.
+	Version byte
+
+	// Source is the MUID of the sender
+	Source MUID
+
+	// Destination is the MUID of the receiver or Broadcast
+	Destination MUID
+}
+
+// CIHeader returns the header.
+func (h Header) CIHeader() Header {
+	return h
+}
+
+// sysex returns the system exclusive message with the header, the given sub-ID#2 and the given data
+func (h Header) sysex(subID byte, data ...[]byte) []byte {
+	version := h.Version
+	if version == 0 {
+		version = Version
+	}
+
+	b := []byte{0xF0, 0x7E, h.DeviceID & 0x7F, subIDMIDICI, subID, version}
+	b = append(b, h.Source.bytes()...)
+	b = append(b, h.Destination.bytes()...)
+	for _, d := range data {
+		b = append(b, d...)
+	}
+	return append(b, 0xF7)
+}
+
+// reply returns the header for a reply to a message with the header h, sent by the given MUID
+func (h Header) reply(source MUID) Header {
+	return Header{DeviceID: h.DeviceID, Source: source, Destination: h.Source}
+}
+
+func u14(v uint16) []byte {
+	return []byte{byte(v) & 0x7F, byte(v>>7) & 0x7F}
+}
+
+func u28(v uint32) []byte {
+	return MUID(v).bytes()
+}
+
+// Identity is the identity of a device, as reported by the discovery messages.
+type Identity struct {
+	Manufacturer [3]byte
+	Family       uint16
+	Model        uint16
+	Version      [4]byte
+}
+
+func (i Identity) bytes() []byte {
+	b := append([]byte{}, i.Manufacturer[:]...)
+	b = append(b, u14(i.Family)...)
+	b = append(b, u14(i.Model)...)
+	return append(b, i.Version[:]...)
+}
+
+// Discovery is the discovery message of an initiator.
+type Discovery struct {
+	Header
+	Identity     Identity
+	Categories   byte
+	MaxSysExSize uint32
+	OutputPathID byte
+}
+
+// SysEx returns the system exclusive message.
+func (d Discovery) SysEx() []byte {
+	return d.sysex(subDiscovery, d.Identity.bytes(), []byte{d.Categories}, u28(d.MaxSysExSize), []byte{d.OutputPathID})
+}
+
+// DiscoveryReply is the reply to a discovery message.
+type DiscoveryReply struct {
+	Header
+	Identity      Identity
+	Categories    byte
+	MaxSysExSize  uint32
+	OutputPathID  byte
+	FunctionBlock byte
+}
+
+// SysEx returns the system exclusive message.
+func (d DiscoveryReply) SysEx() []byte {
+	return d.sysex(subDiscoveryReply, d.Identity.bytes(), []byte{d.Categories}, u28(d.MaxSysExSize), []byte{d.OutputPathID, d.FunctionBlock})
+}
+
+// InvalidateMUID is sent, when a MUID is no longer valid (e.g. because the device is going offline).
+type InvalidateMUID struct {
+	Header
+	Target MUID
+}
+
+// SysEx returns the system exclusive message.
+func (i InvalidateMUID) SysEx() []byte {
+	return i.sysex(subInvalidateMUID, i.Target.bytes())
+}
+
+// NAK is the negative acknowledgement of a message.
+type NAK struct {
+	Header
+
+	// OriginalSubID is the sub-ID#2 of the message that is not acknowledged
+	OriginalSubID byte
+	StatusCode    byte
+	StatusData    byte
+	Details       [5]byte
+	Text          string
+}
+
+// SysEx returns the system exclusive message.
+func (n NAK) SysEx() []byte {
+	return n.sysex(subNAK, []byte{n.OriginalSubID, n.StatusCode, n.StatusData}, n.Details[:], u14(uint16(len(n.Text))), []byte(n.Text))
+}
+
+func (n NAK) Error() string {
+	if n.Text != "" {
+		return fmt.Sprintf("MIDI-CI NAK for message 0x%02X: %s", n.OriginalSubID, n.Text)
+	}
+	return fmt.Sprintf("MIDI-CI NAK for message 0x%02X (status 0x%02X)", n.OriginalSubID, n.StatusCode)
+}
+
+// Profile is the 5 byte ID of a profile.
+type Profile [5]byte
+
+func (p Profile) String() string {
+	return fmt.Sprintf("% X", p[:])
+}
+
+// ProfileInquiry asks a device for its profiles.
+type ProfileInquiry struct {
+	Header
+}
+
+// SysEx returns the system exclusive message.
+func (p ProfileInquiry) SysEx() []byte {
+	return p.sysex(subProfileInquiry)
+}
+
+// ProfileInquiryReply reports the enabled and disabled profiles of a device.
+type ProfileInquiryReply struct {
+	Header
+	Enabled  []Profile
+	Disabled []Profile
+}
+
+func profileList(profiles []Profile) []byte {
+	b := u14(uint16(len(profiles)))
+	for _, p := range profiles {
+		b = append(b, p[:]...)
+	}
+	return b
+}
+
+// SysEx returns the system exclusive message.
+func (p ProfileInquiryReply) SysEx() []byte {
+	return p.sysex(subProfileInquiryReply, profileList(p.Enabled), profileList(p.Disabled))
+}
+
+// SetProfileOn asks a device to enable a profile.
+type SetProfileOn struct {
+	Header
+	Profile  Profile
+	Channels uint16
+}
+
+// SysEx returns the system exclusive message.
+func (p SetProfileOn) SysEx() []byte {
+	return p.sysex(subSetProfileOn, p.Profile[:], u14(p.Channels))
+}
+
+// SetProfileOff asks a device to disable a profile.
+type SetProfileOff struct {
+	Header
+	Profile Profile
+}
+
+// SysEx returns the system exclusive message.
+func (p SetProfileOff) SysEx() []byte {
+	return p.sysex(subSetProfileOff, p.Profile[:], u14(0))
+}
+
+// ProfileEnabled reports that a profile has been enabled.
+type ProfileEnabled struct {
+	Header
+	Profile  Profile
+	Channels uint16
+}
+
+// SysEx returns the system exclusive message.
+func (p ProfileEnabled) SysEx() []byte {
+	return p.sysex(subProfileEnabled, p.Profile[:], u14(p.Channels))
+}
+
+// ProfileDisabled reports that a profile has been disabled.
+type ProfileDisabled struct {
+	Header
+	Profile  Profile
+	Channels uint16
+}
+
+// SysEx returns the system exclusive message.
+func (p ProfileDisabled) SysEx() []byte {
+	return p.sysex(subProfileDisabled, p.Profile[:], u14(p.Channels))
+}
+
+// PropertyCapabilities asks a device for its property exchange capabilities.
+type PropertyCapabilities struct {
+	Header
+
+	// MaxRequests is the number of simultaneous requests that are supported
+	MaxRequests byte
+	Major       byte
+	Minor       byte
+}
+
+// SysEx returns the system exclusive message.
+func (p PropertyCapabilities) SysEx() []byte {
+	return p.sysex(subPECapabilities, []byte{p.MaxRequests, p.Major, p.Minor})
+}
+
+// PropertyCapabilitiesReply reports the property exchange capabilities of a device.
+type PropertyCapabilitiesReply struct {
+	Header
+
+	// MaxRequests is the number of simultaneous requests that are supported
+	MaxRequests byte
+	Major       byte
+	Minor       byte
+}
+
+// SysEx returns the system exclusive message.
+func (p PropertyCapabilitiesReply) SysEx() []byte {
+	return p.sysex(subPECapabilitiesReply, []byte{p.MaxRequests, p.Major, p.Minor})
+}
+
+// PropertyKind is the kind of a property exchange message.
+type PropertyKind byte
+
+// The kinds of property exchange messages.
+const (
+	PEGet               PropertyKind = 0x34
+	PEGetReply          PropertyKind = 0x35
+	PESet               PropertyKind = 0x36
+	PESetReply          PropertyKind = 0x37
+	PESubscription      PropertyKind = 0x38
+	PESubscriptionReply PropertyKind = 0x39
+	PENotify            PropertyKind = 0x3F
+)
+
+// PropertyMessage is a chunk of a property exchange message.
+type PropertyMessage struct {
+	Header
+	Kind      PropertyKind
+	RequestID byte
+
+	// PropertyHeader is the JSON header (see PropertyHeader), that is only part of the first chunk
+	PropertyHeader []byte
+
+	// NumChunks is the number of chunks of the message (0 if unknown)
+	NumChunks uint16
+
+	// Chunk is the number of this chunk, starting with 1
+	Chunk uint16
+
+	// Data is the property data of this chunk
+	Data []byte
+}
+
+// SysEx returns the system exclusive message.
+func (p PropertyMessage) SysEx() []byte {
+	return p.sysex(byte(p.Kind), []byte{p.RequestID & 0x7F}, u14(uint16(len(p.PropertyHeader))), p.PropertyHeader,
+		u14(p.NumChunks), u14(p.Chunk), u14(uint16(len(p.Data))), p.Data)
+}
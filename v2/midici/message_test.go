@@ -0,0 +1,102 @@
+package midici
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	h := Header{DeviceID: FunctionBlock, Version: Version, Source: 0x1234567, Destination: Broadcast}
+	id := Identity{Manufacturer: [3]byte{0x00, 0x21, 0x09}, Family: 0x1234, Model: 3, Version: [4]byte{1, 2, 3, 4}}
+	p1 := Profile{0x7E, 0x01, 0x02, 0x03, 0x04}
+	p2 := Profile{0x7E, 0x05, 0x06, 0x07, 0x08}
+
+	tests := []Message{
+		Discovery{Header: h, Identity: id, Categories: CategoryProfiles, MaxSysExSize: 512, OutputPathID: 1},
+		DiscoveryReply{Header: h, Identity: id, Categories: CategoryPropertyExchange, MaxSysExSize: 0xFFFFFF, FunctionBlock: FunctionBlock},
+		InvalidateMUID{Header: h, Target: 0x0ABCDEF},
+		NAK{Header: h, OriginalSubID: subSetProfileOn, StatusCode: 0x01, Text: "unknown"},
+		ProfileInquiry{Header: h},
+		ProfileInquiryReply{Header: h, Enabled: []Profile{p1}, Disabled: []Profile{p2, p1}},
+		SetProfileOn{Header: h, Profile: p1, Channels: 1},
+		SetProfileOff{Header: h, Profile: p2},
+		ProfileEnabled{Header: h, Profile: p1, Channels: 200},
+		ProfileDisabled{Header: h, Profile: p1},
+		PropertyCapabilities{Header: h, MaxRequests: 4},
+		PropertyCapabilitiesReply{Header: h, MaxRequests: 1, Major: 1},
+		PropertyMessage{Header: h, Kind: PEGet, RequestID: 5, PropertyHeader: []byte(`{"resource":"DeviceInfo"}`),
+			NumChunks: 1, Chunk: 1, Data: []byte(`{}`)},
+	}
+
+	for i, test := range tests {
+		sx := test.SysEx()
+
+		if sx[0] != 0xF0 || sx[1] != 0x7E || sx[3] != 0x0D || sx[len(sx)-1] != 0xF7 {
+			t.Errorf("[%v] invalid sysex: % X", i, sx)
+		}
+
+		got, err := Parse(sx)
+		if err != nil {
+			t.Errorf("[%v] Parse(% X) returned error: %v", i, sx, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, test) {
+			t.Errorf("[%v] Parse() = %#v; wanted %#v", i, got, test)
+		}
+	}
+
+	_, err := Parse([]byte{0xF0, 0x7E, 0x7F, 0x06, 0x01, 0xF7})
+	if err != ErrNoMIDICI {
+		t.Errorf("Parse(identity request) returned %v; wanted %v", err, ErrNoMIDICI)
+	}
+
+	_, err = Parse(Discovery{Header: h}.SysEx()[:20])
+	if err == nil {
+		t.Errorf("Parse() of truncated message must return an error")
+	}
+}
+
+func TestChunks(t *testing.T) {
+	h := Header{DeviceID: FunctionBlock, Version: Version, Source: 1, Destination: 2}
+
+	data := make([]byte, 300)
+	for i := range data {
+		data[i] = byte(i % 100)
+	}
+
+	chunks := Chunks(h, PESet, 3, PropertyHeader{Resource: "X"}, data, 128)
+
+	if len(chunks) != 4 {
+		t.Fatalf("len(Chunks()) = %v; wanted 4", len(chunks))
+	}
+
+	a := assembler{}
+	var complete *PropertyMessage
+
+	for i, c := range chunks {
+		if size := len(c.SysEx()); size > 128 {
+			t.Errorf("chunk %v has size %v", i, size)
+		}
+
+		// parse, to get the messages as they would be received
+		m, err := Parse(c.SysEx())
+		if err != nil {
+			t.Fatalf("Parse() returned error: %v", err)
+		}
+
+		complete = a.add(m.(PropertyMessage))
+		if (complete != nil) != (i == len(chunks)-1) {
+			t.Fatalf("chunk %v: unexpected completion %v", i, complete != nil)
+		}
+	}
+
+	if !reflect.DeepEqual(complete.Data, data) {
+		t.Errorf("assembled data differs")
+	}
+
+	hd, err := ParsePropertyHeader(complete.PropertyHeader)
+	if err != nil || hd.Resource != "X" {
+		t.Errorf("ParsePropertyHeader() = %+v, %v", hd, err)
+	}
+}
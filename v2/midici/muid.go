@@ -0,0 +1,41 @@
+package midici
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// MUID is the 28-bit unique identifier of a MIDI-CI device.
+type MUID uint32
+
+// Broadcast is the MUID that addresses all devices.
+const Broadcast MUID = 0x0FFFFFFF
+
+// reservedMUIDs is the first of the MUIDs that are reserved (up to Broadcast)
+const reservedMUIDs MUID = 0x0FFFFF00
+
+var random = rand.New(rand.NewSource(time.Now().UnixNano()))
+
+// NewMUID returns a random MUID, that is not reserved.
+func NewMUID() MUID {
+	for {
+		m := MUID(random.Uint32() & 0x0FFFFFFF)
+		if m < reservedMUIDs {
+			return m
+		}
+	}
+}
+
+func (m MUID) String() string {
+	return fmt.Sprintf("%07X", uint32(m))
+}
+
+// bytes returns the MUID as 4 bytes of 7 bits, LSB first
+func (m MUID) bytes() []byte {
+	return []byte{byte(m) & 0x7F, byte(m>>7) & 0x7F, byte(m>>14) & 0x7F, byte(m>>21) & 0x7F}
+}
+
+func muidFromBytes(b []byte) MUID {
+	return MUID(uint32(b[0]) | uint32(b[1])<<7 | uint32(b[2])<<14 | uint32(b[3])<<21)
+}
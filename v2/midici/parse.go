@@ -0,0 +1,161 @@
+package midici
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNoMIDICI is returned by Parse, if the data is no MIDI-CI message
+	ErrNoMIDICI = errors.New("no MIDI-CI message")
+
+	errTooShort = errors.New("MIDI-CI message too short")
+)
+
+// parser reads the fields of a message
+type parser struct {
+	data  []byte
+	short bool
+}
+
+func (p *parser) bytes(n int) []byte {
+	if len(p.data) < n {
+		p.short = true
+		p.data = nil
+		return make([]byte, n)
+	}
+	b := p.data[:n]
+	p.data = p.data[n:]
+	return b
+}
+
+func (p *parser) byte() byte {
+	return p.bytes(1)[0]
+}
+
+func (p *parser) u14() uint16 {
+	b := p.bytes(2)
+	return uint16(b[0]&0x7F) | uint16(b[1]&0x7F)<<7
+}
+
+func (p *parser) u28() uint32 {
+	return uint32(muidFromBytes(p.bytes(4)))
+}
+
+func (p *parser) muid() MUID {
+	return muidFromBytes(p.bytes(4))
+}
+
+// optional reads a byte that is missing in older versions of the message
+func (p *parser) optional() byte {
+	if len(p.data) == 0 {
+		return 0
+	}
+	return p.byte()
+}
+
+func (p *parser) profile() (pr Profile) {
+	copy(pr[:], p.bytes(5))
+	return
+}
+
+func (p *parser) profiles() []Profile {
+	n := int(p.u14())
+	if n*5 > len(p.data) {
+		p.short = true
+		return nil
+	}
+
+	profiles := make([]Profile, n)
+	for i := range profiles {
+		profiles[i] = p.profile()
+	}
+	return profiles
+}
+
+func (p *parser) identity() (id Identity) {
+	copy(id.Manufacturer[:], p.bytes(3))
+	id.Family = p.u14()
+	id.Model = p.u14()
+	copy(id.Version[:], p.bytes(4))
+	return
+}
+
+// Parse parses the given system exclusive message (with or without the start and end bytes) as MIDI-CI message.
+// ErrNoMIDICI is returned, if the message is no MIDI-CI message.
+func Parse(sysex []byte) (Message, error) {
+	if len(sysex) > 0 && sysex[0] == 0xF0 {
+		sysex = sysex[1:]
+	}
+	if len(sysex) > 0 && sysex[len(sysex)-1] == 0xF7 {
+		sysex = sysex[:len(sysex)-1]
+	}
+
+	if len(sysex) < 4 || sysex[0] != 0x7E || sysex[2] != subIDMIDICI {
+		return nil, ErrNoMIDICI
+	}
+
+	subID := sysex[3]
+
+	// the parsed data must not refer to the given (e.g. reused) buffer
+	p := &parser{data: append([]byte(nil), sysex[4:]...)}
+
+	var h Header
+	h.DeviceID = sysex[1]
+	h.Version = p.byte()
+	h.Source = p.muid()
+	h.Destination = p.muid()
+
+	var msg Message
+
+	switch {
+	case subID == subDiscovery:
+		msg = Discovery{Header: h, Identity: p.identity(), Categories: p.byte(), MaxSysExSize: p.u28(), OutputPathID: p.optional()}
+	case subID == subDiscoveryReply:
+		msg = DiscoveryReply{Header: h, Identity: p.identity(), Categories: p.byte(), MaxSysExSize: p.u28(),
+			OutputPathID: p.optional(), FunctionBlock: p.optional()}
+	case subID == subInvalidateMUID:
+		msg = InvalidateMUID{Header: h, Target: p.muid()}
+	case subID == subNAK:
+		n := NAK{Header: h}
+		if len(p.data) > 0 {
+			n.OriginalSubID = p.byte()
+			n.StatusCode = p.byte()
+			n.StatusData = p.byte()
+			copy(n.Details[:], p.bytes(5))
+			n.Text = string(p.bytes(int(p.u14())))
+		}
+		msg = n
+	case subID == subProfileInquiry:
+		msg = ProfileInquiry{Header: h}
+	case subID == subProfileInquiryReply:
+		msg = ProfileInquiryReply{Header: h, Enabled: p.profiles(), Disabled: p.profiles()}
+	case subID == subSetProfileOn:
+		msg = SetProfileOn{Header: h, Profile: p.profile(), Channels: uint16(p.optional()) | uint16(p.optional())<<7}
+	case subID == subSetProfileOff:
+		msg = SetProfileOff{Header: h, Profile: p.profile()}
+	case subID == subProfileEnabled:
+		msg = ProfileEnabled{Header: h, Profile: p.profile(), Channels: uint16(p.optional()) | uint16(p.optional())<<7}
+	case subID == subProfileDisabled:
+		msg = ProfileDisabled{Header: h, Profile: p.profile(), Channels: uint16(p.optional()) | uint16(p.optional())<<7}
+	case subID == subPECapabilities:
+		msg = PropertyCapabilities{Header: h, MaxRequests: p.byte(), Major: p.optional(), Minor: p.optional()}
+	case subID == subPECapabilitiesReply:
+		msg = PropertyCapabilitiesReply{Header: h, MaxRequests: p.byte(), Major: p.optional(), Minor: p.optional()}
+	case subID >= firstPropertyMessageType && subID <= lastPropertyMessageType:
+		pm := PropertyMessage{Header: h, Kind: PropertyKind(subID), RequestID: p.byte()}
+		pm.PropertyHeader = p.bytes(int(p.u14()))
+		pm.NumChunks = p.u14()
+		pm.Chunk = p.u14()
+		pm.Data = p.bytes(int(p.u14()))
+		msg = pm
+	default:
+		return nil, fmt.Errorf("unsupported MIDI-CI message 0x%02X", subID)
+	}
+
+	if p.short {
+		return nil, errTooShort
+	}
+
+	return msg, nil
+}
@@ -0,0 +1,145 @@
+package midici
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Status codes of the property exchange replies.
+const (
+	StatusOK          = 200
+	StatusAccepted    = 202
+	StatusBadRequest  = 400
+	StatusNotFound    = 404
+	StatusUnsupported = 405
+	StatusInternal    = 500
+)
+
+// The commands of subscription messages.
+const (
+	CommandStart = "start"
+	CommandEnd   = "end"
+	CommandFull  = "full"
+)
+
+// PropertyHeader is the JSON header of property exchange messages. Empty fields are omitted.
+type PropertyHeader struct {
+	Resource       string `json:"resource,omitempty"`
+	ResID          string `json:"resId,omitempty"`
+	Command        string `json:"command,omitempty"`
+	SubscribeID    string `json:"subscribeId,omitempty"`
+	MutualEncoding string `json:"mutualEncoding,omitempty"`
+	Status         int    `json:"status,omitempty"`
+	Message        string `json:"message,omitempty"`
+}
+
+// ParsePropertyHeader parses the given JSON header.
+func ParsePropertyHeader(data []byte) (h PropertyHeader, err error) {
+	if len(data) == 0 {
+		return h, nil
+	}
+	err = json.Unmarshal(data, &h)
+	return
+}
+
+// Bytes returns the JSON representation of the header.
+func (h PropertyHeader) Bytes() []byte {
+	b, _ := json.Marshal(h)
+	return b
+}
+
+// Err returns an error, if the status of a reply header is not successful.
+func (h PropertyHeader) Err() error {
+	if h.Status >= 200 && h.Status < 300 {
+		return nil
+	}
+	if h.Message != "" {
+		return fmt.Errorf("property exchange failed with status %v: %s", h.Status, h.Message)
+	}
+	return fmt.Errorf("property exchange failed with status %v", h.Status)
+}
+
+// propertyOverhead is the number of bytes of a property exchange message without header and data
+const propertyOverhead = 24
+
+// minChunkSize is the minimal number of data bytes per chunk
+const minChunkSize = 32
+
+// Chunks splits the property data into messages that are not larger than the given maximal system exclusive size.
+// The given header is part of the first chunk.
+func Chunks(h Header, kind PropertyKind, requestID byte, header PropertyHeader, data []byte, maxSysExSize uint32) (msgs []PropertyMessage) {
+	hd := header.Bytes()
+
+	size := int(maxSysExSize) - propertyOverhead - len(hd)
+	if size < minChunkSize {
+		size = minChunkSize
+	}
+
+	num := (len(data) + size - 1) / size
+	if num == 0 {
+		num = 1
+	}
+
+	for i := 0; i < num; i++ {
+		end := (i + 1) * size
+		if end > len(data) {
+			end = len(data)
+		}
+
+		m := PropertyMessage{
+			Header:    h,
+			Kind:      kind,
+			RequestID: requestID,
+			NumChunks: uint16(num),
+			Chunk:     uint16(i + 1),
+			Data:      data[i*size : end],
+		}
+
+		if i == 0 {
+			m.PropertyHeader = hd
+		}
+
+		msgs = append(msgs, m)
+	}
+	return
+}
+
+// chunkKey identifies the chunks of a property exchange message
+type chunkKey struct {
+	source    MUID
+	kind      PropertyKind
+	requestID byte
+}
+
+// assembler assembles the chunks of property exchange messages
+type assembler map[chunkKey]*PropertyMessage
+
+// add adds the given chunk and returns the complete message, if it is the last chunk
+func (a assembler) add(m PropertyMessage) (complete *PropertyMessage) {
+	key := chunkKey{m.Source, m.Kind, m.RequestID}
+
+	if m.Chunk <= 1 {
+		delete(a, key)
+		if m.NumChunks <= 1 {
+			return &m
+		}
+
+		m.Data = append([]byte(nil), m.Data...)
+		a[key] = &m
+		return nil
+	}
+
+	first, has := a[key]
+	if !has {
+		return nil
+	}
+
+	first.Data = append(first.Data, m.Data...)
+
+	if m.Chunk >= first.NumChunks {
+		delete(a, key)
+		first.Chunk = first.NumChunks
+		return first
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package midici
+
+import (
+	"fmt"
+)
+
+// maxRequests is the number of simultaneous property exchange requests that are supported
+const maxRequests = 4
+
+// respond handles the messages that are not replies to requests of the device
+func (d *Device) respond(m Message) {
+	switch v := m.(type) {
+	case Discovery:
+		d.send(DiscoveryReply{
+			Header:        v.reply(d.muid),
+			Identity:      d.identity,
+			Categories:    CategoryProfiles | CategoryPropertyExchange,
+			MaxSysExSize:  d.maxSysExSize,
+			OutputPathID:  v.OutputPathID,
+			FunctionBlock: FunctionBlock,
+		})
+	case InvalidateMUID:
+		d.invalidate(v.Target)
+	case ProfileInquiry:
+		d.respondProfileInquiry(v)
+	case SetProfileOn:
+		d.respondSetProfile(v.Header, subSetProfileOn, v.Profile, true, v.Channels)
+	case SetProfileOff:
+		d.respondSetProfile(v.Header, subSetProfileOff, v.Profile, false, 0)
+	case PropertyCapabilities:
+		d.send(PropertyCapabilitiesReply{Header: v.reply(d.muid), MaxRequests: maxRequests})
+	case PropertyMessage:
+		d.respondProperty(v)
+	}
+}
+
+// invalidate removes all data of the given remote MUID
+func (d *Device) invalidate(m MUID) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.remotes, m)
+
+	for res, subs := range d.subscribers {
+		var keep []subscriber
+		for _, s := range subs {
+			if s.muid != m {
+				keep = append(keep, s)
+			}
+		}
+		d.subscribers[res] = keep
+	}
+
+	for key := range d.subscriptions {
+		if key.muid == m {
+			delete(d.subscriptions, key)
+		}
+	}
+}
+
+func (d *Device) respondProfileInquiry(m ProfileInquiry) {
+	reply := ProfileInquiryReply{Header: m.reply(d.muid)}
+
+	d.mu.Lock()
+	for _, ps := range d.profiles {
+		if ps.enabled {
+			reply.Enabled = append(reply.Enabled, ps.profile)
+		} else {
+			reply.Disabled = append(reply.Disabled, ps.profile)
+		}
+	}
+	d.mu.Unlock()
+
+	d.send(reply)
+}
+
+func (d *Device) respondSetProfile(h Header, subID byte, p Profile, enable bool, channels uint16) {
+	d.mu.Lock()
+	idx := -1
+	for i, ps := range d.profiles {
+		if ps.profile == p {
+			idx = i
+		}
+	}
+	d.mu.Unlock()
+
+	if idx < 0 {
+		d.send(NAK{Header: h.reply(d.muid), OriginalSubID: subID, Text: fmt.Sprintf("unknown profile %s", p)})
+		return
+	}
+
+	if d.onProfile != nil && !d.onProfile(p, enable) {
+		d.send(NAK{Header: h.reply(d.muid), OriginalSubID: subID, Text: fmt.Sprintf("profile %s rejected", p)})
+		return
+	}
+
+	d.mu.Lock()
+	d.profiles[idx].enabled = enable
+	d.mu.Unlock()
+
+	// the reports are sent to all devices
+	report := h.reply(d.muid)
+	report.Destination = Broadcast
+
+	if enable {
+		d.send(ProfileEnabled{Header: report, Profile: p, Channels: channels})
+	} else {
+		d.send(ProfileDisabled{Header: report, Profile: p})
+	}
+}
+
+// replyProperty sends the reply to the given property exchange message
+func (d *Device) replyProperty(m PropertyMessage, header PropertyHeader, data []byte) {
+	d.sendChunks(Chunks(m.reply(d.muid), m.Kind+1, m.RequestID, header, data, d.remoteSysExSize(m.Source)))
+}
+
+func (d *Device) respondProperty(m PropertyMessage) {
+	h, err := ParsePropertyHeader(m.PropertyHeader)
+	if err != nil {
+		if m.Kind == PEGet || m.Kind == PESet || m.Kind == PESubscription {
+			d.replyProperty(m, PropertyHeader{Status: StatusBadRequest, Message: err.Error()}, nil)
+		}
+		return
+	}
+
+	switch m.Kind {
+	case PEGet:
+		data, has := d.Resource(h.Resource)
+		if !has {
+			d.replyProperty(m, PropertyHeader{Status: StatusNotFound}, nil)
+			return
+		}
+		d.replyProperty(m, PropertyHeader{Status: StatusOK}, data)
+	case PESet:
+		if d.onSetProperty != nil && !d.onSetProperty(h.Resource, m.Data) {
+			d.replyProperty(m, PropertyHeader{Status: StatusBadRequest}, nil)
+			return
+		}
+		d.replyProperty(m, PropertyHeader{Status: StatusOK}, nil)
+		d.setResource(h.Resource, m.Data, m.Source)
+	case PESubscription:
+		d.replyProperty(m, d.subscription(m.Source, h, m.Data), nil)
+	}
+}
+
+// subscription handles the given subscription message and returns the header of the reply
+func (d *Device) subscription(source MUID, h PropertyHeader, data []byte) PropertyHeader {
+	key := subscriptionKey{source, h.SubscribeID}
+
+	d.mu.Lock()
+	fn, isSubscribed := d.subscriptions[key]
+	d.mu.Unlock()
+
+	switch {
+	// notification for a subscription of this device
+	case isSubscribed && h.Command == CommandEnd:
+		d.mu.Lock()
+		delete(d.subscriptions, key)
+		d.mu.Unlock()
+		return PropertyHeader{Status: StatusOK}
+	case isSubscribed:
+		fn(data)
+		return PropertyHeader{Status: StatusOK}
+
+	// subscription of a remote device
+	case h.Command == CommandStart:
+		d.mu.Lock()
+		defer d.mu.Unlock()
+
+		if _, has := d.resources[h.Resource]; !has {
+			return PropertyHeader{Status: StatusNotFound}
+		}
+
+		d.subscribeID++
+		id := fmt.Sprintf("sub%v", d.subscribeID)
+		d.subscribers[h.Resource] = append(d.subscribers[h.Resource], subscriber{source, id})
+		return PropertyHeader{Status: StatusOK, SubscribeID: id}
+	case h.Command == CommandEnd:
+		d.mu.Lock()
+		defer d.mu.Unlock()
+
+		for res, subs := range d.subscribers {
+			for i, s := range subs {
+				if s.muid == source && s.id == h.SubscribeID {
+					d.subscribers[res] = append(subs[:i], subs[i+1:]...)
+					return PropertyHeader{Status: StatusOK}
+				}
+			}
+		}
+		return PropertyHeader{Status: StatusNotFound}
+	default:
+		return PropertyHeader{Status: StatusNotFound}
+	}
+}
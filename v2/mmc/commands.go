@@ -0,0 +1,385 @@
+package mmc
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+)
+
+// Instruction is a single command of a MMC command message.
+// The commands without parameters are represented by their Command value (e.g. PlayCmd).
+type Instruction interface {
+	// Command returns the command byte.
+	Command() Command
+
+	// Params returns the bytes after the command byte (including the count for commands 40-77).
+	Params() []byte
+}
+
+// Command returns the command itself, so that commands without parameters can be used as Instruction.
+func (c Command) Command() Command {
+	return c
+}
+
+// Params returns nil, since commands without parameters have no parameter bytes.
+func (c Command) Params() []byte {
+	return nil
+}
+
+// withCount returns the given bytes, prefixed by their count
+func withCount(b ...byte) []byte {
+	return append([]byte{byte(len(b))}, b...)
+}
+
+// Write writes the data to the given field of the device (e.g. the TrackRecordReadyField to arm tracks).
+// For standard time code fields, the data are the 5 bytes of the time code (see WriteTime).
+type Write struct {
+	Field Field
+	Data  []byte
+}
+
+// WriteTime returns the write command for the given time code field and time.
+func WriteTime(f Field, t Time) Write {
+	return Write{Field: f, Data: t.Bytes()}
+}
+
+func (w Write) Command() Command {
+	return WriteCmd
+}
+
+func (w Write) Params() []byte {
+	var bf bytes.Buffer
+	appendField(&bf, w.Field, w.Data)
+	return withCount(bf.Bytes()...)
+}
+
+func (w Write) String() string {
+	return fmt.Sprintf("Write %02X: % X", byte(w.Field), w.Data)
+}
+
+// MaskedWrite changes the bits of the given mask within one byte of a bitmap field (e.g. to arm a single track).
+type MaskedWrite struct {
+	Field Field
+	Byte  byte
+	Mask  byte
+	Data  byte
+}
+
+// MaskedWriteTrack returns the masked write command that sets or clears the given bit (see Tracks) of the field.
+func MaskedWriteTrack(f Field, bit int, on bool) MaskedWrite {
+	m := MaskedWrite{Field: f, Byte: byte(bit / 7), Mask: 1 << uint(bit%7)}
+	if on {
+		m.Data = m.Mask
+	}
+	return m
+}
+
+func (m MaskedWrite) Command() Command {
+	return MaskedWriteCmd
+}
+
+func (m MaskedWrite) Params() []byte {
+	return withCount(byte(m.Field), m.Byte, m.Mask, m.Data)
+}
+
+// Apply applies the masked write to the given bitmap.
+func (m MaskedWrite) Apply(t Tracks) Tracks {
+	for len(t) <= int(m.Byte) {
+		t = append(t, 0)
+	}
+	t[m.Byte] = t[m.Byte]&^m.Mask | m.Data&m.Mask
+	return t
+}
+
+func (m MaskedWrite) String() string {
+	return fmt.Sprintf("MaskedWrite %02X byte: %v mask: %07b data: %07b", byte(m.Field), m.Byte, m.Mask, m.Data)
+}
+
+// Read requests the given fields. The device replies with a response message.
+type Read struct {
+	Fields []Field
+}
+
+func (r Read) Command() Command {
+	return ReadCmd
+}
+
+func (r Read) Params() []byte {
+	b := make([]byte, len(r.Fields))
+	for i, f := range r.Fields {
+		b[i] = byte(f)
+	}
+	return withCount(b...)
+}
+
+// Locate moves the device to the Target time or, if Field is not 0, to the time within the given time code field.
+type Locate struct {
+	Field  Field
+	Target Time
+}
+
+func (l Locate) Command() Command {
+	return LocateCmd
+}
+
+func (l Locate) Params() []byte {
+	if l.Field != 0 {
+		return withCount(0x00, byte(l.Field))
+	}
+	return withCount(append([]byte{0x01}, l.Target.Bytes()...)...)
+}
+
+func (l Locate) String() string {
+	if l.Field != 0 {
+		return fmt.Sprintf("Locate field: %02X", byte(l.Field))
+	}
+	return fmt.Sprintf("Locate target: %s", l.Target)
+}
+
+// VariablePlay plays with the given speed (1 is the normal play speed, negative speeds play reverse).
+type VariablePlay struct {
+	Speed float64
+}
+
+func (v VariablePlay) Command() Command {
+	return VariablePlayCmd
+}
+
+func (v VariablePlay) Params() []byte {
+	return withCount(EncodeSpeed(v.Speed)...)
+}
+
+// Search moves with the given speed, while the material is monitored.
+type Search struct {
+	Speed float64
+}
+
+func (s Search) Command() Command {
+	return SearchCmd
+}
+
+func (s Search) Params() []byte {
+	return withCount(EncodeSpeed(s.Speed)...)
+}
+
+// Shuttle moves with the given speed (1 is the normal play speed, negative speeds move reverse).
+type Shuttle struct {
+	Speed float64
+}
+
+func (s Shuttle) Command() Command {
+	return ShuttleCmd
+}
+
+func (s Shuttle) Params() []byte {
+	return withCount(EncodeSpeed(s.Speed)...)
+}
+
+// Step moves the given number of steps (-63 to 63). The length of a step is defined by the StepLengthField.
+type Step struct {
+	Steps int8
+}
+
+func (s Step) Command() Command {
+	return StepCmd
+}
+
+func (s Step) Params() []byte {
+	n := int(s.Steps)
+	var b byte
+	if n < 0 {
+		b = 0x40
+		n = -n
+	}
+	if n > 63 {
+		n = 63
+	}
+	return withCount(b | byte(n))
+}
+
+// Generic is a command with parameters that has no dedicated type (e.g. MoveCmd or UpdateCmd).
+// Data are the parameters without the count.
+type Generic struct {
+	Cmd  Command
+	Data []byte
+}
+
+func (g Generic) Command() Command {
+	return g.Cmd
+}
+
+func (g Generic) Params() []byte {
+	return withCount(g.Data...)
+}
+
+// EncodeSpeed returns the 3 bytes (sh sm sl) of the standard speed for the given speed.
+// The speed is encoded with the highest possible resolution. Negative speeds are reverse.
+func EncodeSpeed(speed float64) []byte {
+	var sh byte
+	if speed < 0 {
+		sh = 0x40
+		speed = -speed
+	}
+
+	// the number of bits of the integer part is 3 + shift
+	shift := uint(0)
+	for shift < 7 && speed >= float64(uint(1)<<(3+shift)) {
+		shift++
+	}
+
+	v := math.Round(speed * float64(uint(1)<<(14-shift)))
+	if v > 0x1FFFF {
+		v = 0x1FFFF
+	}
+	val := uint32(v)
+
+	sh |= byte(shift)<<3 | byte(val>>14)&0x07
+	return []byte{sh, byte(val>>7) & 0x7F, byte(val) & 0x7F}
+}
+
+// DecodeSpeed returns the speed of the given 3 bytes of a standard speed.
+func DecodeSpeed(b []byte) float64 {
+	if len(b) < 3 {
+		return 0
+	}
+	shift := uint(b[0]>>3) & 0x07
+	val := uint32(b[0]&0x07)<<14 | uint32(b[1]&0x7F)<<7 | uint32(b[2]&0x7F)
+	speed := float64(val) / float64(uint(1)<<(14-shift))
+	if b[0]&0x40 != 0 {
+		return -speed
+	}
+	return speed
+}
+
+// CommandMessage is a MMC command message (sub ID 06) with one or more commands.
+type CommandMessage struct {
+	DeviceID     byte
+	Instructions []Instruction
+}
+
+// Commands returns the command message for the given device and instructions.
+func Commands(deviceID byte, instructions ...Instruction) CommandMessage {
+	return CommandMessage{DeviceID: deviceID, Instructions: instructions}
+}
+
+func (m CommandMessage) SysEx() []byte {
+	var bf bytes.Buffer
+	bf.Write([]byte{0xF0, 0x7F, m.DeviceID, 0x06})
+	for _, in := range m.Instructions {
+		bf.WriteByte(byte(in.Command()))
+		bf.Write(in.Params())
+	}
+	bf.WriteByte(0xF7)
+	return bf.Bytes()
+}
+
+func (m CommandMessage) String() string {
+	var bf bytes.Buffer
+	fmt.Fprintf(&bf, "MMC device: %v commands:", m.DeviceID)
+	for _, in := range m.Instructions {
+		fmt.Fprintf(&bf, " %v", in)
+	}
+	return bf.String()
+}
+
+// ParseCommands parses a MMC command message (with 0xF0 and 0xF7).
+func ParseCommands(bt []byte) (m CommandMessage, err error) {
+	data, err := unwrap(bt, 0x06)
+	if err != nil {
+		return m, err
+	}
+
+	m.DeviceID = bt[2]
+
+	for len(data) > 0 {
+		c := Command(data[0])
+		data = data[1:]
+
+		if c < 0x40 || c >= 0x78 {
+			if c == 0 {
+				return m, fmt.Errorf("extended commands are not supported")
+			}
+			m.Instructions = append(m.Instructions, c)
+			continue
+		}
+
+		if len(data) == 0 || len(data) < 1+int(data[0]) {
+			return m, fmt.Errorf("%s command too short", c)
+		}
+
+		params := data[1 : 1+int(data[0])]
+		data = data[1+int(data[0]):]
+
+		in, err := parseInstruction(c, params)
+		if err != nil {
+			return m, err
+		}
+		m.Instructions = append(m.Instructions, in)
+	}
+
+	return m, nil
+}
+
+// parseInstruction parses a command with the given parameters (without count)
+func parseInstruction(c Command, params []byte) (Instruction, error) {
+	wrongLength := func() error {
+		return fmt.Errorf("wrong length of %s command: %v", c, len(params))
+	}
+
+	switch c {
+	case WriteCmd:
+		if len(params) == 0 {
+			return nil, wrongLength()
+		}
+		f, data, _, err := readField(params)
+		if err != nil {
+			return nil, err
+		}
+		return Write{Field: f, Data: data}, nil
+	case MaskedWriteCmd:
+		if len(params) != 4 {
+			return nil, wrongLength()
+		}
+		return MaskedWrite{Field: Field(params[0]), Byte: params[1], Mask: params[2], Data: params[3]}, nil
+	case ReadCmd:
+		r := Read{}
+		for _, f := range params {
+			r.Fields = append(r.Fields, Field(f))
+		}
+		return r, nil
+	case LocateCmd:
+		switch {
+		case len(params) == 2 && params[0] == 0x00:
+			return Locate{Field: Field(params[1])}, nil
+		case len(params) == 6 && params[0] == 0x01:
+			t, err := TimeFromBytes(params[1:])
+			return Locate{Target: t}, err
+		default:
+			return nil, wrongLength()
+		}
+	case VariablePlayCmd, SearchCmd, ShuttleCmd:
+		if len(params) != 3 {
+			return nil, wrongLength()
+		}
+		speed := DecodeSpeed(params)
+		switch c {
+		case VariablePlayCmd:
+			return VariablePlay{Speed: speed}, nil
+		case SearchCmd:
+			return Search{Speed: speed}, nil
+		default:
+			return Shuttle{Speed: speed}, nil
+		}
+	case StepCmd:
+		if len(params) != 1 {
+			return nil, wrongLength()
+		}
+		n := int8(params[0] & 0x3F)
+		if params[0]&0x40 != 0 {
+			n = -n
+		}
+		return Step{Steps: n}, nil
+	default:
+		return Generic{Cmd: c, Data: params}, nil
+	}
+}
@@ -0,0 +1,139 @@
+package mmc
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestCommands(t *testing.T) {
+	target := Time{Rate: FPS25, Hours: 1, Minutes: 2, Seconds: 3, Frames: 4, Subframes: 5}
+
+	tests := []struct {
+		msg      CommandMessage
+		expected string
+	}{
+		{
+			Commands(AllDevices, StopCmd),
+			"F0 7F 7F 06 01 F7",
+		},
+		{
+			Commands(1, Locate{Target: target}, PlayCmd),
+			"F0 7F 01 06 44 06 01 21 02 03 04 05 02 F7",
+		},
+		{
+			Commands(1, Locate{Field: GP0Field}),
+			"F0 7F 01 06 44 02 00 08 F7",
+		},
+		{
+			Commands(1, Write{Field: TrackRecordReadyField, Data: Tracks(nil).Set(AudioTrack(1), true).Set(AudioTrack(3), true)}),
+			"F0 7F 01 06 40 04 4F 02 20 01 F7",
+		},
+		{
+			Commands(1, WriteTime(GP1Field, target)),
+			"F0 7F 01 06 40 06 09 21 02 03 04 05 F7",
+		},
+		{
+			Commands(1, MaskedWriteTrack(TrackRecordReadyField, AudioTrack(2), true)),
+			"F0 7F 01 06 41 04 4F 00 40 40 F7",
+		},
+		{
+			Commands(1, Read{Fields: []Field{SelectedTimeCodeField, MotionControlTallyField}}),
+			"F0 7F 01 06 42 02 01 48 F7",
+		},
+		{
+			Commands(1, Shuttle{Speed: 1}, VariablePlay{Speed: -0.5}, Search{Speed: 20}),
+			"F0 7F 01 06 47 03 01 00 00 45 03 40 40 00 46 03 15 00 00 F7",
+		},
+		{
+			Commands(1, Step{Steps: -3}, Step{Steps: 63}),
+			"F0 7F 01 06 48 01 43 48 01 3F F7",
+		},
+		{
+			Commands(1, Generic{Cmd: MoveCmd, Data: []byte{0x08, 0x01}}, ResumeCmd),
+			"F0 7F 01 06 4C 02 08 01 7F F7",
+		},
+	}
+
+	for i, test := range tests {
+		sx := test.msg.SysEx()
+
+		if got := fmt.Sprintf("% X", sx); got != test.expected {
+			t.Errorf("[%v] SysEx() = %s; expected %s", i, got, test.expected)
+		}
+
+		m, err := ParseCommands(sx)
+		if err != nil {
+			t.Errorf("[%v] ParseCommands() returned error: %v", i, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(m, test.msg) {
+			t.Errorf("[%v] ParseCommands() = %v; expected %v", i, m, test.msg)
+		}
+	}
+
+	_, err := ParseCommands([]byte{0xF0, 0x7F, 0x01, 0x06, 0x44, 0x06, 0x01, 0xF7})
+	if err == nil {
+		t.Errorf("ParseCommands() of truncated command must return an error")
+	}
+}
+
+func TestSpeed(t *testing.T) {
+	tests := []struct {
+		speed    float64
+		expected string
+	}{
+		{1, "01 00 00"},
+		{0.5, "00 40 00"},
+		{-2.25, "42 20 00"},
+		{7.5, "07 40 00"},
+		{8, "0C 00 00"},
+		{100, "26 20 00"},
+	}
+
+	for i, test := range tests {
+		b := EncodeSpeed(test.speed)
+
+		if got := fmt.Sprintf("% X", b); got != test.expected {
+			t.Errorf("[%v] EncodeSpeed(%v) = %s; expected %s", i, test.speed, got, test.expected)
+		}
+
+		if got := DecodeSpeed(b); got != test.speed {
+			t.Errorf("[%v] DecodeSpeed() = %v; expected %v", i, got, test.speed)
+		}
+	}
+}
+
+func TestTime(t *testing.T) {
+	tests := []struct {
+		time   Time
+		frames int
+		str    string
+	}{
+		{Time{Rate: FPS25, Hours: 1, Minutes: 2, Seconds: 3, Frames: 4}, ((60+2)*60+3)*25 + 4, "01:02:03:04.00"},
+		{Time{Rate: FPS24, Seconds: 1, Frames: 1, Negative: true}, -25, "-00:00:01:01.00"},
+		{Time{Rate: FPS30Drop, Minutes: 1, Frames: 2}, 1800, "00:01:00;02.00"},
+		{Time{Rate: FPS30Drop, Minutes: 10}, 17982, "00:10:00;00.00"},
+		{Time{Rate: FPS30Drop, Minutes: 10, Seconds: 59, Frames: 29}, 17982 + 1799, "00:10:59;29.00"},
+		{Time{Rate: FPS30Drop, Minutes: 11, Frames: 2}, 17982 + 1800, "00:11:00;02.00"},
+	}
+
+	for i, test := range tests {
+		if got := test.time.TotalFrames(); got != test.frames {
+			t.Errorf("[%v] TotalFrames() = %v; expected %v", i, got, test.frames)
+		}
+
+		if got := TimeFromFrames(test.time.Rate, test.frames); got != test.time {
+			t.Errorf("[%v] TimeFromFrames(%v) = %v; expected %v", i, test.frames, got, test.time)
+		}
+
+		if got := test.time.String(); got != test.str {
+			t.Errorf("[%v] String() = %q; expected %q", i, got, test.str)
+		}
+
+		if got, _ := TimeFromBytes(test.time.Bytes()); got != test.time {
+			t.Errorf("[%v] TimeFromBytes() = %v; expected %v", i, got, test.time)
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package mmc
+
+import (
+	"sync"
+
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/drivers"
+)
+
+// AllDevices is the device ID to address all devices.
+const AllDevices = 0x7F
+
+// State is the motion state of the transport of a Device.
+type State byte
+
+const (
+	Stopped State = iota
+	Playing
+	Paused
+	FastForwarding
+	Rewinding
+	Shuttling
+)
+
+func (s State) String() string {
+	switch s {
+	case Stopped:
+		return "stopped"
+	case Playing:
+		return "playing"
+	case Paused:
+		return "paused"
+	case FastForwarding:
+		return "fast forwarding"
+	case Rewinding:
+		return "rewinding"
+	case Shuttling:
+		return "shuttling"
+	default:
+		return "unknown"
+	}
+}
+
+// command returns the motion control command that corresponds to the state
+func (s State) command() Command {
+	switch s {
+	case Playing:
+		return PlayCmd
+	case Paused:
+		return PauseCmd
+	case FastForwarding:
+		return FastForwardCmd
+	case Rewinding:
+		return RewindCmd
+	case Shuttling:
+		return ShuttleCmd
+	default:
+		return StopCmd
+	}
+}
+
+// DeviceOption is an option for a Device.
+type DeviceOption func(*Device)
+
+// HandleCommand is an option to set a callback that is called for each command, after the device has handled it.
+// The callback must not call methods of the device that send messages.
+func HandleCommand(fn func(in Instruction)) DeviceOption {
+	return func(d *Device) {
+		d.onCommand = fn
+	}
+}
+
+// Device emulates the transport of a MMC device (e.g. a tape machine) that is controlled via MMC commands.
+// It responds to Read and Update commands with response messages.
+// The time code does not advance on its own; use SetTime to move it while playing.
+// Its methods may be called from different goroutines.
+type Device struct {
+	id        byte
+	out       drivers.Out
+	stop      func()
+	onCommand func(Instruction)
+
+	sendMu sync.Mutex
+
+	mu          sync.Mutex
+	state       State
+	speed       float64
+	time        Time
+	recording   bool
+	recordReady Tracks
+	lastCommand Command
+	fields      map[Field][]byte
+}
+
+// NewDevice returns a device with the given device ID, that listens on the given in port for commands and sends
+// the responses to the given out port. The ports are opened, if needed.
+func NewDevice(in drivers.In, out drivers.Out, deviceID byte, rate FrameRate, opts ...DeviceOption) (*Device, error) {
+	d := &Device{
+		id:     deviceID,
+		out:    out,
+		time:   Time{Rate: rate},
+		fields: map[Field][]byte{},
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	if !out.IsOpen() {
+		err := out.Open()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	stop, err := midi.ListenTo(in, d.receive, midi.UseSysEx())
+	if err != nil {
+		return nil, err
+	}
+
+	d.stop = stop
+	return d, nil
+}
+
+// Close stops listening.
+func (d *Device) Close() {
+	d.stop()
+}
+
+// State returns the motion state of the device.
+func (d *Device) State() State {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.state
+}
+
+// Speed returns the current speed (1 is the normal play speed, 0 if stopped or paused).
+func (d *Device) Speed() float64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.speed
+}
+
+// Time returns the current time code.
+func (d *Device) Time() Time {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.time
+}
+
+// SetTime sets the current time code.
+func (d *Device) SetTime(t Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.time = t
+}
+
+// Recording returns true, if the device is recording.
+func (d *Device) Recording() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.recording
+}
+
+// RecordReady returns the tracks that are armed for recording.
+func (d *Device) RecordReady() Tracks {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append(Tracks(nil), d.recordReady...)
+}
+
+func (d *Device) receive(msg midi.Message, timestampms int32) {
+	var data []byte
+	if !msg.GetSysEx(&data) || len(data) < 3 || data[0] != 0x7F || data[2] != 0x06 {
+		return
+	}
+
+	if data[1] != d.id && data[1] != AllDevices {
+		return
+	}
+
+	m, err := ParseCommands(msg)
+	if err != nil {
+		return
+	}
+
+	var responses []Response
+	for _, in := range m.Instructions {
+		responses = append(responses, d.handle(in)...)
+		if d.onCommand != nil {
+			d.onCommand(in)
+		}
+	}
+
+	if len(responses) > 0 {
+		d.sendMu.Lock()
+		d.out.Send(ResponseMessage{DeviceID: d.id, Responses: responses}.SysEx())
+		d.sendMu.Unlock()
+	}
+}
+
+// setState sets the motion state. d.mu must be locked.
+func (d *Device) setState(s State, speed float64) {
+	d.state = s
+	d.speed = speed
+	if s != Playing || speed != 1 {
+		d.recording = false
+	}
+}
+
+// handle handles a single instruction and returns the responses
+func (d *Device) handle(in Instruction) []Response {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	switch c := in.(type) {
+	case Command:
+		d.lastCommand = c
+		switch c {
+		case StopCmd, EjectCmd:
+			d.setState(Stopped, 0)
+		case PlayCmd, DeferredPlayCmd:
+			d.setState(Playing, 1)
+		case FastForwardCmd:
+			d.setState(FastForwarding, 10)
+		case RewindCmd:
+			d.setState(Rewinding, -10)
+		case PauseCmd:
+			d.setState(Paused, 0)
+		case RecordPauseCmd:
+			d.setState(Paused, 0)
+		case RecordStrobeCmd:
+			if d.state != Playing || d.speed != 1 {
+				d.setState(Playing, 1)
+			}
+			d.recording = d.recordReady.Any()
+		case RecordExitCmd:
+			d.recording = false
+		case ResetCmd:
+			rate := d.time.Rate
+			d.setState(Stopped, 0)
+			d.time = Time{Rate: rate}
+			d.recordReady = nil
+			d.fields = map[Field][]byte{}
+		}
+	case Write:
+		d.lastCommand = WriteCmd
+		d.write(c.Field, c.Data)
+	case MaskedWrite:
+		d.lastCommand = MaskedWriteCmd
+		if c.Field == TrackRecordReadyField {
+			d.recordReady = c.Apply(d.recordReady)
+		} else {
+			d.fields[c.Field] = c.Apply(d.fields[c.Field])
+		}
+	case Read:
+		return d.read(c.Fields)
+	case Generic:
+		if c.Cmd == UpdateCmd && len(c.Data) > 1 {
+			// the first byte is the sub command (begin or end)
+			fields := make([]Field, len(c.Data)-1)
+			for i, f := range c.Data[1:] {
+				fields[i] = Field(f)
+			}
+			return d.read(fields)
+		}
+	case Locate:
+		d.lastCommand = LocateCmd
+		t := c.Target
+		if c.Field != 0 {
+			var err error
+			t, err = TimeFromBytes(d.fieldData(c.Field))
+			if err != nil {
+				return nil
+			}
+		}
+		t.Rate = d.time.Rate
+		d.time = t
+		d.setState(Stopped, 0)
+	case VariablePlay:
+		d.lastCommand = VariablePlayCmd
+		d.setState(Playing, c.Speed)
+	case Search:
+		d.lastCommand = SearchCmd
+		d.setState(Shuttling, c.Speed)
+	case Shuttle:
+		d.lastCommand = ShuttleCmd
+		d.setState(Shuttling, c.Speed)
+	case Step:
+		d.lastCommand = StepCmd
+		d.setState(Paused, 0)
+		d.time = TimeFromFrames(d.time.Rate, d.time.TotalFrames()+int(c.Steps))
+	}
+	return nil
+}
+
+// write writes to the given field. d.mu must be locked.
+func (d *Device) write(f Field, data []byte) {
+	switch f {
+	case SelectedTimeCodeField:
+		t, err := TimeFromBytes(data)
+		if err == nil {
+			t.Rate = d.time.Rate
+			d.time = t
+		}
+	case TrackRecordReadyField:
+		d.recordReady = append(Tracks(nil), data...)
+	default:
+		d.fields[f] = append([]byte(nil), data...)
+	}
+}
+
+// fieldData returns the data of the given field. d.mu must be locked.
+func (d *Device) fieldData(f Field) []byte {
+	switch f {
+	case SelectedTimeCodeField:
+		return d.time.Bytes()
+	case TrackRecordReadyField:
+		return d.recordReady
+	case MotionControlTallyField:
+		return []byte{byte(d.lastCommand), byte(d.state.command()), 0}
+	case RecordStatusField:
+		if d.recording {
+			return []byte{0x01}
+		}
+		return []byte{0x00}
+	default:
+		return d.fields[f]
+	}
+}
+
+// read returns the responses for the given fields. d.mu must be locked.
+// Unknown fields are not included.
+func (d *Device) read(fields []Field) (res []Response) {
+	for _, f := range fields {
+		var data []byte
+		if f.IsShortTimeCode() {
+			data = d.fieldData(f &^ 0x20)
+			if len(data) == 5 {
+				data = data[3:]
+			}
+		} else {
+			data = d.fieldData(f)
+		}
+
+		if data == nil && f != TrackRecordReadyField {
+			continue
+		}
+		res = append(res, Response{Field: f, Data: data})
+	}
+	return
+}
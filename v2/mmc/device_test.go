@@ -0,0 +1,124 @@
+package mmc
+
+import (
+	"testing"
+
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/drivers/testdrv"
+)
+
+func TestDevice(t *testing.T) {
+	a := testdrv.New("controller")
+	b := testdrv.New("device")
+
+	aIns, _ := a.Ins()
+	aOuts, _ := a.Outs()
+	bIns, _ := b.Ins()
+	bOuts, _ := b.Outs()
+
+	// the device listens to the messages the controller sends to a and the other way round
+	dev, err := NewDevice(aIns[0], bOuts[0], 0x10, FPS25)
+	if err != nil {
+		t.Fatalf("NewDevice() returned error: %v", err)
+	}
+	defer dev.Close()
+
+	var responses []ResponseMessage
+	_, err = midi.ListenTo(bIns[0], func(msg midi.Message, timestampms int32) {
+		r, err := ParseResponses(msg)
+		if err == nil {
+			responses = append(responses, r)
+		}
+	}, midi.UseSysEx())
+
+	if err != nil {
+		t.Fatalf("ListenTo() returned error: %v", err)
+	}
+
+	out := aOuts[0]
+	out.Open()
+
+	send := func(id byte, instructions ...Instruction) {
+		err := out.Send(Commands(id, instructions...).SysEx())
+		if err != nil {
+			t.Fatalf("Send() returned error: %v", err)
+		}
+	}
+
+	send(0x10, PlayCmd)
+	if dev.State() != Playing || dev.Speed() != 1 {
+		t.Errorf("state = %v, speed = %v; expected playing with speed 1", dev.State(), dev.Speed())
+	}
+
+	// other device
+	send(0x11, StopCmd)
+	if dev.State() != Playing {
+		t.Errorf("device reacted to command for other device")
+	}
+
+	send(AllDevices, Shuttle{Speed: -2.5})
+	if dev.State() != Shuttling || dev.Speed() != -2.5 {
+		t.Errorf("state = %v, speed = %v; expected shuttling with speed -2.5", dev.State(), dev.Speed())
+	}
+
+	target := Time{Rate: FPS25, Minutes: 1, Seconds: 30, Frames: 24}
+	send(0x10, Locate{Target: target}, Step{Steps: 2})
+	if tm := dev.Time(); tm.String() != "00:01:31:01.00" || dev.State() != Paused {
+		t.Errorf("time = %v, state = %v; expected 00:01:31:01.00, paused", tm, dev.State())
+	}
+
+	send(0x10, WriteTime(GP0Field, target), Locate{Field: GP0Field})
+	if tm := dev.Time(); tm != target || dev.State() != Stopped {
+		t.Errorf("time = %v, state = %v; expected %v, stopped", tm, dev.State(), target)
+	}
+
+	// recording without armed tracks
+	send(0x10, RecordStrobeCmd)
+	if dev.State() != Playing || dev.Recording() {
+		t.Errorf("state = %v, recording = %v; expected playing without recording", dev.State(), dev.Recording())
+	}
+
+	send(0x10,
+		Write{Field: TrackRecordReadyField, Data: Tracks(nil).Set(AudioTrack(1), true)},
+		MaskedWriteTrack(TrackRecordReadyField, AudioTrack(4), true),
+		MaskedWriteTrack(TrackRecordReadyField, AudioTrack(1), false),
+		RecordStrobeCmd,
+	)
+
+	ready := dev.RecordReady()
+	if !dev.Recording() || ready.IsSet(AudioTrack(1)) || !ready.IsSet(AudioTrack(4)) {
+		t.Errorf("recording = %v, record ready = %07b; expected recording on track 4", dev.Recording(), ready)
+	}
+
+	send(0x10, Read{Fields: []Field{SelectedTimeCodeField, SelectedTimeCodeField.Short(), MotionControlTallyField, RecordStatusField, GP7Field}})
+
+	if len(responses) != 1 {
+		t.Fatalf("got %v responses; expected 1", len(responses))
+	}
+
+	r := responses[0]
+	if r.DeviceID != 0x10 || len(r.Responses) != 4 {
+		t.Fatalf("unexpected response: %v", r)
+	}
+
+	if tc, _ := r.Responses[0].Time(); tc != target {
+		t.Errorf("selected time code = %v; expected %v", tc, target)
+	}
+
+	if short := r.Responses[1]; short.Field != 0x21 || len(short.Data) != 2 || short.Data[0] != 24 {
+		t.Errorf("short time code = %v", short)
+	}
+
+	if tally, _ := r.Response(MotionControlTallyField); len(tally.Data) != 3 || Command(tally.Data[0]) != RecordStrobeCmd || Command(tally.Data[1]) != PlayCmd {
+		t.Errorf("motion control tally = %v", tally)
+	}
+
+	if status, _ := r.Response(RecordStatusField); len(status.Data) != 1 || status.Data[0] != 0x01 {
+		t.Errorf("record status = %v", status)
+	}
+
+	send(0x10, StopCmd, ResetCmd)
+	if dev.State() != Stopped || dev.Recording() || dev.RecordReady().Any() || dev.Time().TotalFrames() != 0 {
+		t.Errorf("device has not been reset")
+	}
+}
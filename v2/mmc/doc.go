@@ -4,5 +4,9 @@
 
 /*
 Package mmc helps with reading and writing of MIDI Universal Real Time SysEx Commands.
+
+MIDI Machine Control (MMC) command messages are built and parsed with Commands and ParseCommands,
+response messages with ResponseMessage and ParseResponses.
+Device emulates the transport of a MMC device that is controlled via a MIDI port.
 */
 package mmc
@@ -0,0 +1,267 @@
+package mmc
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Field is the name of an information field of a MMC device.
+// Fields 01-1F are standard time code fields, 21-3F are their short forms (frames and subframes only),
+// fields 40-77 have a variable length and fields 78-7F have no data.
+type Field byte
+
+const (
+	SelectedTimeCodeField         Field = 0x01
+	SelectedMasterCodeField       Field = 0x02
+	RequestedOffsetField          Field = 0x03
+	ActualOffsetField             Field = 0x04
+	LockDeviationField            Field = 0x05
+	GeneratorTimeCodeField        Field = 0x06
+	MIDITimeCodeInputField        Field = 0x07
+	GP0Field                      Field = 0x08
+	GP1Field                      Field = 0x09
+	GP2Field                      Field = 0x0A
+	GP3Field                      Field = 0x0B
+	GP4Field                      Field = 0x0C
+	GP5Field                      Field = 0x0D
+	GP6Field                      Field = 0x0E
+	GP7Field                      Field = 0x0F
+	SignatureField                Field = 0x40
+	UpdateRateField               Field = 0x41
+	ResponseErrorField            Field = 0x42
+	CommandErrorField             Field = 0x43
+	CommandErrorLevelField        Field = 0x44
+	TimeStandardField             Field = 0x45
+	SelectedTimeCodeSourceField   Field = 0x46
+	SelectedTimeCodeUserbitsField Field = 0x47
+	MotionControlTallyField       Field = 0x48
+	VelocityTallyField            Field = 0x49
+	StopModeField                 Field = 0x4A
+	FastModeField                 Field = 0x4B
+	RecordModeField               Field = 0x4C
+	RecordStatusField             Field = 0x4D
+	TrackRecordStatusField        Field = 0x4E
+	TrackRecordReadyField         Field = 0x4F
+	GlobalMonitorField            Field = 0x50
+	RecordMonitorField            Field = 0x51
+	TrackSyncMonitorField         Field = 0x52
+	TrackInputMonitorField        Field = 0x53
+	StepLengthField               Field = 0x54
+	PlaySpeedReferenceField       Field = 0x55
+	FixedSpeedField               Field = 0x56
+	LifterDefeatField             Field = 0x57
+	ControlDisableField           Field = 0x58
+	ResolvedPlayModeField         Field = 0x59
+	ChaseModeField                Field = 0x5A
+	GeneratorCommandTallyField    Field = 0x5B
+	GeneratorSetUpField           Field = 0x5C
+	GeneratorUserbitsField        Field = 0x5D
+	MTCCommandTallyField          Field = 0x5E
+	MTCSetUpField                 Field = 0x5F
+	ProcedureResponseField        Field = 0x60
+	EventResponseField            Field = 0x61
+	TrackMuteField                Field = 0x62
+	VITCInsertEnableField         Field = 0x63
+	ResponseSegmentField          Field = 0x64
+	FailureField                  Field = 0x65
+	WaitField                     Field = 0x7C
+	ResumeField                   Field = 0x7F
+)
+
+// IsTimeCode returns true, if the field is a standard time code field (01-1F).
+func (f Field) IsTimeCode() bool {
+	return f >= 0x01 && f <= 0x1F
+}
+
+// IsShortTimeCode returns true, if the field is the short form of a standard time code field (21-3F).
+func (f Field) IsShortTimeCode() bool {
+	return f >= 0x21 && f <= 0x3F
+}
+
+// Short returns the short form of a standard time code field.
+func (f Field) Short() Field {
+	return f | 0x20
+}
+
+// appendField appends the field and its data (with the count, if needed) to bf
+func appendField(bf *bytes.Buffer, f Field, data []byte) {
+	bf.WriteByte(byte(f))
+	switch {
+	case f.IsTimeCode(), f.IsShortTimeCode():
+		bf.Write(data)
+	case f >= 0x40 && f <= 0x77:
+		bf.WriteByte(byte(len(data)))
+		bf.Write(data)
+	}
+}
+
+// readField reads a field and its data from b
+func readField(b []byte) (f Field, data, rest []byte, err error) {
+	f = Field(b[0])
+	b = b[1:]
+
+	var n int
+	switch {
+	case f.IsTimeCode():
+		n = 5
+	case f.IsShortTimeCode():
+		n = 2
+	case f >= 0x40 && f <= 0x77:
+		if len(b) == 0 {
+			return f, nil, nil, fmt.Errorf("missing length of field %02X", byte(f))
+		}
+		n, b = int(b[0]), b[1:]
+	case f >= 0x78:
+		n = 0
+	default:
+		return f, nil, nil, fmt.Errorf("invalid field %02X", byte(f))
+	}
+
+	if len(b) < n {
+		return f, nil, nil, fmt.Errorf("field %02X too short", byte(f))
+	}
+	return f, b[:n], b[n:], nil
+}
+
+// Tracks is a track bitmap, as it is used by the track fields (e.g. TrackRecordReadyField).
+// The bits are numbered from bit 0 of the first byte: 0 is the video track, 2 the time code track,
+// 3 and 4 the aux tracks A and B and 5 the first audio track. Each byte holds 7 bits.
+type Tracks []byte
+
+// The special tracks of a Tracks bitmap.
+const (
+	TrackVideo    = 0
+	TrackTimeCode = 2
+	TrackAuxA     = 3
+	TrackAuxB     = 4
+)
+
+// AudioTrack returns the bit of the given audio track (starting with 1) within a Tracks bitmap.
+func AudioTrack(n int) int {
+	return n + 4
+}
+
+// IsSet returns true, if the given bit is set.
+func (t Tracks) IsSet(bit int) bool {
+	i := bit / 7
+	return i < len(t) && t[i]&(1<<uint(bit%7)) != 0
+}
+
+// Set returns the bitmap with the given bit set or cleared. The bitmap is extended, if needed.
+func (t Tracks) Set(bit int, on bool) Tracks {
+	i := bit / 7
+	for len(t) <= i {
+		t = append(t, 0)
+	}
+	if on {
+		t[i] |= 1 << uint(bit%7)
+	} else {
+		t[i] &^= 1 << uint(bit%7)
+	}
+	return t
+}
+
+// Any returns true, if any bit is set.
+func (t Tracks) Any() bool {
+	for _, b := range t {
+		if b&0x7F != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Response is a field of a MMC response message. For standard time code fields, Data contains the
+// 5 bytes of the time code.
+type Response struct {
+	Field Field
+	Data  []byte
+}
+
+// TimeResponse returns the response for the given time code field and time.
+func TimeResponse(f Field, t Time) Response {
+	return Response{Field: f, Data: t.Bytes()}
+}
+
+// Time returns the time of a standard time code field.
+func (r Response) Time() (Time, bool) {
+	if !r.Field.IsTimeCode() {
+		return Time{}, false
+	}
+	t, err := TimeFromBytes(r.Data)
+	return t, err == nil
+}
+
+func (r Response) String() string {
+	if t, ok := r.Time(); ok {
+		return fmt.Sprintf("%02X: %s", byte(r.Field), t)
+	}
+	return fmt.Sprintf("%02X: % X", byte(r.Field), r.Data)
+}
+
+// ResponseMessage is a MMC response message (sub ID 07) with one or more response fields.
+type ResponseMessage struct {
+	DeviceID  byte
+	Responses []Response
+}
+
+func (m ResponseMessage) SysEx() []byte {
+	var bf bytes.Buffer
+	bf.Write([]byte{0xF0, 0x7F, m.DeviceID, 0x07})
+	for _, r := range m.Responses {
+		appendField(&bf, r.Field, r.Data)
+	}
+	bf.WriteByte(0xF7)
+	return bf.Bytes()
+}
+
+func (m ResponseMessage) String() string {
+	return fmt.Sprintf("MMC response device: %v fields: %v", m.DeviceID, m.Responses)
+}
+
+// Response returns the response for the given field.
+func (m ResponseMessage) Response(f Field) (Response, bool) {
+	for _, r := range m.Responses {
+		if r.Field == f {
+			return r, true
+		}
+	}
+	return Response{}, false
+}
+
+// ParseResponses parses a MMC response message (with 0xF0 and 0xF7).
+func ParseResponses(bt []byte) (m ResponseMessage, err error) {
+	data, err := unwrap(bt, 0x07)
+	if err != nil {
+		return m, err
+	}
+
+	m.DeviceID = bt[2]
+
+	for len(data) > 0 {
+		var r Response
+		r.Field, r.Data, data, err = readField(data)
+		if err != nil {
+			return m, err
+		}
+		m.Responses = append(m.Responses, r)
+	}
+	return m, nil
+}
+
+// unwrap checks the frame of a MMC message with the given sub ID and returns the data after the sub ID
+func unwrap(bt []byte, subID byte) ([]byte, error) {
+	if len(bt) < 5 {
+		return nil, fmt.Errorf("wrong length: %v (must be >= 5)", len(bt))
+	}
+
+	if bt[0] != 0xF0 || bt[1] != 0x7F || bt[len(bt)-1] != 0xF7 {
+		return nil, fmt.Errorf("no realtime system exclusive message")
+	}
+
+	if bt[3] != subID {
+		return nil, fmt.Errorf("wrong sub ID: %02X (must be %02X)", bt[3], subID)
+	}
+
+	return bt[4 : len(bt)-1], nil
+}
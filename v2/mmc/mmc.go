@@ -17,7 +17,7 @@ F0, 7F, nn, sub-ID, data, F7
 nn = channel number, 00 to 7F; 7F = global
 sub-IDs:
 01 = Long Form MTC
-02 = MIDI Show Control
+02 = MIDI Show Control (see package msc)
 03 = Notation Information
 04 = Device Control
 05 = Real Time MTC Cueing
@@ -40,18 +40,45 @@ type Command byte
 09 Pause (pause playback)
 0A Eject (disengage media container from MMC device)
 0B Chase
+0C Command Error Reset
 0D MMC Reset (to default/startup state)
 40 Write (AKA Record Ready, AKA Arm Tracks)
 
 	parameters: <length1> 4F <length2> <track-bitmap-bytes>
 
+41 Masked Write
+
+	parameters: <length>=04 <field> <byte number> <mask> <data>
+
+42 Read
+
+	parameters: <length> <fields>
+
+43 Update
+
+	parameters: <length> <sub command> <fields>
+
 44 Goto (AKA Locate)
 
 	parameters: <length>=06 01 <hours> <minutes> <seconds> <frames> <subframes>
+	or <length>=02 00 <field>
 
-47 Shuttle
+45 Variable Play, 46 Search, 47 Shuttle
 
 	parameters: <length>=03 <sh> <sm> <sl> (MIDI Standard Speed codes)
+
+48 Step
+
+	parameters: <length>=01 <steps> (bit 6 is the sign)
+
+4C Move
+
+	parameters: <length>=02 <destination field> <source field>
+
+7C Wait
+7F Resume
+
+Commands 01-3F and 78-7F have no parameters, commands 40-77 are followed by the number of parameter bytes.
 */
 const (
 	StopCmd         Command = 0x01
@@ -67,13 +94,23 @@ const (
 	PauseCmd        Command = 0x09
 	EjectCmd        Command = 0x0A
 	ChaseCmd        Command = 0x0B
+	ErrorResetCmd   Command = 0x0C
 	ResetCmd        Command = 0x0D
 	WriteCmd        Command = 0x40
 	RecordReadyCmd  Command = 0x40
 	ArmTrackCmd     Command = 0x40
+	MaskedWriteCmd  Command = 0x41
+	ReadCmd         Command = 0x42
+	UpdateCmd       Command = 0x43
 	GoToCmd         Command = 0x44
 	LocateCmd       Command = 0x44
+	VariablePlayCmd Command = 0x45
+	SearchCmd       Command = 0x46
 	ShuttleCmd      Command = 0x47
+	StepCmd         Command = 0x48
+	MoveCmd         Command = 0x4C
+	WaitCmd         Command = 0x7C
+	ResumeCmd       Command = 0x7F
 )
 
 func (c Command) String() string {
@@ -100,14 +137,34 @@ func (c Command) String() string {
 		return "EjectCmd"
 	case 0x0B:
 		return "ChaseCmd"
+	case 0x0C:
+		return "ErrorResetCmd"
 	case 0x0D:
 		return "ResetCmd"
 	case 0x40:
 		return "WriteCmd/RecordReadyCmd/ArmTrackCmd"
+	case 0x41:
+		return "MaskedWriteCmd"
+	case 0x42:
+		return "ReadCmd"
+	case 0x43:
+		return "UpdateCmd"
 	case 0x44:
 		return "GotoCmd/LocateCmd"
+	case 0x45:
+		return "VariablePlayCmd"
+	case 0x46:
+		return "SearchCmd"
 	case 0x47:
 		return "ShuttleCmd"
+	case 0x48:
+		return "StepCmd"
+	case 0x4C:
+		return "MoveCmd"
+	case 0x7C:
+		return "WaitCmd"
+	case 0x7F:
+		return "ResumeCmd"
 	default:
 		return "unknownCmd"
 	}
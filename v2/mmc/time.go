@@ -0,0 +1,146 @@
+package mmc
+
+import (
+	"fmt"
+)
+
+// FrameRate is the time code type of a standard time code.
+type FrameRate byte
+
+const (
+	FPS24     FrameRate = 0
+	FPS25     FrameRate = 1
+	FPS30Drop FrameRate = 2
+	FPS30     FrameRate = 3
+)
+
+// FramesPerSecond returns the (nominal) number of frames per second.
+func (r FrameRate) FramesPerSecond() int {
+	switch r & 0x03 {
+	case FPS24:
+		return 24
+	case FPS25:
+		return 25
+	default:
+		return 30
+	}
+}
+
+func (r FrameRate) String() string {
+	switch r & 0x03 {
+	case FPS24:
+		return "24fps"
+	case FPS25:
+		return "25fps"
+	case FPS30Drop:
+		return "29.97fps drop frame"
+	default:
+		return "30fps"
+	}
+}
+
+// Time is the standard time code of MMC, as it is used for the time code fields and the Locate command.
+// If HasStatus is true, Subframes contains the status byte instead of the subframes.
+type Time struct {
+	Rate       FrameRate
+	Hours      byte
+	Minutes    byte
+	Seconds    byte
+	Frames     byte
+	Subframes  byte
+	ColorFrame bool
+	Negative   bool
+	HasStatus  bool
+}
+
+// Bytes returns the 5 bytes of the standard time code (hr mn sc fr ff).
+func (t Time) Bytes() []byte {
+	hr := byte(t.Rate&0x03)<<5 | t.Hours&0x1F
+	mn := t.Minutes & 0x3F
+	if t.ColorFrame {
+		mn |= 0x40
+	}
+	fr := t.Frames & 0x1F
+	if t.Negative {
+		fr |= 0x40
+	}
+	if t.HasStatus {
+		fr |= 0x20
+	}
+	return []byte{hr, mn, t.Seconds & 0x3F, fr, t.Subframes & 0x7F}
+}
+
+// TimeFromBytes returns the Time of the given 5 bytes of a standard time code.
+func TimeFromBytes(b []byte) (t Time, err error) {
+	if len(b) != 5 {
+		return t, fmt.Errorf("wrong length of standard time code: %v (must be 5)", len(b))
+	}
+
+	t.Rate = FrameRate(b[0]>>5) & 0x03
+	t.Hours = b[0] & 0x1F
+	t.Minutes = b[1] & 0x3F
+	t.ColorFrame = b[1]&0x40 != 0
+	t.Seconds = b[2] & 0x3F
+	t.Frames = b[3] & 0x1F
+	t.HasStatus = b[3]&0x20 != 0
+	t.Negative = b[3]&0x40 != 0
+	t.Subframes = b[4] & 0x7F
+	return t, nil
+}
+
+// TotalFrames returns the number of frames since 00:00:00:00 (negative for negative times).
+// Subframes are ignored. For 29.97fps drop frame time code, the dropped frame numbers are not counted.
+func (t Time) TotalFrames() int {
+	fps := t.Rate.FramesPerSecond()
+	frames := ((int(t.Hours)*60+int(t.Minutes))*60+int(t.Seconds))*fps + int(t.Frames)
+
+	if t.Rate == FPS30Drop {
+		minutes := int(t.Hours)*60 + int(t.Minutes)
+		frames -= 2 * (minutes - minutes/10)
+	}
+
+	if t.Negative {
+		return -frames
+	}
+	return frames
+}
+
+// TimeFromFrames returns the Time for the given number of frames since 00:00:00:00 with the given rate.
+func TimeFromFrames(rate FrameRate, frames int) (t Time) {
+	t.Rate = rate & 0x03
+
+	if frames < 0 {
+		t.Negative = true
+		frames = -frames
+	}
+
+	fps := t.Rate.FramesPerSecond()
+
+	if t.Rate == FPS30Drop {
+		// add the dropped frame numbers: 2 per minute, except every 10th minute
+		d, m := frames/17982, frames%17982
+		frames += 18 * d
+		if m >= 2 {
+			frames += 2 * ((m - 2) / 1798)
+		}
+	}
+
+	t.Frames = byte(frames % fps)
+	secs := frames / fps
+	t.Seconds = byte(secs % 60)
+	t.Minutes = byte(secs / 60 % 60)
+	t.Hours = byte(secs / 3600 % 24)
+	return
+}
+
+func (t Time) String() string {
+	sign := ""
+	if t.Negative {
+		sign = "-"
+	}
+	sep := ":"
+	if t.Rate == FPS30Drop {
+		sep = ";"
+	}
+	return fmt.Sprintf("%s%02d:%02d:%02d%s%02d.%02d", sign, t.Hours, t.Minutes, t.Seconds, sep, t.Frames, t.Subframes)
+}
@@ -0,0 +1,19 @@
+// Copyright (c) 2022 Marc René Arns. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+/*
+Package mpe helps with MIDI Polyphonic Expression (MPE).
+
+An MPE zone consists of a manager channel and a number of member channels. The lower zone has its manager on
+channel 0 and its member channels above, the upper zone has its manager on channel 15 and its member channels below.
+Zones are configured with the MPE Configuration Message (RPN 6) that is sent on the manager channel.
+
+A Sender allocates a member channel for each note, so that the pitch bend, the timbre (CC74) and the pressure
+(channel pressure) of each note can be controlled independently. If all member channels are in use,
+a note is stolen, based on the Strategy.
+
+A Receiver tracks the zone configuration and reassembles the messages of the member channels into notes with
+their expression.
+*/
+package mpe
@@ -0,0 +1,134 @@
+package mpe
+
+import (
+	"gitlab.com/gomidi/midi/v2"
+)
+
+// Receiver reassembles the messages of the member channels into notes with their expression.
+// The zones are configured by the MPE configuration messages that are received or by setting the Config.
+// It is not safe for concurrent use.
+type Receiver struct {
+	// Config is the zone configuration
+	Config Config
+
+	// NoteOn is called, when a note starts on a member channel. The expression is the one that has been
+	// set on the channel before the note started.
+	NoteOn func(n Note, velocity uint8, e Expression)
+
+	// NoteOff is called, when a note on a member channel ends
+	NoteOff func(n Note, velocity uint8)
+
+	// Expression is called for each playing note of a member channel, when its pitch bend, timbre or pressure changes
+	Expression func(n Note, e Expression)
+
+	// Message is called for all other messages, e.g. the messages of the manager channels (which apply to the whole zone),
+	// the messages of channels outside of the zones and the MPE configuration messages
+	Message func(msg midi.Message)
+
+	expression [16]Expression
+	notes      [16][]uint8
+	hasInit    [16]bool
+}
+
+// Receive handles the given message.
+func (r *Receiver) Receive(msg midi.Message) {
+	var ch, key, val uint8
+	var pitch int16
+
+	if r.Config.Read(msg) {
+		r.reset()
+	}
+
+	if !msg.GetChannel(&ch) {
+		r.message(msg)
+		return
+	}
+
+	if _, isManager, ok := r.Config.Zone(ch); !ok || isManager {
+		r.message(msg)
+		return
+	}
+
+	e := r.channelExpression(ch)
+
+	switch {
+	case msg.GetNoteStart(nil, &key, &val):
+		r.notes[ch] = append(r.notes[ch], key)
+		if r.NoteOn != nil {
+			r.NoteOn(Note{Channel: ch, Key: key}, val, *e)
+		}
+	case msg.GetNoteOff(nil, &key, &val), msg.GetNoteOn(nil, &key, &val):
+		if !r.removeNote(ch, key) {
+			return
+		}
+		if r.NoteOff != nil {
+			r.NoteOff(Note{Channel: ch, Key: key}, val)
+		}
+	case msg.GetPitchBend(nil, &pitch, nil):
+		e.PitchBend = pitch
+		r.expressionChanged(ch)
+	case msg.GetAfterTouch(nil, &val):
+		e.Pressure = val
+		r.expressionChanged(ch)
+	case msg.GetControlChange(nil, &key, &val) && key == CCTimbre:
+		e.Timbre = val
+		r.expressionChanged(ch)
+	default:
+		r.message(msg)
+	}
+}
+
+// Notes returns the currently playing notes.
+func (r *Receiver) Notes() (notes []Note) {
+	for ch, keys := range r.notes {
+		for _, key := range keys {
+			notes = append(notes, Note{Channel: uint8(ch), Key: key})
+		}
+	}
+	return
+}
+
+// NoteExpression returns the current expression of the given note.
+func (r *Receiver) NoteExpression(n Note) Expression {
+	return *r.channelExpression(n.Channel & 0xF)
+}
+
+func (r *Receiver) channelExpression(ch uint8) *Expression {
+	if !r.hasInit[ch] {
+		r.expression[ch] = DefaultExpression
+		r.hasInit[ch] = true
+	}
+	return &r.expression[ch]
+}
+
+func (r *Receiver) message(msg midi.Message) {
+	if r.Message != nil {
+		r.Message(msg)
+	}
+}
+
+func (r *Receiver) expressionChanged(ch uint8) {
+	if r.Expression == nil {
+		return
+	}
+
+	for _, key := range r.notes[ch] {
+		r.Expression(Note{Channel: ch, Key: key}, r.expression[ch])
+	}
+}
+
+func (r *Receiver) removeNote(ch, key uint8) bool {
+	for i, k := range r.notes[ch] {
+		if k == key {
+			r.notes[ch] = append(r.notes[ch][:i], r.notes[ch][i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// reset resets the notes and expressions, after the zones have been configured
+func (r *Receiver) reset() {
+	r.notes = [16][]uint8{}
+	r.hasInit = [16]bool{}
+}
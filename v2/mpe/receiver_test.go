@@ -0,0 +1,68 @@
+package mpe
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"gitlab.com/gomidi/midi/v2"
+)
+
+func TestReceiver(t *testing.T) {
+	var bf bytes.Buffer
+
+	r := &Receiver{
+		NoteOn: func(n Note, velocity uint8, e Expression) {
+			fmt.Fprintf(&bf, "on %v/%v vel %v %+v\n", n.Channel, n.Key, velocity, e)
+		},
+		NoteOff: func(n Note, velocity uint8) {
+			fmt.Fprintf(&bf, "off %v/%v\n", n.Channel, n.Key)
+		},
+		Expression: func(n Note, e Expression) {
+			fmt.Fprintf(&bf, "expr %v/%v %+v\n", n.Channel, n.Key, e)
+		},
+		Message: func(msg midi.Message) {
+			if !msg.Is(midi.ControlChangeMsg) {
+				fmt.Fprintf(&bf, "msg %s\n", msg)
+			}
+		},
+	}
+
+	s := NewSender(LowerZone(2), RoundRobin)
+
+	var msgs []midi.Message
+	msgs = append(msgs, s.Configure()...)
+
+	a, m := s.NoteOn(60, 100, Expression{PitchBend: -100, Timbre: 10, Pressure: 5})
+	msgs = append(msgs, m...)
+	msgs = append(msgs, s.PitchBend(a, 200)...)
+
+	b, m := s.NoteOn(64, 90, DefaultExpression)
+	msgs = append(msgs, m...)
+	msgs = append(msgs, s.Timbre(b, 80)...)
+	msgs = append(msgs, s.Pressure(a, 50)...)
+	msgs = append(msgs, s.NoteOff(a, 0)...)
+	msgs = append(msgs, midi.Pitchbend(0, 500), midi.NoteOn(5, 30, 100))
+
+	for _, msg := range msgs {
+		r.Receive(msg)
+	}
+
+	expected := `on 1/60 vel 100 {PitchBend:-100 Timbre:10 Pressure:5}
+expr 1/60 {PitchBend:200 Timbre:10 Pressure:5}
+on 2/64 vel 90 {PitchBend:0 Timbre:64 Pressure:0}
+expr 2/64 {PitchBend:0 Timbre:80 Pressure:0}
+expr 1/60 {PitchBend:200 Timbre:10 Pressure:50}
+off 1/60
+msg PitchBend channel: 0 pitch: 500 (8692)
+msg NoteOn channel: 5 key: 30 velocity: 100
+`
+
+	if got := bf.String(); got != expected {
+		t.Errorf("got:\n%s\nwanted:\n%s", got, expected)
+	}
+
+	if notes := r.Notes(); len(notes) != 1 || notes[0].Channel != 2 || notes[0].Key != 64 {
+		t.Errorf("Notes() = %v", notes)
+	}
+}
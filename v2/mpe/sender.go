@@ -0,0 +1,204 @@
+package mpe
+
+import (
+	"gitlab.com/gomidi/midi/v2"
+)
+
+// Strategy is the strategy for the allocation of member channels.
+type Strategy int
+
+const (
+	// RoundRobin allocates the member channels one after the other. If all member channels are in use,
+	// the note on the next channel in the rotation is stolen.
+	RoundRobin Strategy = iota
+
+	// LeastRecentlyUsed allocates the free member channel that has been used least recently. If all member channels are in use,
+	// the oldest note is stolen.
+	LeastRecentlyUsed
+)
+
+// CCTimbre is the controller for the timbre (third dimension) of a note.
+const CCTimbre uint8 = 74
+
+// Expression is the expression of a note.
+type Expression struct {
+	// PitchBend is the pitch bend of the note (-8192 to 8191)
+	PitchBend int16
+
+	// Timbre is the value of CC74 (default 64)
+	Timbre uint8
+
+	// Pressure is the channel pressure of the note
+	Pressure uint8
+}
+
+// DefaultExpression is the expression of a note without pitch bend and pressure and with the timbre in the center.
+var DefaultExpression = Expression{Timbre: 64}
+
+// messages returns the messages to set the expression on the given channel
+func (e Expression) messages(channel uint8) []midi.Message {
+	return []midi.Message{
+		midi.Pitchbend(channel, e.PitchBend),
+		midi.ControlChange(channel, CCTimbre, e.Timbre),
+		midi.AfterTouch(channel, e.Pressure),
+	}
+}
+
+// Note is a note that is playing on a channel.
+type Note struct {
+	Channel uint8
+	Key     uint8
+
+	// id identifies notes of a Sender, to prevent changes of stolen notes
+	id uint64
+}
+
+type memberState struct {
+	key      uint8
+	active   bool
+	id       uint64
+	lastUsed uint64
+}
+
+// Sender allocates the member channels of a zone to the notes that are played.
+// It returns the messages that should be sent and is not safe for concurrent use.
+type Sender struct {
+	zone     Zone
+	strategy Strategy
+	members  []uint8
+	state    [16]memberState
+	next     int
+	counter  uint64
+}
+
+// NewSender returns a Sender for the given zone that allocates the member channels with the given strategy.
+func NewSender(zone Zone, strategy Strategy) *Sender {
+	return &Sender{
+		zone:     zone,
+		strategy: strategy,
+		members:  zone.Members(),
+	}
+}
+
+// Zone returns the zone of the sender.
+func (s *Sender) Zone() Zone {
+	return s.zone
+}
+
+// Configure returns the MPE configuration message for the zone of the sender.
+func (s *Sender) Configure() []midi.Message {
+	return s.zone.Configure()
+}
+
+// NoteOn allocates a member channel for the given key and returns the note and the messages to send:
+// a note off for a stolen note (if any), the initial expression of the note and the note on.
+// If the zone has no member channels, the note is played on the manager channel.
+func (s *Sender) NoteOn(key, velocity uint8, e Expression) (n Note, msgs []midi.Message) {
+	s.counter++
+
+	if len(s.members) == 0 {
+		ch := s.zone.Manager()
+		return Note{Channel: ch, Key: key, id: s.counter}, []midi.Message{midi.NoteOn(ch, key, velocity)}
+	}
+
+	ch := s.allocate()
+	st := &s.state[ch]
+
+	if st.active {
+		msgs = append(msgs, midi.NoteOff(ch, st.key))
+	}
+
+	*st = memberState{key: key, active: true, id: s.counter, lastUsed: s.counter}
+
+	msgs = append(msgs, e.messages(ch)...)
+	msgs = append(msgs, midi.NoteOn(ch, key, velocity))
+	return Note{Channel: ch, Key: key, id: s.counter}, msgs
+}
+
+// allocate returns the member channel for the next note
+func (s *Sender) allocate() uint8 {
+	switch s.strategy {
+	case LeastRecentlyUsed:
+		var free, busy int = -1, -1
+
+		for i, ch := range s.members {
+			st := s.state[ch]
+			switch {
+			case !st.active && (free < 0 || st.lastUsed < s.state[s.members[free]].lastUsed):
+				free = i
+			case st.active && (busy < 0 || st.lastUsed < s.state[s.members[busy]].lastUsed):
+				busy = i
+			}
+		}
+
+		if free >= 0 {
+			return s.members[free]
+		}
+		return s.members[busy]
+	default:
+		n := len(s.members)
+		idx := s.next % n
+
+		for i := 0; i < n; i++ {
+			j := (s.next + i) % n
+			if !s.state[s.members[j]].active {
+				idx = j
+				break
+			}
+		}
+
+		s.next = idx + 1
+		return s.members[idx]
+	}
+}
+
+// isPlaying returns true, if the note has not been stopped or stolen
+func (s *Sender) isPlaying(n Note) bool {
+	if len(s.members) == 0 {
+		return n.Channel == s.zone.Manager()
+	}
+	st := s.state[n.Channel&0xF]
+	return st.active && st.id == n.id
+}
+
+// NoteOff returns the note off message for the given note.
+// If the note has already been stopped or stolen, no message is returned.
+func (s *Sender) NoteOff(n Note, velocity uint8) []midi.Message {
+	if !s.isPlaying(n) {
+		return nil
+	}
+
+	s.counter++
+	st := &s.state[n.Channel&0xF]
+	st.active = false
+	st.lastUsed = s.counter
+
+	return []midi.Message{midi.NoteOffVelocity(n.Channel, n.Key, velocity)}
+}
+
+// PitchBend returns the pitch bend message for the given note.
+// If the note has already been stopped or stolen, no message is returned.
+func (s *Sender) PitchBend(n Note, value int16) []midi.Message {
+	if !s.isPlaying(n) {
+		return nil
+	}
+	return []midi.Message{midi.Pitchbend(n.Channel, value)}
+}
+
+// Timbre returns the timbre (CC74) message for the given note.
+// If the note has already been stopped or stolen, no message is returned.
+func (s *Sender) Timbre(n Note, value uint8) []midi.Message {
+	if !s.isPlaying(n) {
+		return nil
+	}
+	return []midi.Message{midi.ControlChange(n.Channel, CCTimbre, value)}
+}
+
+// Pressure returns the channel pressure message for the given note.
+// If the note has already been stopped or stolen, no message is returned.
+func (s *Sender) Pressure(n Note, value uint8) []midi.Message {
+	if !s.isPlaying(n) {
+		return nil
+	}
+	return []midi.Message{midi.AfterTouch(n.Channel, value)}
+}
@@ -0,0 +1,160 @@
+package mpe
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gitlab.com/gomidi/midi/v2"
+)
+
+func TestZone(t *testing.T) {
+	tests := []struct {
+		zone     Zone
+		members  []uint8
+		expected string
+	}{
+		{LowerZone(3), []uint8{1, 2, 3}, "lower zone (3 member channels)"},
+		{UpperZone(2), []uint8{14, 13}, "upper zone (2 member channels)"},
+		{LowerZone(15), []uint8{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}, "lower zone (15 member channels)"},
+		{UpperZone(0), []uint8{}, "upper zone (0 member channels)"},
+	}
+
+	for i, test := range tests {
+		got := test.zone.Members()
+
+		if !reflect.DeepEqual(got, test.members) {
+			t.Errorf("[%v] Members() = %v; wanted %v", i, got, test.members)
+		}
+
+		for _, ch := range got {
+			if !test.zone.IsMember(ch) {
+				t.Errorf("[%v] IsMember(%v) = false", i, ch)
+			}
+		}
+
+		if test.zone.IsMember(test.zone.Manager()) {
+			t.Errorf("[%v] manager channel must not be a member", i)
+		}
+
+		if s := test.zone.String(); s != test.expected {
+			t.Errorf("[%v] String() = %q; wanted %q", i, s, test.expected)
+		}
+	}
+}
+
+func TestConfig(t *testing.T) {
+	var c Config
+
+	read := func(msgs []midi.Message) (configured bool) {
+		for _, msg := range msgs {
+			if c.Read(msg) {
+				configured = true
+			}
+		}
+		return
+	}
+
+	if !read(LowerZone(7).Configure()) || c.Lower.MemberChannels != 7 {
+		t.Fatalf("lower zone not configured: %v", c.Lower)
+	}
+
+	if !read(UpperZone(10).Configure()) || c.Upper.MemberChannels != 10 || c.Lower.MemberChannels != 4 {
+		t.Errorf("expected upper zone with 10 and lower zone with 4 member channels, got %v and %v", c.Upper, c.Lower)
+	}
+
+	if !read(LowerZone(15).Configure()) || c.Upper.MemberChannels != 0 {
+		t.Errorf("expected upper zone to be disabled, got %v", c.Upper)
+	}
+
+	if read(PitchBendSensitivity(0, 2)) {
+		t.Errorf("pitch bend sensitivity must not configure a zone")
+	}
+
+	z, isManager, ok := c.Zone(15)
+	if !ok || isManager || z.Upper {
+		t.Errorf("Zone(15) = %v, %v, %v", z, isManager, ok)
+	}
+}
+
+func msgString(msgs []midi.Message) string {
+	var s []string
+	for _, msg := range msgs {
+		s = append(s, msg.String())
+	}
+	return strings.Join(s, "\n")
+}
+
+func TestSender(t *testing.T) {
+	tests := []struct {
+		strategy Strategy
+		channels []uint8
+		stolen   int
+	}{
+		// 3 notes on, 2nd note off, 2 notes on
+		{RoundRobin, []uint8{1, 2, 3, 2, 3}, 2},
+		{LeastRecentlyUsed, []uint8{1, 2, 3, 2, 1}, 0},
+	}
+
+	for i, test := range tests {
+		s := NewSender(LowerZone(3), test.strategy)
+
+		var notes []Note
+		for key := uint8(60); key < 63; key++ {
+			n, _ := s.NoteOn(key, 100, DefaultExpression)
+			notes = append(notes, n)
+		}
+
+		s.NoteOff(notes[1], 0)
+
+		n, _ := s.NoteOn(70, 100, DefaultExpression)
+		notes = append(notes, n)
+
+		n, msgs := s.NoteOn(71, 100, DefaultExpression)
+		notes = append(notes, n)
+
+		var got []uint8
+		for _, n := range notes {
+			got = append(got, n.Channel)
+		}
+
+		if !reflect.DeepEqual(got, test.channels) {
+			t.Errorf("[%v] channels = %v; wanted %v", i, got, test.channels)
+		}
+
+		stolen := notes[test.stolen]
+		if fmt.Sprint(msgs[0]) != fmt.Sprint(midi.NoteOff(stolen.Channel, stolen.Key)) {
+			t.Errorf("[%v] expected note off for stolen note, got\n%s", i, msgString(msgs))
+		}
+
+		if s.PitchBend(stolen, 100) != nil {
+			t.Errorf("[%v] stolen note must not get pitch bend", i)
+		}
+
+		if got := s.PitchBend(notes[4], 100); len(got) != 1 || fmt.Sprint(got[0]) != fmt.Sprint(midi.Pitchbend(stolen.Channel, 100)) {
+			t.Errorf("[%v] PitchBend() = %v", i, got)
+		}
+	}
+}
+
+func TestSenderLRU(t *testing.T) {
+	s := NewSender(UpperZone(3), LeastRecentlyUsed)
+
+	a, _ := s.NoteOn(60, 100, DefaultExpression)
+	b, _ := s.NoteOn(61, 100, DefaultExpression)
+	s.NoteOff(b, 0)
+	s.NoteOff(a, 0)
+
+	// channel 12 has never been used, then channel 13 (b) has been released before channel 14 (a)
+	var got []uint8
+	for key := uint8(62); key < 65; key++ {
+		n, _ := s.NoteOn(key, 100, DefaultExpression)
+		got = append(got, n.Channel)
+	}
+
+	expected := []uint8{12, 13, 14}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("channels = %v; wanted %v", got, expected)
+	}
+}
@@ -0,0 +1,183 @@
+package mpe
+
+import (
+	"fmt"
+
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/rpn_nrpn"
+)
+
+const (
+	// LowerManager is the manager channel of the lower zone
+	LowerManager uint8 = 0
+
+	// UpperManager is the manager channel of the upper zone
+	UpperManager uint8 = 15
+
+	// mcmParameter is the LSB of the RPN of the MPE configuration message (MSB is 0)
+	mcmParameter uint8 = 6
+
+	// maxMembers is the maximal number of member channels of a zone
+	maxMembers uint8 = 15
+)
+
+// Zone is a MPE zone.
+type Zone struct {
+	// Upper is true for the upper zone (manager channel 15) and false for the lower zone (manager channel 0)
+	Upper bool
+
+	// MemberChannels is the number of member channels (0-15). A zone without member channels is disabled.
+	MemberChannels uint8
+}
+
+// LowerZone returns the lower zone with the given number of member channels.
+func LowerZone(memberChannels uint8) Zone {
+	return Zone{MemberChannels: memberChannels}
+}
+
+// UpperZone returns the upper zone with the given number of member channels.
+func UpperZone(memberChannels uint8) Zone {
+	return Zone{Upper: true, MemberChannels: memberChannels}
+}
+
+// IsEnabled returns true, if the zone has member channels.
+func (z Zone) IsEnabled() bool {
+	return z.MemberChannels > 0
+}
+
+// Manager returns the manager channel of the zone.
+func (z Zone) Manager() uint8 {
+	if z.Upper {
+		return UpperManager
+	}
+	return LowerManager
+}
+
+// Members returns the member channels of the zone, starting with the channel next to the manager channel.
+func (z Zone) Members() []uint8 {
+	n := z.MemberChannels
+	if n > maxMembers {
+		n = maxMembers
+	}
+
+	members := make([]uint8, n)
+	for i := range members {
+		if z.Upper {
+			members[i] = UpperManager - 1 - uint8(i)
+		} else {
+			members[i] = LowerManager + 1 + uint8(i)
+		}
+	}
+	return members
+}
+
+// IsMember returns true, if the given channel is a member channel of the zone.
+func (z Zone) IsMember(channel uint8) bool {
+	n := z.MemberChannels
+	if n > maxMembers {
+		n = maxMembers
+	}
+
+	if z.Upper {
+		return channel < UpperManager && channel >= UpperManager-n
+	}
+	return channel > LowerManager && channel <= LowerManager+n
+}
+
+// Configure returns the MPE configuration message for the zone.
+func (z Zone) Configure() []midi.Message {
+	ch := z.Manager()
+	return []midi.Message{
+		midi.ControlChange(ch, rpn_nrpn.CC_RPN0, 0),
+		midi.ControlChange(ch, rpn_nrpn.CC_RPN1, mcmParameter),
+		midi.ControlChange(ch, rpn_nrpn.CC_MSB, z.MemberChannels),
+	}
+}
+
+func (z Zone) String() string {
+	name := "lower"
+	if z.Upper {
+		name = "upper"
+	}
+	return fmt.Sprintf("%s zone (%v member channels)", name, z.MemberChannels)
+}
+
+// PitchBendSensitivity returns the messages to set the pitch bend sensitivity of the given channel to the given
+// number of semitones. The default is 48 semitones for the member channels and 2 semitones for the manager channels.
+func PitchBendSensitivity(channel, semitones uint8) []midi.Message {
+	return rpn_nrpn.PitchBendSensitivity(channel, semitones, 0)
+}
+
+// Config is the configuration of the lower and upper zone.
+// It follows the rules of the MPE specification: if the configured zone overlaps with the other zone,
+// the other zone is shrunk or disabled.
+type Config struct {
+	Lower Zone
+	Upper Zone
+
+	handler *rpn_nrpn.Handler
+}
+
+// Set sets the given zone and adjusts the other zone, if needed.
+func (c *Config) Set(z Zone) {
+	if z.MemberChannels > maxMembers {
+		z.MemberChannels = maxMembers
+	}
+
+	other := &c.Upper
+	if z.Upper {
+		other = &c.Lower
+		c.Upper = z
+	} else {
+		c.Lower = z
+	}
+
+	switch {
+	case z.MemberChannels == maxMembers:
+		other.MemberChannels = 0
+	case other.MemberChannels > maxMembers-1-z.MemberChannels:
+		other.MemberChannels = maxMembers - 1 - z.MemberChannels
+	}
+}
+
+// Zone returns the zone the given channel belongs to, either as manager or as member channel.
+func (c *Config) Zone(channel uint8) (z Zone, isManager, ok bool) {
+	for _, z := range [2]Zone{c.Lower, c.Upper} {
+		if !z.IsEnabled() {
+			continue
+		}
+
+		if z.Manager() == channel {
+			return z, true, true
+		}
+
+		if z.IsMember(channel) {
+			return z, false, true
+		}
+	}
+	return Zone{}, false, false
+}
+
+// Read reads the given message and returns true, if it completed a MPE configuration message.
+// Then the configuration has been changed accordingly.
+func (c *Config) Read(msg midi.Message) (configured bool) {
+	var ch, cc, val uint8
+
+	if !msg.GetControlChange(&ch, &cc, &val) || (ch != LowerManager && ch != UpperManager) {
+		return false
+	}
+
+	if c.handler == nil {
+		c.handler = &rpn_nrpn.Handler{}
+		c.handler.RPN.MSB = func(channel, typ1, typ2, msbVal uint8) (handled bool) {
+			if typ1 != 0 || typ2 != mcmParameter {
+				return false
+			}
+
+			c.Set(Zone{Upper: channel == UpperManager, MemberChannels: msbVal})
+			return true
+		}
+	}
+
+	return c.handler.ReadCCMessage(ch, cc, val)
+}
@@ -0,0 +1,18 @@
+// Copyright (c) 2022 Marc René Arns. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+/*
+Package msc implements MIDI Show Control (MSC), the Universal Real Time SysEx messages with the sub ID 02.
+
+A MSC message addresses a device (or a group of devices, or all devices) and has a command format that describes the
+kind of controlled equipment (e.g. lighting, sound or machinery) and a command (e.g. GO, STOP or SET).
+Many commands refer to a cue, which is identified by its number and optionally by its cue list and cue path.
+Cue numbers, lists and paths consist of the digits 0-9 and the decimal point, e.g. "235.6".
+
+Messages are built with the builder functions (e.g. Go, TimedGo, Set) or as Message literals and converted to
+system exclusive messages via their SysEx method. Parse parses them.
+
+A Receiver calls its callbacks for the messages that are addressed to it.
+*/
+package msc
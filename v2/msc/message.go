@@ -0,0 +1,327 @@
+package msc
+
+import (
+	"bytes"
+	"fmt"
+
+	"gitlab.com/gomidi/midi/v2/mmc"
+)
+
+// Cue identifies a cue by its number and optionally by its cue list and cue path.
+// Each part consists of the digits 0-9 and the decimal point, e.g. "235.6". Empty parts are omitted.
+// For the commands that refer to a cue number, a list or a path can only be given together with a number.
+// A path without a list is sent with an empty list.
+type Cue struct {
+	Number string
+	List   string
+	Path   string
+}
+
+// IsZero returns true, if no part of the cue is set.
+func (c Cue) IsZero() bool {
+	return c.Number == "" && c.List == "" && c.Path == ""
+}
+
+func (c Cue) String() string {
+	var bf bytes.Buffer
+	bf.WriteString(c.Number)
+	if c.List != "" {
+		fmt.Fprintf(&bf, " list %s", c.List)
+	}
+	if c.Path != "" {
+		fmt.Fprintf(&bf, " path %s", c.Path)
+	}
+	return bf.String()
+}
+
+// validCuePart returns an error, if the part of a cue contains other characters than the digits and the decimal point
+func validCuePart(s string) error {
+	for _, r := range s {
+		if (r < '0' || r > '9') && r != '.' {
+			return fmt.Errorf("invalid character %q in cue %q", r, s)
+		}
+	}
+	return nil
+}
+
+// Validate returns an error, if a part of the cue contains other characters than the digits and the decimal point.
+func (c Cue) Validate() error {
+	for _, p := range []string{c.Number, c.List, c.Path} {
+		if err := validCuePart(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Message is a MIDI Show Control message.
+// Which of the fields are used, depends on the command:
+//   - Cue is used by the commands that refer to a cue, a cue list or a cue path (only the relevant part is sent)
+//   - Time is used by TIMED_GO and SET_CLOCK and by SET, if HasTime is true
+//   - Control and Value (14bit each) are used by SET
+//   - Macro is used by FIRE
+//
+// Data contains the bytes after the command for commands that are not known.
+type Message struct {
+	DeviceID byte
+	Format   Format
+	Command  Command
+	Cue      Cue
+	Time     mmc.Time
+	HasTime  bool
+	Control  uint16
+	Value    uint16
+	Macro    byte
+	Data     []byte
+}
+
+// Go returns a GO message that starts the given cue. If cue is zero, the next cue is started.
+func Go(deviceID byte, f Format, cue Cue) Message {
+	return Message{DeviceID: deviceID, Format: f, Command: GoCmd, Cue: cue}
+}
+
+// Stop returns a STOP message that stops the given cue. If cue is zero, all running cues are stopped.
+func Stop(deviceID byte, f Format, cue Cue) Message {
+	return Message{DeviceID: deviceID, Format: f, Command: StopCmd, Cue: cue}
+}
+
+// Resume returns a RESUME message that resumes the given cue. If cue is zero, all stopped cues are resumed.
+func Resume(deviceID byte, f Format, cue Cue) Message {
+	return Message{DeviceID: deviceID, Format: f, Command: ResumeCmd, Cue: cue}
+}
+
+// TimedGo returns a TIMED_GO message that starts the given cue with the given transition time.
+func TimedGo(deviceID byte, f Format, t mmc.Time, cue Cue) Message {
+	return Message{DeviceID: deviceID, Format: f, Command: TimedGoCmd, Cue: cue, Time: t, HasTime: true}
+}
+
+// Load returns a LOAD message that loads the given cue, so that it is started by the next GO.
+func Load(deviceID byte, f Format, cue Cue) Message {
+	return Message{DeviceID: deviceID, Format: f, Command: LoadCmd, Cue: cue}
+}
+
+// Set returns a SET message that sets the generic control to the given value (both 14bit).
+func Set(deviceID byte, f Format, control, value uint16) Message {
+	return Message{DeviceID: deviceID, Format: f, Command: SetCmd, Control: control, Value: value}
+}
+
+// Fire returns a FIRE message that fires the given macro.
+func Fire(deviceID byte, f Format, macro byte) Message {
+	return Message{DeviceID: deviceID, Format: f, Command: FireCmd, Macro: macro}
+}
+
+// AllOff returns an ALL_OFF message that stops all running cues and turns all outputs off.
+func AllOff(deviceID byte, f Format) Message {
+	return Message{DeviceID: deviceID, Format: f, Command: AllOffCmd}
+}
+
+// Restore returns a RESTORE message that restores the state before the last ALL_OFF.
+func Restore(deviceID byte, f Format) Message {
+	return Message{DeviceID: deviceID, Format: f, Command: RestoreCmd}
+}
+
+// Reset returns a RESET message that stops all cues and loads the first cue of each open cue list.
+func Reset(deviceID byte, f Format) Message {
+	return Message{DeviceID: deviceID, Format: f, Command: ResetCmd}
+}
+
+// GoOff returns a GO_OFF message that sends the given cue to its off state.
+func GoOff(deviceID byte, f Format, cue Cue) Message {
+	return Message{DeviceID: deviceID, Format: f, Command: GoOffCmd, Cue: cue}
+}
+
+// appendCue appends the cue number with its list and path, separated by 00
+func appendCue(bf *bytes.Buffer, c Cue) {
+	if c.Number == "" {
+		return
+	}
+	bf.WriteString(c.Number)
+	if c.List == "" && c.Path == "" {
+		return
+	}
+	bf.WriteByte(0)
+	bf.WriteString(c.List)
+	if c.Path == "" {
+		return
+	}
+	bf.WriteByte(0)
+	bf.WriteString(c.Path)
+}
+
+// Validate returns an error, if the message can't be sent, i.e. if
+//   - the part of the cue that is used by the command contains other characters than the digits and the decimal point
+//   - a cue list or cue path is given without a cue number for a command that refers to a cue number
+//   - the device ID, the format, the command or the data of an unknown command are not 7-bit values
+func (m Message) Validate() error {
+	if m.DeviceID > 0x7F || m.Format > 0x7F || m.Command > 0x7F {
+		return fmt.Errorf("invalid device ID %02X, format %02X or command %02X", m.DeviceID, byte(m.Format), byte(m.Command))
+	}
+
+	switch m.Command.cueKind() {
+	case cueNumber:
+		if m.Cue.Number == "" && (m.Cue.List != "" || m.Cue.Path != "") {
+			return fmt.Errorf("cue %q of %s message has a list or path, but no number", m.Cue, m.Command)
+		}
+		return m.Cue.Validate()
+	case cueList:
+		return validCuePart(m.Cue.List)
+	case cuePath:
+		return validCuePart(m.Cue.Path)
+	default:
+		for _, b := range m.Data {
+			if b > 0x7F {
+				return fmt.Errorf("invalid data byte %02X in %s message", b, m.Command)
+			}
+		}
+	}
+	return nil
+}
+
+func append14bit(bf *bytes.Buffer, v uint16) {
+	bf.WriteByte(byte(v) & 0x7F)
+	bf.WriteByte(byte(v>>7) & 0x7F)
+}
+
+// SysEx returns the system exclusive message. It returns nil, if the message is not valid (see Validate).
+func (m Message) SysEx() []byte {
+	if m.Validate() != nil {
+		return nil
+	}
+
+	var bf bytes.Buffer
+	bf.Write([]byte{0xF0, 0x7F, m.DeviceID, 0x02, byte(m.Format), byte(m.Command)})
+
+	switch m.Command {
+	case TimedGoCmd, SetClockCmd:
+		bf.Write(m.Time.Bytes())
+	case SetCmd:
+		append14bit(&bf, m.Control)
+		append14bit(&bf, m.Value)
+		if m.HasTime {
+			bf.Write(m.Time.Bytes())
+		}
+	case FireCmd:
+		bf.WriteByte(m.Macro & 0x7F)
+	}
+
+	switch m.Command.cueKind() {
+	case cueNumber:
+		appendCue(&bf, m.Cue)
+	case cueList:
+		bf.WriteString(m.Cue.List)
+	case cuePath:
+		bf.WriteString(m.Cue.Path)
+	default:
+		bf.Write(m.Data)
+	}
+
+	bf.WriteByte(0xF7)
+	return bf.Bytes()
+}
+
+func (m Message) String() string {
+	var bf bytes.Buffer
+	fmt.Fprintf(&bf, "MSC device: %v format: %s command: %s", m.DeviceID, m.Format, m.Command)
+
+	switch m.Command {
+	case SetCmd:
+		fmt.Fprintf(&bf, " control: %v value: %v", m.Control, m.Value)
+	case FireCmd:
+		fmt.Fprintf(&bf, " macro: %v", m.Macro)
+	}
+
+	if m.HasTime {
+		fmt.Fprintf(&bf, " time: %s", m.Time)
+	}
+
+	if !m.Cue.IsZero() {
+		fmt.Fprintf(&bf, " cue: %s", m.Cue)
+	}
+
+	if len(m.Data) > 0 {
+		fmt.Fprintf(&bf, " data: % X", m.Data)
+	}
+	return bf.String()
+}
+
+// Parse parses a MIDI Show Control message (with 0xF0 and 0xF7).
+func Parse(bt []byte) (m Message, err error) {
+	if len(bt) < 7 {
+		return m, fmt.Errorf("wrong length: %v (must be >= 7)", len(bt))
+	}
+
+	if bt[0] != 0xF0 || bt[1] != 0x7F || bt[len(bt)-1] != 0xF7 {
+		return m, fmt.Errorf("no realtime system exclusive message")
+	}
+
+	if bt[3] != 0x02 {
+		return m, fmt.Errorf("wrong sub ID: %02X (must be 02)", bt[3])
+	}
+
+	m.DeviceID = bt[2]
+	m.Format = Format(bt[4])
+	m.Command = Command(bt[5])
+	data := bt[6 : len(bt)-1]
+
+	readTime := func() error {
+		if len(data) < 5 {
+			return fmt.Errorf("%s message too short", m.Command)
+		}
+		m.Time, err = mmc.TimeFromBytes(data[:5])
+		m.HasTime = true
+		data = data[5:]
+		return err
+	}
+
+	switch m.Command {
+	case TimedGoCmd, SetClockCmd:
+		if err := readTime(); err != nil {
+			return m, err
+		}
+	case SetCmd:
+		if len(data) < 4 {
+			return m, fmt.Errorf("%s message too short", m.Command)
+		}
+		m.Control = uint16(data[0]&0x7F) | uint16(data[1]&0x7F)<<7
+		m.Value = uint16(data[2]&0x7F) | uint16(data[3]&0x7F)<<7
+		data = data[4:]
+		if len(data) > 0 {
+			if err := readTime(); err != nil {
+				return m, err
+			}
+		}
+	case FireCmd:
+		if len(data) < 1 {
+			return m, fmt.Errorf("%s message too short", m.Command)
+		}
+		m.Macro = data[0]
+		data = data[1:]
+	case LoadCmd, OpenCueListCmd, CloseCueListCmd, OpenCuePathCmd, CloseCuePathCmd:
+		if len(data) == 0 {
+			return m, fmt.Errorf("missing cue in %s message", m.Command)
+		}
+	}
+
+	switch m.Command.cueKind() {
+	case cueNumber:
+		parts := bytes.SplitN(data, []byte{0}, 3)
+		m.Cue.Number = string(parts[0])
+		if len(parts) > 1 {
+			m.Cue.List = string(parts[1])
+		}
+		if len(parts) > 2 {
+			m.Cue.Path = string(parts[2])
+		}
+	case cueList:
+		m.Cue.List = string(data)
+	case cuePath:
+		m.Cue.Path = string(data)
+	default:
+		if len(data) > 0 {
+			m.Data = append([]byte(nil), data...)
+		}
+		return m, nil
+	}
+
+	return m, m.Validate()
+}
@@ -0,0 +1,305 @@
+package msc
+
+import (
+	"fmt"
+)
+
+/*
+MIDI Show Control message format (all numbers are in hexadecimal notation):
+
+F0 7F <device ID> 02 <command format> <command> <data> F7
+
+device ID: 00-6F individual devices, 70-7E groups 1-15, 7F all call
+*/
+
+// AllCall is the device ID that addresses all devices.
+const AllCall = 0x7F
+
+// Group returns the device ID of the given group (1-15).
+func Group(n byte) byte {
+	return 0x6F + n
+}
+
+// Format is the command format of a MSC message, i.e. the kind of the controlled equipment.
+type Format byte
+
+const (
+	Lighting        Format = 0x01
+	MovingLights    Format = 0x02
+	ColorChangers   Format = 0x03
+	Strobes         Format = 0x04
+	Lasers          Format = 0x05
+	Chasers         Format = 0x06
+	Sound           Format = 0x10
+	Music           Format = 0x11
+	CDPlayers       Format = 0x12
+	EPROMPlayback   Format = 0x13
+	AudioTape       Format = 0x14
+	Intercoms       Format = 0x15
+	Amplifiers      Format = 0x16
+	AudioEffects    Format = 0x17
+	Equalizers      Format = 0x18
+	Machinery       Format = 0x20
+	Rigging         Format = 0x21
+	Flys            Format = 0x22
+	Lifts           Format = 0x23
+	Turntables      Format = 0x24
+	Trusses         Format = 0x25
+	Robots          Format = 0x26
+	Animation       Format = 0x27
+	Floats          Format = 0x28
+	Breakaways      Format = 0x29
+	Barges          Format = 0x2A
+	Video           Format = 0x30
+	VideoTape       Format = 0x31
+	VideoCassette   Format = 0x32
+	VideoDisc       Format = 0x33
+	VideoSwitchers  Format = 0x34
+	VideoEffects    Format = 0x35
+	VideoCharacters Format = 0x36
+	VideoStills     Format = 0x37
+	VideoMonitors   Format = 0x38
+	Projection      Format = 0x40
+	FilmProjectors  Format = 0x41
+	SlideProjectors Format = 0x42
+	VideoProjectors Format = 0x43
+	Dissolvers      Format = 0x44
+	ShutterControls Format = 0x45
+	ProcessControl  Format = 0x50
+	HydraulicOil    Format = 0x51
+	H2O             Format = 0x52
+	CO2             Format = 0x53
+	CompressedAir   Format = 0x54
+	NaturalGas      Format = 0x55
+	Fog             Format = 0x56
+	Smoke           Format = 0x57
+	CrackedHaze     Format = 0x58
+	Pyro            Format = 0x60
+	Fireworks       Format = 0x61
+	Explosions      Format = 0x62
+	Flame           Format = 0x63
+	SmokePots       Format = 0x64
+	AllTypes        Format = 0x7F
+)
+
+var formatNames = map[Format]string{
+	Lighting:        "Lighting (General)",
+	MovingLights:    "Moving Lights",
+	ColorChangers:   "Color Changers",
+	Strobes:         "Strobes",
+	Lasers:          "Lasers",
+	Chasers:         "Chasers",
+	Sound:           "Sound (General)",
+	Music:           "Music",
+	CDPlayers:       "CD Players",
+	EPROMPlayback:   "EPROM Playback",
+	AudioTape:       "Audio Tape Machines",
+	Intercoms:       "Intercoms",
+	Amplifiers:      "Amplifiers",
+	AudioEffects:    "Audio Effects Devices",
+	Equalizers:      "Equalizers",
+	Machinery:       "Machinery (General)",
+	Rigging:         "Rigging",
+	Flys:            "Flys",
+	Lifts:           "Lifts",
+	Turntables:      "Turntables",
+	Trusses:         "Trusses",
+	Robots:          "Robots",
+	Animation:       "Animation",
+	Floats:          "Floats",
+	Breakaways:      "Breakaways",
+	Barges:          "Barges",
+	Video:           "Video (General)",
+	VideoTape:       "Video Tape Machines",
+	VideoCassette:   "Video Cassette Machines",
+	VideoDisc:       "Video Disc Players",
+	VideoSwitchers:  "Video Switchers",
+	VideoEffects:    "Video Effects",
+	VideoCharacters: "Video Character Generators",
+	VideoStills:     "Video Still Stores",
+	VideoMonitors:   "Video Monitors",
+	Projection:      "Projection (General)",
+	FilmProjectors:  "Film Projectors",
+	SlideProjectors: "Slide Projectors",
+	VideoProjectors: "Video Projectors",
+	Dissolvers:      "Dissolvers",
+	ShutterControls: "Shutter Controls",
+	ProcessControl:  "Process Control (General)",
+	HydraulicOil:    "Hydraulic Oil",
+	H2O:             "H2O",
+	CO2:             "CO2",
+	CompressedAir:   "Compressed Air",
+	NaturalGas:      "Natural Gas",
+	Fog:             "Fog",
+	Smoke:           "Smoke",
+	CrackedHaze:     "Cracked Haze",
+	Pyro:            "Pyro (General)",
+	Fireworks:       "Fireworks",
+	Explosions:      "Explosions",
+	Flame:           "Flame",
+	SmokePots:       "Smoke pots",
+	AllTypes:        "All-types",
+}
+
+func (f Format) String() string {
+	if s, has := formatNames[f]; has {
+		return s
+	}
+	return fmt.Sprintf("Format(%02X)", byte(f))
+}
+
+// General returns the general format of the category of the format, e.g. Lighting for MovingLights.
+// AllTypes is returned unchanged.
+func (f Format) General() Format {
+	if f == AllTypes {
+		return f
+	}
+	if f < 0x10 {
+		return Lighting
+	}
+	return f &^ 0x0F
+}
+
+// Command is a MSC command.
+type Command byte
+
+/*
+01 GO [<Q_number> [00 <Q_list> [00 <Q_path>]]]
+02 STOP [<Q_number> [00 <Q_list> [00 <Q_path>]]]
+03 RESUME [<Q_number> [00 <Q_list> [00 <Q_path>]]]
+04 TIMED_GO <hr mn sc fr ff> [<Q_number> [00 <Q_list> [00 <Q_path>]]]
+05 LOAD <Q_number> [00 <Q_list> [00 <Q_path>]]
+06 SET <control number LSB MSB> <control value LSB MSB> [<hr mn sc fr ff>]
+07 FIRE <macro number>
+08 ALL_OFF
+09 RESTORE
+0A RESET
+0B GO_OFF [<Q_number> [00 <Q_list> [00 <Q_path>]]]
+10 GO/JAM_CLOCK [<Q_number> [00 <Q_list> [00 <Q_path>]]]
+11 STANDBY_+ [<Q_list>]
+12 STANDBY_- [<Q_list>]
+13 SEQUENCE_+ [<Q_list>]
+14 SEQUENCE_- [<Q_list>]
+15 START_CLOCK [<Q_list>]
+16 STOP_CLOCK [<Q_list>]
+17 ZERO_CLOCK [<Q_list>]
+18 SET_CLOCK <hr mn sc fr ff> [<Q_list>]
+19 MTC_CHASE_ON [<Q_list>]
+1A MTC_CHASE_OFF [<Q_list>]
+1B OPEN_CUE_LIST <Q_list>
+1C CLOSE_CUE_LIST <Q_list>
+1D OPEN_CUE_PATH <Q_path>
+1E CLOSE_CUE_PATH <Q_path>
+*/
+const (
+	GoCmd            Command = 0x01
+	StopCmd          Command = 0x02
+	ResumeCmd        Command = 0x03
+	TimedGoCmd       Command = 0x04
+	LoadCmd          Command = 0x05
+	SetCmd           Command = 0x06
+	FireCmd          Command = 0x07
+	AllOffCmd        Command = 0x08
+	RestoreCmd       Command = 0x09
+	ResetCmd         Command = 0x0A
+	GoOffCmd         Command = 0x0B
+	GoJamClockCmd    Command = 0x10
+	StandbyPlusCmd   Command = 0x11
+	StandbyMinusCmd  Command = 0x12
+	SequencePlusCmd  Command = 0x13
+	SequenceMinusCmd Command = 0x14
+	StartClockCmd    Command = 0x15
+	StopClockCmd     Command = 0x16
+	ZeroClockCmd     Command = 0x17
+	SetClockCmd      Command = 0x18
+	MTCChaseOnCmd    Command = 0x19
+	MTCChaseOffCmd   Command = 0x1A
+	OpenCueListCmd   Command = 0x1B
+	CloseCueListCmd  Command = 0x1C
+	OpenCuePathCmd   Command = 0x1D
+	CloseCuePathCmd  Command = 0x1E
+)
+
+func (c Command) String() string {
+	switch c {
+	case GoCmd:
+		return "GO"
+	case StopCmd:
+		return "STOP"
+	case ResumeCmd:
+		return "RESUME"
+	case TimedGoCmd:
+		return "TIMED_GO"
+	case LoadCmd:
+		return "LOAD"
+	case SetCmd:
+		return "SET"
+	case FireCmd:
+		return "FIRE"
+	case AllOffCmd:
+		return "ALL_OFF"
+	case RestoreCmd:
+		return "RESTORE"
+	case ResetCmd:
+		return "RESET"
+	case GoOffCmd:
+		return "GO_OFF"
+	case GoJamClockCmd:
+		return "GO/JAM_CLOCK"
+	case StandbyPlusCmd:
+		return "STANDBY_+"
+	case StandbyMinusCmd:
+		return "STANDBY_-"
+	case SequencePlusCmd:
+		return "SEQUENCE_+"
+	case SequenceMinusCmd:
+		return "SEQUENCE_-"
+	case StartClockCmd:
+		return "START_CLOCK"
+	case StopClockCmd:
+		return "STOP_CLOCK"
+	case ZeroClockCmd:
+		return "ZERO_CLOCK"
+	case SetClockCmd:
+		return "SET_CLOCK"
+	case MTCChaseOnCmd:
+		return "MTC_CHASE_ON"
+	case MTCChaseOffCmd:
+		return "MTC_CHASE_OFF"
+	case OpenCueListCmd:
+		return "OPEN_CUE_LIST"
+	case CloseCueListCmd:
+		return "CLOSE_CUE_LIST"
+	case OpenCuePathCmd:
+		return "OPEN_CUE_PATH"
+	case CloseCuePathCmd:
+		return "CLOSE_CUE_PATH"
+	default:
+		return fmt.Sprintf("Command(%02X)", byte(c))
+	}
+}
+
+// cueKind is the kind of the cue data of a command
+type cueKind int
+
+const (
+	noCue cueKind = iota
+	cueNumber
+	cueList
+	cuePath
+)
+
+// cueKind returns the kind of cue data the command carries
+func (c Command) cueKind() cueKind {
+	switch c {
+	case GoCmd, StopCmd, ResumeCmd, TimedGoCmd, LoadCmd, GoOffCmd, GoJamClockCmd:
+		return cueNumber
+	case StandbyPlusCmd, StandbyMinusCmd, SequencePlusCmd, SequenceMinusCmd, StartClockCmd, StopClockCmd,
+		ZeroClockCmd, SetClockCmd, MTCChaseOnCmd, MTCChaseOffCmd, OpenCueListCmd, CloseCueListCmd:
+		return cueList
+	case OpenCuePathCmd, CloseCuePathCmd:
+		return cuePath
+	default:
+		return noCue
+	}
+}
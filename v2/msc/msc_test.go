@@ -0,0 +1,178 @@
+package msc
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/mmc"
+)
+
+func TestMessages(t *testing.T) {
+	tm := mmc.Time{Rate: mmc.FPS30, Hours: 1, Minutes: 2, Seconds: 3, Frames: 4}
+
+	tests := []struct {
+		msg      Message
+		expected string
+	}{
+		{
+			Go(1, Lighting, Cue{}),
+			"F0 7F 01 02 01 01 F7",
+		},
+		{
+			Go(1, Lighting, Cue{Number: "235.6"}),
+			"F0 7F 01 02 01 01 32 33 35 2E 36 F7",
+		},
+		{
+			Stop(AllCall, Sound, Cue{Number: "1", List: "2", Path: "3"}),
+			"F0 7F 7F 02 10 02 31 00 32 00 33 F7",
+		},
+		{
+			TimedGo(Group(1), Machinery, tm, Cue{Number: "5", List: "1"}),
+			"F0 7F 70 02 20 04 61 02 03 04 00 35 00 31 F7",
+		},
+		{
+			Load(2, AllTypes, Cue{Number: "10"}),
+			"F0 7F 02 02 7F 05 31 30 F7",
+		},
+		{
+			Set(1, Lighting, 200, 0x3FFF),
+			"F0 7F 01 02 01 06 48 01 7F 7F F7",
+		},
+		{
+			Message{DeviceID: 1, Format: Lighting, Command: SetCmd, Control: 1, Value: 2, Time: tm, HasTime: true},
+			"F0 7F 01 02 01 06 01 00 02 00 61 02 03 04 00 F7",
+		},
+		{
+			Fire(1, Pyro, 9),
+			"F0 7F 01 02 60 07 09 F7",
+		},
+		{
+			AllOff(1, Video),
+			"F0 7F 01 02 30 08 F7",
+		},
+		{
+			Message{DeviceID: 1, Format: Lighting, Command: SetClockCmd, Time: tm, HasTime: true, Cue: Cue{List: "4"}},
+			"F0 7F 01 02 01 18 61 02 03 04 00 34 F7",
+		},
+		{
+			Message{DeviceID: 1, Format: Lighting, Command: OpenCuePathCmd, Cue: Cue{Path: "7"}},
+			"F0 7F 01 02 01 1D 37 F7",
+		},
+	}
+
+	for i, test := range tests {
+		sx := test.msg.SysEx()
+		got := fmt.Sprintf("% X", sx)
+		if got != test.expected {
+			t.Errorf("[%v] %s SysEx() = %q; expected %q", i, test.msg, got, test.expected)
+			continue
+		}
+
+		m, err := Parse(sx)
+		if err != nil {
+			t.Errorf("[%v] Parse() returned error: %v", i, err)
+			continue
+		}
+
+		expected := test.msg
+		if expected.Command == SetClockCmd {
+			expected.HasTime = true
+		}
+
+		if !reflect.DeepEqual(m, expected) {
+			t.Errorf("[%v] Parse() = %v; expected %v", i, m, expected)
+		}
+	}
+}
+
+func TestInvalidMessages(t *testing.T) {
+	tests := []Message{
+		Go(1, Lighting, Cue{Number: "1A"}),
+		Go(1, Lighting, Cue{Number: "1", List: "\u00e41"}),
+		Go(1, Lighting, Cue{List: "2"}),
+		Stop(1, Lighting, Cue{Path: "3"}),
+		{DeviceID: 1, Format: Lighting, Command: OpenCueListCmd, Cue: Cue{List: "x"}},
+		{DeviceID: 1, Format: Lighting, Command: 0x30, Data: []byte{0x01, 0x80}},
+		Go(0x80, Lighting, Cue{}),
+	}
+
+	for i, test := range tests {
+		if err := test.Validate(); err == nil {
+			t.Errorf("[%v] %s Validate() expected error", i, test)
+		}
+
+		if sx := test.SysEx(); sx != nil {
+			t.Errorf("[%v] %s SysEx() = % X; expected nil", i, test, sx)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := [][]byte{
+		{0xF0, 0x7F, 0x01, 0x06, 0x01, 0xF7},
+		{0xF0, 0x7F, 0x01, 0x02, 0x01, 0x04, 0x61, 0x02, 0xF7},
+		{0xF0, 0x7F, 0x01, 0x02, 0x01, 0x05, 0xF7},
+		{0xF0, 0x7F, 0x01, 0x02, 0x01, 0x01, 0x41, 0xF7},
+		{0xF0, 0x7F, 0x01, 0x02, 0x01, 0x06, 0x01, 0x00, 0xF7},
+		{0xF0, 0x7F, 0x01, 0x02, 0x01, 0x01, 0x00, 0x32, 0xF7},
+	}
+
+	for i, test := range tests {
+		if _, err := Parse(test); err == nil {
+			t.Errorf("[%v] Parse(% X) expected error", i, test)
+		}
+	}
+}
+
+func TestReceiver(t *testing.T) {
+	var got []string
+
+	r := &Receiver{
+		DeviceID: 3,
+		Groups:   []byte{2},
+		Formats:  []Format{MovingLights},
+		Go: func(f Format, cue Cue) {
+			got = append(got, fmt.Sprintf("go %s %s", f, cue))
+		},
+		Set: func(f Format, control, value uint16, tm *mmc.Time) {
+			got = append(got, fmt.Sprintf("set %v %v %v", control, value, tm != nil))
+		},
+		Message: func(m Message) {
+			got = append(got, fmt.Sprintf("message %s", m.Command))
+		},
+	}
+
+	msgs := []struct {
+		msg      Message
+		received bool
+	}{
+		{Go(3, MovingLights, Cue{Number: "1"}), true},
+		{Go(Group(2), Lighting, Cue{Number: "2", List: "1"}), true},
+		{Go(AllCall, AllTypes, Cue{}), true},
+		{Go(4, MovingLights, Cue{Number: "3"}), false},
+		{Go(Group(3), MovingLights, Cue{Number: "3"}), false},
+		{Go(3, Sound, Cue{Number: "3"}), false},
+		{Set(3, MovingLights, 1, 2), true},
+		{Stop(3, MovingLights, Cue{}), true},
+	}
+
+	for i, m := range msgs {
+		if r.Receive(midi.Message(m.msg.SysEx())) != m.received {
+			t.Errorf("[%v] Receive(%s) returned %v", i, m.msg, !m.received)
+		}
+	}
+
+	expected := []string{
+		"go Moving Lights 1",
+		"go Lighting (General) 2 list 1",
+		"go All-types ",
+		"set 1 2 false",
+		"message STOP",
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %q; expected %q", got, expected)
+	}
+}
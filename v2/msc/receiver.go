@@ -0,0 +1,148 @@
+package msc
+
+import (
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/drivers"
+	"gitlab.com/gomidi/midi/v2/mmc"
+)
+
+// Receiver calls its callbacks for the MSC messages that are addressed to it. A message is addressed to the receiver, if
+// its device ID is the DeviceID of the receiver, one of its Groups or AllCall and if its format is one of the Formats
+// of the receiver, the general format of their category or AllTypes.
+// The callbacks that are nil are ignored.
+type Receiver struct {
+	// DeviceID is the device ID of the receiver (00-6F)
+	DeviceID byte
+
+	// Groups are the groups (1-15) the receiver belongs to
+	Groups []byte
+
+	// Formats are the command formats the receiver responds to. If it is empty, the receiver responds to all formats.
+	Formats []Format
+
+	// Go is called for GO messages
+	Go func(f Format, cue Cue)
+
+	// Stop is called for STOP messages
+	Stop func(f Format, cue Cue)
+
+	// Resume is called for RESUME messages
+	Resume func(f Format, cue Cue)
+
+	// TimedGo is called for TIMED_GO messages
+	TimedGo func(f Format, t mmc.Time, cue Cue)
+
+	// Load is called for LOAD messages
+	Load func(f Format, cue Cue)
+
+	// GoOff is called for GO_OFF messages
+	GoOff func(f Format, cue Cue)
+
+	// Set is called for SET messages. If the message has no time, t is nil.
+	Set func(f Format, control, value uint16, t *mmc.Time)
+
+	// Fire is called for FIRE messages
+	Fire func(f Format, macro byte)
+
+	// AllOff is called for ALL_OFF messages
+	AllOff func(f Format)
+
+	// Restore is called for RESTORE messages
+	Restore func(f Format)
+
+	// Reset is called for RESET messages
+	Reset func(f Format)
+
+	// Message is called for all messages that are addressed to the receiver and have no callback
+	// (e.g. GO/JAM_CLOCK, the clock and the cue list commands)
+	Message func(m Message)
+}
+
+// addressed returns true, if the message is addressed to the receiver
+func (r *Receiver) addressed(m Message) bool {
+	if m.DeviceID != r.DeviceID && m.DeviceID != AllCall {
+		var inGroup bool
+		for _, g := range r.Groups {
+			if Group(g) == m.DeviceID {
+				inGroup = true
+				break
+			}
+		}
+		if !inGroup {
+			return false
+		}
+	}
+
+	if len(r.Formats) == 0 || m.Format == AllTypes {
+		return true
+	}
+
+	for _, f := range r.Formats {
+		if f == m.Format || f.General() == m.Format {
+			return true
+		}
+	}
+	return false
+}
+
+// Receive handles the given message. It returns false, if the message is no valid MSC message or is not addressed to
+// the receiver.
+func (r *Receiver) Receive(msg midi.Message) bool {
+	var data []byte
+	if !msg.GetSysEx(&data) || len(data) < 3 || data[0] != 0x7F || data[2] != 0x02 {
+		return false
+	}
+
+	m, err := Parse(msg)
+	if err != nil || !r.addressed(m) {
+		return false
+	}
+
+	if !r.dispatch(m) && r.Message != nil {
+		r.Message(m)
+	}
+	return true
+}
+
+// dispatch calls the callback for the message and returns false, if there is none
+func (r *Receiver) dispatch(m Message) bool {
+	switch {
+	case m.Command == GoCmd && r.Go != nil:
+		r.Go(m.Format, m.Cue)
+	case m.Command == StopCmd && r.Stop != nil:
+		r.Stop(m.Format, m.Cue)
+	case m.Command == ResumeCmd && r.Resume != nil:
+		r.Resume(m.Format, m.Cue)
+	case m.Command == TimedGoCmd && r.TimedGo != nil:
+		r.TimedGo(m.Format, m.Time, m.Cue)
+	case m.Command == LoadCmd && r.Load != nil:
+		r.Load(m.Format, m.Cue)
+	case m.Command == GoOffCmd && r.GoOff != nil:
+		r.GoOff(m.Format, m.Cue)
+	case m.Command == SetCmd && r.Set != nil:
+		var t *mmc.Time
+		if m.HasTime {
+			t = &m.Time
+		}
+		r.Set(m.Format, m.Control, m.Value, t)
+	case m.Command == FireCmd && r.Fire != nil:
+		r.Fire(m.Format, m.Macro)
+	case m.Command == AllOffCmd && r.AllOff != nil:
+		r.AllOff(m.Format)
+	case m.Command == RestoreCmd && r.Restore != nil:
+		r.Restore(m.Format)
+	case m.Command == ResetCmd && r.Reset != nil:
+		r.Reset(m.Format)
+	default:
+		return false
+	}
+	return true
+}
+
+// Listen listens on the given in port and calls Receive for each message.
+// The returned stop function stops the listening.
+func (r *Receiver) Listen(in drivers.In) (stop func(), err error) {
+	return midi.ListenTo(in, func(msg midi.Message, timestampms int32) {
+		r.Receive(msg)
+	}, midi.UseSysEx())
+}
@@ -0,0 +1,19 @@
+// Copyright (c) 2022 Marc René Arns. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+/*
+Package mtc implements MIDI Time Code (MTC).
+
+A running time code is transmitted with quarter frame messages (see midi.MTC). Each of the 8 pieces of a sequence
+carries 4 bits of the time code, so that a complete time code is transmitted every 2 frames. The pieces are sent
+in reverse order, if the time code runs backwards. A jump to another time code is transmitted with a Full Frame
+message, a Universal Real Time SysEx message (F0 7F <device ID> 01 01 hr mn sc fr F7).
+
+The Reader assembles the quarter frames into a time code, detects the direction and parses Full Frame messages.
+The Generator sends quarter frames for a running position, that is taken from a clock or a source like the
+SMF player (see Source).
+
+The frame rates are 24, 25, 29.97 drop frame and 30 frames per second, as the SMPTE rates of smf.TimeCode.
+*/
+package mtc
@@ -0,0 +1,186 @@
+package mtc
+
+import (
+	"sync"
+	"time"
+
+	"gitlab.com/gomidi/midi/v2/drivers"
+)
+
+// GeneratorOption is an option for a Generator.
+type GeneratorOption func(*Generator)
+
+// Source sets the function that returns the position since 00:00:00:00, e.g. the Current method of the SMF player.
+// Without a source, the position is taken from the internal clock of the generator, which runs while the generator
+// is started.
+func Source(fn func() time.Duration) GeneratorOption {
+	return func(g *Generator) {
+		g.source = fn
+	}
+}
+
+// Generator sends quarter frame messages for a running position.
+// The quarter frames are sent as the position passes them, so a position that does not change (e.g. of a paused
+// player) sends nothing and a position that moves backwards sends the quarter frames in reverse order. If the position
+// jumps by more than a sequence of quarter frames, a Full Frame message is sent instead.
+// Its methods may be called from different goroutines.
+type Generator struct {
+	out    drivers.Out
+	rate   Rate
+	source func() time.Duration
+
+	mu       sync.Mutex
+	running  bool
+	stop     chan struct{}
+	done     chan struct{}
+	last     int
+	startPos time.Duration
+	started  time.Time
+}
+
+// NewGenerator returns a generator that sends the time code with the given rate to the given out port.
+func NewGenerator(out drivers.Out, rate Rate, opts ...GeneratorOption) *Generator {
+	g := &Generator{
+		out:  out,
+		rate: rate & 0x03,
+	}
+
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// quarterFrame returns the duration of a quarter frame
+func (g *Generator) quarterFrame() time.Duration {
+	return g.rate.FrameDuration() / 4
+}
+
+// position returns the current position. g.mu must be locked.
+func (g *Generator) position() time.Duration {
+	if g.source != nil {
+		return g.source()
+	}
+	if !g.running {
+		return g.startPos
+	}
+	return g.startPos + time.Since(g.started)
+}
+
+// Locate sends a Full Frame message for the given time and continues the quarter frames from there.
+// Without a source, it also moves the position of the internal clock to the time.
+func (g *Generator) Locate(t Time) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	t.Rate = g.rate
+	if g.source == nil {
+		g.startPos = t.Duration()
+		g.started = time.Now()
+	}
+	g.last = g.rate.frames(t.Duration(), true)
+	return g.out.Send(t.FullFrame())
+}
+
+// Start sends a Full Frame message for the current position and starts sending the quarter frames.
+func (g *Generator) Start() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.running {
+		return nil
+	}
+
+	if !g.out.IsOpen() {
+		if err := g.out.Open(); err != nil {
+			return err
+		}
+	}
+
+	pos := g.position()
+	if err := g.out.Send(TimeAt(g.rate, pos).FullFrame()); err != nil {
+		return err
+	}
+
+	g.last = g.rate.frames(pos, true)
+	g.started = time.Now()
+	g.running = true
+	g.stop = make(chan struct{})
+	g.done = make(chan struct{})
+	go g.run(g.stop, g.done)
+	return nil
+}
+
+// Stop stops sending the quarter frames. Without a source, the internal clock stops at the current position.
+func (g *Generator) Stop() {
+	g.mu.Lock()
+	if !g.running {
+		g.mu.Unlock()
+		return
+	}
+	g.startPos = g.position()
+	g.running = false
+	close(g.stop)
+	done := g.done
+	g.mu.Unlock()
+
+	<-done
+}
+
+// Current returns the time code of the current position.
+func (g *Generator) Current() Time {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return TimeAt(g.rate, g.position())
+}
+
+// run sends the quarter frames until stop is closed
+func (g *Generator) run(stop, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(g.quarterFrame() / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			g.mu.Lock()
+			g.update()
+			g.mu.Unlock()
+		}
+	}
+}
+
+// update sends the quarter frames between the last and the current position. g.mu must be locked.
+func (g *Generator) update() {
+	pos := g.position()
+	if pos < 0 {
+		pos = 0
+	}
+	q := g.rate.frames(pos, true)
+
+	switch diff := q - g.last; {
+	case diff == 0:
+	case diff > 0 && diff <= 8:
+		for i := g.last + 1; i <= q; i++ {
+			g.sendQuarterFrame(i)
+		}
+	case diff < 0 && diff >= -8:
+		for i := g.last - 1; i >= q; i-- {
+			g.sendQuarterFrame(i)
+		}
+	default:
+		_ = g.out.Send(TimeAt(g.rate, pos).FullFrame())
+	}
+	g.last = q
+}
+
+// sendQuarterFrame sends the quarter frame with the given number since 00:00:00:00.
+// A sequence of 8 quarter frames carries the time of the frame at its start.
+func (g *Generator) sendQuarterFrame(n int) {
+	start := n - n%8
+	t := TimeFromFrames(g.rate, start/4)
+	_ = g.out.Send(t.QuarterFrame(uint8(n % 8)))
+}
@@ -0,0 +1,225 @@
+package mtc
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/drivers/testdrv"
+	"gitlab.com/gomidi/midi/v2/smf"
+)
+
+func TestTime(t *testing.T) {
+	tests := []struct {
+		time     Time
+		frames   int
+		duration time.Duration
+		str      string
+	}{
+		{Time{Rate: FPS25, Seconds: 1, Frames: 1}, 26, 1040 * time.Millisecond, "00:00:01:01"},
+		{Time{Rate: FPS24, Hours: 1}, 86400, time.Hour, "01:00:00:00"},
+		{Time{Rate: FPS30, Minutes: 1, Frames: 29}, 1829, 60966666666, "00:01:00:29"},
+		{Time{Rate: FPS30Drop, Minutes: 1, Frames: 2}, 1800, 1800 * time.Second * 1001 / 30000, "00:01:00;02"},
+		{Time{Rate: FPS30Drop, Minutes: 10}, 17982, 17982 * time.Second * 1001 / 30000, "00:10:00;00"},
+	}
+
+	for i, test := range tests {
+		if got := test.time.TotalFrames(); got != test.frames {
+			t.Errorf("[%v] TotalFrames() = %v; expected %v", i, got, test.frames)
+		}
+
+		if got := TimeFromFrames(test.time.Rate, test.frames); got != test.time {
+			t.Errorf("[%v] TimeFromFrames() = %v; expected %v", i, got, test.time)
+		}
+
+		if got := test.time.Duration(); got != test.duration {
+			t.Errorf("[%v] Duration() = %v; expected %v", i, got, test.duration)
+		}
+
+		if got := TimeAt(test.time.Rate, test.duration+time.Millisecond); got != test.time {
+			t.Errorf("[%v] TimeAt() = %v; expected %v", i, got, test.time)
+		}
+
+		if got := test.time.String(); got != test.str {
+			t.Errorf("[%v] String() = %q; expected %q", i, got, test.str)
+		}
+
+		r, err := RateOf(test.time.Rate.TimeCode(0))
+		if err != nil || r != test.time.Rate {
+			t.Errorf("[%v] RateOf(TimeCode()) = %v, %v; expected %v", i, r, err, test.time.Rate)
+		}
+	}
+
+	late := Time{Rate: FPS30Drop, Hours: 23, Minutes: 59, Seconds: 59, Frames: 29}
+	if got, want := FPS30Drop.frames(late.Duration()+time.Millisecond, true), late.TotalFrames()*4; got != want {
+		t.Errorf("frames() of quarter frames at %v = %v; expected %v", late, got, want)
+	}
+
+	if got := TimeAt(FPS30Drop, late.Duration()+time.Millisecond); got != late {
+		t.Errorf("TimeAt() = %v; expected %v", got, late)
+	}
+
+	if _, err := RateOf(smf.TimeCode{FramesPerSecond: 50}); err == nil {
+		t.Errorf("RateOf() with 50 fps expected error")
+	}
+}
+
+func TestMessages(t *testing.T) {
+	tm := Time{Rate: FPS30Drop, Hours: 17, Minutes: 35, Seconds: 42, Frames: 27}
+
+	var qfs []string
+	for _, qf := range tm.QuarterFrames() {
+		qfs = append(qfs, fmt.Sprintf("% X", []byte(qf)))
+	}
+
+	expected := []string{"F1 0B", "F1 11", "F1 2A", "F1 32", "F1 43", "F1 52", "F1 61", "F1 75"}
+	if !reflect.DeepEqual(qfs, expected) {
+		t.Errorf("QuarterFrames() = %q; expected %q", qfs, expected)
+	}
+
+	ff := tm.FullFrame()
+	if got, want := fmt.Sprintf("% X", []byte(ff)), "F0 7F 7F 01 01 51 23 2A 1B F7"; got != want {
+		t.Errorf("FullFrame() = %q; expected %q", got, want)
+	}
+
+	got, err := ParseFullFrame(ff)
+	if err != nil || got != tm {
+		t.Errorf("ParseFullFrame() = %v, %v; expected %v", got, err, tm)
+	}
+
+	if _, err := ParseFullFrame([]byte{0xF0, 0x7F, 0x7F, 0x01, 0x02, 0, 0, 0, 0, 0xF7}); err == nil {
+		t.Errorf("ParseFullFrame() with wrong sub ID expected error")
+	}
+}
+
+func TestReader(t *testing.T) {
+	var got []string
+	r := &Reader{
+		Time: func(tm Time, dir Direction) {
+			got = append(got, fmt.Sprintf("%s %s", tm, dir))
+		},
+	}
+
+	t1 := Time{Rate: FPS25, Hours: 1, Minutes: 2, Seconds: 3, Frames: 4}
+	t2 := Time{Rate: FPS25, Hours: 1, Minutes: 2, Seconds: 3, Frames: 8}
+	qf1, qf2 := t1.QuarterFrames(), t2.QuarterFrames()
+
+	// starting in the middle of a sequence
+	for _, qf := range qf1[4:] {
+		r.Receive(qf)
+	}
+	for _, qf := range qf2 {
+		r.Receive(qf)
+	}
+
+	// reverse
+	for i := 7; i >= 0; i-- {
+		r.Receive(qf1[i])
+	}
+	for i := 7; i >= 0; i-- {
+		r.Receive(qf1[i])
+	}
+
+	if !r.Receive(t2.FullFrame()) {
+		t.Errorf("Receive(FullFrame()) returned false")
+	}
+
+	if r.Receive(midi.NoteOn(0, 1, 2)) {
+		t.Errorf("Receive(NoteOn()) returned true")
+	}
+
+	expected := []string{
+		"01:02:03:10 forward",
+		"01:02:03:04 reverse",
+		"01:02:03:04 reverse",
+		"01:02:03:08 unknown",
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %q; expected %q", got, expected)
+	}
+}
+
+func TestGenerator(t *testing.T) {
+	drv := testdrv.New("mtc")
+	ins, _ := drv.Ins()
+	outs, _ := drv.Outs()
+
+	var mu sync.Mutex
+	var got []string
+	r := &Reader{
+		Time: func(tm Time, dir Direction) {
+			mu.Lock()
+			got = append(got, fmt.Sprintf("%s %s", tm, dir))
+			mu.Unlock()
+		},
+	}
+
+	_, err := r.Listen(ins[0])
+	if err != nil {
+		t.Fatalf("Listen() returned error: %v", err)
+	}
+
+	// 25 fps: a quarter frame takes 10ms
+	qf := 10 * time.Millisecond
+	pos := 1000 * qf
+
+	g := NewGenerator(outs[0], FPS25, Source(func() time.Duration { return pos }))
+	outs[0].Open()
+
+	move := func(quarterFrames int) {
+		pos += time.Duration(quarterFrames) * qf
+		g.mu.Lock()
+		g.update()
+		g.mu.Unlock()
+	}
+
+	g.Locate(TimeAt(FPS25, pos))
+
+	// step forward by quarter frames and then backwards
+	for i := 0; i < 15; i++ {
+		move(1)
+	}
+	for i := 0; i < 15; i++ {
+		move(-1)
+	}
+
+	// jump
+	move(100000)
+
+	expected := []string{
+		"00:00:10:00 unknown",
+		"00:00:10:04 forward",
+		"00:00:10:00 reverse",
+		"00:16:50:00 unknown",
+	}
+
+	mu.Lock()
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %q; expected %q", got, expected)
+	}
+	got = nil
+	mu.Unlock()
+
+	// the internal clock
+	g = NewGenerator(outs[0], FPS30)
+	if err := g.Start(); err != nil {
+		t.Fatalf("Start() returned error: %v", err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	g.Stop()
+
+	cur := g.Current()
+	if cur.TotalFrames() < 5 {
+		t.Errorf("Current() = %v after 200ms; expected a running time code", cur)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) < 2 || got[0] != "00:00:00:00 unknown" {
+		t.Errorf("got %q; expected a full frame followed by time codes", got)
+	}
+}
@@ -0,0 +1,145 @@
+package mtc
+
+import (
+	"sync"
+
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/drivers"
+)
+
+// Direction is the direction of a running time code.
+type Direction int
+
+const (
+	Unknown Direction = iota
+	Forward
+	Reverse
+)
+
+func (d Direction) String() string {
+	switch d {
+	case Forward:
+		return "forward"
+	case Reverse:
+		return "reverse"
+	default:
+		return "unknown"
+	}
+}
+
+// Reader assembles the quarter frame messages into time codes and parses Full Frame messages.
+// The time code of a quarter frame sequence is complete with the last piece of the sequence (piece 7 when running
+// forward, piece 0 when running in reverse). Running forward, the time is adjusted by the 2 frames that have passed
+// while the sequence has been sent.
+// The methods may be called from different goroutines.
+type Reader struct {
+	// Time is called with each complete time code of the quarter frames and with the time of each Full Frame message
+	// (with the direction Unknown).
+	Time func(t Time, dir Direction)
+
+	mu      sync.Mutex
+	pieces  [8]uint8
+	last    int
+	count   int
+	dir     Direction
+	current Time
+	valid   bool
+}
+
+// Current returns the last complete time code and false, if there is none.
+func (r *Reader) Current() (Time, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current, r.valid
+}
+
+// Direction returns the direction of the running time code.
+func (r *Reader) Direction() Direction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.dir
+}
+
+// Receive handles the given message. It returns false, if the message is neither a quarter frame nor a Full Frame
+// message.
+func (r *Reader) Receive(msg midi.Message) bool {
+	var qf uint8
+	var data []byte
+
+	switch {
+	case msg.GetMTC(&qf):
+		if t, dir, ok := r.quarterFrame(qf); ok && r.Time != nil {
+			r.Time(t, dir)
+		}
+		return true
+	case msg.GetSysEx(&data) && len(data) == 8 && data[0] == 0x7F && data[2] == 0x01 && data[3] == 0x01:
+		t, err := ParseFullFrame(msg)
+		if err != nil {
+			return false
+		}
+		r.mu.Lock()
+		r.current, r.valid = t, true
+		r.dir = Unknown
+		r.count = 0
+		r.mu.Unlock()
+		if r.Time != nil {
+			r.Time(t, Unknown)
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// quarterFrame handles the data of a quarter frame and returns the time, if it is complete
+func (r *Reader) quarterFrame(qf uint8) (t Time, dir Direction, complete bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	piece := int(qf >> 4 & 0x07)
+	r.pieces[piece] = qf & 0x0F
+
+	switch {
+	case r.count > 0 && piece == (r.last+1)%8 && r.dir != Reverse:
+		r.dir = Forward
+		r.count++
+	case r.count > 0 && piece == (r.last+7)%8 && r.dir != Forward:
+		r.dir = Reverse
+		r.count++
+	case r.count > 0 && piece == (r.last+1)%8:
+		// the direction has changed, so the pieces before belong to another sequence
+		r.dir = Forward
+		r.count = 1
+	case r.count > 0 && piece == (r.last+7)%8:
+		r.dir = Reverse
+		r.count = 1
+	default:
+		r.dir = Unknown
+		r.count = 1
+	}
+	r.last = piece
+
+	if r.count < 8 {
+		return t, r.dir, false
+	}
+
+	switch {
+	case r.dir == Forward && piece == 7:
+		t = timeFromPieces(r.pieces).Add(2)
+	case r.dir == Reverse && piece == 0:
+		t = timeFromPieces(r.pieces)
+	default:
+		return t, r.dir, false
+	}
+
+	r.current, r.valid = t, true
+	return t, r.dir, true
+}
+
+// Listen listens on the given in port and calls Receive for each message.
+// The returned stop function stops the listening.
+func (r *Reader) Listen(in drivers.In) (stop func(), err error) {
+	return midi.ListenTo(in, func(msg midi.Message, timestampms int32) {
+		r.Receive(msg)
+	}, midi.UseTimeCode(), midi.UseSysEx())
+}
@@ -0,0 +1,213 @@
+package mtc
+
+import (
+	"fmt"
+	"time"
+
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/mmc"
+	"gitlab.com/gomidi/midi/v2/smf"
+)
+
+// Rate is the frame rate of a MIDI time code, as it is encoded in the quarter frame and Full Frame messages.
+type Rate uint8
+
+const (
+	FPS24     Rate = 0
+	FPS25     Rate = 1
+	FPS30Drop Rate = 2
+	FPS30     Rate = 3
+)
+
+// RateOf returns the rate of the given SMPTE time format of a SMF.
+func RateOf(tc smf.TimeCode) (Rate, error) {
+	switch tc.FramesPerSecond {
+	case 24:
+		return FPS24, nil
+	case 25:
+		return FPS25, nil
+	case 29:
+		return FPS30Drop, nil
+	case 30:
+		return FPS30, nil
+	default:
+		return 0, fmt.Errorf("invalid frames per second: %v", tc.FramesPerSecond)
+	}
+}
+
+// TimeCode returns the SMPTE time format of a SMF with the rate and the given subframes.
+func (r Rate) TimeCode(subframes uint8) smf.TimeCode {
+	switch r & 0x03 {
+	case FPS24:
+		return smf.SMPTE24(subframes)
+	case FPS25:
+		return smf.SMPTE25(subframes)
+	case FPS30Drop:
+		return smf.SMPTE30DropFrame(subframes)
+	default:
+		return smf.SMPTE30(subframes)
+	}
+}
+
+// FramesPerSecond returns the (nominal) number of frames per second.
+func (r Rate) FramesPerSecond() int {
+	return mmc.FrameRate(r & 0x03).FramesPerSecond()
+}
+
+// FrameDuration returns the duration of a frame. A frame of 29.97fps drop frame time code takes 1001/30000 seconds.
+func (r Rate) FrameDuration() time.Duration {
+	return r.duration(1)
+}
+
+// ratio returns the duration of a frame in seconds as fraction
+func (r Rate) ratio() (num, denom int64) {
+	if r&0x03 == FPS30Drop {
+		return 1001, 30000
+	}
+	return 1, int64(r.FramesPerSecond())
+}
+
+// duration returns the exact duration of the given number of frames
+func (r Rate) duration(frames int64) time.Duration {
+	num, denom := r.ratio()
+	return time.Duration(frames * int64(time.Second) * num / denom)
+}
+
+// frames returns the number of frames (or of quarter frames, if quarter is true) that have passed at the given
+// duration
+func (r Rate) frames(d time.Duration, quarter bool) int {
+	num, denom := r.ratio()
+	if quarter {
+		denom *= 4
+	}
+	// split into whole seconds and the rest to prevent an overflow for long durations
+	secs, rest := int64(d/time.Second), int64(d%time.Second)
+	whole, rem := secs*denom/num, secs*denom%num
+	return int(whole + (rem*int64(time.Second)+rest*denom)/(int64(time.Second)*num))
+}
+
+func (r Rate) String() string {
+	return mmc.FrameRate(r & 0x03).String()
+}
+
+// Time is a MIDI time code.
+type Time struct {
+	Rate    Rate
+	Hours   uint8
+	Minutes uint8
+	Seconds uint8
+	Frames  uint8
+}
+
+// mmc returns the time as standard time of MMC, which has the same frame rates
+func (t Time) mmc() mmc.Time {
+	return mmc.Time{Rate: mmc.FrameRate(t.Rate & 0x03), Hours: t.Hours, Minutes: t.Minutes, Seconds: t.Seconds, Frames: t.Frames}
+}
+
+// TotalFrames returns the number of frames since 00:00:00:00. For 29.97fps drop frame time code, the dropped frame
+// numbers are not counted.
+func (t Time) TotalFrames() int {
+	return t.mmc().TotalFrames()
+}
+
+// TimeFromFrames returns the Time for the given number of frames since 00:00:00:00 with the given rate.
+func TimeFromFrames(rate Rate, frames int) Time {
+	if frames < 0 {
+		frames = 0
+	}
+	m := mmc.TimeFromFrames(mmc.FrameRate(rate&0x03), frames)
+	return Time{Rate: rate & 0x03, Hours: m.Hours, Minutes: m.Minutes, Seconds: m.Seconds, Frames: m.Frames}
+}
+
+// TimeAt returns the Time of the frame that is running at the given duration since 00:00:00:00.
+func TimeAt(rate Rate, d time.Duration) Time {
+	return TimeFromFrames(rate, rate.frames(d, false))
+}
+
+// Duration returns the duration since 00:00:00:00.
+func (t Time) Duration() time.Duration {
+	return t.Rate.duration(int64(t.TotalFrames()))
+}
+
+// Add returns the time that is the given number of frames later (or earlier for negative frames).
+func (t Time) Add(frames int) Time {
+	return TimeFromFrames(t.Rate, t.TotalFrames()+frames)
+}
+
+func (t Time) String() string {
+	sep := ":"
+	if t.Rate&0x03 == FPS30Drop {
+		sep = ";"
+	}
+	return fmt.Sprintf("%02d:%02d:%02d%s%02d", t.Hours, t.Minutes, t.Seconds, sep, t.Frames)
+}
+
+// QuarterFrame returns the quarter frame message with the given piece (0-7) of the time.
+func (t Time) QuarterFrame(piece uint8) midi.Message {
+	var v uint8
+	switch piece & 0x07 {
+	case 0:
+		v = t.Frames & 0x0F
+	case 1:
+		v = t.Frames >> 4 & 0x01
+	case 2:
+		v = t.Seconds & 0x0F
+	case 3:
+		v = t.Seconds >> 4 & 0x03
+	case 4:
+		v = t.Minutes & 0x0F
+	case 5:
+		v = t.Minutes >> 4 & 0x03
+	case 6:
+		v = t.Hours & 0x0F
+	case 7:
+		v = t.Hours>>4&0x01 | uint8(t.Rate&0x03)<<1
+	}
+	return midi.MTC(piece&0x07<<4 | v)
+}
+
+// QuarterFrames returns the 8 quarter frame messages of the time.
+func (t Time) QuarterFrames() (res [8]midi.Message) {
+	for i := range res {
+		res[i] = t.QuarterFrame(uint8(i))
+	}
+	return
+}
+
+// timeFromPieces returns the time of the data of the 8 quarter frame pieces
+func timeFromPieces(p [8]uint8) Time {
+	return Time{
+		Frames:  p[0]&0x0F | (p[1]&0x01)<<4,
+		Seconds: p[2]&0x0F | (p[3]&0x03)<<4,
+		Minutes: p[4]&0x0F | (p[5]&0x03)<<4,
+		Hours:   p[6]&0x0F | (p[7]&0x01)<<4,
+		Rate:    Rate(p[7]>>1) & 0x03,
+	}
+}
+
+// FullFrame returns the Full Frame message for the time, addressed to all devices.
+func (t Time) FullFrame() midi.Message {
+	return midi.Message{0xF0, 0x7F, 0x7F, 0x01, 0x01, uint8(t.Rate&0x03)<<5 | t.Hours&0x1F, t.Minutes & 0x3F, t.Seconds & 0x3F, t.Frames & 0x1F, 0xF7}
+}
+
+// ParseFullFrame parses a Full Frame message (with 0xF0 and 0xF7).
+func ParseFullFrame(bt []byte) (t Time, err error) {
+	if len(bt) != 10 {
+		return t, fmt.Errorf("wrong length: %v (must be 10)", len(bt))
+	}
+
+	if bt[0] != 0xF0 || bt[1] != 0x7F || bt[9] != 0xF7 {
+		return t, fmt.Errorf("no realtime system exclusive message")
+	}
+
+	if bt[3] != 0x01 || bt[4] != 0x01 {
+		return t, fmt.Errorf("no full frame message: sub IDs %02X %02X", bt[3], bt[4])
+	}
+
+	t.Rate = Rate(bt[5]>>5) & 0x03
+	t.Hours = bt[5] & 0x1F
+	t.Minutes = bt[6] & 0x3F
+	t.Seconds = bt[7] & 0x3F
+	t.Frames = bt[8] & 0x1F
+	return t, nil
+}
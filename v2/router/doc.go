@@ -0,0 +1,16 @@
+// Copyright (c) 2022 Marc René Arns. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+/*
+Package router routes MIDI messages between in and out ports (MIDI thru).
+
+A Route connects a drivers.In to a drivers.Out. Its filters decide which messages pass (e.g. Channels, Types,
+KeyRange, VelocityRange) and its transforms change them (e.g. RemapChannel, Transpose, VelocityCurve, RemapCC).
+Any number of routes may share the same in or out ports.
+
+A Router listens on the in ports of its routes and sends the messages to their out ports.
+The routes can be replaced at runtime (see SetRoutes and Update). The change is atomic: each message is routed either
+by the old or by the new routes and the in ports that are used by both are not interrupted, so no messages get lost.
+*/
+package router
@@ -0,0 +1,197 @@
+package router
+
+import (
+	"math"
+
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/drivers"
+)
+
+// Filter returns true, if the message passes.
+type Filter func(msg midi.Message) bool
+
+// Transform returns the transformed message or nil, if the message should be dropped.
+// It must not modify the given message, but return a new one.
+type Transform func(msg midi.Message) midi.Message
+
+// Route routes the messages of the in port that pass all filters to the out port, after applying the transforms in
+// order.
+type Route struct {
+	In         drivers.In
+	Out        drivers.Out
+	Filters    []Filter
+	Transforms []Transform
+}
+
+// Process returns the message, as it is sent by the route, or nil, if the route does not send it.
+func (r Route) Process(msg midi.Message) midi.Message {
+	for _, f := range r.Filters {
+		if !f(msg) {
+			return nil
+		}
+	}
+
+	for _, t := range r.Transforms {
+		msg = t(msg)
+		if msg == nil {
+			return nil
+		}
+	}
+	return msg
+}
+
+// Channels lets the channel messages of the given channels pass. All other messages are blocked.
+func Channels(channels ...uint8) Filter {
+	return func(msg midi.Message) bool {
+		var ch uint8
+		if !msg.GetChannel(&ch) {
+			return false
+		}
+		for _, c := range channels {
+			if c == ch {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Types lets the messages pass that are of one of the given types (see midi.Message.Is).
+func Types(types ...midi.Type) Filter {
+	return func(msg midi.Message) bool {
+		for _, t := range types {
+			if msg.Is(t) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// key returns the key of note and polyphonic aftertouch messages
+func key(msg midi.Message) (uint8, bool) {
+	var k uint8
+	switch {
+	case msg.GetNoteOn(nil, &k, nil), msg.GetNoteOff(nil, &k, nil), msg.GetPolyAfterTouch(nil, &k, nil):
+		return k, true
+	default:
+		return 0, false
+	}
+}
+
+// KeyRange lets the note and polyphonic aftertouch messages pass that have a key within from and to (inclusive).
+// All other messages pass.
+func KeyRange(from, to uint8) Filter {
+	return func(msg midi.Message) bool {
+		k, ok := key(msg)
+		return !ok || (k >= from && k <= to)
+	}
+}
+
+// VelocityRange lets the note on messages pass that have a velocity within from and to (inclusive).
+// All other messages pass, also the note offs, so that no notes are left hanging.
+func VelocityRange(from, to uint8) Filter {
+	return func(msg midi.Message) bool {
+		var vel uint8
+		if !msg.GetNoteStart(nil, nil, &vel) {
+			return true
+		}
+		return vel >= from && vel <= to
+	}
+}
+
+// Not inverts the given filter.
+func Not(f Filter) Filter {
+	return func(msg midi.Message) bool {
+		return !f(msg)
+	}
+}
+
+// clone returns a copy of the message
+func clone(msg midi.Message) midi.Message {
+	return append(midi.Message(nil), msg...)
+}
+
+// RemapChannel moves the channel messages of channel from to channel to.
+func RemapChannel(from, to uint8) Transform {
+	return func(msg midi.Message) midi.Message {
+		var ch uint8
+		if !msg.GetChannel(&ch) || ch != from {
+			return msg
+		}
+		m := clone(msg)
+		m[0] = m[0]&0xF0 | to&0x0F
+		return m
+	}
+}
+
+// SetChannel moves all channel messages to the given channel.
+func SetChannel(channel uint8) Transform {
+	return func(msg midi.Message) midi.Message {
+		if !msg.GetChannel(nil) {
+			return msg
+		}
+		m := clone(msg)
+		m[0] = m[0]&0xF0 | channel&0x0F
+		return m
+	}
+}
+
+// Transpose transposes the keys of note and polyphonic aftertouch messages by the given semitones.
+// Messages whose key would be out of range are dropped.
+func Transpose(semitones int8) Transform {
+	return func(msg midi.Message) midi.Message {
+		k, ok := key(msg)
+		if !ok {
+			return msg
+		}
+		nk := int(k) + int(semitones)
+		if nk < 0 || nk > 127 {
+			return nil
+		}
+		m := clone(msg)
+		m[1] = uint8(nk)
+		return m
+	}
+}
+
+// VelocityCurve maps the velocity of note on messages with the given function.
+// The result is limited to 1-127, so that note ons are not turned into note offs.
+func VelocityCurve(fn func(velocity uint8) uint8) Transform {
+	return func(msg midi.Message) midi.Message {
+		if !msg.GetNoteStart(nil, nil, nil) {
+			return msg
+		}
+		v := fn(msg[2])
+		if v < 1 {
+			v = 1
+		}
+		if v > 127 {
+			v = 127
+		}
+		m := clone(msg)
+		m[2] = v
+		return m
+	}
+}
+
+// Gamma returns a velocity curve function for VelocityCurve with the given exponent. Values < 1 make the velocities
+// louder, values > 1 softer.
+func Gamma(exponent float64) func(velocity uint8) uint8 {
+	return func(velocity uint8) uint8 {
+		return uint8(math.Round(127 * math.Pow(float64(velocity)/127, exponent)))
+	}
+}
+
+// RemapCC changes the controller from of control change messages to the controller to.
+func RemapCC(from, to uint8) Transform {
+	return func(msg midi.Message) midi.Message {
+		var cc uint8
+		if !msg.GetControlChange(nil, &cc, nil) || cc != from {
+			return msg
+		}
+		m := clone(msg)
+		m[1] = to & 0x7F
+		return m
+	}
+}
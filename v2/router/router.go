@@ -0,0 +1,188 @@
+package router
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/drivers"
+)
+
+// Option is an option for a Router.
+type Option func(*Router)
+
+// ListenOptions sets the options for listening on the in ports. By default, system exclusive and time code messages
+// (including the timing clock) are received.
+func ListenOptions(opts ...midi.Option) Option {
+	return func(r *Router) {
+		r.listenOpts = opts
+	}
+}
+
+// HandleError sets a callback for the errors that happen when sending to an out port.
+func HandleError(fn func(error)) Option {
+	return func(r *Router) {
+		r.onErr = fn
+	}
+}
+
+// table is an immutable set of routes, with a mutex for each out port
+type table struct {
+	routes []Route
+	outMu  map[drivers.Out]*sync.Mutex
+}
+
+// Router routes messages between ports.
+// Its methods may be called from different goroutines.
+type Router struct {
+	listenOpts []midi.Option
+	onErr      func(error)
+	table      atomic.Pointer[table]
+
+	// mu serializes the changes of the routes
+	mu        sync.Mutex
+	listening map[drivers.In]func()
+}
+
+// New returns a Router without routes.
+func New(opts ...Option) *Router {
+	r := &Router{
+		listenOpts: []midi.Option{midi.UseSysEx(), midi.UseTimeCode()},
+		listening:  map[drivers.In]func(){},
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	r.table.Store(&table{})
+	return r
+}
+
+// Routes returns the current routes.
+func (r *Router) Routes() []Route {
+	return append([]Route(nil), r.table.Load().routes...)
+}
+
+// SetRoutes replaces the routes atomically. The out ports are opened, if needed.
+// If an error happens, the routes are not changed.
+func (r *Router) SetRoutes(routes ...Route) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.setRoutes(routes)
+}
+
+// AddRoutes adds the given routes.
+func (r *Router) AddRoutes(routes ...Route) error {
+	return r.Update(func(current []Route) []Route {
+		return append(current, routes...)
+	})
+}
+
+// Update replaces the routes atomically by the ones returned by fn, which is called with a copy of the current routes.
+// It allows to change the routes based on the current ones, without interfering with other changes.
+func (r *Router) Update(fn func(routes []Route) []Route) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.setRoutes(fn(r.Routes()))
+}
+
+// Close removes all routes and stops listening on the in ports.
+func (r *Router) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_ = r.setRoutes(nil)
+}
+
+// setRoutes replaces the routes. r.mu must be locked.
+func (r *Router) setRoutes(routes []Route) error {
+	old := r.table.Load()
+	t := &table{
+		routes: append([]Route(nil), routes...),
+		outMu:  map[drivers.Out]*sync.Mutex{},
+	}
+
+	used := map[drivers.In]bool{}
+
+	for i, rt := range t.routes {
+		if rt.In == nil || rt.Out == nil {
+			return fmt.Errorf("route %v has no in or out port", i)
+		}
+
+		used[rt.In] = true
+
+		if t.outMu[rt.Out] != nil {
+			continue
+		}
+
+		if !rt.Out.IsOpen() {
+			if err := rt.Out.Open(); err != nil {
+				return err
+			}
+		}
+
+		// keep the mutex, so that the messages that are still routed by the old table are serialized with the new ones
+		if mu := old.outMu[rt.Out]; mu != nil {
+			t.outMu[rt.Out] = mu
+		} else {
+			t.outMu[rt.Out] = &sync.Mutex{}
+		}
+	}
+
+	// start listening on the new in ports before the routes are changed
+	var started []drivers.In
+	for in := range used {
+		if r.listening[in] != nil {
+			continue
+		}
+
+		stop, err := midi.ListenTo(in, r.handler(in), r.listenOpts...)
+		if err != nil {
+			for _, s := range started {
+				r.listening[s]()
+				delete(r.listening, s)
+			}
+			return err
+		}
+		r.listening[in] = stop
+		started = append(started, in)
+	}
+
+	r.table.Store(t)
+
+	// stop listening on the in ports that are no longer used
+	for in, stop := range r.listening {
+		if !used[in] {
+			stop()
+			delete(r.listening, in)
+		}
+	}
+	return nil
+}
+
+// handler returns the listener for the given in port
+func (r *Router) handler(in drivers.In) func(msg midi.Message, timestampms int32) {
+	return func(msg midi.Message, timestampms int32) {
+		t := r.table.Load()
+		for _, rt := range t.routes {
+			if rt.In != in {
+				continue
+			}
+
+			m := rt.Process(msg)
+			if m == nil {
+				continue
+			}
+
+			mu := t.outMu[rt.Out]
+			mu.Lock()
+			err := rt.Out.Send(m)
+			mu.Unlock()
+
+			if err != nil && r.onErr != nil {
+				r.onErr(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package router
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/drivers"
+	"gitlab.com/gomidi/midi/v2/drivers/testdrv"
+)
+
+func TestProcess(t *testing.T) {
+	tests := []struct {
+		route    Route
+		msg      midi.Message
+		expected midi.Message
+	}{
+		{Route{Filters: []Filter{Channels(1, 2)}}, midi.NoteOn(2, 60, 100), midi.NoteOn(2, 60, 100)},
+		{Route{Filters: []Filter{Channels(1, 2)}}, midi.NoteOn(3, 60, 100), nil},
+		{Route{Filters: []Filter{Channels(1, 2)}}, midi.Start(), nil},
+		{Route{Filters: []Filter{Types(midi.NoteOnMsg, midi.NoteOffMsg)}}, midi.NoteOff(0, 60), midi.NoteOff(0, 60)},
+		{Route{Filters: []Filter{Types(midi.NoteOnMsg)}}, midi.ControlChange(0, 1, 2), nil},
+		{Route{Filters: []Filter{Not(Types(midi.TimingClockMsg))}}, midi.TimingClock(), nil},
+		{Route{Filters: []Filter{KeyRange(36, 59)}}, midi.NoteOn(0, 60, 100), nil},
+		{Route{Filters: []Filter{KeyRange(36, 59)}}, midi.NoteOn(0, 59, 100), midi.NoteOn(0, 59, 100)},
+		{Route{Filters: []Filter{KeyRange(36, 59)}}, midi.Pitchbend(0, 10), midi.Pitchbend(0, 10)},
+		{Route{Filters: []Filter{VelocityRange(1, 63)}}, midi.NoteOn(0, 60, 64), nil},
+		{Route{Filters: []Filter{VelocityRange(1, 63)}}, midi.NoteOff(0, 60), midi.NoteOff(0, 60)},
+		{Route{Transforms: []Transform{RemapChannel(1, 9)}}, midi.NoteOn(1, 36, 100), midi.NoteOn(9, 36, 100)},
+		{Route{Transforms: []Transform{RemapChannel(1, 9)}}, midi.NoteOn(2, 36, 100), midi.NoteOn(2, 36, 100)},
+		{Route{Transforms: []Transform{SetChannel(5)}}, midi.ProgramChange(0, 3), midi.ProgramChange(5, 3)},
+		{Route{Transforms: []Transform{Transpose(-12)}}, midi.NoteOff(0, 60), midi.NoteOff(0, 48)},
+		{Route{Transforms: []Transform{Transpose(12)}}, midi.PolyAfterTouch(0, 120, 5), nil},
+		{Route{Transforms: []Transform{VelocityCurve(func(v uint8) uint8 { return v * 2 })}}, midi.NoteOn(0, 60, 100), midi.NoteOn(0, 60, 127)},
+		{Route{Transforms: []Transform{VelocityCurve(Gamma(2))}}, midi.NoteOn(0, 60, 64), midi.NoteOn(0, 60, 32)},
+		{Route{Transforms: []Transform{VelocityCurve(Gamma(2))}}, midi.NoteOn(0, 60, 1), midi.NoteOn(0, 60, 1)},
+		{Route{Transforms: []Transform{RemapCC(1, 11)}}, midi.ControlChange(0, 1, 2), midi.ControlChange(0, 11, 2)},
+		{
+			Route{Filters: []Filter{Channels(0)}, Transforms: []Transform{SetChannel(1), Transpose(2), RemapChannel(1, 3)}},
+			midi.NoteOn(0, 60, 100), midi.NoteOn(3, 62, 100),
+		},
+	}
+
+	for i, test := range tests {
+		msg := append(midi.Message(nil), test.msg...)
+		got := test.route.Process(msg)
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("[%v] Process(%s) = %s; expected %s", i, test.msg, got, test.expected)
+		}
+
+		if !reflect.DeepEqual(msg, test.msg) {
+			t.Errorf("[%v] Process() modified the message: %s", i, msg)
+		}
+	}
+}
+
+// port is a testdrv driver with its in and out port
+type port struct {
+	in  drivers.In
+	out drivers.Out
+}
+
+func newPort(name string) port {
+	d := testdrv.New(name)
+	ins, _ := d.Ins()
+	outs, _ := d.Outs()
+	return port{ins[0], outs[0]}
+}
+
+// collector collects the messages that are received on an in port
+type collector struct {
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (c *collector) listen(t *testing.T, in drivers.In) {
+	_, err := midi.ListenTo(in, func(msg midi.Message, timestampms int32) {
+		c.mu.Lock()
+		c.msgs = append(c.msgs, msg.String())
+		c.mu.Unlock()
+	}, midi.UseTimeCode())
+	if err != nil {
+		t.Fatalf("ListenTo() returned error: %v", err)
+	}
+}
+
+func (c *collector) take() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	msgs := c.msgs
+	c.msgs = nil
+	return msgs
+}
+
+func TestRouter(t *testing.T) {
+	src1, src2 := newPort("src1"), newPort("src2")
+	dst1, dst2 := newPort("dst1"), newPort("dst2")
+
+	var got1, got2 collector
+	got1.listen(t, dst1.in)
+	got2.listen(t, dst2.in)
+
+	r := New()
+	defer r.Close()
+
+	err := r.SetRoutes(
+		Route{In: src1.in, Out: dst1.out, Filters: []Filter{Channels(0)}, Transforms: []Transform{Transpose(12)}},
+		Route{In: src1.in, Out: dst2.out},
+		Route{In: src2.in, Out: dst2.out, Transforms: []Transform{RemapCC(1, 74)}},
+	)
+	if err != nil {
+		t.Fatalf("SetRoutes() returned error: %v", err)
+	}
+
+	src1.out.Open()
+	src2.out.Open()
+
+	src1.out.Send(midi.NoteOn(0, 60, 100))
+	src1.out.Send(midi.NoteOn(1, 60, 100))
+	src1.out.Send(midi.TimingClock())
+	src2.out.Send(midi.ControlChange(0, 1, 10))
+
+	expected1 := []string{
+		"NoteOn channel: 0 key: 72 velocity: 100",
+	}
+	expected2 := []string{
+		"NoteOn channel: 0 key: 60 velocity: 100",
+		"NoteOn channel: 1 key: 60 velocity: 100",
+		"TimingClock",
+		"ControlChange channel: 0 controller: 74 value: 10",
+	}
+
+	if got := got1.take(); !reflect.DeepEqual(got, expected1) {
+		t.Errorf("dst1 got %q; expected %q", got, expected1)
+	}
+
+	if got := got2.take(); !reflect.DeepEqual(got, expected2) {
+		t.Errorf("dst2 got %q; expected %q", got, expected2)
+	}
+
+	// remove the routes to dst2
+	err = r.Update(func(routes []Route) []Route {
+		return routes[:1]
+	})
+	if err != nil {
+		t.Fatalf("Update() returned error: %v", err)
+	}
+
+	if n := len(r.Routes()); n != 1 {
+		t.Errorf("len(Routes()) = %v; expected 1", n)
+	}
+
+	src1.out.Send(midi.NoteOn(0, 62, 100))
+
+	if got := got1.take(); len(got) != 1 {
+		t.Errorf("dst1 got %q; expected 1 message", got)
+	}
+
+	if got := got2.take(); len(got) != 0 {
+		t.Errorf("dst2 got %q; expected no messages", got)
+	}
+
+	if err := r.AddRoutes(Route{In: src1.in}); err == nil {
+		t.Errorf("AddRoutes() without out port expected error")
+	}
+}
+
+func TestChangeRoutes(t *testing.T) {
+	src, dst := newPort("src"), newPort("dst")
+
+	var got collector
+	got.listen(t, dst.in)
+
+	r := New()
+	defer r.Close()
+
+	a := Route{In: src.in, Out: dst.out, Transforms: []Transform{SetChannel(1)}}
+	b := Route{In: src.in, Out: dst.out, Transforms: []Transform{SetChannel(2)}}
+
+	if err := r.SetRoutes(a); err != nil {
+		t.Fatalf("SetRoutes() returned error: %v", err)
+	}
+
+	src.out.Open()
+
+	const n = 1000
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < n; i++ {
+			src.out.Send(midi.NoteOn(0, uint8(i%128), 100))
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		routes := a
+		if i%2 == 0 {
+			routes = b
+		}
+		if err := r.SetRoutes(routes); err != nil {
+			t.Fatalf("SetRoutes() returned error: %v", err)
+		}
+	}
+	<-done
+
+	msgs := got.take()
+	if len(msgs) != n {
+		t.Errorf("received %v messages; expected %v", len(msgs), n)
+	}
+
+	for i, msg := range msgs {
+		if a, b := fmt.Sprintf("NoteOn channel: 1 key: %v velocity: 100", i%128), fmt.Sprintf("NoteOn channel: 2 key: %v velocity: 100", i%128); msg != a && msg != b {
+			t.Fatalf("message %v is %q", i, msg)
+		}
+	}
+}
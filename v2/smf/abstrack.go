@@ -0,0 +1,198 @@
+package smf
+
+import (
+	"sort"
+)
+
+// AbsEvent is an event of a track with its absolute position in ticks (from the start of the track).
+type AbsEvent struct {
+	AbsTicks int64
+	Message  Message
+}
+
+// AbsTrack is an editable view of a Track, where each event has an absolute position in ticks instead of a delta.
+// The events are always sorted by their position. Events at the same position keep their insertion order.
+// If the track is closed, the end of track message is always the last event.
+// Use the Track method to get back a Track with the deltas recalculated.
+type AbsTrack []AbsEvent
+
+// Abs returns the track as an AbsTrack, i.e. with absolute ticks instead of deltas.
+func (t Track) Abs() AbsTrack {
+	a := make(AbsTrack, 0, len(t))
+	var absTicks int64
+	for _, ev := range t {
+		absTicks += int64(ev.Delta)
+		a = append(a, AbsEvent{AbsTicks: absTicks, Message: ev.Message})
+	}
+	return a
+}
+
+// Track returns the events as a Track with the deltas recalculated.
+func (a AbsTrack) Track() Track {
+	t := make(Track, 0, len(a))
+	var last int64
+	for _, ev := range a {
+		delta := ev.AbsTicks - last
+		if delta < 0 {
+			delta = 0
+		}
+		t = append(t, Event{Delta: uint32(delta), Message: ev.Message})
+		last += delta
+	}
+	return t
+}
+
+// IsClosed returns true, if the last event is an end of track message.
+func (a AbsTrack) IsClosed() bool {
+	if len(a) == 0 {
+		return false
+	}
+	return isEOT(a[len(a)-1].Message)
+}
+
+// End returns the position of the end of track message.
+// If the track is not closed, the position of the last event is returned.
+func (a AbsTrack) End() int64 {
+	if len(a) == 0 {
+		return 0
+	}
+	return a[len(a)-1].AbsTicks
+}
+
+// Close closes the track at the given position. If the track is already closed, the end of track
+// message is moved to the given position. The end of track never comes before the last message.
+func (a *AbsTrack) Close(absTicks int64) {
+	if a.IsClosed() {
+		*a = (*a)[:len(*a)-1]
+	}
+	if end := a.End(); absTicks < end {
+		absTicks = end
+	}
+	*a = append(*a, AbsEvent{AbsTicks: absTicks, Message: EOT})
+}
+
+// Len returns the number of events (without the end of track message).
+func (a AbsTrack) Len() int {
+	if a.IsClosed() {
+		return len(a) - 1
+	}
+	return len(a)
+}
+
+// Insert inserts the given messages at the given position in ticks.
+// The messages are placed after the existing messages at the same position.
+// If the position is after the end of the track, the end of track is moved to the position.
+// End of track messages are ignored, use Close instead.
+func (a *AbsTrack) Insert(absTicks int64, msgs ...[]byte) {
+	if absTicks < 0 {
+		absTicks = 0
+	}
+	closed := a.IsClosed()
+	end := a.End()
+	if closed {
+		*a = (*a)[:len(*a)-1]
+	}
+
+	for _, msg := range msgs {
+		if isEOT(msg) {
+			continue
+		}
+		i := a.searchAfter(absTicks)
+		*a = append(*a, AbsEvent{})
+		copy((*a)[i+1:], (*a)[i:])
+		(*a)[i] = AbsEvent{AbsTicks: absTicks, Message: msg}
+	}
+
+	if closed {
+		a.Close(end)
+	}
+}
+
+// Remove removes the event at the given index. The end of track message can't be removed.
+func (a *AbsTrack) Remove(i int) {
+	if i < 0 || i >= a.Len() {
+		return
+	}
+	*a = append((*a)[:i], (*a)[i+1:]...)
+}
+
+// RemoveIf removes all events (except the end of track) for which fn returns true.
+// It returns the number of removed events.
+func (a *AbsTrack) RemoveIf(fn func(ev AbsEvent) bool) (removed int) {
+	res := (*a)[:0]
+	for _, ev := range *a {
+		if !isEOT(ev.Message) && fn(ev) {
+			removed++
+			continue
+		}
+		res = append(res, ev)
+	}
+	*a = res
+	return
+}
+
+// RemoveRange removes all events (except the end of track) within the range from <= AbsTicks < to.
+// It returns the number of removed events.
+func (a *AbsTrack) RemoveRange(from, to int64) (removed int) {
+	return a.RemoveIf(func(ev AbsEvent) bool {
+		return ev.AbsTicks >= from && ev.AbsTicks < to
+	})
+}
+
+// Move moves the event at the given index to the given position in ticks.
+// The moved event is placed after the existing events at the target position.
+// It returns the new index of the event. The end of track message can be moved with Close.
+func (a *AbsTrack) Move(i int, absTicks int64) (newIndex int) {
+	if i < 0 || i >= a.Len() {
+		return -1
+	}
+	ev := (*a)[i]
+	a.Remove(i)
+	if absTicks < 0 {
+		absTicks = 0
+	}
+	a.Insert(absTicks, ev.Message)
+	return a.searchAfter(absTicks) - 1
+}
+
+// Range returns a copy of the events (without the end of track) within the range from <= AbsTicks < to.
+func (a AbsTrack) Range(from, to int64) AbsTrack {
+	var res AbsTrack
+	for _, ev := range a {
+		if ev.AbsTicks >= to {
+			break
+		}
+		if ev.AbsTicks >= from && !isEOT(ev.Message) {
+			res = append(res, ev)
+		}
+	}
+	return res
+}
+
+// Shift moves all events (except the end of track) within the range from <= AbsTicks < to by the given
+// number of ticks. Events are not moved before 0.
+func (a *AbsTrack) Shift(from, to int64, ticks int64) {
+	var moved AbsTrack
+	a.RemoveIf(func(ev AbsEvent) bool {
+		if ev.AbsTicks >= from && ev.AbsTicks < to {
+			moved = append(moved, ev)
+			return true
+		}
+		return false
+	})
+	for _, ev := range moved {
+		a.Insert(ev.AbsTicks+ticks, ev.Message)
+	}
+}
+
+// searchAfter returns the index after the last event (before the end of track) that is at or before absTicks.
+func (a AbsTrack) searchAfter(absTicks int64) int {
+	n := a.Len()
+	return sort.Search(n, func(i int) bool {
+		return a[i].AbsTicks > absTicks
+	})
+}
+
+func isEOT(msg []byte) bool {
+	return Message(msg).Is(MetaEndOfTrackMsg)
+}
@@ -0,0 +1,172 @@
+package smf
+
+import (
+	"reflect"
+	"testing"
+
+	"gitlab.com/gomidi/midi/v2"
+)
+
+func TestAbsTrackRoundTrip(t *testing.T) {
+	var tr Track
+	tr.Add(0, midi.NoteOn(0, 60, 100))
+	tr.Add(96, midi.NoteOff(0, 60))
+	tr.Add(0, midi.NoteOn(0, 62, 100))
+	tr.Add(96, midi.NoteOff(0, 62))
+	tr.Close(10)
+
+	abs := tr.Abs()
+
+	if got, want := abs.End(), int64(202); got != want {
+		t.Errorf("End() = %v; want %v", got, want)
+	}
+
+	if got, want := abs.Len(), 4; got != want {
+		t.Errorf("Len() = %v; want %v", got, want)
+	}
+
+	if got, want := abs.Track(), tr; !reflect.DeepEqual(got, want) {
+		t.Errorf("Abs().Track() = %v; want %v", got, want)
+	}
+}
+
+func TestAbsTrackEdit(t *testing.T) {
+	var tr Track
+	tr.Add(0, midi.NoteOn(0, 60, 100))
+	tr.Add(96, midi.NoteOff(0, 60))
+	tr.Close(0)
+
+	tests := []struct {
+		descr    string
+		edit     func(a *AbsTrack)
+		expected Track
+	}{
+		{
+			"insert in between",
+			func(a *AbsTrack) {
+				a.Insert(48, midi.ControlChange(0, 7, 100))
+			},
+			Track{
+				{0, Message(midi.NoteOn(0, 60, 100))},
+				{48, Message(midi.ControlChange(0, 7, 100))},
+				{48, Message(midi.NoteOff(0, 60))},
+				{0, EOT},
+			},
+		},
+		{
+			"insert at same position comes after",
+			func(a *AbsTrack) {
+				a.Insert(0, midi.ControlChange(0, 7, 100))
+			},
+			Track{
+				{0, Message(midi.NoteOn(0, 60, 100))},
+				{0, Message(midi.ControlChange(0, 7, 100))},
+				{96, Message(midi.NoteOff(0, 60))},
+				{0, EOT},
+			},
+		},
+		{
+			"insert after end moves EOT",
+			func(a *AbsTrack) {
+				a.Insert(200, midi.ControlChange(0, 7, 100))
+			},
+			Track{
+				{0, Message(midi.NoteOn(0, 60, 100))},
+				{96, Message(midi.NoteOff(0, 60))},
+				{104, Message(midi.ControlChange(0, 7, 100))},
+				{0, EOT},
+			},
+		},
+		{
+			"remove",
+			func(a *AbsTrack) {
+				a.Remove(0)
+			},
+			Track{
+				{96, Message(midi.NoteOff(0, 60))},
+				{0, EOT},
+			},
+		},
+		{
+			"remove EOT is ignored",
+			func(a *AbsTrack) {
+				a.Remove(2)
+			},
+			Track{
+				{0, Message(midi.NoteOn(0, 60, 100))},
+				{96, Message(midi.NoteOff(0, 60))},
+				{0, EOT},
+			},
+		},
+		{
+			"move",
+			func(a *AbsTrack) {
+				a.Move(1, 48)
+			},
+			Track{
+				{0, Message(midi.NoteOn(0, 60, 100))},
+				{48, Message(midi.NoteOff(0, 60))},
+				{48, EOT},
+			},
+		},
+		{
+			"shift",
+			func(a *AbsTrack) {
+				a.Shift(0, 200, 10)
+			},
+			Track{
+				{10, Message(midi.NoteOn(0, 60, 100))},
+				{96, Message(midi.NoteOff(0, 60))},
+				{0, EOT},
+			},
+		},
+		{
+			"remove range",
+			func(a *AbsTrack) {
+				a.RemoveRange(0, 96)
+			},
+			Track{
+				{96, Message(midi.NoteOff(0, 60))},
+				{0, EOT},
+			},
+		},
+		{
+			"close",
+			func(a *AbsTrack) {
+				a.Close(384)
+			},
+			Track{
+				{0, Message(midi.NoteOn(0, 60, 100))},
+				{96, Message(midi.NoteOff(0, 60))},
+				{288, EOT},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		abs := tr.Abs()
+		test.edit(&abs)
+
+		if got, want := abs.Track(), test.expected; !reflect.DeepEqual(got, want) {
+			t.Errorf("[%s] got:\n%v\nwant:\n%v", test.descr, got, want)
+		}
+	}
+}
+
+func TestAbsTrackRange(t *testing.T) {
+	var tr Track
+	tr.Add(0, midi.NoteOn(0, 60, 100))
+	tr.Add(96, midi.NoteOff(0, 60))
+	tr.Add(96, midi.NoteOn(0, 62, 100))
+	tr.Close(96)
+
+	r := tr.Abs().Range(96, 500)
+
+	if got, want := len(r), 2; got != want {
+		t.Fatalf("len(Range(96, 500)) = %v; want %v", got, want)
+	}
+
+	if got, want := r[1].AbsTicks, int64(192); got != want {
+		t.Errorf("Range(96, 500)[1].AbsTicks = %v; want %v", got, want)
+	}
+}
@@ -0,0 +1,249 @@
+package smf
+
+import (
+	"fmt"
+	"sort"
+
+	"gitlab.com/gomidi/midi/v2"
+)
+
+// ConvertToSMF0 converts a given SMF format 1 or 2 to SMF format 0.
+// The tracks of a SMF format 1 are merged by their absolute positions, the tracks of a SMF format 2
+// (independent sequences) are concatenated one after the other.
+func (src SMF) ConvertToSMF0() (dest SMF) {
+	dest.TimeFormat = src.TimeFormat
+	dest.format = 0
+
+	var merged AbsTrack
+
+	switch src.format {
+	case 0:
+		if len(src.Tracks) > 0 {
+			merged = src.Tracks[0].Abs()
+		}
+	case 2:
+		var offset int64
+		for _, tr := range src.Tracks {
+			for _, ev := range tr.Abs() {
+				if !isEOT(ev.Message) {
+					merged = append(merged, AbsEvent{AbsTicks: ev.AbsTicks + offset, Message: ev.Message})
+				}
+			}
+			offset += tr.Abs().End()
+		}
+		merged.Close(offset)
+	default:
+		abs := make([]AbsTrack, len(src.Tracks))
+		for i, tr := range src.Tracks {
+			abs[i] = tr.Abs()
+		}
+		merged = mergeAbsTracks(abs...)
+	}
+
+	merged.Close(merged.End())
+	dest.Add(merged.Track())
+	return dest
+}
+
+// mergeAbsTracks merges the given tracks by the absolute positions of their events.
+// Events at the same position are ordered by the track order. The resulting track is closed at the
+// end of the longest track.
+func mergeAbsTracks(tracks ...AbsTrack) (merged AbsTrack) {
+	var end int64
+	for _, tr := range tracks {
+		for _, ev := range tr {
+			if !isEOT(ev.Message) {
+				merged = append(merged, ev)
+			}
+		}
+		if e := tr.End(); e > end {
+			end = e
+		}
+	}
+
+	sort.SliceStable(merged, func(a, b int) bool {
+		return merged[a].AbsTicks < merged[b].AbsTicks
+	})
+
+	merged.Close(end)
+	return merged
+}
+
+// SplitByChannel splits the track by the channels of its channel messages.
+// The returned rest contains all messages that are no channel messages.
+// All returned tracks are closed. Channel tracks without messages are nil.
+func (t Track) SplitByChannel() (rest Track, channels [16]Track) {
+	var restAbs AbsTrack
+	var channelsAbs [16]AbsTrack
+	var channel uint8
+
+	abs := t.Abs()
+
+	for _, ev := range abs {
+		if isEOT(ev.Message) {
+			continue
+		}
+		if ev.Message.GetChannel(&channel) {
+			channelsAbs[channel] = append(channelsAbs[channel], ev)
+		} else {
+			restAbs = append(restAbs, ev)
+		}
+	}
+
+	restAbs.Close(abs.End())
+	rest = restAbs.Track()
+
+	for ch, chAbs := range channelsAbs {
+		if len(chAbs) > 0 {
+			chAbs.Close(abs.End())
+			channels[ch] = chAbs.Track()
+		}
+	}
+
+	return
+}
+
+// SplitByChannel splits every track of the SMF by the channels of its channel messages.
+// The resulting SMF has format 1 and contains for every source track a track with the messages
+// that are no channel messages, followed by a track for every used channel. Empty tracks are skipped,
+// except for the first track. A SMF format 2 is converted to format 0 before.
+func (src SMF) SplitByChannel() (dest SMF) {
+	if src.format == 2 {
+		src = src.ConvertToSMF0()
+	}
+
+	dest.TimeFormat = src.TimeFormat
+	dest.format = 1
+
+	for i, tr := range src.Tracks {
+		rest, channels := tr.SplitByChannel()
+		if i == 0 || !rest.IsEmpty() {
+			dest.Add(rest)
+		}
+		for _, chTrack := range channels {
+			if chTrack != nil {
+				dest.Add(chTrack)
+			}
+		}
+	}
+
+	return dest
+}
+
+// Extract returns a new SMF with the events within the range from <= AbsTicks < to, moved to the start.
+// Tempo, time signature and key signature that are active at from are placed at the start of the first track
+// (of each sequence for SMF format 2). Notes that start within the range and end after it are ended
+// at the end of the range, note ends without a note start within the range are skipped.
+func (src SMF) Extract(from, to int64) (dest SMF) {
+	dest.TimeFormat = src.TimeFormat
+	dest.format = src.format
+
+	if to < from {
+		to = from
+	}
+
+	if src.format == 2 {
+		for _, tr := range src.Tracks {
+			chase := chaseMeta([]Track{tr}, from)
+			dest.Add(extractTrack(tr, from, to, chase))
+		}
+		return dest
+	}
+
+	chase := chaseMeta(src.Tracks, from)
+
+	for i, tr := range src.Tracks {
+		if i == 0 {
+			dest.Add(extractTrack(tr, from, to, chase))
+			continue
+		}
+		dest.Add(extractTrack(tr, from, to, nil))
+	}
+
+	return dest
+}
+
+// ExtractBars returns a new SMF with the bars fromBar to toBar (both included, counted from 1),
+// see Extract. The bar positions are taken from the MeterMap of the SMF.
+func (src SMF) ExtractBars(fromBar, toBar int) (dest SMF, err error) {
+	if fromBar < 1 || toBar < fromBar {
+		return dest, fmt.Errorf("invalid bar range %v-%v", fromBar, toBar)
+	}
+
+	meters := src.MeterMap()
+	if meters == nil {
+		return dest, fmt.Errorf("SMF time format is not metric ticks, but %s (currently not supported)", src.TimeFormat.String())
+	}
+
+	return src.Extract(meters.BarStart(fromBar), meters.BarStart(toBar+1)), nil
+}
+
+// chaseMeta returns the last tempo, time signature and key signature messages of the given tracks
+// before or at the given position.
+func chaseMeta(tracks []Track, pos int64) (chase []Message) {
+	var tempo, timeSig, key Message
+	var tempoPos, timeSigPos, keyPos int64 = -1, -1, -1
+
+	for _, tr := range tracks {
+		for _, ev := range tr.Abs() {
+			if ev.AbsTicks > pos {
+				break
+			}
+			switch {
+			case ev.Message.Is(MetaTempoMsg) && ev.AbsTicks >= tempoPos:
+				tempo, tempoPos = ev.Message, ev.AbsTicks
+			case ev.Message.Is(MetaTimeSigMsg) && ev.AbsTicks >= timeSigPos:
+				timeSig, timeSigPos = ev.Message, ev.AbsTicks
+			case ev.Message.Is(MetaKeySigMsg) && ev.AbsTicks >= keyPos:
+				key, keyPos = ev.Message, ev.AbsTicks
+			}
+		}
+	}
+
+	for _, msg := range []Message{tempo, timeSig, key} {
+		if msg != nil {
+			chase = append(chase, msg)
+		}
+	}
+	return
+}
+
+// extractTrack returns the events of the track within the range from <= AbsTicks < to moved to the start,
+// with the given chase messages placed at the start. Messages of the same type as the chase messages are
+// skipped at the start of the range to avoid duplicates.
+func extractTrack(tr Track, from, to int64, chase []Message) Track {
+	var res AbsTrack
+	var open [16][128]int
+	var channel, key uint8
+
+	for _, msg := range chase {
+		res.Insert(0, msg)
+	}
+
+	for _, ev := range tr.Abs().Range(from, to) {
+		msg := ev.Message
+		switch {
+		case msg.GetNoteStart(&channel, &key, nil):
+			open[channel][key]++
+		case msg.GetNoteEnd(&channel, &key):
+			if open[channel][key] == 0 {
+				continue
+			}
+			open[channel][key]--
+		case ev.AbsTicks == from && len(chase) > 0 && msg.IsOneOf(MetaTempoMsg, MetaTimeSigMsg, MetaKeySigMsg):
+			continue
+		}
+		res.Insert(ev.AbsTicks-from, msg)
+	}
+
+	for ch := range open {
+		for k, n := range open[ch] {
+			for ; n > 0; n-- {
+				res.Insert(to-from, Message(midi.NoteOff(uint8(ch), uint8(k))))
+			}
+		}
+	}
+
+	res.Close(to - from)
+	return res.Track()
+}
@@ -0,0 +1,161 @@
+package smf
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"gitlab.com/gomidi/midi/v2"
+)
+
+func testSMF1() *SMF {
+	var meta, piano, bass Track
+	meta.Add(0, MetaMeter(4, 4))
+	meta.Add(0, MetaTempo(100))
+	meta.Add(384, MetaMeter(3, 4))
+	meta.Add(0, MetaTempo(140))
+	meta.Close(0)
+
+	piano.Add(0, midi.NoteOn(0, 60, 100))
+	piano.Add(96, midi.NoteOff(0, 60))
+	piano.Add(480, midi.NoteOn(0, 62, 100))
+	piano.Add(96, midi.NoteOff(0, 62))
+	piano.Close(0)
+
+	bass.Add(48, midi.NoteOn(1, 40, 100))
+	bass.Add(384, midi.NoteOff(1, 40))
+	bass.Close(0)
+
+	s := NewSMF1()
+	s.TimeFormat = MetricTicks(96)
+	s.Add(meta)
+	s.Add(piano)
+	s.Add(bass)
+	return s
+}
+
+func TestConvertToSMF0(t *testing.T) {
+	src := testSMF1()
+	dest := src.ConvertToSMF0()
+
+	if got, want := dest.Format(), uint16(0); got != want {
+		t.Errorf("Format() = %v; want %v", got, want)
+	}
+
+	if got, want := len(dest.Tracks), 1; got != want {
+		t.Fatalf("len(Tracks) = %v; want %v", got, want)
+	}
+
+	abs := dest.Tracks[0].Abs()
+
+	if got, want := abs.Len(), 10; got != want {
+		t.Errorf("Len() = %v; want %v", got, want)
+	}
+
+	if got, want := abs.End(), int64(672); got != want {
+		t.Errorf("End() = %v; want %v", got, want)
+	}
+
+	back := dest.ConvertToSMF1()
+
+	if got, want := len(back.Tracks), 3; got != want {
+		t.Errorf("len(ConvertToSMF1().Tracks) = %v; want %v", got, want)
+	}
+
+	var bf bytes.Buffer
+	if _, err := dest.WriteTo(&bf); err != nil {
+		t.Errorf("WriteTo() returned error: %v", err)
+	}
+}
+
+func TestConvertSMF2ToSMF0(t *testing.T) {
+	var seq1, seq2 Track
+	seq1.Add(0, midi.NoteOn(0, 60, 100))
+	seq1.Add(96, midi.NoteOff(0, 60))
+	seq1.Close(0)
+	seq2.Add(0, midi.NoteOn(0, 62, 100))
+	seq2.Add(96, midi.NoteOff(0, 62))
+	seq2.Close(0)
+
+	s := NewSMF2()
+	s.Add(seq1)
+	s.Add(seq2)
+
+	dest := s.ConvertToSMF0()
+
+	expected := Track{
+		{0, Message(midi.NoteOn(0, 60, 100))},
+		{96, Message(midi.NoteOff(0, 60))},
+		{0, Message(midi.NoteOn(0, 62, 100))},
+		{96, Message(midi.NoteOff(0, 62))},
+		{0, EOT},
+	}
+
+	if got, want := dest.Tracks[0], expected; !reflect.DeepEqual(got, want) {
+		t.Errorf("ConvertToSMF0() = %v; want %v", got, want)
+	}
+}
+
+func TestSplitByChannel(t *testing.T) {
+	src := testSMF1().ConvertToSMF0()
+	dest := src.SplitByChannel()
+
+	if got, want := len(dest.Tracks), 3; got != want {
+		t.Fatalf("len(Tracks) = %v; want %v", got, want)
+	}
+
+	if got, want := dest.Tracks[2].Abs().Len(), 2; got != want {
+		t.Errorf("len(Tracks[2]) = %v; want %v", got, want)
+	}
+}
+
+func TestExtractBars(t *testing.T) {
+	src := testSMF1()
+
+	// bar 1 is 4/4 (0-384), bar 2 is 3/4 (384-672)
+	dest, err := src.ExtractBars(2, 2)
+
+	if err != nil {
+		t.Fatalf("ExtractBars(2, 2) returned error: %v", err)
+	}
+
+	expectedMeta := Track{
+		{0, MetaTempo(140)},
+		{0, MetaMeter(3, 4)},
+		{288, EOT},
+	}
+
+	if got, want := dest.Tracks[0], expectedMeta; !reflect.DeepEqual(got, want) {
+		t.Errorf("Tracks[0] = %v; want %v", got, want)
+	}
+
+	expectedPiano := Track{
+		{192, Message(midi.NoteOn(0, 62, 100))},
+		{96, Message(midi.NoteOff(0, 62))},
+		{0, EOT},
+	}
+
+	if got, want := dest.Tracks[1], expectedPiano; !reflect.DeepEqual(got, want) {
+		t.Errorf("Tracks[1] = %v; want %v", got, want)
+	}
+
+	// the bass note starts before the range, so its end is skipped
+	if got, want := dest.Tracks[2], (Track{{288, EOT}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Tracks[2] = %v; want %v", got, want)
+	}
+}
+
+func TestExtractEndsNotes(t *testing.T) {
+	src := testSMF1()
+	dest := src.Extract(0, 200)
+
+	expectedBass := Track{
+		{48, Message(midi.NoteOn(1, 40, 100))},
+		{152, Message(midi.NoteOff(1, 40))},
+		{0, EOT},
+	}
+
+	if got, want := dest.Tracks[2], expectedBass; !reflect.DeepEqual(got, want) {
+		t.Errorf("Tracks[2] = %v; want %v", got, want)
+	}
+}
@@ -8,6 +8,7 @@ var (
 	errExpectedMthd          = errors.New("Expected SMF Midi header.")
 	errBadSizeChunk          = errors.New("Chunk was an unexpected size.")
 	errInterruptedByCallback = errors.New("interrupted by callback")
+	errNoRMID                = errors.New("RIFF data is not of type RMID")
 
 	// ErrMissing is the error returned, if there is no more data, but tracks are missing
 	ErrMissing = errors.New("incomplete, tracks missing")
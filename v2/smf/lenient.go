@@ -0,0 +1,374 @@
+package smf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Recover is a ReadOption that makes the reading lenient: instead of giving up on corrupt data,
+// as much as possible is salvaged. The problems that have been found are available via the Warnings method
+// of the returned SMF. Handled problems are e.g. truncated chunks, wrong chunk lengths, missing end of track
+// messages, running status across meta and sysex messages, data bytes without status and missing tracks.
+// The whole data is read into memory before parsing.
+func Recover() ReadOption {
+	return func(c *readConfig) {
+		c.Recover = true
+	}
+}
+
+// Warning is a problem that has been found and worked around while reading with the Recover option.
+type Warning struct {
+	// Offset is the byte offset within the data, where the problem was found
+	Offset int64
+
+	// Track is the number of the track, where the problem was found (-1 if it is not within a track)
+	Track int
+
+	// Problem describes the problem
+	Problem string
+}
+
+// String returns a string representation of the warning.
+func (w Warning) String() string {
+	if w.Track < 0 {
+		return fmt.Sprintf("offset %v: %s", w.Offset, w.Problem)
+	}
+	return fmt.Sprintf("offset %v track %v: %s", w.Offset, w.Track, w.Problem)
+}
+
+// Warnings returns the problems that have been found while reading with the Recover option.
+func (s *SMF) Warnings() []Warning {
+	return s.warnings
+}
+
+type lenientReader struct {
+	data   []byte
+	smf    *SMF
+	logger Logger
+}
+
+func (l *lenientReader) warn(offset int, track int, format string, vals ...interface{}) {
+	w := Warning{Offset: int64(offset), Track: track, Problem: fmt.Sprintf(format, vals...)}
+	if l.logger != nil {
+		l.logger.Printf("warning: %s\n", w)
+	}
+	l.smf.warnings = append(l.smf.warnings, w)
+}
+
+func readLenient(f io.Reader, c readConfig) (*SMF, error) {
+	data, err := io.ReadAll(f)
+	if err != nil && len(data) == 0 {
+		return nil, err
+	}
+
+	l := &lenientReader{
+		data:   data,
+		smf:    &SMF{},
+		logger: c.Logger,
+	}
+
+	if err != nil {
+		l.warn(len(data), -1, "reading stopped with error: %v", err)
+	}
+
+	pos, err := l.readHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	l.readTracks(pos)
+
+	if _, isMetric := l.smf.TimeFormat.(MetricTicks); isMetric {
+		l.smf.finishTempoChanges()
+	}
+
+	return l.smf, nil
+}
+
+// readHeader reads the header and returns the position after it
+func (l *lenientReader) readHeader() (pos int, err error) {
+	pos = bytes.Index(l.data, []byte("MThd"))
+	if pos < 0 {
+		return 0, errExpectedMthd
+	}
+
+	if pos > 0 {
+		l.warn(0, -1, "skipped %v bytes before header", pos)
+	}
+
+	if len(l.data) < pos+14 {
+		return 0, errBadSizeChunk
+	}
+
+	length := int(binary.BigEndian.Uint32(l.data[pos+4 : pos+8]))
+	if length != 6 {
+		l.warn(pos+4, -1, "header length is %v, expected 6", length)
+		if length < 6 || pos+8+length > len(l.data) {
+			length = 6
+		}
+	}
+
+	format := binary.BigEndian.Uint16(l.data[pos+8 : pos+10])
+	if format > 2 {
+		l.warn(pos+8, -1, "invalid format %v, using format 1", format)
+		format = 1
+	}
+	l.smf.format = format
+	l.smf.numTracks = binary.BigEndian.Uint16(l.data[pos+10 : pos+12])
+
+	division := binary.BigEndian.Uint16(l.data[pos+12 : pos+14])
+	if division&0x8000 == 0x0000 {
+		l.smf.TimeFormat = MetricTicks(division & 0x7FFF)
+	} else {
+		l.smf.TimeFormat = parseTimeCode(division)
+	}
+
+	return pos + 8 + length, nil
+}
+
+// isChunkHeader returns true, if the data at the given position looks like a chunk header
+func (l *lenientReader) isChunkHeader(pos int) bool {
+	if pos+8 > len(l.data) {
+		return false
+	}
+	for _, b := range l.data[pos : pos+4] {
+		isAlphaNum := (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z') || (b >= '0' && b <= '9')
+		if !isAlphaNum {
+			return false
+		}
+	}
+	return true
+}
+
+func (l *lenientReader) readTracks(pos int) {
+	for pos < len(l.data) {
+		if pos+8 > len(l.data) {
+			l.warn(pos, -1, "ignored %v trailing bytes", len(l.data)-pos)
+			break
+		}
+
+		if !l.isChunkHeader(pos) {
+			next := bytes.Index(l.data[pos:], []byte("MTrk"))
+			if next < 0 {
+				l.warn(pos, -1, "ignored %v trailing bytes without chunk header", len(l.data)-pos)
+				break
+			}
+			l.warn(pos, -1, "skipped %v bytes without chunk header", next)
+			pos += next
+		}
+
+		typ := string(l.data[pos : pos+4])
+		length := int(binary.BigEndian.Uint32(l.data[pos+4 : pos+8]))
+
+		if typ != "MTrk" {
+			l.logf("skipping chunk %q", typ)
+			pos += 8 + length
+			continue
+		}
+
+		pos = l.readTrack(pos, length)
+	}
+
+	if got, want := len(l.smf.Tracks), int(l.smf.numTracks); got != want {
+		l.warn(len(l.data), -1, "header announces %v tracks, but found %v", want, got)
+	}
+
+	l.smf.numTracks = uint16(len(l.smf.Tracks))
+	if l.smf.format == 0 && len(l.smf.Tracks) > 1 {
+		l.warn(0, -1, "format 0 with %v tracks, using format 1", len(l.smf.Tracks))
+		l.smf.format = 1
+	}
+}
+
+// readTrack reads the track chunk at the given position with the given declared length and returns the position
+// of the next chunk.
+func (l *lenientReader) readTrack(pos int, length int) (next int) {
+	no := len(l.smf.Tracks)
+	start := pos + 8
+	end := start + length
+
+	switch {
+	case end > len(l.data):
+		l.warn(pos+4, no, "chunk length %v exceeds the data by %v bytes", length, end-len(l.data))
+		end = len(l.data)
+	case end == len(l.data) || l.isChunkHeader(end):
+	default:
+		// the declared length is wrong, look for the next track
+		found := bytes.Index(l.data[start:], []byte("MTrk"))
+		if found < 0 {
+			end = len(l.data)
+		} else {
+			end = start + found
+		}
+		l.warn(pos+4, no, "chunk length is %v, but the next chunk is found after %v bytes", length, end-start)
+	}
+
+	track, eot := l.readEvents(start, end, no)
+
+	if eot < 0 {
+		l.warn(end, no, "missing end of track")
+	} else if eot < end {
+		if l.isChunkHeader(eot) && string(l.data[eot:eot+4]) == "MTrk" {
+			l.warn(pos+4, no, "chunk length is %v, but the end of track is found after %v bytes", length, eot-start)
+			end = eot
+		} else {
+			l.warn(eot, no, "ignored %v bytes after end of track", end-eot)
+		}
+	}
+
+	track.Close(0)
+	l.smf.Tracks = append(l.smf.Tracks, track)
+	return end
+}
+
+// readEvents reads the events within start and end and returns the position after the end of track message
+// (-1 if there is none)
+func (l *lenientReader) readEvents(start, end int, no int) (track Track, eot int) {
+	var status, lastChannelStatus byte
+	var delta uint32
+	var absTicks int64
+	pos := start
+	data := l.data[:end]
+
+	for pos < end {
+		evStart := pos
+
+		d, n := readVLQ(data[pos:])
+		if n == 0 {
+			l.warn(pos, no, "truncated delta time")
+			return track, -1
+		}
+		delta += d
+		pos += n
+
+		if pos >= end {
+			l.warn(evStart, no, "truncated event")
+			return track, -1
+		}
+
+		canary := data[pos]
+		var msg Message
+
+		switch {
+		case canary >= 0x80 && canary < 0xF0:
+			status, lastChannelStatus = canary, canary
+			pos++
+			msg, n = readChannelData(status, data[pos:])
+			if msg == nil {
+				l.warn(evStart, no, "incomplete channel message % X", data[pos-1:pos+n])
+				pos += n
+				continue
+			}
+			pos += n
+
+		case canary < 0x80:
+			if status == 0 {
+				if lastChannelStatus == 0 {
+					l.warn(pos, no, "skipped data byte %02X without status", canary)
+					pos++
+					continue
+				}
+				l.warn(pos, no, "running status across meta or sysex message")
+				status = lastChannelStatus
+			}
+			msg, n = readChannelData(status, data[pos:])
+			if msg == nil {
+				l.warn(evStart, no, "incomplete channel message")
+				pos += n
+				continue
+			}
+			pos += n
+
+		case canary == 0xF0 || canary == 0xF7:
+			status = 0
+			pos++
+			ln, n := readVLQ(data[pos:])
+			if n == 0 || pos+n+int(ln) > end {
+				l.warn(evStart, no, "truncated sysex message")
+				return track, -1
+			}
+			pos += n
+			msg = Message(append([]byte{canary}, data[pos:pos+int(ln)]...))
+			pos += int(ln)
+
+		case canary == 0xFF:
+			status = 0
+			pos++
+			if pos >= end {
+				l.warn(evStart, no, "truncated meta message")
+				return track, -1
+			}
+			typ := data[pos]
+			pos++
+			ln, n := readVLQ(data[pos:])
+			if n == 0 || pos+n+int(ln) > end {
+				l.warn(evStart, no, "truncated meta message")
+				return track, -1
+			}
+			pos += n
+			msg = _MetaMessage(typ, data[pos:pos+int(ln)])
+			pos += int(ln)
+
+			if msg.Is(MetaEndOfTrackMsg) {
+				track.Close(delta)
+				return track, pos
+			}
+
+		default:
+			l.warn(pos, no, "skipped invalid status byte %02X", canary)
+			status = 0
+			pos++
+			continue
+		}
+
+		absTicks += int64(delta)
+		if msg.Is(MetaTempoMsg) {
+			tc := TempoChange{AbsTicks: absTicks}
+			msg.GetMetaTempo(&tc.BPM)
+			l.smf.tempoChanges = append(l.smf.tempoChanges, &tc)
+		}
+
+		track = append(track, Event{Delta: delta, Message: msg})
+		delta = 0
+	}
+
+	return track, -1
+}
+
+func (l *lenientReader) logf(format string, vals ...interface{}) {
+	if l.logger != nil {
+		l.logger.Printf(format+"\n", vals...)
+	}
+}
+
+// readChannelData reads the data bytes of a channel message with the given status.
+// If the data is incomplete, nil is returned together with the number of the valid data bytes.
+func readChannelData(status byte, data []byte) (msg Message, n int) {
+	n = 2
+	if typ := status >> 4; typ == 0xC || typ == 0xD {
+		n = 1
+	}
+
+	for i := 0; i < n; i++ {
+		if i >= len(data) || data[i] >= 0x80 {
+			return nil, i
+		}
+	}
+
+	return Message(append([]byte{status}, data[:n]...)), n
+}
+
+// readVLQ reads a variable length quantity and returns the value and the number of bytes read (0 if the data is incomplete).
+func readVLQ(data []byte) (val uint32, n int) {
+	for n < len(data) && n < 4 {
+		b := data[n]
+		n++
+		val = val<<7 | uint32(b&0x7F)
+		if b&0x80 == 0 {
+			return val, n
+		}
+	}
+	return 0, 0
+}
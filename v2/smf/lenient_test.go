@@ -0,0 +1,159 @@
+package smf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func lenientHeader(numTracks byte) []byte {
+	return []byte{
+		0x4D, 0x54, 0x68, 0x64, // MThd
+		0x00, 0x00, 0x00, 0x06, // chunk length
+		0x00, 0x01, // format 1
+		0x00, numTracks,
+		0x00, 0x60, // 96 per quarter-note
+	}
+}
+
+func lenientTrack(length byte, data ...byte) []byte {
+	return append([]byte{0x4D, 0x54, 0x72, 0x6B, 0x00, 0x00, 0x00, length}, data...)
+}
+
+func TestReadRecover(t *testing.T) {
+	tests := []struct {
+		descr    string
+		input    []byte
+		expected string
+		warnings []string
+	}{
+		{
+			"tracks missing",
+			SpecSMF1Missing,
+			`
+SMF1
+3 Track(s)
+TimeFormat: 96 MetricTicks
+Track 0@0 MetaTimeSig meter: 4/4
+Track 0@0 MetaTempo bpm: 120.00
+Track 0@384 MetaEndOfTrack
+Track 1@0 ProgramChange channel: 0 program: 5
+Track 1@192 NoteOn channel: 0 key: 76 velocity: 32
+Track 1@192 NoteOn channel: 0 key: 76 velocity: 0
+Track 1@0 MetaEndOfTrack
+Track 2@0 ProgramChange channel: 1 program: 46
+Track 2@96 NoteOn channel: 1 key: 67 velocity: 64
+Track 2@288 NoteOn channel: 1 key: 67 velocity: 0
+Track 2@0 MetaEndOfTrack
+`,
+			[]string{"header announces 4 tracks, but found 3"},
+		},
+		{
+			"wrong chunk lengths",
+			bytes.Join([][]byte{
+				lenientHeader(2),
+				lenientTrack(0x02, 0x00, 0x90, 0x3C, 0x40, 0x60, 0x3C, 0x00, 0x00, 0xFF, 0x2F, 0x00),
+				lenientTrack(0x40, 0x00, 0xC0, 0x05, 0x00, 0xFF, 0x2F, 0x00),
+			}, nil),
+			`
+SMF1
+2 Track(s)
+TimeFormat: 96 MetricTicks
+Track 0@0 NoteOn channel: 0 key: 60 velocity: 64
+Track 0@96 NoteOn channel: 0 key: 60 velocity: 0
+Track 0@0 MetaEndOfTrack
+Track 1@0 ProgramChange channel: 0 program: 5
+Track 1@0 MetaEndOfTrack
+`,
+			[]string{
+				"chunk length is 2, but the next chunk is found after 11 bytes",
+				"chunk length 64 exceeds the data by 57 bytes",
+			},
+		},
+		{
+			"missing end of track and truncated event",
+			bytes.Join([][]byte{
+				lenientHeader(1),
+				lenientTrack(0x07, 0x00, 0x90, 0x3C, 0x40, 0x60, 0x3C),
+			}, nil),
+			`
+SMF1
+1 Track(s)
+TimeFormat: 96 MetricTicks
+Track 0@0 NoteOn channel: 0 key: 60 velocity: 64
+Track 0@0 MetaEndOfTrack
+`,
+			[]string{
+				"chunk length 7 exceeds the data by 1 bytes",
+				"incomplete channel message",
+				"missing end of track",
+			},
+		},
+		{
+			"running status across meta",
+			bytes.Join([][]byte{
+				lenientHeader(1),
+				lenientTrack(0x0F, 0x00, 0x90, 0x3C, 0x40, 0x00, 0xFF, 0x01, 0x00, 0x60, 0x3C, 0x00, 0x00, 0xFF, 0x2F, 0x00),
+			}, nil),
+			`
+SMF1
+1 Track(s)
+TimeFormat: 96 MetricTicks
+Track 0@0 NoteOn channel: 0 key: 60 velocity: 64
+Track 0@0 MetaText text: ""
+Track 0@96 NoteOn channel: 0 key: 60 velocity: 0
+Track 0@0 MetaEndOfTrack
+`,
+			[]string{"running status across meta or sysex message"},
+		},
+		{
+			"data byte without status",
+			bytes.Join([][]byte{
+				lenientHeader(1),
+				lenientTrack(0x08, 0x00, 0x3C, 0x00, 0xC0, 0x05, 0x00, 0xFF, 0x2F, 0x00),
+			}, nil),
+			`
+SMF1
+1 Track(s)
+TimeFormat: 96 MetricTicks
+Track 0@0 ProgramChange channel: 0 program: 5
+Track 0@0 MetaEndOfTrack
+`,
+			[]string{
+				"chunk length is 8, but the next chunk is found after 9 bytes",
+				"skipped data byte 3C without status",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		s, err := ReadFrom(bytes.NewReader(test.input), Recover())
+		if err != nil {
+			t.Errorf("[%s] can't read: %v", test.descr, err)
+			continue
+		}
+
+		var bf bytes.Buffer
+		_, err = s.WriteTo(&bf)
+		if err != nil {
+			t.Errorf("[%s] can't write: %v", test.descr, err)
+			continue
+		}
+
+		if got, want := testRead(t, bf.Bytes()), test.expected; got != want {
+			t.Errorf("[%s] got:\n%v\n\nwanted\n%v\n\n", test.descr, got, want)
+		}
+
+		warnings := s.Warnings()
+		if len(warnings) != len(test.warnings) {
+			t.Errorf("[%s] got warnings %v; want %v", test.descr, warnings, test.warnings)
+			continue
+		}
+
+		for i, w := range warnings {
+			if !strings.Contains(w.Problem, test.warnings[i]) {
+				t.Errorf("[%s] warning %v = %q; want %q", test.descr, i, w.Problem, test.warnings[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package smf
+
+import (
+	"fmt"
+	"sort"
+)
+
+// MeterChange is a change of the time signature.
+type MeterChange struct {
+	// AbsTicks is the absolute position of the time signature in ticks.
+	AbsTicks int64
+
+	// Bar is the bar (counted from 1) that starts with the time signature.
+	Bar int
+
+	// Num is the numerator of the time signature.
+	Num uint8
+
+	// Denom is the denominator of the time signature.
+	Denom uint8
+}
+
+// Position is a musical position, defined by bar, beat and ticks.
+type Position struct {
+	// Bar is the bar, counted from 1.
+	Bar int
+
+	// Beat is the beat within the bar, counted from 1. A beat has the length of the denominator of
+	// the time signature, e.g. in a 6/8 time signature, there are 6 beats of an eighth note.
+	Beat int
+
+	// Ticks are the ticks within the beat, counted from 0.
+	Ticks int64
+}
+
+// String returns the position as bar:beat:ticks.
+func (p Position) String() string {
+	return fmt.Sprintf("%v:%v:%v", p.Bar, p.Beat, p.Ticks)
+}
+
+// MeterMap maps absolute ticks to musical positions (bar, beat, ticks) and back, based on the time signatures.
+// If there is no time signature at the start, 4/4 is assumed.
+// A time signature that is not placed at the start of a bar, starts a new bar, i.e. the previous bar is shortened.
+type MeterMap struct {
+	ticks   MetricTicks
+	changes []*MeterChange
+}
+
+// MeterMap returns the meter map of the SMF, based on the time signature messages of all tracks.
+// For SMF format 2 only the first track (sequence) is considered.
+// If the time format of the SMF is not MetricTicks, nil is returned.
+func (s *SMF) MeterMap() *MeterMap {
+	mt, ok := s.TimeFormat.(MetricTicks)
+	if !ok {
+		return nil
+	}
+
+	tracks := s.Tracks
+	if s.format == 2 && len(tracks) > 0 {
+		tracks = tracks[:1]
+	}
+
+	abs := make([]AbsTrack, len(tracks))
+	for i, tr := range tracks {
+		abs[i] = tr.Abs()
+	}
+
+	m := &MeterMap{ticks: mt}
+	m.changes = append(m.changes, &MeterChange{AbsTicks: 0, Bar: 1, Num: 4, Denom: 4})
+
+	var num, denom uint8
+
+	for _, ev := range mergeAbsTracks(abs...) {
+		if !ev.Message.GetMetaMeter(&num, &denom) || num == 0 {
+			continue
+		}
+		m.add(ev.AbsTicks, num, denom)
+	}
+
+	return m
+}
+
+// add adds a time signature at the given position
+func (m *MeterMap) add(absTicks int64, num, denom uint8) {
+	last := m.changes[len(m.changes)-1]
+
+	if absTicks == last.AbsTicks {
+		last.Num, last.Denom = num, denom
+		return
+	}
+
+	barLen := m.barLen(last)
+	diff := absTicks - last.AbsTicks
+	bars := int(diff / barLen)
+	if diff%barLen != 0 {
+		bars++
+	}
+
+	m.changes = append(m.changes, &MeterChange{AbsTicks: absTicks, Bar: last.Bar + bars, Num: num, Denom: denom})
+}
+
+// Changes returns the time signature changes. The first change is always at position 0.
+func (m *MeterMap) Changes() []MeterChange {
+	res := make([]MeterChange, len(m.changes))
+	for i, c := range m.changes {
+		res[i] = *c
+	}
+	return res
+}
+
+// MeterAt returns the time signature at the given absolute position.
+func (m *MeterMap) MeterAt(absTicks int64) (num, denom uint8) {
+	c := m.changeAt(absTicks)
+	return c.Num, c.Denom
+}
+
+// Position returns the musical position for the given absolute position in ticks.
+func (m *MeterMap) Position(absTicks int64) (p Position) {
+	if absTicks < 0 {
+		absTicks = 0
+	}
+	c := m.changeAt(absTicks)
+	diff := absTicks - c.AbsTicks
+	barLen := m.barLen(c)
+	beatLen := m.beatLen(c)
+
+	p.Bar = c.Bar + int(diff/barLen)
+	inBar := diff % barLen
+	p.Beat = int(inBar/beatLen) + 1
+	p.Ticks = inBar % beatLen
+	return
+}
+
+// AbsTicks returns the absolute position in ticks of the given musical position.
+func (m *MeterMap) AbsTicks(p Position) int64 {
+	c := m.changeOfBar(p.Bar)
+	abs := c.AbsTicks + int64(p.Bar-c.Bar)*m.barLen(c)
+	if p.Beat > 1 {
+		abs += int64(p.Beat-1) * m.beatLen(c)
+	}
+	return abs + p.Ticks
+}
+
+// BarStart returns the absolute position in ticks of the start of the given bar (counted from 1).
+func (m *MeterMap) BarStart(bar int) int64 {
+	return m.AbsTicks(Position{Bar: bar, Beat: 1})
+}
+
+// BarLength returns the length in ticks of the given bar (counted from 1).
+func (m *MeterMap) BarLength(bar int) int64 {
+	return m.BarStart(bar+1) - m.BarStart(bar)
+}
+
+func (m *MeterMap) changeAt(absTicks int64) *MeterChange {
+	i := sort.Search(len(m.changes), func(i int) bool {
+		return m.changes[i].AbsTicks > absTicks
+	})
+	if i == 0 {
+		return m.changes[0]
+	}
+	return m.changes[i-1]
+}
+
+func (m *MeterMap) changeOfBar(bar int) *MeterChange {
+	i := sort.Search(len(m.changes), func(i int) bool {
+		return m.changes[i].Bar > bar
+	})
+	if i == 0 {
+		return m.changes[0]
+	}
+	return m.changes[i-1]
+}
+
+func (m *MeterMap) beatLen(c *MeterChange) int64 {
+	denom := c.Denom
+	if denom == 0 {
+		denom = 4
+	}
+	return int64(m.ticks.Ticks4th()) * 4 / int64(denom)
+}
+
+func (m *MeterMap) barLen(c *MeterChange) int64 {
+	return m.beatLen(c) * int64(c.Num)
+}
@@ -0,0 +1,64 @@
+package smf
+
+import (
+	"testing"
+)
+
+func TestMeterMap(t *testing.T) {
+	var tr Track
+	tr.Add(0, MetaMeter(4, 4))
+	tr.Add(96*4*2, MetaMeter(6, 8))  // bar 3
+	tr.Add(48*6+96, MetaMeter(3, 4)) // within bar 4, starts bar 5
+	tr.Close(0)
+
+	s := New()
+	s.TimeFormat = MetricTicks(96)
+	s.Add(tr)
+
+	m := s.MeterMap()
+
+	tests := []struct {
+		absTicks int64
+		expected string
+	}{
+		{0, "1:1:0"},
+		{95, "1:1:95"},
+		{96, "1:2:0"},
+		{384, "2:1:0"},
+		{768, "3:1:0"},
+		{768 + 48*5 + 10, "3:6:10"},
+		{768 + 48*6, "4:1:0"},
+		{768 + 48*6 + 96, "5:1:0"},
+		{768 + 48*6 + 96 + 288 + 100, "6:2:4"},
+	}
+
+	for _, test := range tests {
+		p := m.Position(test.absTicks)
+		if got, want := p.String(), test.expected; got != want {
+			t.Errorf("Position(%v) = %v; want %v", test.absTicks, got, want)
+		}
+
+		if got, want := m.AbsTicks(p), test.absTicks; got != want {
+			t.Errorf("AbsTicks(%v) = %v; want %v", p, got, want)
+		}
+	}
+
+	if got, want := m.BarLength(4), int64(96); got != want {
+		t.Errorf("BarLength(4) = %v; want %v", got, want)
+	}
+
+	if num, denom := m.MeterAt(800); num != 6 || denom != 8 {
+		t.Errorf("MeterAt(800) = %v/%v; want 6/8", num, denom)
+	}
+}
+
+func TestMeterMapDefault(t *testing.T) {
+	s := New()
+	s.TimeFormat = MetricTicks(96)
+
+	m := s.MeterMap()
+
+	if got, want := m.BarStart(3), int64(768); got != want {
+		t.Errorf("BarStart(3) = %v; want %v", got, want)
+	}
+}
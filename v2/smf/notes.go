@@ -0,0 +1,193 @@
+package smf
+
+import (
+	"sort"
+
+	"gitlab.com/gomidi/midi/v2"
+)
+
+// Note is a note that has been paired from a note start and a note end message.
+type Note struct {
+	// TrackNo is the number of the track the note was found in
+	TrackNo int
+
+	// Channel is the MIDI channel of the note
+	Channel uint8
+
+	// Key is the key of the note
+	Key uint8
+
+	// Velocity is the velocity of the note start
+	Velocity uint8
+
+	// ReleaseVelocity is the velocity of the note off message (0 for note on messages with velocity 0)
+	ReleaseVelocity uint8
+
+	// AbsTicks is the absolute position of the note start in ticks
+	AbsTicks int64
+
+	// Duration is the distance between the note start and the note end in ticks
+	Duration int64
+
+	// Hanging is true, if the note has not been ended before the end of the track.
+	// Then the note is ended at the end of the track.
+	Hanging bool
+}
+
+// End returns the absolute position of the note end in ticks.
+func (n Note) End() int64 {
+	return n.AbsTicks + n.Duration
+}
+
+// Notes is a list of notes.
+type Notes []Note
+
+func (n Notes) Len() int {
+	return len(n)
+}
+
+func (n Notes) Swap(a, b int) {
+	n[a], n[b] = n[b], n[a]
+}
+
+func (n Notes) Less(a, b int) bool {
+	return n[a].AbsTicks < n[b].AbsTicks
+}
+
+// NotePairing defines, which note start is ended by a note end, if there are overlapping notes on the same
+// channel and key.
+type NotePairing int
+
+const (
+	// PairFIFO ends the note that started first (first in, first out).
+	PairFIFO NotePairing = iota
+
+	// PairLIFO ends the note that started last (last in, first out).
+	PairLIFO
+)
+
+// Notes pairs the note start and note end messages of the track and returns the resulting notes,
+// sorted by their start position. Notes that are not ended before the end of the track are ended there
+// and marked as hanging.
+func (t Track) Notes(pairing NotePairing) Notes {
+	return t.notes(0, pairing)
+}
+
+// Notes pairs the note start and note end messages of all tracks and returns the resulting notes,
+// sorted by their start position. See Track.Notes.
+func (s *SMF) Notes(pairing NotePairing) Notes {
+	var res Notes
+	for i, tr := range s.Tracks {
+		res = append(res, tr.notes(i, pairing)...)
+	}
+	sort.Stable(res)
+	return res
+}
+
+func (t Track) notes(trackNo int, pairing NotePairing) Notes {
+	var res Notes
+	var open [16][128][]int // indices into res of the running notes
+
+	var absTicks int64
+	var channel, key, velocity uint8
+
+	for _, ev := range t {
+		absTicks += int64(ev.Delta)
+		msg := ev.Message
+
+		switch {
+		case msg.GetNoteStart(&channel, &key, &velocity):
+			open[channel][key] = append(open[channel][key], len(res))
+			res = append(res, Note{
+				TrackNo:  trackNo,
+				Channel:  channel,
+				Key:      key,
+				Velocity: velocity,
+				AbsTicks: absTicks,
+			})
+		case msg.GetNoteEnd(&channel, &key):
+			running := open[channel][key]
+			if len(running) == 0 {
+				continue
+			}
+			var idx int
+			if pairing == PairLIFO {
+				idx = running[len(running)-1]
+				open[channel][key] = running[:len(running)-1]
+			} else {
+				idx = running[0]
+				open[channel][key] = running[1:]
+			}
+			velocity = 0
+			msg.GetNoteOff(nil, nil, &velocity)
+			res[idx].ReleaseVelocity = velocity
+			res[idx].Duration = absTicks - res[idx].AbsTicks
+		}
+	}
+
+	for ch := range open {
+		for k := range open[ch] {
+			for _, idx := range open[ch][k] {
+				res[idx].Hanging = true
+				res[idx].Duration = absTicks - res[idx].AbsTicks
+			}
+		}
+	}
+
+	return res
+}
+
+// Track returns a closed track with a note on and a note off message for each note.
+// The track is closed at the end of the last note. The TrackNo field of the notes is ignored.
+// If note ends and note starts fall on the same position, the note ends come first. Notes without duration
+// are started and ended in between, so that the note start always comes before its own note end.
+func (n Notes) Track() Track {
+	// groups of the events at the same position
+	const (
+		groupEnd = iota
+		groupZero
+		groupStart
+	)
+
+	type noteEvent struct {
+		absTicks int64
+		group    int
+		note     int
+		isEnd    bool
+		msg      Message
+	}
+
+	evts := make([]noteEvent, 0, len(n)*2)
+
+	for i, nt := range n {
+		start, end := groupStart, groupEnd
+		if nt.Duration == 0 {
+			start, end = groupZero, groupZero
+		}
+		evts = append(evts,
+			noteEvent{nt.AbsTicks, start, i, false, Message(midi.NoteOn(nt.Channel, nt.Key, nt.Velocity))},
+			noteEvent{nt.End(), end, i, true, Message(midi.NoteOffVelocity(nt.Channel, nt.Key, nt.ReleaseVelocity))},
+		)
+	}
+
+	sort.SliceStable(evts, func(a, b int) bool {
+		ea, eb := evts[a], evts[b]
+		switch {
+		case ea.absTicks != eb.absTicks:
+			return ea.absTicks < eb.absTicks
+		case ea.group != eb.group:
+			return ea.group < eb.group
+		case ea.group == groupZero && ea.note != eb.note:
+			return ea.note < eb.note
+		default:
+			return !ea.isEnd && eb.isEnd
+		}
+	})
+
+	abs := make(AbsTrack, 0, len(evts)+1)
+	for _, ev := range evts {
+		abs = append(abs, AbsEvent{AbsTicks: ev.absTicks, Message: ev.msg})
+	}
+	abs.Close(abs.End())
+	return abs.Track()
+}
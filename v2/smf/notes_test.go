@@ -0,0 +1,123 @@
+package smf
+
+import (
+	"reflect"
+	"testing"
+
+	"gitlab.com/gomidi/midi/v2"
+)
+
+func TestTrackNotes(t *testing.T) {
+	var tr Track
+	tr.Add(0, midi.NoteOn(0, 60, 100))
+	tr.Add(10, midi.NoteOn(0, 60, 90))
+	tr.Add(10, midi.NoteOffVelocity(0, 60, 40))
+	tr.Add(10, midi.NoteOn(0, 60, 0))
+	tr.Add(0, midi.NoteOn(1, 64, 80))
+	tr.Close(20)
+
+	tests := []struct {
+		pairing  NotePairing
+		expected Notes
+	}{
+		{
+			PairFIFO,
+			Notes{
+				{Channel: 0, Key: 60, Velocity: 100, ReleaseVelocity: 40, AbsTicks: 0, Duration: 20},
+				{Channel: 0, Key: 60, Velocity: 90, ReleaseVelocity: 0, AbsTicks: 10, Duration: 20},
+				{Channel: 1, Key: 64, Velocity: 80, AbsTicks: 30, Duration: 20, Hanging: true},
+			},
+		},
+		{
+			PairLIFO,
+			Notes{
+				{Channel: 0, Key: 60, Velocity: 100, ReleaseVelocity: 0, AbsTicks: 0, Duration: 30},
+				{Channel: 0, Key: 60, Velocity: 90, ReleaseVelocity: 40, AbsTicks: 10, Duration: 10},
+				{Channel: 1, Key: 64, Velocity: 80, AbsTicks: 30, Duration: 20, Hanging: true},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		if got, want := tr.Notes(test.pairing), test.expected; !reflect.DeepEqual(got, want) {
+			t.Errorf("Notes(%v) = %v; want %v", test.pairing, got, want)
+		}
+	}
+}
+
+func TestNotesTrack(t *testing.T) {
+	notes := Notes{
+		{Channel: 0, Key: 60, Velocity: 100, AbsTicks: 0, Duration: 96},
+		{Channel: 0, Key: 62, Velocity: 90, ReleaseVelocity: 30, AbsTicks: 96, Duration: 96},
+	}
+
+	expected := Track{
+		{0, Message(midi.NoteOn(0, 60, 100))},
+		{96, Message(midi.NoteOffVelocity(0, 60, 0))},
+		{0, Message(midi.NoteOn(0, 62, 90))},
+		{96, Message(midi.NoteOffVelocity(0, 62, 30))},
+		{0, EOT},
+	}
+
+	tr := notes.Track()
+
+	if got, want := tr, expected; !reflect.DeepEqual(got, want) {
+		t.Errorf("Track() = %v; want %v", got, want)
+	}
+
+	if got, want := tr.Notes(PairFIFO), notes; !reflect.DeepEqual(got, want) {
+		t.Errorf("Track().Notes() = %v; want %v", got, want)
+	}
+}
+
+func TestNotesTrackZeroDuration(t *testing.T) {
+	notes := Notes{
+		{Channel: 0, Key: 60, Velocity: 100, AbsTicks: 0, Duration: 96},
+		{Channel: 0, Key: 64, Velocity: 80, AbsTicks: 96, Duration: 0},
+		{Channel: 0, Key: 62, Velocity: 90, AbsTicks: 96, Duration: 96},
+	}
+
+	expected := Track{
+		{0, Message(midi.NoteOn(0, 60, 100))},
+		{96, Message(midi.NoteOffVelocity(0, 60, 0))},
+		{0, Message(midi.NoteOn(0, 64, 80))},
+		{0, Message(midi.NoteOffVelocity(0, 64, 0))},
+		{0, Message(midi.NoteOn(0, 62, 90))},
+		{96, Message(midi.NoteOffVelocity(0, 62, 0))},
+		{0, EOT},
+	}
+
+	tr := notes.Track()
+
+	if got, want := tr, expected; !reflect.DeepEqual(got, want) {
+		t.Errorf("Track() = %v; want %v", got, want)
+	}
+
+	if got, want := tr.Notes(PairFIFO), notes; !reflect.DeepEqual(got, want) {
+		t.Errorf("Track().Notes() = %v; want %v", got, want)
+	}
+}
+
+func TestSMFNotes(t *testing.T) {
+	var tr1, tr2 Track
+	tr1.Add(10, midi.NoteOn(0, 60, 100))
+	tr1.Add(10, midi.NoteOff(0, 60))
+	tr1.Close(0)
+	tr2.Add(5, midi.NoteOn(1, 60, 100))
+	tr2.Add(10, midi.NoteOff(1, 60))
+	tr2.Close(0)
+
+	s := NewSMF1()
+	s.Add(tr1)
+	s.Add(tr2)
+
+	notes := s.Notes(PairFIFO)
+
+	if got, want := len(notes), 2; got != want {
+		t.Fatalf("len(Notes()) = %v; want %v", got, want)
+	}
+
+	if got, want := notes[0].TrackNo, 1; got != want {
+		t.Errorf("Notes()[0].TrackNo = %v; want %v", got, want)
+	}
+}
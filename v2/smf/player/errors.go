@@ -8,7 +8,11 @@ var (
 	ErrIsStopped  = errors.New("already stopped")
 	ErrInvalidSMF = errors.New("failed to read tracks from SMF data")
 	ErrNoOutPort  = errors.New("failed to get sound out port")
-	errDone       = errors.New("done playing SMF data")
-	errStopped    = errors.New("stopped playing")
-	errPaused     = errors.New("paused playing")
+
+	ErrInvalidLoop        = errors.New("invalid loop region")
+	ErrInvalidTempoFactor = errors.New("tempo factor must be greater than 0")
+
+	errDone    = errors.New("done playing SMF data")
+	errStopped = errors.New("stopped playing")
+	errPaused  = errors.New("paused playing")
 )
@@ -2,13 +2,13 @@ package player
 
 import (
 	"context"
-	"errors"
 	"io"
 	"runtime"
 	"sort"
 	"sync"
 	"time"
 
+	"gitlab.com/gomidi/midi/v2"
 	"gitlab.com/gomidi/midi/v2/drivers"
 	"gitlab.com/gomidi/midi/v2/smf"
 )
@@ -18,45 +18,91 @@ stolen from https://github.com/Minoxs/gomidi-player
 */
 
 type (
-	// message is an smf.Message to be played, followed by the sleeping time to the next message
+	// message is an smf.Message to be played at the given time since the start of the song.
+	// clocks is the number of timing clocks before the message.
 	message struct {
-		msg   smf.Message
-		sleep time.Duration
+		msg     smf.Message
+		at      time.Duration
+		isClock bool
+		clocks  int
 	}
 
-	// Player plays SMF data
+	// Option is an option for a Player
+	Option func(*Player)
+
+	// Player plays SMF data.
+	// The position can be changed with Seek, the playing can be looped with SetLoop and be made faster or slower
+	// with SetTempoFactor, also while playing.
+	// Sounding notes are silenced when pausing, stopping and seeking. When starting and seeking, the state of the
+	// controllers, programs and pitch bends at the position is sent (chased).
+	// If the player is created with the SendClock option, it acts as clock master.
 	Player struct {
-		mutex      sync.RWMutex
-		isPlaying  bool
-		ctx        context.Context
-		cancelFn   context.CancelCauseFunc
-		currentDur time.Duration
-		totalDur   time.Duration
-		currentMsg int
-		messages   []message
-		outPort    drivers.Out
+		mutex       sync.RWMutex
+		isPlaying   bool
+		ctx         context.Context
+		cancelFn    context.CancelCauseFunc
+		update      chan struct{}
+		anchorDur   time.Duration
+		anchorTime  time.Time
+		totalDur    time.Duration
+		currentMsg  int
+		messages    []message
+		smf         *smf.SMF
+		tempoFactor float64
+		loopFrom    time.Duration
+		loopTo      time.Duration
+		cursor      time.Duration
+		notes       [16][128]bool
+		sustain     [16]bool
+		outPort     drivers.Out
+		clock       bool
+		catchUp     int
 	}
 )
 
+// SendClock lets the player send timing clock messages (24 per quarter note), following the tempo map of the SMF data
+// and the tempo factor.
+// Start sends midi.Start, if playing from the start, otherwise midi.Continue. Pause, Stop and the end of the song send
+// midi.Stop. Seeking sends midi.SPP with the last sixteenth note at or before the position (enclosed by midi.Stop and
+// midi.Continue while playing). Since the song position pointer counts sixteenth notes, the timing clocks between the
+// sixteenth note and the position are sent directly after midi.Continue.
+// SMF data with SMPTE time format has no quarter notes, so no timing clocks are sent for it.
+func SendClock() Option {
+	return func(p *Player) {
+		p.clock = true
+	}
+}
+
 // New returns a Player that plays on the given output port
-func New(outPort drivers.Out) *Player {
-	return &Player{
-		ctx:      UnavailableContext(),
-		cancelFn: func(cause error) {},
-		outPort:  outPort,
+func New(outPort drivers.Out, opts ...Option) *Player {
+	p := &Player{
+		ctx:         UnavailableContext(),
+		cancelFn:    func(cause error) {},
+		tempoFactor: 1,
+		outPort:     outPort,
 	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
 }
 
-// stop will signal the player to stop playing
+// stop will signal the player to stop playing and silences the sounding notes. p.mutex must be locked.
 func (p *Player) stop(cause error) error {
 	if !p.isPlaying {
 		return ErrIsStopped
 	}
+	p.anchorDur = p.position()
+	p.isPlaying = false
 	p.cancelFn(cause)
+	p.silence()
+	p.sendClock(midi.Stop())
 	return nil
 }
 
 // SetSMF takes in a SMF and creates units that can be played.
+// The loop region is cleared.
 func (p *Player) SetSMF(smfdata io.Reader, tracks ...int) error {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
@@ -65,8 +111,8 @@ func (p *Player) SetSMF(smfdata io.Reader, tracks ...int) error {
 		return ErrIsPlaying
 	}
 
-	p.currentMsg = 0
-	p.currentDur = 0
+	p.rewind()
+	p.loopFrom, p.loopTo = 0, 0
 	p.messages = nil
 
 	var events smfReader
@@ -75,11 +121,12 @@ func (p *Player) SetSMF(smfdata io.Reader, tracks ...int) error {
 		return err
 	}
 
-	p.messages, p.totalDur = events.getMessages()
+	p.smf = events.smf
+	p.messages, p.totalDur = events.getMessages(p.clock)
 	return nil
 }
 
-// Start starts playing. It is non-blocking. Call wait to wait until it is finished.
+// Start starts playing at the current position. It is non-blocking. Call wait to wait until it is finished.
 func (p *Player) Start() error {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
@@ -93,60 +140,325 @@ func (p *Player) Start() error {
 	}
 
 	p.isPlaying = true
+	p.anchorTime = time.Now()
 	p.ctx, p.cancelFn = context.WithCancelCause(context.Background())
+	p.update = make(chan struct{}, 1)
+	p.chase()
+
+	if p.currentMsg == 0 && p.anchorDur == 0 {
+		p.sendClock(midi.Start())
+	} else {
+		p.sendClock(midi.Continue())
+		p.sendCatchUp()
+	}
 
-	go p.playOn(p.outPort)
+	go p.playOn(p.ctx, p.update, p.outPort)
 	return nil
 }
 
-// Stop stops the playing.
+// Stop stops the playing and goes back to the start.
 func (p *Player) Stop() (err error) {
-	return p.stop(errStopped)
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	err = p.stop(errStopped)
+	p.rewind()
+	return err
 }
 
-// Pause pauses the playing.
+// Pause pauses the playing. The next Start continues at the current position.
 func (p *Player) Pause() (err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
 	return p.stop(errPaused)
 }
 
+// Seek moves the current position to the given time since the start of the song.
+// Messages at exactly that time are played after the seek.
+func (p *Player) Seek(pos time.Duration) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.messages == nil {
+		return ErrNoSMFData
+	}
+
+	p.seek(pos)
+	return nil
+}
+
+// SeekTicks moves the current position to the given absolute ticks.
+func (p *Player) SeekTicks(ticks int64) error {
+	return p.Seek(p.TickTime(ticks))
+}
+
+// TickTime returns the time since the start of the song for the given absolute ticks, considering the tempo changes.
+// For SMF data with SMPTE time format, the ticks are subframes and the tempo changes have no effect.
+// It can be used to set the loop region in ticks.
+func (p *Player) TickTime(ticks int64) time.Duration {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	if p.smf == nil {
+		return 0
+	}
+	return time.Duration(p.smf.TimeAt(ticks)) * time.Microsecond
+}
+
+// SetLoop sets the loop region from <= position < to. When the playing reaches the end of the region, it continues at
+// the start of the region. The region is only looped if the position is before its end.
+// SetLoop(0, 0) clears the loop region.
+func (p *Player) SetLoop(from, to time.Duration) error {
+	if from < 0 || (to <= from && (from != 0 || to != 0)) {
+		return ErrInvalidLoop
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.loopFrom, p.loopTo = from, to
+	p.notify()
+	return nil
+}
+
+// Loop returns the loop region. If no loop region is set, both are 0.
+func (p *Player) Loop() (from, to time.Duration) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.loopFrom, p.loopTo
+}
+
+// SetTempoFactor scales the tempo of the song by the given factor, e.g. 0.5 plays with the half tempo and 2 with
+// the double tempo. The factor must be > 0. The default is 1.
+func (p *Player) SetTempoFactor(factor float64) error {
+	if factor <= 0 {
+		return ErrInvalidTempoFactor
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.isPlaying {
+		p.anchorDur = p.position()
+		p.anchorTime = time.Now()
+	}
+	p.tempoFactor = factor
+	p.notify()
+	return nil
+}
+
+// TempoFactor returns the factor the tempo is scaled by.
+func (p *Player) TempoFactor() float64 {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.tempoFactor
+}
+
 // Wait will block until the player finishes playing.
 func (p *Player) Wait() {
-	for p.isPlaying {
+	for p.IsPlaying() {
 		time.Sleep(50 * time.Millisecond)
 	}
 }
 
 // IsPlaying returns wether the player is playing
 func (p *Player) IsPlaying() bool {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
 	return p.isPlaying
 }
 
 // Duration is the total duration of the song.
 func (p *Player) Duration() time.Duration {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
 	return p.totalDur
 }
 
 // Current is the current time on the song.
 func (p *Player) Current() time.Duration {
-	return p.currentDur
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.position()
 }
 
 // Remaining is the remaining duration of the smf data.
 func (p *Player) Remaining() time.Duration {
-	return p.totalDur - p.currentDur
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.totalDur - p.position()
+}
+
+// position returns the current position. p.mutex must be locked.
+func (p *Player) position() time.Duration {
+	if !p.isPlaying {
+		return p.anchorDur
+	}
+	return p.anchorDur + time.Duration(float64(time.Since(p.anchorTime))*p.tempoFactor)
+}
+
+// rewind goes back to the start. p.mutex must be locked.
+func (p *Player) rewind() {
+	p.currentMsg = 0
+	p.anchorDur = 0
+	p.cursor = 0
+	p.catchUp = 0
+}
+
+// notify signals the playing goroutine that the position, the loop or the tempo factor has changed
+func (p *Player) notify() {
+	select {
+	case p.update <- struct{}{}:
+	default:
+	}
+}
+
+// seek moves the position. While playing, the sounding notes are silenced and the state at the new position is chased.
+// p.mutex must be locked.
+func (p *Player) seek(pos time.Duration) {
+	if pos < 0 {
+		pos = 0
+	}
+	if pos > p.totalDur {
+		pos = p.totalDur
+	}
+
+	p.currentMsg = sort.Search(len(p.messages), func(i int) bool {
+		return p.messages[i].at >= pos
+	})
+	p.anchorDur = pos
+	p.cursor = pos
+
+	clocks := p.totalClocks()
+	if p.currentMsg < len(p.messages) {
+		clocks = p.messages[p.currentMsg].clocks
+	}
+	p.catchUp = clocks % 6
+
+	if !p.isPlaying {
+		p.sendClock(midi.SPP(uint16(clocks / 6)))
+		return
+	}
+
+	p.anchorTime = time.Now()
+	p.silence()
+	p.sendClock(midi.Stop())
+	p.sendClock(midi.SPP(uint16(clocks / 6)))
+	p.chase()
+	p.sendClock(midi.Continue())
+	p.sendCatchUp()
+	p.notify()
+}
+
+// totalClocks returns the number of timing clocks of the song. p.mutex must be locked.
+func (p *Player) totalClocks() int {
+	if len(p.messages) == 0 {
+		return 0
+	}
+	last := p.messages[len(p.messages)-1]
+	if last.isClock {
+		return last.clocks + 1
+	}
+	return last.clocks
+}
+
+// sendClock sends the given clock message, if the player sends the clock. p.mutex must be locked.
+func (p *Player) sendClock(msg midi.Message) {
+	if p.clock {
+		_ = p.outPort.Send(msg)
+	}
+}
+
+// sendCatchUp sends the timing clocks between the song position pointer and the position after a seek.
+// p.mutex must be locked.
+func (p *Player) sendCatchUp() {
+	for ; p.catchUp > 0; p.catchUp-- {
+		p.sendClock(midi.TimingClock())
+	}
+}
+
+// play sends the given message. p.mutex must be locked.
+func (p *Player) play(m message) {
+	if m.isClock {
+		p.sendClock(midi.TimingClock())
+		return
+	}
+	p.send(m.msg)
+}
+
+// send sends the message and keeps track of the sounding notes and the sustain pedal. p.mutex must be locked.
+func (p *Player) send(msg smf.Message) {
+	var ch, key, val uint8
+	switch {
+	case msg.GetNoteStart(&ch, &key, nil):
+		p.notes[ch][key] = true
+	case msg.GetNoteEnd(&ch, &key):
+		p.notes[ch][key] = false
+	case msg.GetControlChange(&ch, &key, &val) && key == 64:
+		p.sustain[ch] = val >= 64
+	}
+	_ = p.outPort.Send(msg)
+}
+
+// silence sends note offs for the sounding notes and releases the sustain pedals. p.mutex must be locked.
+func (p *Player) silence() {
+	for ch := range p.notes {
+		for key, on := range p.notes[ch] {
+			if on {
+				p.send(smf.Message{0x80 | byte(ch), byte(key), 0})
+			}
+		}
+		if p.sustain[ch] {
+			p.send(smf.Message{0xB0 | byte(ch), 64, 0})
+		}
+	}
+}
+
+// chase sends the last control change (except all sound off and all notes off), program change and pitch bend of
+// each channel before the current message, in the order of their occurrence. p.mutex must be locked.
+func (p *Player) chase() {
+	type state struct {
+		status, controller uint8
+	}
+
+	last := map[state]int{}
+	for i, m := range p.messages[:p.currentMsg] {
+		var ch, cc uint8
+		switch {
+		case m.msg.GetControlChange(&ch, &cc, nil):
+			if cc == 120 || cc == 123 {
+				continue
+			}
+			last[state{0xB0 | ch, cc}] = i
+		case m.msg.GetProgramChange(&ch, nil):
+			last[state{0xC0 | ch, 0}] = i
+		case m.msg.GetPitchBend(&ch, nil, nil):
+			last[state{0xE0 | ch, 0}] = i
+		}
+	}
+
+	indices := make([]int, 0, len(last))
+	for _, i := range last {
+		indices = append(indices, i)
+	}
+	sort.Ints(indices)
+
+	for _, i := range indices {
+		p.send(p.messages[i].msg)
+	}
 }
 
 type smfReader struct {
 	trackEvents []smf.TrackEvent
+	smf         *smf.SMF
 }
 
 // read reads SMF data and parses all the track events.
 func (e *smfReader) read(smfdata io.Reader, tracks ...int) (err error) {
 	e.trackEvents = make([]smf.TrackEvent, 0, 100)
-	return WrapOnError(
-		smf.ReadTracksFrom(smfdata, tracks...).Do(e.readEvent).Error(),
-		ErrInvalidSMF,
-	)
+	rd := smf.ReadTracksFrom(smfdata, tracks...).Do(e.readEvent)
+	e.smf = rd.SMF()
+	return WrapOnError(rd.Error(), ErrInvalidSMF)
 }
 
 // readEvent reads a single event from the track.
@@ -156,22 +468,60 @@ func (e *smfReader) readEvent(event smf.TrackEvent) {
 	}
 }
 
-// getMessages parses the track events and returns the playable units.
-func (e *smfReader) getMessages() (messages []message, totalDur time.Duration) {
+// getMessages parses the track events and returns the playable units. If clock is true, the timing clocks are included.
+func (e *smfReader) getMessages(clock bool) (messages []message, totalDur time.Duration) {
 	e.sortTrackEvents()
-	messages = make([]message, len(e.trackEvents))
-	totalDur = 0
-	for i := 0; i < len(messages); i++ {
-		var event = e.trackEvents[i]
-		messages[i] = message{
-			msg:   event.Message,
-			sleep: time.Microsecond * time.Duration(event.AbsMicroSeconds-totalDur.Microseconds()),
+	if len(e.trackEvents) > 0 {
+		totalDur = time.Microsecond * time.Duration(e.trackEvents[len(e.trackEvents)-1].AbsMicroSeconds)
+	}
+
+	var clocks []time.Duration
+	if clock {
+		clocks = e.clocks(totalDur)
+	}
+	messages = make([]message, 0, len(e.trackEvents)+len(clocks))
+
+	// the clocks are sent before the messages at the same time
+	var c int
+	for _, event := range e.trackEvents {
+		at := time.Microsecond * time.Duration(event.AbsMicroSeconds)
+		for ; c < len(clocks) && clocks[c] <= at; c++ {
+			messages = append(messages, message{at: clocks[c], isClock: true, clocks: c})
 		}
-		totalDur += messages[i].sleep
+		messages = append(messages, message{msg: event.Message, at: at, clocks: c})
 	}
 	return
 }
 
+// clocks returns the times of the timing clocks (24 per quarter note) until the given duration
+func (e *smfReader) clocks(totalDur time.Duration) (res []time.Duration) {
+	mt, ok := e.smf.TimeFormat.(smf.MetricTicks)
+	if !ok || mt.Resolution() == 0 {
+		return nil
+	}
+
+	timeAt := func(ticks int64) time.Duration {
+		return time.Microsecond * time.Duration(e.smf.TimeAt(ticks))
+	}
+
+	perClock := float64(mt.Resolution()) / 24
+	for k := 0; ; k++ {
+		ticks := float64(k) * perClock
+		tick := int64(ticks)
+		at := timeAt(tick)
+
+		// interpolate, if the clock is between two ticks
+		if frac := ticks - float64(tick); frac > 0 {
+			at += time.Duration(frac * float64(timeAt(tick+1)-at))
+		}
+
+		if at > totalDur {
+			return
+		}
+		res = append(res, at)
+	}
+}
+
 // sortTrackEvents makes sure the song is ordered by time.
 func (e *smfReader) sortTrackEvents() {
 	sort.SliceStable(
@@ -181,10 +531,8 @@ func (e *smfReader) sortTrackEvents() {
 	)
 }
 
-// playOn will play the current song in the given out port
-func (p *Player) playOn(out drivers.Out) {
-	defer p.cleanupAfterPlaying()
-
+// playOn will play the current song in the given out port, until the given context is cancelled
+func (p *Player) playOn(ctx context.Context, update chan struct{}, out drivers.Out) {
 	// Drivers may invoke CGO
 	// Makes sure thread is locked to avoid weird errors
 	runtime.LockOSThread()
@@ -198,37 +546,91 @@ func (p *Player) playOn(out drivers.Out) {
 	// Makes sure channel is drained
 	<-sleep.C
 
-	// play all messages
-	for i, m := range p.messages[p.currentMsg:] {
-		p.currentDur += m.sleep
-		p.currentMsg = i
-		if m.sleep > 0 {
-			sleep.Reset(m.sleep)
+	for {
+		p.mutex.Lock()
+		if ctx.Err() != nil {
+			p.mutex.Unlock()
+			return
+		}
+
+		// drain pending updates, since the state is read now
+		select {
+		case <-update:
+		default:
+		}
+
+		target, atLoopEnd, done := p.next()
+		if done {
+			p.finish()
+			p.mutex.Unlock()
+			return
+		}
+
+		wait := time.Duration(float64(target-p.position()) / p.tempoFactor)
+		p.mutex.Unlock()
+
+		if wait > 0 {
+			sleep.Reset(wait)
 			select {
 			case <-sleep.C:
-				break
-			case <-p.ctx.Done():
+			case <-update:
+				if !sleep.Stop() {
+					select {
+					case <-sleep.C:
+					default:
+					}
+				}
+				continue
+			case <-ctx.Done():
 				return
 			}
 		}
-		_ = out.Send(m.msg)
+
+		p.mutex.Lock()
+		select {
+		case <-update:
+			// the state has changed while waiting
+			p.mutex.Unlock()
+			continue
+		default:
+		}
+
+		if ctx.Err() == nil {
+			if atLoopEnd {
+				p.seek(p.loopFrom)
+			} else {
+				m := p.messages[p.currentMsg]
+				p.play(m)
+				p.cursor = m.at
+				p.currentMsg++
+			}
+		}
+		p.mutex.Unlock()
 	}
 }
 
-// cleanupAfterPlaying does the cleaning up after playOn ends
-func (p *Player) cleanupAfterPlaying() {
-	_ = p.stop(errDone)
-	var err = context.Cause(p.ctx)
+// next returns the time of the next message or of the end of the loop region and wether the playing is done.
+// The loop region is only looped, if the last played message (or the last seek) is before its end. p.mutex must be locked.
+func (p *Player) next() (target time.Duration, atLoopEnd, done bool) {
+	hasMsg := p.currentMsg < len(p.messages)
+	if hasMsg {
+		target = p.messages[p.currentMsg].at
+	}
 
-	switch {
-	case errors.Is(err, errDone):
-		fallthrough
-	case errors.Is(err, errStopped):
-		p.currentMsg = 0
-		p.currentDur = 0
+	if p.loopTo > p.loopFrom && p.cursor < p.loopTo && (!hasMsg || p.loopTo <= target) {
+		return p.loopTo, true, false
 	}
 
+	return target, false, !hasMsg
+}
+
+// finish ends the playing at the end of the song and goes back to the start. p.mutex must be locked.
+func (p *Player) finish() {
+	p.cancelFn(errDone)
 	p.isPlaying = false
+	p.silence()
+	p.sendClock(midi.Stop())
+	p.rewind()
 }
 
 func Play(out drivers.Out, smfdata io.Reader, tracks ...int) (*Player, error) {
@@ -0,0 +1,328 @@
+package player
+
+import (
+	"bytes"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/smf"
+)
+
+// recorder is an out port that records the sent messages
+type recorder struct {
+	mx   sync.Mutex
+	msgs []string
+}
+
+func (r *recorder) Open() error             { return nil }
+func (r *recorder) Close() error            { return nil }
+func (r *recorder) IsOpen() bool            { return true }
+func (r *recorder) Number() int             { return 0 }
+func (r *recorder) String() string          { return "recorder" }
+func (r *recorder) Underlying() interface{} { return nil }
+func (r *recorder) Send(data []byte) error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	r.msgs = append(r.msgs, midi.Message(data).String())
+	return nil
+}
+
+// take returns the recorded messages and clears them
+func (r *recorder) take() []string {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	msgs := r.msgs
+	r.msgs = nil
+	return msgs
+}
+
+// waitFor waits until n messages are recorded
+func (r *recorder) waitFor(t *testing.T, n int) {
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		r.mx.Lock()
+		l := len(r.msgs)
+		r.mx.Unlock()
+		if l >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timeout while waiting for %v messages", n)
+}
+
+// testSMF returns a song with 960 ticks per quarter note at 120 BPM, so that 960 ticks are 500ms.
+func testSMF(t *testing.T) *bytes.Reader {
+	var tr smf.Track
+	tr.Add(0, smf.MetaTempo(120))
+	tr.Add(0, midi.ControlChange(0, 7, 100))
+	tr.Add(0, midi.ProgramChange(0, 5))
+	tr.Add(0, midi.NoteOn(0, 60, 100))
+	tr.Add(480, midi.Pitchbend(0, 100))
+	tr.Add(0, midi.ControlChange(0, 64, 127))
+	tr.Add(480, midi.NoteOff(0, 60))
+	tr.Add(0, midi.ControlChange(0, 7, 80))
+	tr.Add(0, midi.NoteOn(0, 62, 100))
+	tr.Add(960, midi.NoteOff(0, 62))
+	tr.Close(0)
+
+	s := smf.New()
+	s.TimeFormat = smf.MetricTicks(960)
+	s.Add(tr)
+
+	var bf bytes.Buffer
+	if _, err := s.WriteTo(&bf); err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(bf.Bytes())
+}
+
+func TestSeekAndSilence(t *testing.T) {
+	var out recorder
+	p := New(&out)
+
+	if err := p.SetSMF(testSMF(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := p.Duration(), 1000*time.Millisecond; got != want {
+		t.Errorf("Duration() = %v; want %v", got, want)
+	}
+
+	if err := p.SeekTicks(960); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := p.Current(), 500*time.Millisecond; got != want {
+		t.Errorf("Current() = %v; want %v", got, want)
+	}
+
+	if err := p.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the chased state and the messages at the seek position
+	out.waitFor(t, 7)
+
+	if err := p.Pause(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := out.take()
+	expected := []string{
+		// chased
+		"ControlChange channel: 0 controller: 7 value: 100",
+		"ProgramChange channel: 0 program: 5",
+		"PitchBend channel: 0 pitch: 100 (8292)",
+		"ControlChange channel: 0 controller: 64 value: 127",
+		// played, since they are at the seek position
+		"NoteOff channel: 0 key: 60",
+		"ControlChange channel: 0 controller: 7 value: 80",
+		"NoteOn channel: 0 key: 62 velocity: 100",
+		// silenced on pause
+		"NoteOff channel: 0 key: 62",
+		"ControlChange channel: 0 controller: 64 value: 0",
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got\n%q\nexpected\n%q", got, expected)
+	}
+
+	if p.IsPlaying() {
+		t.Errorf("IsPlaying() = true after Pause()")
+	}
+}
+
+func TestLoopAndTempoFactor(t *testing.T) {
+	var out recorder
+	p := New(&out)
+
+	if err := p.SetSMF(testSMF(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.SetLoop(500*time.Millisecond, 400*time.Millisecond); err != ErrInvalidLoop {
+		t.Errorf("SetLoop() with to < from returned %v; expected %v", err, ErrInvalidLoop)
+	}
+
+	if err := p.SetTempoFactor(0); err != ErrInvalidTempoFactor {
+		t.Errorf("SetTempoFactor(0) returned %v; expected %v", err, ErrInvalidTempoFactor)
+	}
+
+	// the loop region contains the first note, which takes 500ms, i.e. 50ms with a tempo factor of 10
+	if err := p.SetLoop(0, p.TickTime(960)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.SetTempoFactor(10); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 5 messages per loop, the note off at the end of the loop region is not played but sent by the silencing
+	// (together with the release of the sustain pedal)
+	out.waitFor(t, 5+2+5)
+
+	if err := p.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := out.take()
+	loop := []string{
+		"ControlChange channel: 0 controller: 7 value: 100",
+		"ProgramChange channel: 0 program: 5",
+		"NoteOn channel: 0 key: 60 velocity: 100",
+		"PitchBend channel: 0 pitch: 100 (8292)",
+		"ControlChange channel: 0 controller: 64 value: 127",
+		"NoteOff channel: 0 key: 60",
+		"ControlChange channel: 0 controller: 64 value: 0",
+	}
+
+	for i, msg := range got[:12] {
+		if msg != loop[i%len(loop)] {
+			t.Fatalf("message %v is %q; expected %q (got %q)", i, msg, loop[i%len(loop)], got)
+		}
+	}
+
+	// silenced on stop
+	if p.notes != [16][128]bool{} || p.sustain != [16]bool{} {
+		t.Errorf("notes are sounding after Stop()")
+	}
+
+	if p.Current() != 0 {
+		t.Errorf("Current() = %v after Stop(); expected 0", p.Current())
+	}
+}
+
+func TestSMPTE(t *testing.T) {
+	var tr smf.Track
+	tr.Add(0, smf.MetaTempo(60))
+	tr.Add(0, midi.NoteOn(0, 60, 100))
+	tr.Add(1000, midi.NoteOff(0, 60))
+	tr.Close(0)
+
+	// 25 frames with 40 subframes: 1000 ticks per second
+	s := smf.New()
+	s.TimeFormat = smf.SMPTE25(40)
+	s.Add(tr)
+
+	var bf bytes.Buffer
+	if _, err := s.WriteTo(&bf); err != nil {
+		t.Fatal(err)
+	}
+
+	var out recorder
+	p := New(&out, SendClock())
+
+	if err := p.SetSMF(bytes.NewReader(bf.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := p.Duration(); got != time.Second {
+		t.Errorf("Duration() = %v; expected 1s", got)
+	}
+
+	if got := p.TickTime(500); got != 500*time.Millisecond {
+		t.Errorf("TickTime(500) = %v; expected 500ms", got)
+	}
+
+	if err := p.SeekTicks(250); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := p.Current(); got != 250*time.Millisecond {
+		t.Errorf("Current() = %v after SeekTicks(250); expected 250ms", got)
+	}
+}
+
+func TestClock(t *testing.T) {
+	var out recorder
+	p := New(&out, SendClock())
+
+	if err := p.SetSMF(testSMF(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	// slow down, so that the next timing clock is far away
+	if err := p.SetTempoFactor(0.01); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	out.waitFor(t, 5)
+
+	if err := p.Pause(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 960 ticks per quarter note: a timing clock every 40 ticks, 27 clocks before tick 1080
+	if err := p.SeekTicks(1080); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	out.waitFor(t, 5+2+1+8+1)
+
+	if err := p.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := out.take()
+	expected := []string{
+		"Start",
+		"TimingClock",
+		"ControlChange channel: 0 controller: 7 value: 100",
+		"ProgramChange channel: 0 program: 5",
+		"NoteOn channel: 0 key: 60 velocity: 100",
+		// pause
+		"NoteOff channel: 0 key: 60",
+		"Stop",
+		// seek
+		"SPP spp: 4",
+		// start
+		"ProgramChange channel: 0 program: 5",
+		"PitchBend channel: 0 pitch: 100 (8292)",
+		"ControlChange channel: 0 controller: 64 value: 127",
+		"ControlChange channel: 0 controller: 7 value: 80",
+		"Continue",
+		"TimingClock",
+		"TimingClock",
+		"TimingClock",
+		// the clock at the position
+		"TimingClock",
+		// stop
+		"ControlChange channel: 0 controller: 64 value: 0",
+		"Stop",
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got\n%q\nexpected\n%q", got, expected)
+	}
+}
+
+func TestNoClock(t *testing.T) {
+	var out recorder
+	p := New(&out)
+
+	if err := p.SetSMF(testSMF(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, m := range p.messages {
+		if m.isClock {
+			t.Fatalf("message %v is a timing clock, although the player does not send the clock", i)
+		}
+	}
+}
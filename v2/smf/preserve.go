@@ -0,0 +1,291 @@
+package smf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"gitlab.com/gomidi/midi/v2/internal/runningstatus"
+)
+
+// Preserve is a ReadOption that keeps the unknown chunks and the original encoding of the events
+// (running status, padding of variable length quantities), so that writing the SMF reproduces
+// the original bytes of all events that have not been changed, added or removed.
+// Changed and added events are encoded as usual, the unknown chunks are written at their original position
+// relative to the track chunks.
+// The whole data is read into memory before parsing. Preserve can be combined with Recover.
+func Preserve() ReadOption {
+	return func(c *readConfig) {
+		c.Preserve = true
+	}
+}
+
+// RawChunk is a chunk that is not a header or track chunk, e.g. a vendor specific chunk.
+type RawChunk struct {
+	// Type is the four character type of the chunk
+	Type string
+
+	// Data is the body of the chunk
+	Data []byte
+}
+
+// UnknownChunks returns the chunks that are neither header nor track chunks.
+// They are only kept, if the SMF was read with the Preserve option.
+func (s *SMF) UnknownChunks() (chunks []RawChunk) {
+	if s.original == nil {
+		return nil
+	}
+
+	for _, ch := range s.original.chunks {
+		chunks = append(chunks, ch.RawChunk)
+	}
+	return
+}
+
+// original keeps the original encoding of a SMF that has been read with the Preserve option
+type original struct {
+	// headerExtra are the bytes of the header chunk beyond the standard 6 bytes
+	headerExtra []byte
+	chunks      []positionedChunk
+	tracks      []originalTrack
+}
+
+// positionedChunk is an unknown chunk that follows the given number of track chunks
+type positionedChunk struct {
+	RawChunk
+	afterTracks int
+}
+
+// originalTrack has the originally read events together with their encoded bytes
+type originalTrack struct {
+	events []Event
+	raw    [][]byte
+
+	// running is true for the channel messages that were encoded with running status
+	running []bool
+
+	index map[string][]int
+}
+
+func readPreserving(f io.Reader, c readConfig) (*SMF, error) {
+	data, err := io.ReadAll(f)
+	if err != nil && (!c.Recover || len(data) == 0) {
+		return nil, err
+	}
+
+	c.Preserve = false
+	s, err := ReadFrom(bytes.NewReader(data), func(cf *readConfig) { *cf = c })
+	if err != nil {
+		return nil, err
+	}
+
+	orig, err := scanOriginal(data)
+	if err == nil {
+		err = orig.matches(s.Tracks)
+	}
+
+	if err != nil {
+		if c.Logger != nil {
+			c.Logger.Printf("could not preserve original encoding: %v\n", err)
+		}
+		return s, nil
+	}
+
+	s.original = orig
+	return s, nil
+}
+
+// scanOriginal scans the chunks and events of the data and keeps their bytes
+func scanOriginal(data []byte) (*original, error) {
+	start := bytes.Index(data, []byte("MThd"))
+	if start < 0 || len(data) < start+8 {
+		return nil, errExpectedMthd
+	}
+
+	o := &original{}
+	pos := start
+	var tracks int
+
+	for pos+8 <= len(data) {
+		typ := string(data[pos : pos+4])
+		length := int(binary.BigEndian.Uint32(data[pos+4 : pos+8]))
+		end := pos + 8 + length
+		if end > len(data) {
+			return nil, fmt.Errorf("chunk %q at offset %v is truncated", typ, pos)
+		}
+		body := data[pos+8 : end]
+
+		switch {
+		case pos == start:
+			if length > 6 {
+				o.headerExtra = append([]byte(nil), body[6:]...)
+			}
+		case typ == "MTrk":
+			tr, err := scanOriginalTrack(body)
+			if err != nil {
+				return nil, fmt.Errorf("track %v: %v", tracks, err)
+			}
+			o.tracks = append(o.tracks, tr)
+			tracks++
+		default:
+			o.chunks = append(o.chunks, positionedChunk{
+				RawChunk:    RawChunk{Type: typ, Data: append([]byte(nil), body...)},
+				afterTracks: tracks,
+			})
+		}
+
+		pos = end
+	}
+
+	return o, nil
+}
+
+// scanOriginalTrack scans the events of a track chunk up to the end of track message
+func scanOriginalTrack(data []byte) (tr originalTrack, err error) {
+	var status byte
+	pos := 0
+
+	for pos < len(data) {
+		evStart := pos
+
+		delta, n := readVLQ(data[pos:])
+		if n == 0 {
+			return tr, fmt.Errorf("invalid delta time at offset %v", pos)
+		}
+		pos += n
+
+		if pos >= len(data) {
+			return tr, fmt.Errorf("truncated event at offset %v", evStart)
+		}
+
+		canary := data[pos]
+		var msg Message
+		var running bool
+
+		switch {
+		case canary < 0x80:
+			if status == 0 {
+				return tr, fmt.Errorf("data byte without status at offset %v", pos)
+			}
+			running = true
+			msg, n = readChannelData(status, data[pos:])
+		case canary < 0xF0:
+			status = canary
+			pos++
+			msg, n = readChannelData(status, data[pos:])
+		case canary == 0xF0 || canary == 0xF7 || canary == 0xFF:
+			status = 0
+			pos++
+			var typ []byte
+			if canary == 0xFF && pos < len(data) {
+				typ = data[pos : pos+1]
+				pos++
+			}
+			ln, lnLen := readVLQ(data[pos:])
+			if lnLen == 0 || pos+lnLen+int(ln) > len(data) {
+				return tr, fmt.Errorf("truncated message at offset %v", evStart)
+			}
+			pos += lnLen
+			body := data[pos : pos+int(ln)]
+			n = int(ln)
+
+			if canary == 0xFF {
+				msg = _MetaMessage(typ[0], body)
+			} else {
+				msg = Message(append([]byte{canary}, body...))
+			}
+		default:
+			return tr, fmt.Errorf("invalid status byte %02X at offset %v", canary, pos)
+		}
+
+		if msg == nil {
+			return tr, fmt.Errorf("incomplete channel message at offset %v", evStart)
+		}
+		pos += n
+
+		tr.events = append(tr.events, Event{Delta: delta, Message: msg})
+		tr.raw = append(tr.raw, data[evStart:pos])
+		tr.running = append(tr.running, running)
+
+		if msg.Is(MetaEndOfTrackMsg) {
+			break
+		}
+	}
+
+	return tr, nil
+}
+
+// matches returns an error, if the scanned tracks do not correspond to the given tracks
+func (o *original) matches(tracks []Track) error {
+	if len(o.tracks) != len(tracks) {
+		return fmt.Errorf("found %v track chunks, but %v tracks", len(o.tracks), len(tracks))
+	}
+
+	for i, tr := range tracks {
+		if len(o.tracks[i].events) != len(tr) {
+			return fmt.Errorf("found %v events in track chunk %v, but %v in track", len(o.tracks[i].events), i, len(tr))
+		}
+	}
+
+	return nil
+}
+
+func eventKey(ev Event) string {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], ev.Delta)
+	return string(b[:]) + string(ev.Message)
+}
+
+// find returns the index of the first original event at or after from that equals the given event (-1 if there is none)
+func (o *originalTrack) find(ev Event, from int) int {
+	if from < len(o.events) && o.events[from].Delta == ev.Delta && bytes.Equal(o.events[from].Message, ev.Message) {
+		return from
+	}
+
+	if o.index == nil {
+		o.index = map[string][]int{}
+		for i, oev := range o.events {
+			key := eventKey(oev)
+			o.index[key] = append(o.index[key], i)
+		}
+	}
+
+	for _, i := range o.index[eventKey(ev)] {
+		if i >= from {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// encode returns the body of the track chunk for the given track, using the original bytes for the
+// untouched events
+func (o *originalTrack) encode(t Track, runningWriter runningstatus.SMFWriter) []byte {
+	var bf bytes.Buffer
+	var status byte
+	var next int
+
+	for _, ev := range t {
+		i := o.find(ev, next)
+
+		// the original encoding with running status can only be used, if the status is the same
+		if i >= 0 && (!o.running[i] || status == ev.Message[0]) {
+			bf.Write(o.raw[i])
+			if runningWriter != nil {
+				runningWriter.Write(ev.Message)
+			}
+			next = i + 1
+		} else {
+			bf.Write(encodeEvent(runningWriter, ev.Delta, ev.Message))
+		}
+
+		status = 0
+		if ev.Message[0] >= 0x80 && ev.Message[0] < 0xF0 {
+			status = ev.Message[0]
+		}
+	}
+
+	return bf.Bytes()
+}
@@ -0,0 +1,148 @@
+package smf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"gitlab.com/gomidi/midi/v2"
+)
+
+func preserveTestData() []byte {
+	return bytes.Join([][]byte{
+		lenientHeader(2),
+		{'X', 'F', 'I', 'H', 0x00, 0x00, 0x00, 0x02, 0x01, 0x02}, // vendor chunk
+		lenientTrack(0x0C,
+			0x00, 0xFF, 0x51, 0x80, 0x03, 0x07, 0xA1, 0x20, // tempo with padded length
+			0x00, 0xFF, 0x2F, 0x00,
+		),
+		lenientTrack(0x18,
+			0x80, 0x00, 0x90, 0x3C, 0x40, // padded delta
+			0x00, 0x3E, 0x40, // running status
+			0x60, 0x90, 0x3C, 0x00, // explicit status, although running status would be possible
+			0x00, 0x3E, 0x00, // running status
+			0x00, 0xF0, 0x02, 0x7E, 0xF7, // sysex
+			0x00, 0xFF, 0x2F, 0x00,
+		),
+		{'J', 'U', 'N', 'K', 0x00, 0x00, 0x00, 0x01, 0x05}, // trailing vendor chunk
+	}, nil)
+}
+
+func TestPreserveRoundTrip(t *testing.T) {
+	data := preserveTestData()
+
+	s, err := ReadFrom(bytes.NewReader(data), Preserve())
+	if err != nil {
+		t.Fatalf("ReadFrom() returned error: %v", err)
+	}
+
+	if got, want := fmt.Sprint(s.UnknownChunks()), "[{XFIH [1 2]} {JUNK [5]}]"; got != want {
+		t.Errorf("UnknownChunks() = %v; want %v", got, want)
+	}
+
+	var bf bytes.Buffer
+	s.WriteTo(&bf)
+
+	if got, want := fmt.Sprintf("% X", bf.Bytes()), fmt.Sprintf("% X", data); got != want {
+		t.Errorf("WriteTo() =\n%s\nwant\n%s", got, want)
+	}
+
+	// without Preserve the encoding is normalized
+	s, err = ReadFrom(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadFrom() returned error: %v", err)
+	}
+
+	bf.Reset()
+	s.WriteTo(&bf)
+
+	if bytes.Equal(bf.Bytes(), data) {
+		t.Errorf("WriteTo() without Preserve should normalize the encoding")
+	}
+}
+
+func TestPreserveChanged(t *testing.T) {
+	tests := []struct {
+		descr    string
+		change   func(tr *Track)
+		expected string
+	}{
+		{
+			"changed velocity",
+			func(tr *Track) {
+				(*tr)[1].Message = Message(midi.NoteOn(0, 62, 50))
+			},
+			"80 00 90 3C 40 00 3E 32 60 90 3C 00 00 3E 00 00 F0 02 7E F7 00 FF 2F 00",
+		},
+		{
+			"changed status breaks running status",
+			func(tr *Track) {
+				(*tr)[0].Message = Message(midi.NoteOn(1, 60, 64))
+			},
+			"00 91 3C 40 00 90 3E 40 60 90 3C 00 00 3E 00 00 F0 02 7E F7 00 FF 2F 00",
+		},
+		{
+			"removed event",
+			func(tr *Track) {
+				*tr = append((*tr)[:1], (*tr)[2:]...)
+			},
+			"80 00 90 3C 40 60 90 3C 00 00 3E 00 00 F0 02 7E F7 00 FF 2F 00",
+		},
+		{
+			"inserted event",
+			func(tr *Track) {
+				*tr = append((*tr)[:2], append(Track{{Delta: 0, Message: Message(midi.ControlChange(0, 7, 100))}}, (*tr)[2:]...)...)
+			},
+			"80 00 90 3C 40 00 3E 40 00 B0 07 64 60 90 3C 00 00 3E 00 00 F0 02 7E F7 00 FF 2F 00",
+		},
+	}
+
+	for _, test := range tests {
+		s, err := ReadFrom(bytes.NewReader(preserveTestData()), Preserve())
+		if err != nil {
+			t.Fatalf("ReadFrom() returned error: %v", err)
+		}
+
+		test.change(&s.Tracks[1])
+
+		var bf bytes.Buffer
+		s.WriteTo(&bf)
+
+		s2, err := ReadFrom(bytes.NewReader(bf.Bytes()), Preserve())
+		if err != nil {
+			t.Fatalf("[%s] ReadFrom() of written data returned error: %v", test.descr, err)
+		}
+
+		if got, want := fmt.Sprintf("% X", s2.original.tracks[1].raw), "["+test.expected+"]"; got != want {
+			t.Errorf("[%s] written track =\n%s\nwant\n%s", test.descr, got, want)
+		}
+	}
+}
+
+// limitWriter fails, as soon as more than n bytes are written
+type limitWriter struct {
+	bytes.Buffer
+	n int
+}
+
+func (w *limitWriter) Write(p []byte) (int, error) {
+	if w.Len()+len(p) > w.n {
+		return 0, errors.New("write limit reached")
+	}
+	return w.Buffer.Write(p)
+}
+
+func TestPreserveWriteError(t *testing.T) {
+	data := preserveTestData()
+
+	s, err := ReadFrom(bytes.NewReader(data), Preserve())
+	if err != nil {
+		t.Fatalf("ReadFrom() returned error: %v", err)
+	}
+
+	// the trailing vendor chunk can't be written
+	if _, err := s.WriteTo(&limitWriter{n: len(data) - 1}); err == nil {
+		t.Errorf("WriteTo() returned no error")
+	}
+}
@@ -0,0 +1,215 @@
+package smf
+
+import (
+	"math"
+	"sort"
+)
+
+// QuantizeOption is an option for quantization.
+type QuantizeOption func(*quantizer)
+
+// Strength sets the strength of the quantization in percent (0-100). Each note is moved by the given
+// percentage of its distance to the grid. The default is 100.
+func Strength(percent uint8) QuantizeOption {
+	return func(q *quantizer) {
+		if percent > 100 {
+			percent = 100
+		}
+		q.strength = percent
+	}
+}
+
+// Swing delays every second grid position by the given percentage (0-99) of the grid.
+// E.g. a swing of 33 on a 8th grid roughly results in a triplet feel.
+func Swing(percent uint8) QuantizeOption {
+	return func(q *quantizer) {
+		if percent > 99 {
+			percent = 99
+		}
+		q.swing = percent
+	}
+}
+
+// Triplets makes the grid a triplet grid, i.e. the grid distance becomes 2/3 of the given grid.
+func Triplets() QuantizeOption {
+	return func(q *quantizer) {
+		q.triplets = true
+	}
+}
+
+// Window only quantizes notes, whose distance to the nearest grid position is within the given
+// percentage of the grid. Notes outside the window are left alone. The default is 100 (all notes).
+func Window(percent uint8) QuantizeOption {
+	return func(q *quantizer) {
+		if percent > 100 {
+			percent = 100
+		}
+		q.window = percent
+	}
+}
+
+// QuantizeEnds also quantizes the note ends. By default only the note starts are quantized and
+// the durations of the notes are kept.
+func QuantizeEnds() QuantizeOption {
+	return func(q *quantizer) {
+		q.ends = true
+	}
+}
+
+type quantizer struct {
+	grid     int64
+	strength uint8
+	swing    uint8
+	triplets bool
+	window   uint8
+	ends     bool
+}
+
+// Quantize returns a copy of the track where the note starts (and optionally the note ends) are snapped
+// to a grid of the given distance in ticks, e.g. MetricTicks.Ticks16th(). Messages that are not notes are
+// left at their positions. Overlapping notes on the same channel and key are paired first in, first out.
+func (t Track) Quantize(grid uint32, opts ...QuantizeOption) Track {
+	q := &quantizer{
+		grid:     int64(grid),
+		strength: 100,
+		window:   100,
+	}
+
+	for _, opt := range opts {
+		opt(q)
+	}
+
+	if q.triplets {
+		q.grid = int64(math.Round(float64(q.grid) * 2 / 3))
+	}
+
+	if q.grid <= 0 {
+		return append(Track{}, t...)
+	}
+
+	return q.quantize(t)
+}
+
+// Quantize quantizes all tracks of the SMF. See Track.Quantize.
+func (s *SMF) Quantize(grid uint32, opts ...QuantizeOption) {
+	for i := range s.Tracks {
+		s.Tracks[i] = s.Tracks[i].Quantize(grid, opts...)
+	}
+}
+
+func (q *quantizer) quantize(t Track) Track {
+	abs := t.Abs()
+	positions := make([]int64, len(abs))
+	isEnd := make([]bool, len(abs))
+	startOf := make([]int, len(abs))
+
+	var open [16][128][]int
+	var channel, key uint8
+
+	for i, ev := range abs {
+		positions[i] = ev.AbsTicks
+		startOf[i] = -1
+
+		switch {
+		case ev.Message.GetNoteStart(&channel, &key, nil):
+			positions[i] = q.position(ev.AbsTicks)
+			open[channel][key] = append(open[channel][key], i)
+		case ev.Message.GetNoteEnd(&channel, &key):
+			isEnd[i] = true
+			running := open[channel][key]
+			if len(running) == 0 {
+				continue
+			}
+			start := running[0]
+			open[channel][key] = running[1:]
+			startOf[i] = start
+
+			if q.ends {
+				positions[i] = q.position(ev.AbsTicks)
+				if positions[i] <= positions[start] {
+					positions[i] = positions[start] + q.grid
+				}
+			} else {
+				positions[i] = ev.AbsTicks + positions[start] - abs[start].AbsTicks
+			}
+		}
+	}
+
+	order := make([]int, len(abs))
+	for i := range order {
+		order[i] = i
+	}
+
+	// at the same position, the note ends come first, followed by the other events in their original order.
+	// The end of a note without length directly follows its own start.
+	sortKey := func(i int) (group, idx int) {
+		if !isEnd[i] {
+			return 1, i
+		}
+		if start := startOf[i]; start >= 0 && positions[start] == positions[i] {
+			return 1, start
+		}
+		return 0, i
+	}
+
+	sort.SliceStable(order, func(a, b int) bool {
+		ia, ib := order[a], order[b]
+		if positions[ia] != positions[ib] {
+			return positions[ia] < positions[ib]
+		}
+		ga, ka := sortKey(ia)
+		gb, kb := sortKey(ib)
+		if ga != gb {
+			return ga < gb
+		}
+		if ka != kb {
+			return ka < kb
+		}
+		return !isEnd[ia] && isEnd[ib]
+	})
+
+	var res AbsTrack
+	var end int64 = -1
+	for _, i := range order {
+		if isEOT(abs[i].Message) {
+			end = positions[i]
+			continue
+		}
+		res = append(res, AbsEvent{AbsTicks: positions[i], Message: abs[i].Message})
+	}
+
+	if end >= 0 {
+		res.Close(end)
+	}
+
+	return res.Track()
+}
+
+// position returns the quantized position for the given absolute ticks.
+func (q *quantizer) position(absTicks int64) int64 {
+	pair := q.grid * 2
+	base := (absTicks / pair) * pair
+	offbeat := base + q.grid + q.grid*int64(q.swing)/100
+
+	target := base
+	for _, candidate := range []int64{offbeat, base + pair} {
+		if abs64(candidate-absTicks) < abs64(target-absTicks) {
+			target = candidate
+		}
+	}
+
+	dist := target - absTicks
+
+	if abs64(dist)*100 > q.grid*int64(q.window) {
+		return absTicks
+	}
+
+	return absTicks + int64(math.Round(float64(dist)*float64(q.strength)/100))
+}
+
+func abs64(i int64) int64 {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
@@ -0,0 +1,100 @@
+package smf
+
+import (
+	"reflect"
+	"testing"
+
+	"gitlab.com/gomidi/midi/v2"
+)
+
+func TestQuantize(t *testing.T) {
+	ticks := MetricTicks(96)
+	grid := ticks.Ticks16th() // 24
+
+	var tr Track
+	tr.Add(3, midi.NoteOn(0, 60, 100))  // 3
+	tr.Add(20, midi.NoteOff(0, 60))     // 23
+	tr.Add(10, midi.NoteOn(0, 62, 100)) // 33
+	tr.Add(10, midi.NoteOff(0, 62))     // 43
+	tr.Close(0)
+
+	tests := []struct {
+		descr    string
+		opts     []QuantizeOption
+		expected []int64
+	}{
+		{"default", nil, []int64{0, 20, 24, 34, 43}},
+		{"strength 50", []QuantizeOption{Strength(50)}, []int64{1, 21, 28, 38, 43}},
+		{"ends", []QuantizeOption{QuantizeEnds()}, []int64{0, 24, 24, 48, 48}},
+		{"window", []QuantizeOption{Window(20)}, []int64{0, 20, 33, 43, 43}},
+		{"swing", []QuantizeOption{Swing(50)}, []int64{0, 20, 36, 46, 46}},
+		{"triplets", []QuantizeOption{Triplets()}, []int64{0, 20, 32, 42, 43}},
+	}
+
+	for _, test := range tests {
+		abs := tr.Quantize(grid, test.opts...).Abs()
+
+		if len(abs) != len(test.expected) {
+			t.Errorf("[%s] got %v events; want %v", test.descr, len(abs), len(test.expected))
+			continue
+		}
+
+		for i, ev := range abs {
+			if got, want := ev.AbsTicks, test.expected[i]; got != want {
+				t.Errorf("[%s] event %v (%s) at %v; want %v", test.descr, i, ev.Message, got, want)
+			}
+		}
+	}
+}
+
+func TestSMFQuantize(t *testing.T) {
+	ticks := MetricTicks(96)
+	grid := ticks.Ticks16th() // 24
+
+	var tr1, tr2 Track
+	tr1.Add(3, midi.NoteOn(0, 60, 100)) // 3
+	tr1.Add(20, midi.NoteOff(0, 60))    // 23
+	tr1.Close(0)
+	tr2.Add(33, midi.NoteOn(1, 62, 100)) // 33
+	tr2.Add(10, midi.NoteOff(1, 62))     // 43
+	tr2.Add(7, midi.NoteOn(1, 64, 100))  // 50, without length
+	tr2.Add(0, midi.NoteOff(1, 64))      // 50
+	tr2.Close(0)
+
+	tests := []struct {
+		descr    string
+		opts     []QuantizeOption
+		expected [][]int64
+	}{
+		{"default", nil, [][]int64{{0, 20, 23}, {24, 34, 48, 48, 50}}},
+		{"swing", []QuantizeOption{Swing(50)}, [][]int64{{0, 20, 23}, {36, 46, 48, 48, 50}}},
+		{"triplets", []QuantizeOption{Triplets()}, [][]int64{{0, 20, 23}, {32, 42, 48, 48, 50}}},
+		{"window", []QuantizeOption{Window(20)}, [][]int64{{0, 20, 23}, {33, 43, 48, 48, 50}}},
+	}
+
+	for _, test := range tests {
+		s := NewSMF1()
+		s.TimeFormat = ticks
+		s.Add(append(Track{}, tr1...))
+		s.Add(append(Track{}, tr2...))
+
+		s.Quantize(grid, test.opts...)
+
+		for no, tr := range s.Tracks {
+			abs := tr.Abs()
+			var got []int64
+			for _, ev := range abs {
+				got = append(got, ev.AbsTicks)
+			}
+
+			if !reflect.DeepEqual(got, test.expected[no]) {
+				t.Errorf("[%s] track %v at %v; want %v", test.descr, no, got, test.expected[no])
+			}
+
+			// a note without length must not be ended before it starts
+			if no == 1 && !(abs[2].Message.Is(midi.NoteOnMsg) && abs[3].Message.Is(midi.NoteOffMsg)) {
+				t.Errorf("[%s] note without length is written as %s, %s", test.descr, abs[2].Message, abs[3].Message)
+			}
+		}
+	}
+}
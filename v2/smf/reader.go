@@ -1,6 +1,7 @@
 package smf
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -40,18 +41,43 @@ func Log(l Logger) ReadOption {
 }
 
 type readConfig struct {
-	Logger Logger
+	Logger   Logger
+	Recover  bool
+	Preserve bool
 }
 
-// ReadFrom reads a SMF from the given io.Reader
+// ReadFrom reads a SMF from the given io.Reader.
+// The SMF data may be wrapped within a RIFF RMID container (.rmi file), see RMID.
 func ReadFrom(f io.Reader, opts ...ReadOption) (*SMF, error) {
+	head := make([]byte, 4)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	head = head[:n]
 
+	if isRIFF(head) {
+		return readRMID(io.MultiReader(bytes.NewReader(head), f), opts)
+	}
+
+	return readFrom(io.MultiReader(bytes.NewReader(head), f), opts...)
+}
+
+func readFrom(f io.Reader, opts ...ReadOption) (*SMF, error) {
 	var c readConfig
 
 	for _, opt := range opts {
 		opt(&c)
 	}
 
+	if c.Preserve {
+		return readPreserving(f, c)
+	}
+
+	if c.Recover {
+		return readLenient(f, c)
+	}
+
 	rd := newReader(f)
 	rd.Logger = c.Logger
 
@@ -226,7 +252,8 @@ func (r *reader) read() (m Message, err error) {
 
 	//fmt.Println("expectChunk", r.expectChunk)
 
-	if r.expectChunk {
+	// skip over unknown chunks until the next track chunk
+	for r.expectChunk && r.error == nil {
 		r.readChunk()
 	}
 
@@ -0,0 +1,219 @@
+package smf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// RMID is the RIFF container of a .rmi file that wraps the SMF data.
+// If the RMID field of a SMF is set, the SMF is written within a RIFF RMID container.
+type RMID struct {
+	// Title is the INAM field of the INFO list
+	Title string
+
+	// Copyright is the ICOP field of the INFO list
+	Copyright string
+
+	// Comments is the ICMT field of the INFO list
+	Comments string
+
+	// Info has the other fields of the INFO list by their four character ID (e.g. "IART" for the artist)
+	Info map[string]string
+
+	// Chunks are the other chunks of the RIFF container, e.g. embedded DLS or SF2 sound banks.
+	// They are kept as opaque blobs.
+	Chunks []RawChunk
+}
+
+// SoundBank returns the complete RIFF data (including the RIFF header) of the embedded DLS or SF2 sound bank,
+// so that it can be written to a .dls or .sf2 file. If there is no sound bank, nil is returned.
+func (r *RMID) SoundBank() []byte {
+	for _, ch := range r.Chunks {
+		if ch.Type != "RIFF" || len(ch.Data) < 4 {
+			continue
+		}
+
+		switch string(ch.Data[:4]) {
+		case "DLS ", "sfbk":
+			var bf bytes.Buffer
+			writeRIFFChunk(&bf, ch.Type, ch.Data)
+			return bf.Bytes()
+		}
+	}
+	return nil
+}
+
+const (
+	riffTitle     = "INAM"
+	riffCopyright = "ICOP"
+	riffComments  = "ICMT"
+)
+
+// isRIFF returns true, if the data starts with a RIFF header
+func isRIFF(head []byte) bool {
+	return len(head) >= 4 && string(head[:4]) == "RIFF"
+}
+
+// readRMID reads the SMF of a RIFF RMID container
+func readRMID(f io.Reader, opts []ReadOption) (*SMF, error) {
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < 12 || string(data[8:12]) != "RMID" {
+		return nil, errNoRMID
+	}
+
+	length := int(binary.LittleEndian.Uint32(data[4:8]))
+	if length+8 < len(data) {
+		data = data[:length+8]
+	}
+
+	var smfData []byte
+	var rmid RMID
+
+	for _, ch := range readRIFFChunks(data[12:]) {
+		switch ch.Type {
+		case "data":
+			if smfData == nil {
+				smfData = ch.Data
+				continue
+			}
+		case "LIST":
+			if len(ch.Data) >= 4 && string(ch.Data[:4]) == "INFO" {
+				rmid.readInfo(ch.Data[4:])
+				continue
+			}
+		}
+		rmid.Chunks = append(rmid.Chunks, ch)
+	}
+
+	if smfData == nil {
+		return nil, fmt.Errorf("RMID without data chunk")
+	}
+
+	s, err := ReadFrom(bytes.NewReader(smfData), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	s.RMID = &rmid
+	return s, nil
+}
+
+// readRIFFChunks reads the chunks of the given data (which is the body of a RIFF or LIST chunk after the form type).
+// A truncated last chunk is returned with the available data.
+func readRIFFChunks(data []byte) (chunks []RawChunk) {
+	pos := 0
+
+	for pos+8 <= len(data) {
+		typ := string(data[pos : pos+4])
+		length := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		start := pos + 8
+		end := start + length
+		if end > len(data) {
+			end = len(data)
+		}
+
+		chunks = append(chunks, RawChunk{Type: typ, Data: append([]byte(nil), data[start:end]...)})
+
+		// chunks are padded to an even length
+		pos = end + length%2
+	}
+
+	return
+}
+
+func (r *RMID) readInfo(data []byte) {
+	for _, ch := range readRIFFChunks(data) {
+		val := strings.TrimRight(string(ch.Data), "\x00")
+
+		switch ch.Type {
+		case riffTitle:
+			r.Title = val
+		case riffCopyright:
+			r.Copyright = val
+		case riffComments:
+			r.Comments = val
+		default:
+			if r.Info == nil {
+				r.Info = map[string]string{}
+			}
+			r.Info[ch.Type] = val
+		}
+	}
+}
+
+// infoBytes returns the body of the INFO list (without the LIST header) or nil if there are no fields
+func (r *RMID) infoBytes() []byte {
+	var bf bytes.Buffer
+
+	writeField := func(id, val string) {
+		if val == "" {
+			return
+		}
+		// the strings are zero terminated
+		writeRIFFChunk(&bf, id, append([]byte(val), 0))
+	}
+
+	writeField(riffTitle, r.Title)
+	writeField(riffCopyright, r.Copyright)
+	writeField(riffComments, r.Comments)
+
+	ids := make([]string, 0, len(r.Info))
+	for id := range r.Info {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		writeField(id, r.Info[id])
+	}
+
+	if bf.Len() == 0 {
+		return nil
+	}
+
+	return append([]byte("INFO"), bf.Bytes()...)
+}
+
+// writeRIFFChunk writes a chunk with the given type and data, padded to an even length
+func writeRIFFChunk(bf *bytes.Buffer, typ string, data []byte) {
+	var typBytes [4]byte
+	copy(typBytes[:], typ)
+	bf.Write(typBytes[:])
+	binary.Write(bf, binary.LittleEndian, uint32(len(data)))
+	bf.Write(data)
+	if len(data)%2 == 1 {
+		bf.WriteByte(0)
+	}
+}
+
+// writeTo writes the given SMF data within a RIFF RMID container
+func (r *RMID) writeTo(wr io.Writer, smfData []byte) (int64, error) {
+	var body bytes.Buffer
+	body.WriteString("RMID")
+	writeRIFFChunk(&body, "data", smfData)
+
+	if info := r.infoBytes(); info != nil {
+		writeRIFFChunk(&body, "LIST", info)
+	}
+
+	for _, ch := range r.Chunks {
+		writeRIFFChunk(&body, ch.Type, ch.Data)
+	}
+
+	var bf bytes.Buffer
+	writeRIFFChunk(&bf, "RIFF", body.Bytes())
+
+	n, err := wr.Write(bf.Bytes())
+	if err != nil {
+		return int64(n), fmt.Errorf("could not write RMID: %v", err)
+	}
+	return int64(n), nil
+}
@@ -0,0 +1,105 @@
+package smf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"testing"
+)
+
+func riffChunk(typ string, data ...byte) []byte {
+	var bf bytes.Buffer
+	writeRIFFChunk(&bf, typ, data)
+	return bf.Bytes()
+}
+
+func TestReadRMID(t *testing.T) {
+	info := append([]byte("INFO"), bytes.Join([][]byte{
+		riffChunk("INAM", []byte("Song\x00")...),
+		riffChunk("ICOP", []byte("(c) me\x00")...),
+		riffChunk("IART", []byte("Band\x00")...),
+	}, nil)...)
+
+	dls := append([]byte("DLS "), riffChunk("colh", 0, 0, 0, 0)...)
+
+	body := bytes.Join([][]byte{
+		[]byte("RMID"),
+		riffChunk("LIST", info...),
+		riffChunk("data", SpecSMF0...),
+		riffChunk("RIFF", dls...),
+	}, nil)
+
+	data := riffChunk("RIFF", body...)
+
+	s, err := ReadFrom(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadFrom() returned error: %v", err)
+	}
+
+	if s.RMID == nil {
+		t.Fatalf("RMID is not set")
+	}
+
+	if got, want := fmt.Sprintf("%q %q %q %v", s.RMID.Title, s.RMID.Copyright, s.RMID.Comments, s.RMID.Info), `"Song" "(c) me" "" map[IART:Band]`; got != want {
+		t.Errorf("RMID info = %s; want %s", got, want)
+	}
+
+	if got, want := fmt.Sprintf("% X", s.RMID.SoundBank()), fmt.Sprintf("% X", riffChunk("RIFF", dls...)); got != want {
+		t.Errorf("SoundBank() = %s; want %s", got, want)
+	}
+
+	if got, want := len(s.Tracks), 1; got != want {
+		t.Errorf("len(Tracks) = %v; want %v", got, want)
+	}
+}
+
+func TestWriteRMID(t *testing.T) {
+	s, err := ReadFrom(bytes.NewReader(SpecSMF0))
+	if err != nil {
+		t.Fatalf("ReadFrom() returned error: %v", err)
+	}
+
+	s.RMID = &RMID{
+		Title:    "Title",
+		Comments: "odd",
+		Chunks:   []RawChunk{{Type: "RIFF", Data: []byte("sfbk")}},
+	}
+
+	var bf bytes.Buffer
+	_, err = s.WriteTo(&bf)
+	if err != nil {
+		t.Fatalf("WriteTo() returned error: %v", err)
+	}
+
+	data := bf.Bytes()
+
+	if got, want := string(data[:4])+string(data[8:12]), "RIFFRMID"; got != want {
+		t.Errorf("header = %q; want %q", got, want)
+	}
+
+	if got, want := int(binary.LittleEndian.Uint32(data[4:8])), len(data)-8; got != want {
+		t.Errorf("RIFF length = %v; want %v", got, want)
+	}
+
+	s2, err := ReadFrom(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadFrom() returned error: %v", err)
+	}
+
+	if got, want := fmt.Sprintf("%q %q %v", s2.RMID.Title, s2.RMID.Comments, s2.RMID.Chunks), `"Title" "odd" [{RIFF [115 102 98 107]}]`; got != want {
+		t.Errorf("RMID = %s; want %s", got, want)
+	}
+
+	if got, want := s2.String(), s.String(); got != want {
+		t.Errorf("SMF = %s; want %s", got, want)
+	}
+
+	// writing without RMID gives the plain SMF data
+	s2.RMID = nil
+	bf.Reset()
+	s2.WriteTo(&bf)
+
+	if got, want := string(bf.Bytes()[:4]), "MThd"; got != want {
+		t.Errorf("header = %q; want %q", got, want)
+	}
+}
@@ -60,6 +60,10 @@ type SMF struct {
 	// Tracks contain the midi events
 	Tracks []Track
 
+	// RMID is the RIFF container of a .rmi file. It is set, if the SMF has been read from a .rmi file.
+	// If it is set, the SMF is written within a RIFF RMID container.
+	RMID *RMID
+
 	// format is the SMF file format: SMF0, SMF1 or SMF2.
 	format uint16
 
@@ -67,6 +71,8 @@ type SMF struct {
 	numTracks uint16
 
 	tempoChanges         TempoChanges
+	warnings             []Warning
+	original             *original
 	tempoChangesFinished bool
 	finished             bool
 }
@@ -90,12 +96,17 @@ func (s SMF) String() string {
 // ConvertToSMF1 converts a given SMF format 0 to SMF format 1
 // channel messages are distributed over the tracks by their channels
 // e.g. channel 0 -> track 1, channel 1 -> track 2 etc.
-// and everything else stays in track 0
+// and everything else stays in track 0.
+// A SMF format 2 is converted to format 0 before (see ConvertToSMF0).
 func (src SMF) ConvertToSMF1() (dest SMF) {
 	if src.format == 1 {
 		return src
 	}
 
+	if src.format == 2 {
+		src = src.ConvertToSMF0()
+	}
+
 	var channelTracks [16]TrackEvents
 	var metaTrack TrackEvents
 
@@ -208,6 +219,12 @@ func (s *SMF) finishTempoChanges() {
 
 func (s *SMF) calculateAbsTimes() {
 	var lasttcTick, lasttcTimeMicroSec int64
+	if tf, ok := s.TimeFormat.(TimeCode); ok {
+		for _, tc := range s.tempoChanges {
+			tc.AbsTimeMicroSec = tf.microseconds(tc.AbsTicks)
+		}
+		return
+	}
 	mt := s.TimeFormat.(MetricTicks)
 	for _, tc := range s.tempoChanges {
 		diffTicks := tc.AbsTicks - lasttcTick
@@ -233,8 +250,12 @@ func (s *SMF) calculateAbsTimes() {
 	}
 }
 
-// TimeAt returns the absolute time for a given absolute tick (considering the tempo changes)
+// TimeAt returns the absolute time for a given absolute tick (considering the tempo changes).
+// For the SMPTE time format, the tempo changes have no effect.
 func (s *SMF) TimeAt(absTicks int64) (absTimeMicroSec int64) {
+	if tf, ok := s.TimeFormat.(TimeCode); ok {
+		return tf.microseconds(absTicks)
+	}
 	s.finishTempoChanges()
 	mt := s.TimeFormat.(MetricTicks)
 	prevTc := s.tempoChanges.TempoChangeAt(absTicks - 1)
@@ -280,6 +301,21 @@ func (s *SMF) Bytes() (data []byte, err error) {
 
 // WriteTo writes the SMF to the given writer
 func (s *SMF) WriteTo(f io.Writer) (size int64, err error) {
+	if s.RMID == nil {
+		return s.writeSMFTo(f)
+	}
+
+	var bf bytes.Buffer
+	_, err = s.writeSMFTo(&bf)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.RMID.writeTo(f, bf.Bytes())
+}
+
+// writeSMFTo writes the SMF data (without a RIFF container) to the given writer
+func (s *SMF) writeSMFTo(f io.Writer) (size int64, err error) {
 	s.numTracks = uint16(len(s.Tracks))
 	if s.numTracks == 0 {
 		return 0, fmt.Errorf("no track added")
@@ -304,13 +340,22 @@ func (s *SMF) WriteTo(f io.Writer) (size int64, err error) {
 		return 0, fmt.Errorf("could not write header: %v", err)
 	}
 
-	for _, t := range s.Tracks {
-		for _, ev := range t {
-			//fmt.Printf("written ev: %v\n ", ev)
-			wr.SetDelta(ev.Delta)
-			err = wr.Write(ev.Message)
-			if err != nil {
-				break
+	for i, t := range s.Tracks {
+		err = wr.writeUnknownChunks(i)
+		if err != nil {
+			break
+		}
+
+		if s.original != nil && i < len(s.original.tracks) {
+			wr.currentChunk.Write(s.original.tracks[i].encode(t, wr.runningWriter))
+		} else {
+			for _, ev := range t {
+				//fmt.Printf("written ev: %v\n ", ev)
+				wr.SetDelta(ev.Delta)
+				err = wr.Write(ev.Message)
+				if err != nil {
+					break
+				}
 			}
 		}
 
@@ -321,7 +366,11 @@ func (s *SMF) WriteTo(f io.Writer) (size int64, err error) {
 		}
 	}
 
-	return wr.output.size, nil
+	if err == nil {
+		err = wr.writeUnknownChunks(len(s.Tracks))
+	}
+
+	return wr.output.size, err
 }
 
 func (s *SMF) log(format string, vals ...interface{}) {
@@ -0,0 +1,429 @@
+package smf
+
+import (
+	"bufio"
+	"container/heap"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"iter"
+	"sort"
+)
+
+// Stream reads the events of SMF data one at a time, without loading the whole file into memory.
+// The header is read by NewStream, the events are read by Next or the iterators Events, Track and Merged.
+// The tracks are not collected, so the memory usage is constant, regardless of the size of the file
+// (only the tempo changes are kept to calculate the absolute times).
+type Stream struct {
+	src    io.Reader
+	ra     io.ReaderAt
+	logger Logger
+
+	header *SMF
+
+	// offset is the position of the first chunk after the header within ra
+	offset int64
+
+	track   *trackStream
+	trackNo int
+	only    map[int]bool
+	tempos  *tempoMap
+	err     error
+}
+
+// NewStream reads the header of the given SMF data and returns a Stream to read the events.
+// If src is an io.ReaderAt (e.g. an *os.File or a *bytes.Reader), the tracks can also be read merged in time order (see Merged).
+// Only the Log ReadOption is respected.
+func NewStream(src io.Reader, opts ...ReadOption) (*Stream, error) {
+	var c readConfig
+
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	s := &Stream{
+		logger:  c.Logger,
+		trackNo: -1,
+	}
+
+	if ra, is := src.(io.ReaderAt); is {
+		s.ra = ra
+	}
+
+	bf := bufio.NewReader(src)
+	s.src = bf
+
+	var hd chunk
+	length, err := hd.ReadHeader(bf)
+	if err != nil {
+		return nil, err
+	}
+
+	if hd.Type() != "MThd" {
+		return nil, errExpectedMthd
+	}
+
+	rd := newReader(bf)
+	rd.Logger = c.Logger
+	err = rd.parseHeaderData(bf)
+	if err != nil {
+		return nil, err
+	}
+
+	if length > 6 {
+		_, err = io.CopyN(ioutil.Discard, bf, int64(length-6))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s.header = rd.SMF
+	s.offset = 8 + int64(length)
+	s.tempos = newTempoMap(s.header.TimeFormat)
+	return s, nil
+}
+
+// Format returns the SMF format.
+func (s *Stream) Format() uint16 {
+	return s.header.format
+}
+
+// NumTracks returns the number of tracks, as defined in the header.
+func (s *Stream) NumTracks() uint16 {
+	return s.header.numTracks
+}
+
+// TimeFormat returns the time format of the SMF.
+func (s *Stream) TimeFormat() TimeFormat {
+	return s.header.TimeFormat
+}
+
+// Only restricts Next, Events and Merged to the given tracks. The chunks of the other tracks are skipped without parsing them.
+// Without a call to Only, all tracks are read.
+func (s *Stream) Only(tracks ...int) *Stream {
+	s.only = map[int]bool{}
+	for _, tr := range tracks {
+		s.only[tr] = true
+	}
+	return s
+}
+
+// Next returns the next event in the order of the file (track by track).
+// The absolute times are based on the tempo changes that have been read so far, which is correct as long as
+// the tempo changes are in the first track (or the SMF has format 0).
+// If all events have been read, ErrFinished is returned.
+func (s *Stream) Next() (te TrackEvent, err error) {
+	if s.err != nil {
+		return te, s.err
+	}
+
+	for {
+		if s.track == nil {
+			s.err = s.nextTrack()
+			if s.err != nil {
+				return te, s.err
+			}
+		}
+
+		te, err = s.track.next()
+
+		if err == ErrFinished {
+			s.track = nil
+			continue
+		}
+
+		if err != nil {
+			s.err = err
+			return te, err
+		}
+
+		s.tempos.add(te.AbsTicks, te.Message)
+		te.AbsMicroSeconds = s.tempos.timeAt(te.AbsTicks)
+		return te, nil
+	}
+}
+
+// nextTrack moves to the next wanted track chunk
+func (s *Stream) nextTrack() error {
+	for {
+		if int(s.header.numTracks) == s.trackNo+1 {
+			return ErrFinished
+		}
+
+		var ch chunk
+		length, err := ch.ReadHeader(s.src)
+
+		if err == io.EOF {
+			return ErrMissing
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if ch.Type() != "MTrk" {
+			s.log("skipping chunk %q", ch.Type())
+			_, err = io.CopyN(ioutil.Discard, s.src, int64(length))
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		s.trackNo++
+
+		if s.only != nil && !s.only[s.trackNo] {
+			_, err = io.CopyN(ioutil.Discard, s.src, int64(length))
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		s.track = newTrackStream(io.LimitReader(s.src, int64(length)), s.trackNo, s.logger)
+		return nil
+	}
+}
+
+// Events returns an iterator over the events in the order of the file (track by track), see Next.
+// The iteration stops after the first error, which is passed to the loop body. ErrFinished is not passed.
+func (s *Stream) Events() iter.Seq2[TrackEvent, error] {
+	return func(yield func(TrackEvent, error) bool) {
+		for {
+			te, err := s.Next()
+			if err == ErrFinished {
+				return
+			}
+			if !yield(te, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Track returns an iterator over the events of the given track. The other tracks are skipped.
+func (s *Stream) Track(no int) iter.Seq2[TrackEvent, error] {
+	return s.Only(no).Events()
+}
+
+// Merged returns an iterator over the events of all tracks (or the tracks given to Only), merged in time order.
+// Events at the same position are ordered by their track number.
+// The source of the stream must be an io.ReaderAt, since the tracks are read in parallel.
+// Only one event per track is kept in memory.
+func (s *Stream) Merged() iter.Seq2[TrackEvent, error] {
+	return func(yield func(TrackEvent, error) bool) {
+		if s.ra == nil {
+			yield(TrackEvent{}, fmt.Errorf("source of the stream is not an io.ReaderAt"))
+			return
+		}
+
+		var q trackQueue
+		err := s.openTracks(&q)
+		if err != nil {
+			yield(TrackEvent{}, err)
+			return
+		}
+
+		heap.Init(&q)
+
+		tempos := newTempoMap(s.header.TimeFormat)
+
+		for q.Len() > 0 {
+			ts := q[0]
+			te := ts.current
+			te.AbsMicroSeconds = tempos.add(te.AbsTicks, te.Message).timeAt(te.AbsTicks)
+
+			if !yield(te, nil) {
+				return
+			}
+
+			ts.current, ts.err = ts.next()
+
+			switch {
+			case ts.err == ErrFinished:
+				heap.Pop(&q)
+			case ts.err != nil:
+				yield(TrackEvent{}, ts.err)
+				return
+			default:
+				heap.Fix(&q, 0)
+			}
+		}
+	}
+}
+
+// openTracks opens a reader for every wanted track chunk and reads the first event of each
+func (s *Stream) openTracks(q *trackQueue) error {
+	offset := s.offset
+	var no int
+
+	for no < int(s.header.numTracks) {
+		var ch chunk
+		length, err := ch.ReadHeader(io.NewSectionReader(s.ra, offset, 8))
+		if err == io.EOF {
+			return ErrMissing
+		}
+		if err != nil {
+			return err
+		}
+		offset += 8
+
+		if ch.Type() == "MTrk" {
+			if s.only == nil || s.only[no] {
+				rd := bufio.NewReader(io.NewSectionReader(s.ra, offset, int64(length)))
+				ts := newTrackStream(rd, no, s.logger)
+				ts.current, ts.err = ts.next()
+
+				switch {
+				case ts.err == ErrFinished:
+				case ts.err != nil:
+					return ts.err
+				default:
+					*q = append(*q, ts)
+				}
+			}
+			no++
+		}
+
+		offset += int64(length)
+	}
+
+	return nil
+}
+
+func (s *Stream) log(format string, vals ...interface{}) {
+	if s.logger != nil {
+		s.logger.Printf(format+"\n", vals...)
+	}
+}
+
+// trackStream reads the events of a single track
+type trackStream struct {
+	src      *bufio.Reader
+	rd       *reader
+	no       int
+	absTicks int64
+	current  TrackEvent
+	err      error
+}
+
+func newTrackStream(src io.Reader, no int, logger Logger) *trackStream {
+	br, ok := src.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(src)
+	}
+	rd := newReader(br)
+	rd.Logger = logger
+	rd.SMF.numTracks = 1
+	rd.processedTracks = 0
+	rd.headerIsRead = true
+	rd.expectChunk = false
+	return &trackStream{src: br, rd: rd, no: no}
+}
+
+// next returns the next event of the track. A missing end of track is tolerated, if the track data ends
+// between two events. Track data that ends within an event is reported as error.
+func (t *trackStream) next() (te TrackEvent, err error) {
+	if _, err := t.src.Peek(1); err == io.EOF {
+		return te, ErrFinished
+	}
+
+	m, err := t.rd.Read()
+
+	// the track data did not end before the event, so it ends within the event
+	if err == io.EOF {
+		return te, io.ErrUnexpectedEOF
+	}
+
+	if err != nil {
+		return te, err
+	}
+
+	t.absTicks += int64(t.rd.deltatime)
+	te.TrackNo = t.no
+	te.Delta = t.rd.deltatime
+	te.Message = m
+	te.AbsTicks = t.absTicks
+	return te, nil
+}
+
+// trackQueue is a priority queue of the current events of the tracks
+type trackQueue []*trackStream
+
+func (q trackQueue) Len() int {
+	return len(q)
+}
+
+func (q trackQueue) Less(a, b int) bool {
+	if q[a].current.AbsTicks == q[b].current.AbsTicks {
+		return q[a].no < q[b].no
+	}
+	return q[a].current.AbsTicks < q[b].current.AbsTicks
+}
+
+func (q trackQueue) Swap(a, b int) {
+	q[a], q[b] = q[b], q[a]
+}
+
+func (q *trackQueue) Push(x any) {
+	*q = append(*q, x.(*trackStream))
+}
+
+func (q *trackQueue) Pop() any {
+	old := *q
+	n := len(old)
+	x := old[n-1]
+	*q = old[:n-1]
+	return x
+}
+
+// tempoMap calculates absolute times from tempo changes that are added while reading
+type tempoMap struct {
+	timeFormat TimeFormat
+	changes    TempoChanges
+}
+
+func newTempoMap(tf TimeFormat) *tempoMap {
+	return &tempoMap{timeFormat: tf}
+}
+
+// add adds the message as tempo change, if it is a tempo message
+func (t *tempoMap) add(absTicks int64, msg Message) *tempoMap {
+	var bpm float64
+	if !msg.GetMetaTempo(&bpm) {
+		return t
+	}
+
+	i := sort.Search(len(t.changes), func(i int) bool {
+		return t.changes[i].AbsTicks > absTicks
+	})
+
+	t.changes = append(t.changes, nil)
+	copy(t.changes[i+1:], t.changes[i:])
+	t.changes[i] = &TempoChange{AbsTicks: absTicks, BPM: bpm}
+
+	for j := i; j < len(t.changes); j++ {
+		t.changes[j].AbsTimeMicroSec = t.timeAt(t.changes[j].AbsTicks)
+	}
+
+	return t
+}
+
+// timeAt returns the absolute time in microseconds for the given absolute ticks
+func (t *tempoMap) timeAt(absTicks int64) int64 {
+	switch tf := t.timeFormat.(type) {
+	case MetricTicks:
+		i := sort.Search(len(t.changes), func(i int) bool {
+			return t.changes[i].AbsTicks >= absTicks
+		})
+		if i == 0 {
+			return tf.Duration(120.00, uint32(absTicks)).Microseconds()
+		}
+		prev := t.changes[i-1]
+		return prev.AbsTimeMicroSec + tf.Duration(prev.BPM, uint32(absTicks-prev.AbsTicks)).Microseconds()
+	case TimeCode:
+		return tf.microseconds(absTicks)
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,186 @@
+package smf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestStreamEvents(t *testing.T) {
+	s, err := NewStream(bytes.NewReader(SpecSMF1))
+	if err != nil {
+		t.Fatalf("can't create stream: %v", err)
+	}
+
+	if got, want := fmt.Sprintf("%v %v %v", s.Format(), s.NumTracks(), s.TimeFormat()), "1 4 96 MetricTicks"; got != want {
+		t.Errorf("header = %q; want %q", got, want)
+	}
+
+	var bd strings.Builder
+	for te, err := range s.Events() {
+		if err != nil {
+			t.Fatalf("got error: %v", err)
+		}
+		bd.WriteString(fmt.Sprintf("Track %v@%v %s\n", te.TrackNo, te.Delta, te.Message))
+	}
+
+	var expected = `
+SMF1
+4 Track(s)
+TimeFormat: 96 MetricTicks
+` + bd.String()
+
+	if got, want := testRead(t, SpecSMF1), expected; got != want {
+		t.Errorf("got:\n%v\n\nwanted\n%v\n\n", got, want)
+	}
+}
+
+func TestStreamTrack(t *testing.T) {
+	s, err := NewStream(bytes.NewReader(SpecSMF1))
+	if err != nil {
+		t.Fatalf("can't create stream: %v", err)
+	}
+
+	var n int
+	for te, err := range s.Track(2) {
+		if err != nil {
+			t.Fatalf("got error: %v", err)
+		}
+		if te.TrackNo != 2 {
+			t.Errorf("got event of track %v", te.TrackNo)
+		}
+		n++
+	}
+
+	if got, want := n, 4; got != want {
+		t.Errorf("got %v events; want %v", got, want)
+	}
+}
+
+func TestStreamMerged(t *testing.T) {
+	s, err := NewStream(bytes.NewReader(SpecSMF1))
+	if err != nil {
+		t.Fatalf("can't create stream: %v", err)
+	}
+
+	var last int64
+	var n int
+	for te, err := range s.Merged() {
+		if err != nil {
+			t.Fatalf("got error: %v", err)
+		}
+		if te.AbsTicks < last {
+			t.Errorf("event %v at %v comes after %v", te.Message, te.AbsTicks, last)
+		}
+		if got, want := te.AbsMicroSeconds, te.AbsTicks*500000/96; got != want {
+			t.Errorf("AbsMicroSeconds at %v = %v; want %v", te.AbsTicks, got, want)
+		}
+		last = te.AbsTicks
+		n++
+	}
+
+	if got, want := n, 17; got != want {
+		t.Errorf("got %v events; want %v", got, want)
+	}
+}
+
+func TestStreamMergedOnly(t *testing.T) {
+	s, err := NewStream(bytes.NewReader(SpecSMF1))
+	if err != nil {
+		t.Fatalf("can't create stream: %v", err)
+	}
+
+	var n int
+	for te, err := range s.Only(1).Merged() {
+		if err != nil {
+			t.Fatalf("got error: %v", err)
+		}
+		if te.TrackNo != 1 {
+			t.Errorf("got event %v of track %v; want only track 1", te.Message, te.TrackNo)
+		}
+		n++
+	}
+
+	if n == 0 {
+		t.Errorf("got no events of track 1")
+	}
+}
+
+func TestStreamMergedNeedsReaderAt(t *testing.T) {
+	s, err := NewStream(io.MultiReader(bytes.NewReader(SpecSMF1)))
+	if err != nil {
+		t.Fatalf("can't create stream: %v", err)
+	}
+
+	for _, err := range s.Merged() {
+		if err == nil {
+			t.Errorf("expected error")
+		}
+	}
+}
+
+func TestStreamMissing(t *testing.T) {
+	s, err := NewStream(bytes.NewReader(SpecSMF1Missing))
+	if err != nil {
+		t.Fatalf("can't create stream: %v", err)
+	}
+
+	var last error
+	for _, err := range s.Events() {
+		last = err
+	}
+
+	if last != ErrMissing {
+		t.Errorf("expected ErrMissing, got: %v", last)
+	}
+}
+
+// singleTrack returns SMF data with the given bytes as content of the only track chunk
+func singleTrack(data ...byte) []byte {
+	header := []byte{0x4D, 0x54, 0x68, 0x64, 0x00, 0x00, 0x00, 0x06, 0x00, 0x00, 0x00, 0x01, 0x00, 0x60}
+	track := []byte{0x4D, 0x54, 0x72, 0x6B, 0x00, 0x00, 0x00, byte(len(data))}
+	return append(append(header, track...), data...)
+}
+
+func TestStreamTruncated(t *testing.T) {
+	tests := []struct {
+		descr   string
+		data    []byte
+		events  int
+		wantErr bool
+	}{
+		{"missing end of track", singleTrack(0x00, 0x90, 0x3C, 0x40), 1, false},
+		{"delta without event", singleTrack(0x00, 0x90, 0x3C, 0x40, 0x60), 1, true},
+		{"truncated delta", singleTrack(0x00, 0x90, 0x3C, 0x40, 0x81), 1, true},
+	}
+
+	for _, test := range tests {
+		for _, merged := range []bool{false, true} {
+			s, err := NewStream(bytes.NewReader(test.data))
+			if err != nil {
+				t.Fatalf("[%s] can't create stream: %v", test.descr, err)
+			}
+
+			events := s.Events()
+			if merged {
+				events = s.Merged()
+			}
+
+			var n int
+			var last error
+			for _, err := range events {
+				if err != nil {
+					last = err
+					continue
+				}
+				n++
+			}
+
+			if n != test.events || (last != nil) != test.wantErr {
+				t.Errorf("[%s] merged: %v got %v events and error %v; expected %v events and error: %v", test.descr, merged, n, last, test.events, test.wantErr)
+			}
+		}
+	}
+}
@@ -0,0 +1,452 @@
+package smf
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/drivers"
+	"gitlab.com/gomidi/midi/v2/internal/runningstatus"
+)
+
+// StreamWriter writes SMF data incrementally, without buffering whole tracks in memory.
+// The events are appended to the output as they are written. The lengths of the track chunks and the
+// number of tracks in the header are patched by seeking back, when Flush or Close is called.
+// If the output is not an io.WriteSeeker, the data is written to a temporary file that is copied to the
+// output on Close (see TempFile and TempFileName).
+//
+// If the program crashes, all flushed events are kept in the output file or in the temporary file
+// (which then misses the end of track, but can be recovered by a lenient reader).
+type StreamWriter struct {
+	// NoRunningStatus is an option for writing to not write running status.
+	// It must be set before the first event is written.
+	NoRunningStatus bool
+
+	// Logger allows logging when writing
+	Logger Logger
+
+	mx            sync.Mutex
+	out           io.Writer
+	ws            io.WriteSeeker
+	tmp           *os.File
+	tmpPath       string
+	closer        io.Closer
+	bf            *bufio.Writer
+	header        *SMF
+	base          int64
+	pos           int64
+	trackStart    int64
+	runningWriter runningstatus.SMFWriter
+	closed        bool
+	err           error
+}
+
+// StreamWriterOption is an option for the StreamWriter.
+type StreamWriterOption func(*StreamWriter)
+
+// TempFile is an option to set the path of the temporary file that is used, if the output is not seekable.
+// By default, the temporary file is created in the default directory for temporary files.
+func TempFile(path string) StreamWriterOption {
+	return func(s *StreamWriter) {
+		s.tmpPath = path
+	}
+}
+
+// NewStreamWriter returns a StreamWriter that writes to the given output with the given time format.
+// The header is written immediately. The format is 0 for a single track and 1 for multiple tracks.
+// The output is not closed by the StreamWriter.
+func NewStreamWriter(output io.Writer, tf TimeFormat, opts ...StreamWriterOption) (*StreamWriter, error) {
+	s := &StreamWriter{
+		out:        output,
+		trackStart: -1,
+		header:     &SMF{TimeFormat: tf},
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	ws, isSeeker := output.(io.WriteSeeker)
+
+	if isSeeker {
+		base, err := ws.Seek(0, io.SeekCurrent)
+		if err != nil {
+			// e.g. pipes implement io.Seeker but can't seek
+			isSeeker = false
+		}
+		s.base = base
+	}
+
+	if !isSeeker {
+		var tmp *os.File
+		var err error
+		if s.tmpPath != "" {
+			tmp, err = os.Create(s.tmpPath)
+		} else {
+			tmp, err = os.CreateTemp("", "gomidi-smf-*.mid")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("output is not seekable and temp file could not be created: %v", err)
+		}
+		s.tmp = tmp
+		s.tmpPath = tmp.Name()
+		ws = tmp
+	} else {
+		s.tmpPath = ""
+	}
+
+	s.ws = ws
+	s.bf = bufio.NewWriter(ws)
+
+	hd, err := s.headerBytes()
+	if err != nil {
+		s.removeTemp()
+		return nil, err
+	}
+
+	s.write(hd)
+	if s.err != nil {
+		s.removeTemp()
+		return nil, s.err
+	}
+	return s, nil
+}
+
+// CreateStreamFile creates the given file and returns a StreamWriter for it.
+// The file is closed, when the StreamWriter is closed.
+func CreateStreamFile(file string, tf TimeFormat) (*StreamWriter, error) {
+	f, err := os.Create(file)
+	if err != nil {
+		return nil, fmt.Errorf("writing midi file failed: could not create file %#v", file)
+	}
+
+	s, err := NewStreamWriter(f, tf)
+	if err != nil {
+		f.Close()
+		os.Remove(file)
+		return nil, err
+	}
+	s.closer = f
+	return s, nil
+}
+
+// TempFileName returns the name of the temporary file, if the output is not seekable, and an empty string
+// otherwise. The flushed events can be recovered from the temporary file, if the program crashes or
+// if it could not be copied to the output.
+func (s *StreamWriter) TempFileName() string {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.tmpPath
+}
+
+// headerBytes returns the bytes of the header chunk
+func (s *StreamWriter) headerBytes() ([]byte, error) {
+	var bf bytes.Buffer
+	wr := newWriter(s.header, &bf)
+	err := wr.writeHeader(&bf)
+	return bf.Bytes(), err
+}
+
+func (s *StreamWriter) printf(format string, vals ...interface{}) {
+	if s.Logger == nil {
+		return
+	}
+
+	s.Logger.Printf("smfstreamwriter: "+format+"\n", vals...)
+}
+
+func (s *StreamWriter) write(b []byte) {
+	if s.err != nil {
+		return
+	}
+	n, err := s.bf.Write(b)
+	s.pos += int64(n)
+	if err != nil {
+		s.err = fmt.Errorf("could not write: %v", err)
+	}
+}
+
+// NewTrack ends the current track (if there is one) and starts a new track.
+func (s *StreamWriter) NewTrack() error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.newTrack()
+}
+
+func (s *StreamWriter) newTrack() error {
+	if s.closed {
+		return fmt.Errorf("stream writer is closed")
+	}
+
+	if s.trackStart >= 0 {
+		s.endTrack(0)
+	}
+
+	s.trackStart = s.pos
+	s.header.numTracks++
+	if s.header.numTracks > 1 {
+		s.header.format = 1
+	}
+	s.printf("start track %v", s.header.numTracks)
+
+	// length is patched later
+	s.write([]byte{'M', 'T', 'r', 'k', 0, 0, 0, 0})
+
+	s.runningWriter = nil
+	if !s.NoRunningStatus {
+		s.runningWriter = runningstatus.NewSMFWriter()
+	}
+	return s.err
+}
+
+// Write appends the given message with the given delta to the current track.
+// If there is no current track, a new one is started. Writing an end of track message ends the track.
+func (s *StreamWriter) Write(deltaticks uint32, msg []byte) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+
+	if len(msg) == 0 {
+		return nil
+	}
+
+	if isEOT(msg) {
+		return s.endTrackIfOpen(deltaticks)
+	}
+
+	if s.trackStart < 0 {
+		err := s.newTrack()
+		if err != nil {
+			return err
+		}
+	}
+
+	s.write(encodeEvent(s.runningWriter, deltaticks, Message(msg)))
+	return s.err
+}
+
+// EndTrack ends the current track with an end of track message after the given delta.
+func (s *StreamWriter) EndTrack(deltaticks uint32) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.endTrackIfOpen(deltaticks)
+}
+
+func (s *StreamWriter) endTrackIfOpen(deltaticks uint32) error {
+	if s.trackStart < 0 {
+		return nil
+	}
+	s.endTrack(deltaticks)
+	return s.err
+}
+
+func (s *StreamWriter) endTrack(deltaticks uint32) {
+	s.write(encodeEvent(nil, deltaticks, EOT))
+	s.patch()
+	s.printf("track %v ended", s.header.numTracks)
+	s.trackStart = -1
+}
+
+// Flush writes the buffered events to the output and patches the lengths, so that
+// the output contains a readable SMF (missing the end of track of the current track).
+func (s *StreamWriter) Flush() error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.patch()
+	return s.err
+}
+
+// patch flushes the buffer and patches the length of the current track and the header
+func (s *StreamWriter) patch() {
+	if s.err != nil {
+		return
+	}
+
+	err := s.bf.Flush()
+	if err != nil {
+		s.err = fmt.Errorf("could not flush: %v", err)
+		return
+	}
+
+	hd, err := s.headerBytes()
+	if err != nil {
+		s.err = err
+		return
+	}
+
+	s.writeAt(0, hd)
+
+	if s.trackStart >= 0 {
+		var length [4]byte
+		binary.BigEndian.PutUint32(length[:], uint32(s.pos-s.trackStart-8))
+		s.writeAt(s.trackStart+4, length[:])
+	}
+
+	if s.err != nil {
+		return
+	}
+
+	_, err = s.ws.Seek(s.base+s.pos, io.SeekStart)
+	if err != nil {
+		s.err = fmt.Errorf("could not seek: %v", err)
+	}
+}
+
+// writeAt writes the given bytes at the given position (relative to the start of the header)
+func (s *StreamWriter) writeAt(pos int64, b []byte) {
+	if s.err != nil {
+		return
+	}
+	_, err := s.ws.Seek(s.base+pos, io.SeekStart)
+	if err != nil {
+		s.err = fmt.Errorf("could not seek: %v", err)
+		return
+	}
+	_, err = s.ws.Write(b)
+	if err != nil {
+		s.err = fmt.Errorf("could not write: %v", err)
+	}
+}
+
+// Close ends the current track and finishes the SMF. If the output has been replaced by a temporary file,
+// the temporary file is copied to the output and removed. If the copying fails, the temporary file is kept
+// (see TempFileName). The output is only closed, if it has been created by CreateStreamFile.
+func (s *StreamWriter) Close() error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if s.closed {
+		return s.err
+	}
+
+	if s.header.numTracks == 0 {
+		s.newTrack()
+	}
+
+	if s.trackStart >= 0 {
+		s.endTrack(0)
+	}
+
+	s.closed = true
+
+	s.patch()
+
+	if s.tmp != nil {
+		if s.err == nil {
+			_, err := s.tmp.Seek(0, io.SeekStart)
+			if err == nil {
+				_, err = io.Copy(s.out, s.tmp)
+			}
+			if err != nil {
+				s.err = fmt.Errorf("could not copy temp file %s to output: %v", s.tmpPath, err)
+			}
+		}
+
+		if s.err == nil {
+			s.removeTemp()
+		} else {
+			s.tmp.Close()
+			s.tmp = nil
+		}
+	}
+
+	if s.closer != nil {
+		err := s.closer.Close()
+		if err != nil && s.err == nil {
+			s.err = err
+		}
+	}
+
+	return s.err
+}
+
+func (s *StreamWriter) removeTemp() {
+	if s.tmp == nil {
+		return
+	}
+	s.tmp.Close()
+	os.Remove(s.tmp.Name())
+	s.tmp = nil
+	s.tmpPath = ""
+}
+
+// RecordFrom records from the given midi in port into a new track of the stream, starting with a tempo
+// message of the given bpm. Every message is flushed to the output, once it has been received.
+// It returns a stop function that must be called to stop the recording. The stop function ends the track,
+// but does not close the StreamWriter.
+func (s *StreamWriter) RecordFrom(inport drivers.In, bpm float64) (stop func() error, err error) {
+	ticks, ok := s.header.TimeFormat.(MetricTicks)
+	if !ok {
+		return nil, fmt.Errorf("SMF time format is not metric ticks, but %s (currently not supported)", s.header.TimeFormat.String())
+	}
+
+	if !inport.IsOpen() {
+		err := inport.Open()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.NewTrack()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.Write(0, MetaTempo(bpm))
+	if err != nil {
+		return nil, err
+	}
+
+	var absmillisec int32
+	_stop, err := midi.ListenTo(inport, func(msg midi.Message, absms int32) {
+		deltams := absms - absmillisec
+		absmillisec = absms
+		delta := ticks.Ticks(bpm, time.Duration(deltams)*time.Millisecond)
+		if s.Write(delta, msg) == nil {
+			s.Flush()
+		}
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return func() error {
+		_stop()
+		return s.EndTrack(0)
+	}, nil
+}
+
+// RecordStreamTo records from the given midi in port into the given filename with the given tempo,
+// like RecordTo. But the messages are written to the file, as they arrive.
+// It returns a stop function that must be called to stop the recording. The file is then completed and closed.
+func RecordStreamTo(inport drivers.In, bpm float64, filename string) (stop func() error, err error) {
+	s, err := CreateStreamFile(filename, MetricTicks(960))
+	if err != nil {
+		return nil, err
+	}
+
+	_stop, err := s.RecordFrom(inport, bpm)
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	return func() error {
+		err := _stop()
+		if err != nil {
+			s.Close()
+			return err
+		}
+		return s.Close()
+	}, nil
+}
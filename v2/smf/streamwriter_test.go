@@ -0,0 +1,233 @@
+package smf
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gitlab.com/gomidi/midi/v2"
+)
+
+func TestStreamWriter(t *testing.T) {
+	var tr1, tr2 Track
+	tr1.Add(0, MetaTempo(120))
+	tr1.Add(0, midi.NoteOn(0, 60, 100))
+	tr1.Add(96, midi.NoteOff(0, 60))
+	tr1.Close(0)
+	tr2.Add(0, midi.SysEx([]byte{0x41, 0x10}))
+	tr2.Add(10, midi.NoteOn(1, 62, 100))
+	tr2.Add(96, midi.NoteOn(1, 62, 0))
+	tr2.Close(0)
+
+	s := NewSMF1()
+	s.TimeFormat = MetricTicks(96)
+	s.Add(tr1)
+	s.Add(tr2)
+
+	expected, err := s.Bytes()
+	if err != nil {
+		t.Fatalf("can't write: %v", err)
+	}
+
+	write := func(sw *StreamWriter) {
+		for _, tr := range s.Tracks {
+			sw.NewTrack()
+			for _, ev := range tr {
+				err := sw.Write(ev.Delta, ev.Message)
+				if err != nil {
+					t.Fatalf("can't write: %v", err)
+				}
+			}
+		}
+		err := sw.Close()
+		if err != nil {
+			t.Fatalf("can't close: %v", err)
+		}
+	}
+
+	// not seekable, falls back to temp file
+	var bf bytes.Buffer
+	sw, err := NewStreamWriter(&bf, MetricTicks(96))
+	if err != nil {
+		t.Fatalf("can't create stream writer: %v", err)
+	}
+	write(sw)
+
+	if got, want := bf.Bytes(), expected; !bytes.Equal(got, want) {
+		t.Errorf("got:\n% X\nwant:\n% X", got, want)
+	}
+
+	// seekable
+	file := filepath.Join(t.TempDir(), "stream.mid")
+	sw, err = CreateStreamFile(file, MetricTicks(96))
+	if err != nil {
+		t.Fatalf("can't create stream writer: %v", err)
+	}
+	write(sw)
+
+	got, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("can't read file: %v", err)
+	}
+
+	if want := expected; !bytes.Equal(got, want) {
+		t.Errorf("got:\n% X\nwant:\n% X", got, want)
+	}
+}
+
+func TestStreamWriterFlush(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "flush.mid")
+	sw, err := CreateStreamFile(file, MetricTicks(96))
+	if err != nil {
+		t.Fatalf("can't create stream writer: %v", err)
+	}
+	defer sw.Close()
+
+	sw.Write(0, midi.NoteOn(0, 60, 100))
+	sw.Write(96, midi.NoteOff(0, 60))
+	sw.Flush()
+	sw.Write(96, midi.NoteOn(0, 62, 100))
+
+	// simulate a crash: the unflushed event is lost, but the flushed ones are readable
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatalf("can't open flushed file: %v", err)
+	}
+	defer f.Close()
+
+	st, err := NewStream(f)
+	if err != nil {
+		t.Fatalf("can't read flushed file: %v", err)
+	}
+
+	var got Track
+	for te, err := range st.Events() {
+		if err != nil {
+			t.Fatalf("can't read flushed file: %v", err)
+		}
+		got = append(got, te.Event)
+	}
+
+	expected := Track{
+		{0, Message(midi.NoteOn(0, 60, 100))},
+		{96, Message(midi.NoteOff(0, 60))},
+	}
+
+	if want := expected; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
+
+// closeRecorder is a non seekable output that records, if it has been closed
+type closeRecorder struct {
+	bf     bytes.Buffer
+	fail   bool
+	closed bool
+}
+
+func (c *closeRecorder) Write(p []byte) (int, error) {
+	if c.fail {
+		return 0, errors.New("write failed")
+	}
+	return c.bf.Write(p)
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestStreamWriterTempFile(t *testing.T) {
+	tmp := filepath.Join(t.TempDir(), "recording.mid")
+	var out closeRecorder
+
+	sw, err := NewStreamWriter(&out, MetricTicks(96), TempFile(tmp))
+	if err != nil {
+		t.Fatalf("can't create stream writer: %v", err)
+	}
+
+	if got := sw.TempFileName(); got != tmp {
+		t.Errorf("TempFileName() = %q; want %q", got, tmp)
+	}
+
+	sw.Write(0, midi.NoteOn(0, 60, 100))
+	sw.Write(96, midi.NoteOff(0, 60))
+	sw.Flush()
+
+	// the flushed events can be recovered from the temp file before Close
+	data, err := os.ReadFile(tmp)
+	if err != nil {
+		t.Fatalf("can't read temp file: %v", err)
+	}
+
+	st, err := NewStream(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("can't read temp file: %v", err)
+	}
+
+	var events int
+	for _, err := range st.Events() {
+		if err != nil {
+			t.Fatalf("can't read temp file: %v", err)
+		}
+		events++
+	}
+
+	if events != 2 {
+		t.Errorf("temp file has %v events; want 2", events)
+	}
+
+	if err := sw.Close(); err != nil {
+		t.Fatalf("can't close: %v", err)
+	}
+
+	if out.closed {
+		t.Errorf("output has been closed, but was not opened by the stream writer")
+	}
+
+	if _, err := os.Stat(tmp); !os.IsNotExist(err) {
+		t.Errorf("temp file has not been removed: %v", err)
+	}
+
+	if got := sw.TempFileName(); got != "" {
+		t.Errorf("TempFileName() = %q after Close; want empty string", got)
+	}
+
+	if _, err := ReadFrom(bytes.NewReader(out.bf.Bytes())); err != nil {
+		t.Errorf("can't read output: %v", err)
+	}
+}
+
+func TestStreamWriterCopyFails(t *testing.T) {
+	tmp := filepath.Join(t.TempDir(), "recording.mid")
+	out := closeRecorder{fail: true}
+
+	sw, err := NewStreamWriter(&out, MetricTicks(96), TempFile(tmp))
+	if err != nil {
+		t.Fatalf("can't create stream writer: %v", err)
+	}
+
+	sw.Write(0, midi.NoteOn(0, 60, 100))
+	sw.Write(96, midi.NoteOff(0, 60))
+
+	if err := sw.Close(); err == nil {
+		t.Fatalf("Close() returned no error")
+	}
+
+	if got := sw.TempFileName(); got != tmp {
+		t.Errorf("TempFileName() = %q; want %q", got, tmp)
+	}
+
+	// the temp file is kept with the complete recording
+	s, err := ReadFile(tmp)
+	if err != nil {
+		t.Fatalf("can't read temp file: %v", err)
+	}
+
+	if got := s.Tracks[0][len(s.Tracks[0])-1].Message; !isEOT(got) {
+		t.Errorf("last message of the temp file is %s; want end of track", got)
+	}
+}
@@ -38,6 +38,19 @@ func (t TimeCode) String() string {
 
 func (t TimeCode) timeformat() {}
 
+// microseconds returns the absolute time in microseconds for the given absolute ticks (subframes).
+// The tempo has no effect on the time.
+func (t TimeCode) microseconds(absTicks int64) int64 {
+	fps := float64(t.FramesPerSecond)
+	if t.FramesPerSecond == 29 {
+		fps = 29.97
+	}
+	if t.SubFrames == 0 || fps == 0 {
+		return 0
+	}
+	return int64(float64(absTicks) * float64(time.Second/time.Microsecond) / (fps * float64(t.SubFrames)))
+}
+
 // SMPTE24 returns a SMPTE24 TimeCode with the given subframes.
 func SMPTE24(subframes uint8) TimeCode {
 	return TimeCode{24, subframes}
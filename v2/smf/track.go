@@ -113,13 +113,6 @@ func ReadTracks(filepath string, tracks ...int) *TracksReader {
 		t.tracks[tr] = true
 	}
 	t.smf, t.err = ReadFile(filepath)
-	if t.err != nil {
-		return t
-	}
-	if _, ok := t.smf.TimeFormat.(MetricTicks); !ok {
-		t.err = fmt.Errorf("SMF time format is not metric ticks, but %s (currently not supported)", t.smf.TimeFormat.String())
-		return t
-	}
 	return t
 }
 
@@ -131,13 +124,6 @@ func ReadTracksFrom(rd io.Reader, tracks ...int) *TracksReader {
 	}
 
 	t.smf, t.err = ReadFrom(rd)
-	if t.err != nil {
-		return t
-	}
-	if _, ok := t.smf.TimeFormat.(MetricTicks); !ok {
-		t.err = fmt.Errorf("SMF time format is not metric ticks, but %s (currently not supported)", t.smf.TimeFormat.String())
-		return t
-	}
 	return t
 }
 
@@ -0,0 +1,401 @@
+package smf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"gitlab.com/gomidi/midi/v2"
+)
+
+// Severity is the severity of a Finding.
+type Severity int
+
+const (
+	// SeverityInfo is for content that is allowed, but unusual.
+	SeverityInfo Severity = iota
+
+	// SeverityWarning is for suspicious content, that might cause problems.
+	SeverityWarning
+
+	// SeverityError is for violations of the SMF specification.
+	SeverityError
+)
+
+// String returns the name of the severity.
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// Finding is a problem that has been found by Validate.
+type Finding struct {
+	Severity Severity
+
+	// Track is the number of the track (-1 if the finding is about the whole file)
+	Track int
+
+	// Event is the index of the event within the track (-1 if the finding is not about a single event)
+	Event int
+
+	// AbsTicks is the absolute position of the event in ticks (-1 if the finding is not about a single event)
+	AbsTicks int64
+
+	// Offset is the byte offset within the data (-1 if unknown). It is only set by ValidateFrom.
+	Offset int64
+
+	// Problem describes the problem
+	Problem string
+}
+
+// String returns a string representation of the finding.
+func (f Finding) String() string {
+	var bf bytes.Buffer
+	bf.WriteString(f.Severity.String())
+
+	if f.Offset >= 0 {
+		fmt.Fprintf(&bf, " offset %v", f.Offset)
+	}
+
+	if f.Track >= 0 {
+		fmt.Fprintf(&bf, " track %v", f.Track)
+	}
+
+	if f.Event >= 0 {
+		fmt.Fprintf(&bf, " event %v @%v", f.Event, f.AbsTicks)
+	}
+
+	fmt.Fprintf(&bf, ": %s", f.Problem)
+	return bf.String()
+}
+
+// ValidateFrom reads the SMF data with the Recover option and returns the problems found while reading
+// (as errors with offsets), followed by the findings of Validate. The error is only returned, if the data
+// could not be read at all.
+func ValidateFrom(rd io.Reader) ([]Finding, error) {
+	s, err := ReadFrom(rd, Recover())
+	if err != nil {
+		return nil, err
+	}
+
+	var res []Finding
+
+	for _, w := range s.Warnings() {
+		res = append(res, Finding{
+			Severity: SeverityError,
+			Track:    w.Track,
+			Event:    -1,
+			AbsTicks: -1,
+			Offset:   w.Offset,
+			Problem:  w.Problem,
+		})
+	}
+
+	return append(res, Validate(s)...), nil
+}
+
+// Validate checks the SMF for violations of the SMF specification and suspicious content.
+// It returns the findings, ordered by track and event.
+func Validate(s *SMF) []Finding {
+	v := &validator{smf: s}
+	v.validateFile()
+
+	for i, tr := range s.Tracks {
+		v.validateTrack(i, tr)
+	}
+
+	return v.findings
+}
+
+type validator struct {
+	smf      *SMF
+	findings []Finding
+}
+
+func (v *validator) add(sev Severity, track, event int, absTicks int64, format string, vals ...interface{}) {
+	v.findings = append(v.findings, Finding{
+		Severity: sev,
+		Track:    track,
+		Event:    event,
+		AbsTicks: absTicks,
+		Offset:   -1,
+		Problem:  fmt.Sprintf(format, vals...),
+	})
+}
+
+func (v *validator) fileFinding(sev Severity, format string, vals ...interface{}) {
+	v.add(sev, -1, -1, -1, format, vals...)
+}
+
+func (v *validator) validateFile() {
+	s := v.smf
+
+	if len(s.Tracks) == 0 {
+		v.fileFinding(SeverityError, "no tracks")
+	}
+
+	if s.format > 2 {
+		v.fileFinding(SeverityError, "invalid format %v", s.format)
+	}
+
+	if s.format == 0 && len(s.Tracks) > 1 {
+		v.fileFinding(SeverityError, "format 0 with %v tracks", len(s.Tracks))
+	}
+
+	switch tf := s.TimeFormat.(type) {
+	case MetricTicks:
+		if tf == 0 {
+			v.fileFinding(SeverityWarning, "metric ticks of 0")
+		}
+		if tf > 0x7FFF {
+			v.fileFinding(SeverityError, "metric ticks %v exceed 32767", uint16(tf))
+		}
+	case TimeCode:
+		switch tf.FramesPerSecond {
+		case 24, 25, 29, 30:
+		default:
+			v.fileFinding(SeverityError, "invalid SMPTE frames per second %v", tf.FramesPerSecond)
+		}
+		if tf.SubFrames == 0 {
+			v.fileFinding(SeverityError, "SMPTE subframes of 0")
+		}
+	default:
+		v.fileFinding(SeverityError, "missing time format")
+	}
+}
+
+func (v *validator) validateTrack(no int, tr Track) {
+	var open [16][128]int
+	var openSince [16][128]int64
+	var absTicks int64
+	var channel, key uint8
+	var lastNonZeroDelta = -1
+	var channelPrefix = -1
+	var eot = -1
+
+	for i, ev := range tr {
+		absTicks += int64(ev.Delta)
+		msg := ev.Message
+
+		if ev.Delta > 0x0FFFFFFF {
+			v.add(SeverityError, no, i, absTicks, "delta %v exceeds the maximum of 0x0FFFFFFF", ev.Delta)
+		}
+
+		if ev.Delta > 0 && lastNonZeroDelta < 0 {
+			lastNonZeroDelta = i
+		}
+
+		if eot >= 0 {
+			v.add(SeverityError, no, i, absTicks, "event after end of track")
+		}
+
+		if len(msg) == 0 {
+			v.add(SeverityError, no, i, absTicks, "empty message")
+			continue
+		}
+
+		switch {
+		case msg.IsMeta():
+			if msg.Is(MetaEndOfTrackMsg) {
+				eot = i
+			}
+			if msg.Is(MetaChannelMsg) && len(msg) > 3 {
+				channelPrefix = i
+			}
+			v.validateMeta(no, i, absTicks, msg, lastNonZeroDelta >= 0)
+			continue
+		case msg[0] == 0xF0 || msg[0] == 0xF7:
+			channelPrefix = -1
+			if len(msg) == 1 {
+				v.add(SeverityError, no, i, absTicks, "empty sysex message")
+				continue
+			}
+			if msg[0] == 0xF0 && msg[len(msg)-1] != 0xF7 {
+				v.add(SeverityInfo, no, i, absTicks, "sysex message is not terminated by F7 (might be continued by a F7 packet)")
+			}
+			for j, b := range msg[1:] {
+				if b == 0xF7 && j == len(msg)-2 {
+					break
+				}
+				if b > 0x7F {
+					v.add(SeverityError, no, i, absTicks, "sysex message contains status byte %02X", b)
+					break
+				}
+			}
+			continue
+		case msg[0] >= 0xF1:
+			v.add(SeverityError, no, i, absTicks, "system common or realtime message %s is not allowed", midi.Message(msg).Type())
+			continue
+		case msg[0] < 0x80:
+			v.add(SeverityError, no, i, absTicks, "message starts with data byte %02X", msg[0])
+			continue
+		}
+
+		// channel message
+		if channelPrefix >= 0 && i == channelPrefix+1 {
+			v.add(SeverityWarning, no, channelPrefix, absTicks, "channel prefix is not followed by meta or sysex messages")
+		}
+		channelPrefix = -1
+
+		if !v.validateChannelMessage(no, i, absTicks, msg) {
+			continue
+		}
+
+		switch {
+		case msg.GetNoteStart(&channel, &key, nil):
+			if open[channel][key] == 0 {
+				openSince[channel][key] = absTicks
+			}
+			open[channel][key]++
+		case msg.GetNoteEnd(&channel, &key):
+			if open[channel][key] == 0 {
+				v.add(SeverityInfo, no, i, absTicks, "note end channel %v key %v without note start", channel, key)
+				continue
+			}
+			open[channel][key]--
+		}
+	}
+
+	if eot < 0 {
+		v.add(SeverityError, no, -1, -1, "missing end of track")
+	}
+
+	for ch := range open {
+		for k, n := range open[ch] {
+			if n > 0 {
+				v.add(SeverityWarning, no, -1, -1, "%v note start(s) channel %v key %v without note end (first at %v)", n, ch, k, openSince[ch][k])
+			}
+		}
+	}
+}
+
+// validateChannelMessage returns false, if the channel message is invalid
+func (v *validator) validateChannelMessage(no, i int, absTicks int64, msg Message) bool {
+	expected := 3
+	if typ := msg[0] >> 4; typ == 0xC || typ == 0xD {
+		expected = 2
+	}
+
+	if len(msg) != expected {
+		v.add(SeverityError, no, i, absTicks, "channel message % X has length %v, expected %v", []byte(msg), len(msg), expected)
+		return false
+	}
+
+	for _, b := range msg[1:] {
+		if b > 0x7F {
+			v.add(SeverityError, no, i, absTicks, "value %v of channel message % X is out of range", b, []byte(msg))
+			return false
+		}
+	}
+
+	return true
+}
+
+// metaLengths are the allowed data lengths of the meta messages with a fixed length
+var metaLengths = map[byte][]int{
+	byteSequenceNumber: {0, 2},
+	byteMIDIChannel:    {1},
+	byteMIDIPort:       {1},
+	byteEndOfTrack:     {0},
+	byteTempo:          {3},
+	byteSMPTEOffset:    {5},
+	byteTimeSignature:  {4},
+	byteKeySignature:   {2},
+}
+
+func (v *validator) validateMeta(no, i int, absTicks int64, msg Message, afterNonZeroDelta bool) {
+	if len(msg) < 3 {
+		v.add(SeverityError, no, i, absTicks, "incomplete meta message % X", []byte(msg))
+		return
+	}
+
+	typ := msg[1]
+	ln, lnLen := readVLQ(msg[2:])
+	if lnLen == 0 {
+		v.add(SeverityError, no, i, absTicks, "meta message with invalid length % X", []byte(msg))
+		return
+	}
+
+	data := msg[2+lnLen:]
+
+	if int(ln) != len(data) {
+		v.add(SeverityError, no, i, absTicks, "meta message %02X announces length %v, but has %v bytes", typ, ln, len(data))
+		return
+	}
+
+	if lengths, has := metaLengths[typ]; has {
+		var ok bool
+		for _, l := range lengths {
+			if l == len(data) {
+				ok = true
+			}
+		}
+		if !ok {
+			v.add(SeverityError, no, i, absTicks, "%s has length %v, expected %v", msg.Type(), len(data), lengths)
+			return
+		}
+	}
+
+	isFirstTrack := no == 0
+
+	switch typ {
+	case byteTempo:
+		if v.smf.format == 1 && !isFirstTrack {
+			v.add(SeverityWarning, no, i, absTicks, "tempo message outside of the first track in SMF1")
+		}
+		if data[0] == 0 && data[1] == 0 && data[2] == 0 {
+			v.add(SeverityError, no, i, absTicks, "tempo of 0 microseconds per quarter note")
+		}
+	case byteTimeSignature:
+		if v.smf.format == 1 && !isFirstTrack {
+			v.add(SeverityWarning, no, i, absTicks, "time signature outside of the first track in SMF1")
+		}
+		if data[0] == 0 {
+			v.add(SeverityError, no, i, absTicks, "time signature with numerator 0")
+		}
+		if data[1] > 6 {
+			v.add(SeverityWarning, no, i, absTicks, "time signature with unusual denominator 2^%v", data[1])
+		}
+	case byteKeySignature:
+		if sf := int8(data[0]); sf < -7 || sf > 7 {
+			v.add(SeverityError, no, i, absTicks, "key signature with %v sharps/flats is out of range", sf)
+		}
+		if data[1] > 1 {
+			v.add(SeverityError, no, i, absTicks, "key signature with invalid mode %v", data[1])
+		}
+	case byteSMPTEOffset:
+		if afterNonZeroDelta {
+			v.add(SeverityError, no, i, absTicks, "SMPTE offset after a non-zero delta time")
+		}
+		if v.smf.format == 1 && !isFirstTrack {
+			v.add(SeverityWarning, no, i, absTicks, "SMPTE offset outside of the first track in SMF1")
+		}
+		if data[0]&0x1F > 23 || data[1] > 59 || data[2] > 59 || data[3] > 29 || data[4] > 99 {
+			v.add(SeverityError, no, i, absTicks, "SMPTE offset % X is out of range", []byte(data))
+		}
+	case byteSequenceNumber:
+		if afterNonZeroDelta {
+			v.add(SeverityError, no, i, absTicks, "sequence number after a non-zero delta time")
+		}
+	case byteMIDIChannel:
+		if data[0] > 15 {
+			v.add(SeverityError, no, i, absTicks, "channel prefix %v is out of range", data[0])
+		}
+	case byteMIDIPort:
+		if data[0] > 127 {
+			v.add(SeverityError, no, i, absTicks, "port %v is out of range", data[0])
+		}
+	}
+
+	if _, known := metaMessages[typ]; !known {
+		v.add(SeverityInfo, no, i, absTicks, "unknown meta message type %02X", typ)
+	}
+}
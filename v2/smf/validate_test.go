@@ -0,0 +1,141 @@
+package smf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gitlab.com/gomidi/midi/v2"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		descr    string
+		tracks   [][]Event
+		expected []string
+	}{
+		{
+			"valid",
+			[][]Event{
+				{{0, MetaTempo(120)}, {0, MetaMeter(3, 4)}, {0, EOT}},
+				{{0, Message(midi.NoteOn(1, 60, 100))}, {96, Message(midi.NoteOff(1, 60))}, {0, EOT}},
+			},
+			nil,
+		},
+		{
+			"missing note end and end of track",
+			[][]Event{
+				{{0, Message(midi.NoteOn(1, 60, 100))}, {96, Message(midi.NoteOn(1, 62, 100))}, {96, Message(midi.NoteOff(1, 62))}},
+			},
+			[]string{
+				"error track 0: missing end of track",
+				"warning track 0: 1 note start(s) channel 1 key 60 without note end (first at 0)",
+			},
+		},
+		{
+			"tempo outside of first track",
+			[][]Event{
+				{{0, EOT}},
+				{{10, MetaTempo(100)}, {0, MetaMeter(3, 4)}, {0, EOT}},
+			},
+			[]string{
+				"warning track 1 event 0 @10: tempo message outside of the first track in SMF1",
+				"warning track 1 event 1 @10: time signature outside of the first track in SMF1",
+			},
+		},
+		{
+			"bad meta lengths",
+			[][]Event{
+				{{0, _MetaMessage(byteTempo, []byte{1, 2})}, {0, _MetaMessage(byteKeySignature, []byte{8, 0})}, {0, Message{0xFF, 0x01, 0x05, 'a'}}, {0, EOT}},
+			},
+			[]string{
+				"error track 0 event 0 @0: MetaTempo has length 2, expected [3]",
+				"error track 0 event 1 @0: key signature with 8 sharps/flats is out of range",
+				"error track 0 event 2 @0: meta message 01 announces length 5, but has 1 bytes",
+			},
+		},
+		{
+			"channel prefix misuse",
+			[][]Event{
+				{{0, MetaChannel(3)}, {0, Message(midi.ProgramChange(3, 5))}, {0, MetaChannel(20)}, {0, MetaInstrument("piano")}, {0, EOT}},
+			},
+			[]string{
+				"warning track 0 event 0 @0: channel prefix is not followed by meta or sysex messages",
+				"error track 0 event 2 @0: channel prefix 20 is out of range",
+			},
+		},
+		{
+			"values out of range",
+			[][]Event{
+				{{0, Message{0x90, 0x80, 0x40}}, {0, Message{0xB0, 0x07}}, {0, Message{0xF8}}, {0, Message{0xF0, 0x7E, 0x90, 0xF7}}, {0, EOT}},
+			},
+			[]string{
+				"error track 0 event 0 @0: value 128 of channel message 90 80 40 is out of range",
+				"error track 0 event 1 @0: channel message B0 07 has length 2, expected 3",
+				"error track 0 event 2 @0: system common or realtime message TimingClock is not allowed",
+				"error track 0 event 3 @0: sysex message contains status byte 90",
+			},
+		},
+		{
+			"empty sysex",
+			[][]Event{
+				{{0, Message{0xF0}}, {0, EOT}},
+			},
+			[]string{
+				"error track 0 event 0 @0: empty sysex message",
+			},
+		},
+		{
+			"event after end of track",
+			[][]Event{
+				{{0, EOT}, {10, Message(midi.ProgramChange(0, 1))}},
+			},
+			[]string{
+				"error track 0 event 1 @10: event after end of track",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		s := NewSMF1()
+		s.TimeFormat = MetricTicks(96)
+		for _, tr := range test.tracks {
+			s.Add(Track(tr))
+		}
+
+		var got []string
+		for _, f := range Validate(s) {
+			got = append(got, f.String())
+		}
+
+		if g, w := strings.Join(got, "\n"), strings.Join(test.expected, "\n"); g != w {
+			t.Errorf("[%s] Validate() =\n%s\n\nwant\n%s", test.descr, g, w)
+		}
+	}
+}
+
+func TestValidateFrom(t *testing.T) {
+	data := bytes.Join([][]byte{
+		lenientHeader(1),
+		lenientTrack(0x0B, 0x00, 0x90, 0x3C, 0x40, 0x00, 0xFF, 0x01, 0x00, 0x60, 0x3C, 0x00),
+	}, nil)
+
+	findings, err := ValidateFrom(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ValidateFrom() returned error: %v", err)
+	}
+
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.String())
+	}
+
+	expected := []string{
+		"error offset 31 track 0: running status across meta or sysex message",
+		"error offset 33 track 0: missing end of track",
+	}
+
+	if g, w := strings.Join(got, "\n"), strings.Join(expected, "\n"); g != w {
+		t.Errorf("ValidateFrom() =\n%s\n\nwant\n%s", g, w)
+	}
+}
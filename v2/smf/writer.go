@@ -175,6 +175,10 @@ func (w *writer) writeHeader(wr io.Writer) error {
 		return fmt.Errorf("could not write header: %v", err)
 	}
 
+	if w.original != nil {
+		bf.Write(w.original.headerExtra)
+	}
+
 	_, err = ch.Write(bf.Bytes())
 	if err != nil {
 		w.printf("ERROR: could not write header: %v", err)
@@ -218,40 +222,67 @@ func (w *writer) writeChunkTo(wr io.Writer) (err error) {
 	return
 }
 
-func (w *writer) appendToChunk(deltaTime uint32, b []byte) {
-	w.currentChunk.Write(append(vlq.VlqEncode(deltaTime), b...))
+// writeUnknownChunks writes the preserved unknown chunks that followed the given number of track chunks.
+// For the last track, all remaining chunks are written.
+func (w *writer) writeUnknownChunks(afterTracks int) error {
+	if w.original == nil {
+		return nil
+	}
+
+	isLast := afterTracks == len(w.Tracks)
+
+	for _, raw := range w.original.chunks {
+		if raw.afterTracks != afterTracks && !(isLast && raw.afterTracks > afterTracks) {
+			continue
+		}
+
+		var ch chunk
+		var typ [4]byte
+		copy(typ[:], raw.Type)
+		ch.SetType(typ)
+		ch.Write(raw.Data)
+
+		w.printf("write unknown chunk %q", raw.Type)
+		_, err := ch.WriteTo(w.output)
+		if err != nil {
+			w.printf("ERROR: could not write chunk %q: %v", raw.Type, err)
+			return fmt.Errorf("could not write chunk %q: %v", raw.Type, err)
+		}
+	}
+
+	return nil
 }
 
 // delta is distance in time to last event in this track (independent of the channel)
 func (w *writer) addMessage(deltaTime uint32, raw Message) {
 	w.absPos += uint64(deltaTime)
+	w.currentChunk.Write(encodeEvent(w.runningWriter, deltaTime, raw))
+}
+
+// encodeEvent returns the bytes of an event within a track chunk.
+// If runningWriter is nil, no running status is used.
+func encodeEvent(runningWriter runningstatus.SMFWriter, deltaTime uint32, raw Message) []byte {
+	b := vlq.VlqEncode(deltaTime)
 
 	isSysEx := raw[0] == 0xF0 || raw[0] == 0xF7
 	if isSysEx {
 		// we have some sort of sysex, so we need to
 		// calculate the length of msg[1:]
 		// set msg to msg[0] + length of msg[1:] + msg[1:]
-		if w.runningWriter != nil {
-			w.runningWriter.ResetStatus()
+		if runningWriter != nil {
+			runningWriter.ResetStatus()
 		}
 
-		//if sys, ok := msg.(sysex.Message); ok {
-		b := []byte{raw[0]}
+		b = append(b, raw[0])
 		b = append(b, vlq.VlqEncode(uint32(len(raw)-1))...)
-		if len(raw[1:]) != 0 {
-			b = append(b, raw[1:]...)
-		}
-
-		w.appendToChunk(deltaTime, b)
-		return
+		return append(b, raw[1:]...)
 	}
 
-	if w.runningWriter != nil {
-		w.appendToChunk(deltaTime, w.runningWriter.Write(raw))
-		return
+	if runningWriter != nil {
+		return append(b, runningWriter.Write(raw)...)
 	}
 
-	w.appendToChunk(deltaTime, raw)
+	return append(b, raw...)
 }
 
 /*
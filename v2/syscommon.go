@@ -31,11 +31,11 @@ func Tune() Message {
 	return []byte{byteSysTuneRequest}
 }
 
-// SPP returns a song position pointer message
+// SPP returns a song position pointer message. The pointer counts sixteenth notes and is sent LSB first.
 func SPP(pointer uint16) Message {
 	var b = make([]byte, 2)
-	b[1] = byte(pointer & 0x7F)
-	b[0] = byte((pointer >> 7) & 0x7F)
+	b[0] = byte(pointer & 0x7F)
+	b[1] = byte((pointer >> 7) & 0x7F)
 	//return NewMessage([]byte{byteSysSongPositionPointer, b[0], b[1]})
 	return []byte{byteSysSongPositionPointer, b[0], b[1]}
 }
@@ -1,6 +1,7 @@
 package midi_test
 
 import (
+	"fmt"
 	"testing"
 
 	"gitlab.com/gomidi/midi/v2"
@@ -44,3 +45,27 @@ func TestSysCommon(t *testing.T) {
 
 	}
 }
+
+func TestSPPBytes(t *testing.T) {
+	tests := []struct {
+		pointer  uint16
+		expected string
+	}{
+		{1, "F2 01 00"},
+		{128, "F2 00 01"},
+		{4000, "F2 20 1F"},
+	}
+
+	for _, test := range tests {
+		msg := midi.SPP(test.pointer)
+
+		if got := fmt.Sprintf("% X", []byte(msg)); got != test.expected {
+			t.Errorf("SPP(%v) = %s; want %s", test.pointer, got, test.expected)
+		}
+
+		var spp uint16
+		if !msg.GetSPP(&spp) || spp != test.pointer {
+			t.Errorf("GetSPP() of %s = %v; want %v", test.expected, spp, test.pointer)
+		}
+	}
+}
@@ -1,11 +1,17 @@
 package sysex
 
-import "bytes"
+import (
+	"bytes"
+	"fmt"
+)
 
+// NonRealtime is a universal non-realtime system exclusive message.
+// Data are the bytes following the sub IDs (without the 0xF7).
 type NonRealtime struct {
 	Channel byte
 	SubID1  byte
 	SubID2  byte
+	Data    []byte
 }
 
 func (r NonRealtime) SysEx() []byte {
@@ -16,23 +22,24 @@ func (r NonRealtime) SysEx() []byte {
 	bf.WriteByte(r.Channel)
 	bf.WriteByte(r.SubID1)
 	bf.WriteByte(r.SubID2)
+	bf.Write(r.Data)
 
 	bf.WriteByte(0xF7)
 	return bf.Bytes()
 
 }
 
+func (r NonRealtime) String() string {
+	return fmt.Sprintf("NonRealtime channel: %v sub IDs: %02X %02X data: % X", r.Channel, r.SubID1, r.SubID2, r.Data)
+}
+
 func GMSystem(channel byte, enable bool) []byte {
-	var n NonRealtime
-	n.Channel = channel
-	n.SubID1 = 0x09
+	mode := GMOff
 	if enable {
-		n.SubID2 = 0x01
-	} else {
-		n.SubID2 = 0x00
+		mode = GM1On
 	}
 
-	return n.SysEx()
+	return GeneralMIDI{Channel: channel, Mode: mode}.SysEx()
 }
 
 /*
@@ -50,7 +57,8 @@ GM-compliant mode when desirable.
 0x7F  The SysEx channel. Could be from 0x00 to 0x7F.
       Here we set it to "disregard channel".
 0x09  Sub-ID -- GM System Enable/Disable
-0xNN  Sub-ID2 -- NN=00 for disable, NN=01 for enable
+0xNN  Sub-ID2 -- NN=01 for GM1 enable, NN=02 for disable, NN=03 for GM2 enable
+      (some older devices use NN=00 for disable)
 0xF7  End of SysEx
 
 It is best to respond as quickly as possible to this message, and to be ready to accept incoming note (and other)
@@ -65,6 +73,8 @@ While GM mode is enabled, a device should also ignore Bank Select messages (sinc
 bank of patches). Only when the GM Disable message is received (with Sub-ID2 = 0 to disable GM mode) will a device
 then respond to Bank Select messages (and knock itself out of GM mode).
 
+GM Level 2 devices are enabled with Sub-ID2 = 3 and then respond to Bank Select messages.
+
 */
 
 func IdentityRequest(channel byte) []byte {
@@ -1,11 +1,17 @@
 package sysex
 
-import "bytes"
+import (
+	"bytes"
+	"fmt"
+)
 
+// Realtime is a universal realtime system exclusive message.
+// Data are the bytes following the sub IDs (without the 0xF7).
 type Realtime struct {
 	Channel byte
 	SubID1  byte
 	SubID2  byte
+	Data    []byte
 }
 
 func (r Realtime) SysEx() []byte {
@@ -16,24 +22,36 @@ func (r Realtime) SysEx() []byte {
 	bf.WriteByte(r.Channel)
 	bf.WriteByte(r.SubID1)
 	bf.WriteByte(r.SubID2)
+	bf.Write(r.Data)
 
 	bf.WriteByte(0xF7)
 	return bf.Bytes()
 }
 
+func (r Realtime) String() string {
+	return fmt.Sprintf("Realtime channel: %v sub IDs: %02X %02X data: % X", r.Channel, r.SubID1, r.SubID2, r.Data)
+}
+
 const EveryChannel = 0x7F
 
+// MasterVolume returns the master volume message for the given 14-bit volume.
 func MasterVolume(channel byte, vol uint16) []byte {
-	var r Realtime
-	r.Channel = channel
-	r.SubID1 = 0x04
-	r.SubID2 = 0x01
+	return DeviceControl{Channel: channel, Control: ControlMasterVolume, Value: vol}.SysEx()
+}
 
-	/*
-		TODO: parse the bits 0 to 6 and 7 to 13 of a 14-bit volume
-	*/
+// MasterBalance returns the master balance message for the given 14-bit balance (0x2000 is the center).
+func MasterBalance(channel byte, balance uint16) []byte {
+	return DeviceControl{Channel: channel, Control: ControlMasterBalance, Value: balance}.SysEx()
+}
+
+// MasterFineTuning returns the master fine tuning message for the given tuning in cents (-100 to 100).
+func MasterFineTuning(channel byte, cents float64) []byte {
+	return DeviceControl{Channel: channel, Control: ControlMasterFineTuning, Value: fineTuning(cents)}.SysEx()
+}
 
-	return r.SysEx()
+// MasterCoarseTuning returns the master coarse tuning message for the given tuning in semitones (-64 to 63).
+func MasterCoarseTuning(channel byte, semitones int8) []byte {
+	return DeviceControl{Channel: channel, Control: ControlMasterCoarseTuning, Value: coarseTuning(semitones)}.SysEx()
 }
 
 /*
@@ -0,0 +1,331 @@
+package sysex
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Scale is a scale of a Scala scale file (.scl).
+// Cents are the pitches of the scale degrees 1 to n, relative to the degree 0 (the tonic). The last pitch is the
+// interval of the formal octave (the period) of the scale.
+type Scale struct {
+	Description string
+	Cents       []float64
+}
+
+// KeyboardMapping is a Scala keyboard mapping (.kbm) that maps the MIDI keys to the degrees of a scale.
+// The mapping is repeated every len(Map) keys and shifted by OctaveDegree scale degrees. Map entries of -1 are unmapped
+// keys. If Map is empty, the keys are mapped linearly to the scale degrees.
+// The key Middle is mapped to the scale degree 0 and the key Reference has the given Frequency (in Hz).
+type KeyboardMapping struct {
+	First        byte
+	Last         byte
+	Middle       byte
+	Reference    byte
+	Frequency    float64
+	OctaveDegree int
+	Map          []int
+}
+
+// DefaultKeyboardMapping is the linear keyboard mapping of Scala with the middle key 60 and A4 (key 69) = 440 Hz.
+var DefaultKeyboardMapping = KeyboardMapping{
+	First:     0,
+	Last:      127,
+	Middle:    60,
+	Reference: 69,
+	Frequency: 440,
+}
+
+// scalaLines returns the lines of a Scala file without the comments
+func scalaLines(rd io.Reader) ([]string, error) {
+	var lines []string
+	sc := bufio.NewScanner(rd)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if strings.HasPrefix(line, "!") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, sc.Err()
+}
+
+// firstField returns the first field of the line
+func firstField(line string) string {
+	f := strings.Fields(line)
+	if len(f) == 0 {
+		return ""
+	}
+	return f[0]
+}
+
+// ReadScale reads a Scala scale (.scl) from the given reader.
+func ReadScale(rd io.Reader) (*Scale, error) {
+	lines, err := scalaLines(rd)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(lines) < 2 {
+		return nil, fmt.Errorf("missing number of notes in scala file")
+	}
+
+	var s Scale
+	s.Description = strings.TrimSpace(lines[0])
+
+	n, err := strconv.Atoi(firstField(lines[1]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid number of notes in scala file: %v", err)
+	}
+
+	lines = lines[2:]
+	if len(lines) < n {
+		return nil, fmt.Errorf("scala file has %v of %v notes", len(lines), n)
+	}
+
+	for _, line := range lines[:n] {
+		c, err := parsePitch(firstField(line))
+		if err != nil {
+			return nil, err
+		}
+		s.Cents = append(s.Cents, c)
+	}
+
+	return &s, nil
+}
+
+// parsePitch parses a pitch of a scala file, which is either given in cents (containing a period) or as ratio
+func parsePitch(p string) (float64, error) {
+	if strings.Contains(p, ".") {
+		c, err := strconv.ParseFloat(p, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid pitch %q in scala file", p)
+		}
+		return c, nil
+	}
+
+	num, denom := p, "1"
+	if i := strings.Index(p, "/"); i >= 0 {
+		num, denom = p[:i], p[i+1:]
+	}
+
+	n, err1 := strconv.ParseUint(num, 10, 64)
+	d, err2 := strconv.ParseUint(denom, 10, 64)
+	if err1 != nil || err2 != nil || n == 0 || d == 0 {
+		return 0, fmt.Errorf("invalid pitch %q in scala file", p)
+	}
+
+	return 1200 * math.Log2(float64(n)/float64(d)), nil
+}
+
+// ReadScaleFile reads the Scala scale file (.scl) with the given path.
+func ReadScaleFile(file string) (*Scale, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadScale(f)
+}
+
+// ReadKeyboardMapping reads a Scala keyboard mapping (.kbm) from the given reader.
+func ReadKeyboardMapping(rd io.Reader) (*KeyboardMapping, error) {
+	lines, err := scalaLines(rd)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []string
+	for _, line := range lines {
+		if f := firstField(line); f != "" {
+			fields = append(fields, f)
+		}
+	}
+
+	if len(fields) < 7 {
+		return nil, fmt.Errorf("keyboard mapping too short")
+	}
+
+	var ints [7]int
+	for i, f := range fields[:7] {
+		if i == 5 {
+			continue
+		}
+		ints[i], err = strconv.Atoi(f)
+		if err != nil || (i >= 1 && i <= 4 && (ints[i] < 0 || ints[i] > 127)) {
+			return nil, fmt.Errorf("invalid value %q in keyboard mapping", f)
+		}
+	}
+
+	var m KeyboardMapping
+	m.First, m.Last, m.Middle, m.Reference = byte(ints[1]), byte(ints[2]), byte(ints[3]), byte(ints[4])
+	m.OctaveDegree = ints[6]
+
+	m.Frequency, err = strconv.ParseFloat(fields[5], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid frequency %q in keyboard mapping", fields[5])
+	}
+
+	size := ints[0]
+	fields = fields[7:]
+	if len(fields) > size {
+		fields = fields[:size]
+	}
+
+	// missing entries at the end are unmapped
+	m.Map = make([]int, size)
+	for i := range m.Map {
+		m.Map[i] = -1
+		if i >= len(fields) || fields[i] == "x" {
+			continue
+		}
+		m.Map[i], err = strconv.Atoi(fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid mapping %q in keyboard mapping", fields[i])
+		}
+	}
+
+	return &m, nil
+}
+
+// ReadKeyboardMappingFile reads the Scala keyboard mapping file (.kbm) with the given path.
+func ReadKeyboardMappingFile(file string) (*KeyboardMapping, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadKeyboardMapping(f)
+}
+
+// degree returns the scale degree of the given key and false, if the key is unmapped
+func (m KeyboardMapping) degree(key int) (int, bool) {
+	d := key - int(m.Middle)
+	if len(m.Map) == 0 {
+		return d, true
+	}
+
+	size := len(m.Map)
+	octave, idx := floorDiv(d, size)
+	if m.Map[idx] < 0 {
+		return 0, false
+	}
+	return octave*m.OctaveDegree + m.Map[idx], true
+}
+
+// cents returns the pitch of the given scale degree in cents
+func (s Scale) cents(degree int) float64 {
+	n := len(s.Cents)
+	octave, idx := floorDiv(degree, n)
+	c := float64(octave) * s.Cents[n-1]
+	if idx > 0 {
+		c += s.Cents[idx-1]
+	}
+	return c
+}
+
+func floorDiv(a, b int) (q, r int) {
+	q, r = a/b, a%b
+	if r < 0 {
+		q--
+		r += b
+	}
+	return
+}
+
+// TuningTable is the frequency (in Hz) of each MIDI key. Keys with a frequency of 0 are not retuned.
+type TuningTable [128]float64
+
+// EqualTemperament returns the tuning table of the 12 tone equal temperament with A4 (key 69) = 440 Hz.
+func EqualTemperament() (t TuningTable) {
+	for i := range t {
+		t[i] = 440 * math.Pow(2, float64(i-69)/12)
+	}
+	return
+}
+
+// NewTuningTable returns the tuning table for the given scale and keyboard mapping.
+// If the mapping is nil, the DefaultKeyboardMapping is used. Unmapped keys and keys outside
+// of the range of the mapping are not retuned.
+func NewTuningTable(s *Scale, m *KeyboardMapping) (t TuningTable) {
+	if m == nil {
+		m = &DefaultKeyboardMapping
+	}
+
+	if len(s.Cents) == 0 {
+		return
+	}
+
+	mp := *m
+	if mp.OctaveDegree == 0 {
+		mp.OctaveDegree = len(s.Cents)
+	}
+
+	ref, ok := mp.degree(int(mp.Reference))
+	if !ok {
+		// use the position of the reference key, if it is unmapped
+		octave, idx := floorDiv(int(mp.Reference)-int(mp.Middle), len(mp.Map))
+		ref = octave*mp.OctaveDegree + idx
+	}
+	refCents := s.cents(ref)
+
+	for key := int(mp.First); key <= int(mp.Last) && key < 128; key++ {
+		d, ok := mp.degree(key)
+		if !ok {
+			continue
+		}
+		t[key] = mp.Frequency * math.Pow(2, (s.cents(d)-refCents)/1200)
+	}
+	return
+}
+
+// Tunings returns the NoteTunings of the keys.
+func (t TuningTable) Tunings() (res [128]NoteTuning) {
+	for i, hz := range t {
+		res[i] = NoteTuningFromHz(hz)
+	}
+	return
+}
+
+// BulkDump returns the bulk tuning dump of the table for the given tuning program.
+func (t TuningTable) BulkDump(channel, program byte, name string) BulkTuningDump {
+	return BulkTuningDump{Channel: channel, Program: program, Name: name, Notes: t.Tunings()}
+}
+
+// NoteChanges returns the single note tuning changes of the table for the given tuning bank and program.
+// Only keys that are retuned are included. Since a single message can hold up to 127 changes,
+// the changes are split into multiple messages if needed.
+func (t TuningTable) NoteChanges(channel byte, realtime bool, bank, program byte) (res []SingleNoteTuning) {
+	var changes []NoteChange
+	for i, n := range t.Tunings() {
+		if n != NoTuningChange {
+			changes = append(changes, NoteChange{Key: byte(i), Tuning: n})
+		}
+	}
+
+	for len(changes) > 0 {
+		n := min(len(changes), maxNoteChanges)
+		res = append(res, SingleNoteTuning{Channel: channel, Realtime: realtime, HasBank: true, Bank: bank, Program: program, Changes: changes[:n]})
+		changes = changes[n:]
+	}
+	return
+}
+
+// ScaleOctave returns the scale/octave tuning of the table, based on the keys 60 to 71 (C4 to B4) for the given
+// MIDI channels (bit mask). Keys that are not retuned get a deviation of 0.
+func (t TuningTable) ScaleOctave(channel byte, realtime, twoByte bool, channels uint16) ScaleOctaveTuning {
+	s := ScaleOctaveTuning{Channel: channel, Realtime: realtime, TwoByte: twoByte, Channels: channels}
+	for i := range s.Cents {
+		hz := t[60+i]
+		if hz <= 0 {
+			continue
+		}
+		s.Cents[i] = 1200*math.Log2(hz/440) - float64(60+i-69)*100
+	}
+	return s
+}
@@ -0,0 +1,146 @@
+package sysex
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const justScale = `! just.scl
+!
+Just intonation major
+ 7
+!
+ 9/8
+ 5/4
+ 4/3   fourth
+ 3/2
+ 5/3
+ 1880.0 ! not a valid cent value within the octave, but allowed
+ 2/1
+`
+
+const whiteKeys = `! white.kbm
+! size of map
+12
+! first and last key
+0
+127
+! middle key
+60
+! reference key and frequency
+69
+440.0
+! formal octave
+7
+! mapping
+0
+x
+1
+x
+2
+3
+x
+4
+x
+5
+x
+6
+`
+
+func TestReadScale(t *testing.T) {
+	s, err := ReadScale(strings.NewReader(justScale))
+	if err != nil {
+		t.Fatalf("ReadScale() returned error: %v", err)
+	}
+
+	if s.Description != "Just intonation major" {
+		t.Errorf("Description = %q", s.Description)
+	}
+
+	if len(s.Cents) != 7 {
+		t.Fatalf("len(Cents) = %v; expected 7", len(s.Cents))
+	}
+
+	if math.Abs(s.Cents[4]-884.3587) > 0.0001 || s.Cents[5] != 1880 || s.Cents[6] != 1200 {
+		t.Errorf("Cents = %v", s.Cents)
+	}
+
+	_, err = ReadScale(strings.NewReader("invalid\n 2\n 100.0\n"))
+	if err == nil {
+		t.Errorf("ReadScale() with missing notes must return an error")
+	}
+}
+
+func TestReadKeyboardMapping(t *testing.T) {
+	m, err := ReadKeyboardMapping(strings.NewReader(whiteKeys))
+	if err != nil {
+		t.Fatalf("ReadKeyboardMapping() returned error: %v", err)
+	}
+
+	expected := KeyboardMapping{
+		First: 0, Last: 127, Middle: 60, Reference: 69, Frequency: 440, OctaveDegree: 7,
+		Map: []int{0, -1, 1, -1, 2, 3, -1, 4, -1, 5, -1, 6},
+	}
+
+	if !reflect.DeepEqual(*m, expected) {
+		t.Errorf("ReadKeyboardMapping() = %v; expected %v", *m, expected)
+	}
+}
+
+func TestTuningTable(t *testing.T) {
+	s, _ := ReadScale(strings.NewReader(justScale))
+	s.Cents[5] = 1200 * math.Log2(15.0/8)
+	m, _ := ReadKeyboardMapping(strings.NewReader(whiteKeys))
+
+	table := NewTuningTable(s, m)
+
+	tests := map[int]float64{
+		60: 264,
+		61: 0,
+		62: 297,
+		64: 330,
+		65: 352,
+		67: 396,
+		69: 440,
+		71: 495,
+		72: 528,
+		48: 132,
+	}
+
+	for key, hz := range tests {
+		if math.Abs(table[key]-hz) > 0.0001 {
+			t.Errorf("table[%v] = %v; expected %v", key, table[key], hz)
+		}
+	}
+
+	changes := table.NoteChanges(0x7F, true, 0, 1)
+	if len(changes) != 1 || len(changes[0].Changes) != 75 {
+		t.Errorf("NoteChanges() returned %v messages; expected 1 with 75 changes", len(changes))
+	}
+
+	changes = EqualTemperament().NoteChanges(0x7F, true, 0, 1)
+	if len(changes) != 2 || len(changes[0].Changes) != 127 || len(changes[1].Changes) != 1 || changes[1].Changes[0].Key != 127 {
+		t.Errorf("NoteChanges() of equal temperament = %v; expected 2 messages with 127 and 1 changes", changes)
+	}
+	for i, c := range changes {
+		if _, err := ParseUniversal(c.SysEx()); err != nil {
+			t.Errorf("[%v] ParseUniversal() of note changes returned error: %v", i, err)
+		}
+	}
+
+	et := NewTuningTable(&Scale{Cents: []float64{100, 200, 300, 400, 500, 600, 700, 800, 900, 1000, 1100, 1200}}, nil)
+	if et != EqualTemperament() {
+		for i := range et {
+			if math.Abs(et[i]-EqualTemperament()[i]) > 0.0001 {
+				t.Errorf("equal temperament differs at key %v: %v", i, et[i])
+			}
+		}
+	}
+
+	so := table.ScaleOctave(0x7F, false, true, AllChannels)
+	if math.Abs(so.Cents[5]-13.6863) > 0.0001 || so.Cents[9] != 0 || so.Cents[1] != 0 {
+		t.Errorf("ScaleOctave().Cents = %v", so.Cents)
+	}
+}
@@ -0,0 +1,342 @@
+package sysex
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrChecksum is returned when parsing a data packet of a sample dump with an invalid checksum.
+var ErrChecksum = errors.New("invalid checksum")
+
+// sub IDs of the sample dump standard
+const (
+	subDumpHeader  = 0x01
+	subDataPacket  = 0x02
+	subDumpRequest = 0x03
+	subLoopPoints  = 0x05
+
+	subLoopPointTransmission = 0x01
+	subLoopPointRequest      = 0x02
+)
+
+// validBits reports whether the given sample format is within the range of 8 to 28 bits that is defined by the
+// sample dump standard.
+func validBits(bits byte) bool {
+	return bits >= 8 && bits <= 28
+}
+
+// The handshake types of the sample dump standard.
+const (
+	HandshakeEOF    byte = 0x7B
+	HandshakeWait   byte = 0x7C
+	HandshakeCancel byte = 0x7D
+	HandshakeNAK    byte = 0x7E
+	HandshakeACK    byte = 0x7F
+)
+
+// The loop types of the sample dump standard.
+const (
+	LoopForward     byte = 0x00
+	LoopAlternating byte = 0x01
+	LoopOff         byte = 0x7F
+)
+
+// DataPacketSize is the number of data bytes within a data packet of a sample dump.
+const DataPacketSize = 120
+
+// DumpHeader is the header of a sample dump (non-realtime, sub ID 01).
+// Period is the sample period in nanoseconds, Length, LoopStart and LoopEnd are given in words (samples).
+type DumpHeader struct {
+	Channel   byte
+	Sample    uint16
+	Bits      byte
+	Period    uint32
+	Length    uint32
+	LoopStart uint32
+	LoopEnd   uint32
+	LoopType  byte
+}
+
+// SampleRate returns the sample rate in Hz.
+func (h DumpHeader) SampleRate() float64 {
+	if h.Period == 0 {
+		return 0
+	}
+	return 1e9 / float64(h.Period)
+}
+
+// BytesPerWord returns the number of bytes that are needed to transmit a single sample.
+func (h DumpHeader) BytesPerWord() int {
+	return (int(h.Bits) + 6) / 7
+}
+
+// NumPackets returns the number of data packets that are needed to transmit the sample.
+func (h DumpHeader) NumPackets() int {
+	return (int(h.Length)*h.BytesPerWord() + DataPacketSize - 1) / DataPacketSize
+}
+
+func (h DumpHeader) SysEx() []byte {
+	data := append(septets(uint32(h.Sample), 2), h.Bits)
+	data = append(data, septets(h.Period, 3)...)
+	data = append(data, septets(h.Length, 3)...)
+	data = append(data, septets(h.LoopStart, 3)...)
+	data = append(data, septets(h.LoopEnd, 3)...)
+	data = append(data, h.LoopType)
+	return sdsMessage(h.Channel, subDumpHeader, data)
+}
+
+func (h DumpHeader) String() string {
+	return fmt.Sprintf("DumpHeader channel: %v sample: %v bits: %v period: %vns length: %v loop: %v-%v (%02X)",
+		h.Channel, h.Sample, h.Bits, h.Period, h.Length, h.LoopStart, h.LoopEnd, h.LoopType)
+}
+
+// DumpRequest requests the dump of a sample (non-realtime, sub ID 03).
+type DumpRequest struct {
+	Channel byte
+	Sample  uint16
+}
+
+func (d DumpRequest) SysEx() []byte {
+	return sdsMessage(d.Channel, subDumpRequest, septets(uint32(d.Sample), 2))
+}
+
+func (d DumpRequest) String() string {
+	return fmt.Sprintf("DumpRequest channel: %v sample: %v", d.Channel, d.Sample)
+}
+
+// DataPacket is a data packet of a sample dump (non-realtime, sub ID 02).
+// Number is the running packet number (0-127) and Data has DataPacketSize bytes.
+type DataPacket struct {
+	Channel byte
+	Number  byte
+	Data    [DataPacketSize]byte
+}
+
+// Checksum returns the checksum of the packet (the XOR of all bytes between 0xF0 and the checksum).
+func (d DataPacket) Checksum() byte {
+	sum := byte(NonRealTimeID) ^ d.Channel ^ subDataPacket ^ d.Number
+	for _, b := range d.Data {
+		sum ^= b
+	}
+	return sum & 0x7F
+}
+
+func (d DataPacket) SysEx() []byte {
+	data := append([]byte{d.Number}, d.Data[:]...)
+	return sdsMessage(d.Channel, subDataPacket, append(data, d.Checksum()))
+}
+
+func (d DataPacket) String() string {
+	return fmt.Sprintf("DataPacket channel: %v number: %v", d.Channel, d.Number)
+}
+
+// Handshake is a handshake message of a sample dump (non-realtime, sub IDs 7B-7F).
+// Type is one of the Handshake... constants and Packet the number of the packet it refers to.
+type Handshake struct {
+	Channel byte
+	Type    byte
+	Packet  byte
+}
+
+func (h Handshake) SysEx() []byte {
+	return sdsMessage(h.Channel, h.Type, []byte{h.Packet})
+}
+
+func (h Handshake) String() string {
+	names := map[byte]string{
+		HandshakeEOF:    "EOF",
+		HandshakeWait:   "WAIT",
+		HandshakeCancel: "CANCEL",
+		HandshakeNAK:    "NAK",
+		HandshakeACK:    "ACK",
+	}
+	return fmt.Sprintf("Handshake channel: %v type: %s packet: %v", h.Channel, names[h.Type], h.Packet)
+}
+
+// LoopPoint sets a loop of a sample (non-realtime, sub IDs 05 01).
+// Start and End are given in words.
+type LoopPoint struct {
+	Channel byte
+	Sample  uint16
+	Loop    uint16
+	Type    byte
+	Start   uint32
+	End     uint32
+}
+
+func (l LoopPoint) SysEx() []byte {
+	data := append([]byte{subLoopPointTransmission}, septets(uint32(l.Sample), 2)...)
+	data = append(data, septets(uint32(l.Loop), 2)...)
+	data = append(data, l.Type)
+	data = append(data, septets(l.Start, 3)...)
+	data = append(data, septets(l.End, 3)...)
+	return sdsMessage(l.Channel, subLoopPoints, data)
+}
+
+func (l LoopPoint) String() string {
+	return fmt.Sprintf("LoopPoint channel: %v sample: %v loop: %v type: %02X %v-%v",
+		l.Channel, l.Sample, l.Loop, l.Type, l.Start, l.End)
+}
+
+// LoopPointRequest requests a loop of a sample (non-realtime, sub IDs 05 02).
+type LoopPointRequest struct {
+	Channel byte
+	Sample  uint16
+	Loop    uint16
+}
+
+func (l LoopPointRequest) SysEx() []byte {
+	data := append([]byte{subLoopPointRequest}, septets(uint32(l.Sample), 2)...)
+	return sdsMessage(l.Channel, subLoopPoints, append(data, septets(uint32(l.Loop), 2)...))
+}
+
+func (l LoopPointRequest) String() string {
+	return fmt.Sprintf("LoopPointRequest channel: %v sample: %v loop: %v", l.Channel, l.Sample, l.Loop)
+}
+
+// sdsMessage returns the non-realtime message with the given sub ID, followed by the data
+func sdsMessage(channel, subID byte, data []byte) []byte {
+	return NonRealtime{Channel: channel, SubID1: subID, SubID2: data[0], Data: data[1:]}.SysEx()
+}
+
+// septets returns the given value as n 7-bit bytes, least significant first
+func septets(v uint32, n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(v>>(7*i)) & 0x7F
+	}
+	return b
+}
+
+// fromSeptets returns the value of the given 7-bit bytes, least significant first
+func fromSeptets(b []byte) (v uint32) {
+	for i, x := range b {
+		v |= uint32(x&0x7F) << (7 * i)
+	}
+	return
+}
+
+// parseSDS parses the sample dump standard messages. data starts with the byte after the sub ID.
+func parseSDS(channel, subID byte, data []byte) (Universal, error) {
+	wrongLength := func(name string) error {
+		return fmt.Errorf("invalid length of %s: %v bytes", name, len(data))
+	}
+
+	switch subID {
+	case subDumpHeader:
+		if len(data) != 16 {
+			return nil, wrongLength("dump header")
+		}
+		if !validBits(data[2]) {
+			return nil, fmt.Errorf("invalid sample format: %v bits", data[2])
+		}
+		return DumpHeader{
+			Channel:   channel,
+			Sample:    uint16(fromSeptets(data[0:2])),
+			Bits:      data[2],
+			Period:    fromSeptets(data[3:6]),
+			Length:    fromSeptets(data[6:9]),
+			LoopStart: fromSeptets(data[9:12]),
+			LoopEnd:   fromSeptets(data[12:15]),
+			LoopType:  data[15],
+		}, nil
+	case subDataPacket:
+		if len(data) != DataPacketSize+2 {
+			return nil, wrongLength("data packet")
+		}
+		p := DataPacket{Channel: channel, Number: data[0]}
+		copy(p.Data[:], data[1:])
+		if p.Checksum() != data[DataPacketSize+1] {
+			return nil, ErrChecksum
+		}
+		return p, nil
+	case subDumpRequest:
+		if len(data) != 2 {
+			return nil, wrongLength("dump request")
+		}
+		return DumpRequest{Channel: channel, Sample: uint16(fromSeptets(data))}, nil
+	case subLoopPoints:
+		switch {
+		case len(data) == 12 && data[0] == subLoopPointTransmission:
+			return LoopPoint{
+				Channel: channel,
+				Sample:  uint16(fromSeptets(data[1:3])),
+				Loop:    uint16(fromSeptets(data[3:5])),
+				Type:    data[5],
+				Start:   fromSeptets(data[6:9]),
+				End:     fromSeptets(data[9:12]),
+			}, nil
+		case len(data) == 5 && data[0] == subLoopPointRequest:
+			return LoopPointRequest{
+				Channel: channel,
+				Sample:  uint16(fromSeptets(data[1:3])),
+				Loop:    uint16(fromSeptets(data[3:5])),
+			}, nil
+		}
+	case HandshakeEOF, HandshakeWait, HandshakeCancel, HandshakeNAK, HandshakeACK:
+		if len(data) != 1 {
+			return nil, wrongLength("handshake")
+		}
+		return Handshake{Channel: channel, Type: subID, Packet: data[0]}, nil
+	}
+
+	return nil, nil
+}
+
+// EncodeSamples encodes the given signed samples with the given bit resolution to the bytes of the data packets
+// of a sample dump. Each sample is sent as an unsigned, left justified word of 7-bit bytes.
+// It returns nil, if bits is outside of the range of 8 to 28.
+func EncodeSamples(bits byte, samples []int32) []byte {
+	if !validBits(bits) {
+		return nil
+	}
+	n := (int(bits) + 6) / 7
+	shift := uint(n*7) - uint(bits)
+	offset := int64(1) << (bits - 1)
+
+	res := make([]byte, 0, len(samples)*n)
+	for _, s := range samples {
+		v := uint32(int64(s)+offset) << shift
+		for i := n - 1; i >= 0; i-- {
+			res = append(res, byte(v>>(7*uint(i)))&0x7F)
+		}
+	}
+	return res
+}
+
+// DecodeSamples decodes the given bytes of the data packets of a sample dump to signed samples
+// of the given bit resolution. Incomplete words at the end are ignored.
+// It returns nil, if bits is outside of the range of 8 to 28.
+func DecodeSamples(bits byte, data []byte) []int32 {
+	if !validBits(bits) {
+		return nil
+	}
+	n := (int(bits) + 6) / 7
+	shift := uint(n*7) - uint(bits)
+	offset := int64(1) << (bits - 1)
+
+	res := make([]int32, 0, len(data)/n)
+	for len(data) >= n {
+		var v uint32
+		for _, b := range data[:n] {
+			v = v<<7 | uint32(b&0x7F)
+		}
+		res = append(res, int32(int64(v>>shift)-offset))
+		data = data[n:]
+	}
+	return res
+}
+
+// DataPackets returns the data packets for the given header and signed samples.
+func DataPackets(h DumpHeader, samples []int32) []DataPacket {
+	data := EncodeSamples(h.Bits, samples)
+
+	var packets []DataPacket
+	for i := 0; len(data) > 0; i++ {
+		p := DataPacket{Channel: h.Channel, Number: byte(i % 128)}
+		n := copy(p.Data[:], data)
+		data = data[n:]
+		packets = append(packets, p)
+	}
+	return packets
+}
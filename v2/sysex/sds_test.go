@@ -0,0 +1,329 @@
+package sysex
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/drivers"
+	"gitlab.com/gomidi/midi/v2/drivers/testdrv"
+)
+
+func TestSDSMessages(t *testing.T) {
+	p := DataPacket{Channel: 1, Number: 5}
+	for i := range p.Data {
+		p.Data[i] = byte(i)
+	}
+
+	tests := []Universal{
+		DumpHeader{Channel: 1, Sample: 300, Bits: 16, Period: 22675, Length: 100000, LoopStart: 10, LoopEnd: 99999, LoopType: LoopForward},
+		DumpRequest{Channel: 0x7F, Sample: 2},
+		p,
+		Handshake{Channel: 1, Type: HandshakeACK, Packet: 5},
+		Handshake{Channel: 1, Type: HandshakeWait, Packet: 6},
+		LoopPoint{Channel: 1, Sample: 2, Loop: 3, Type: LoopAlternating, Start: 1000, End: 2000},
+		LoopPointRequest{Channel: 1, Sample: 2, Loop: 0x3FFF},
+	}
+
+	for i, test := range tests {
+		sx := test.SysEx()
+
+		got, err := ParseUniversal(sx)
+		if err != nil {
+			t.Errorf("[%v] ParseUniversal(% X) returned error: %v", i, sx, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, test) {
+			t.Errorf("[%v] ParseUniversal(% X) = %#v; expected %#v", i, sx, got, test)
+		}
+	}
+
+	sx := p.SysEx()
+	if len(sx) != 127 {
+		t.Errorf("len(DataPacket.SysEx()) = %v; expected 127", len(sx))
+	}
+
+	sx[10] ^= 0x01
+	if _, err := ParseUniversal(sx); err != ErrChecksum {
+		t.Errorf("ParseUniversal() of corrupted packet returned %v; expected %v", err, ErrChecksum)
+	}
+
+	for _, bits := range []byte{0, 7, 29} {
+		sx := DumpHeader{Channel: 1, Bits: bits}.SysEx()
+		if _, err := ParseUniversal(sx); err == nil {
+			t.Errorf("ParseUniversal() of dump header with %v bits returned no error", bits)
+		}
+	}
+}
+
+func TestEncodeSamples(t *testing.T) {
+	tests := []struct {
+		bits     byte
+		samples  []int32
+		expected string
+	}{
+		{8, []int32{-128, 0, 127}, "00 00 40 00 7F 40"},
+		{12, []int32{-2048, 0, 2047}, "00 00 40 00 7F 7C"},
+		{16, []int32{-32768, 0, 32767}, "00 00 00 40 00 00 7F 7F 60"},
+		{24, []int32{-8388608, 1}, "00 00 00 00 40 00 00 10"},
+	}
+
+	for i, test := range tests {
+		got := EncodeSamples(test.bits, test.samples)
+
+		if s := fmt.Sprintf("% X", got); s != test.expected {
+			t.Errorf("[%v] EncodeSamples(%v, %v) = %s; expected %s", i, test.bits, test.samples, s, test.expected)
+		}
+
+		if dec := DecodeSamples(test.bits, got); !reflect.DeepEqual(dec, test.samples) {
+			t.Errorf("[%v] DecodeSamples() = %v; expected %v", i, dec, test.samples)
+		}
+	}
+
+	for _, bits := range []byte{0, 29} {
+		if got := EncodeSamples(bits, []int32{0}); got != nil {
+			t.Errorf("EncodeSamples(%v, ...) = % X; expected nil", bits, got)
+		}
+		if got := DecodeSamples(bits, []byte{0, 0, 0, 0, 0}); got != nil {
+			t.Errorf("DecodeSamples(%v, ...) = %v; expected nil", bits, got)
+		}
+	}
+}
+
+func sinus(n int) []int32 {
+	samples := make([]int32, n)
+	for i := range samples {
+		samples[i] = int32((i*97)%65536 - 32768)
+	}
+	return samples
+}
+
+// crossed returns the ports of two devices that are connected to each other
+func crossed() (inA drivers.In, outA drivers.Out, inB drivers.In, outB drivers.Out) {
+	a := testdrv.New("a")
+	b := testdrv.New("b")
+
+	aIns, _ := a.Ins()
+	aOuts, _ := a.Outs()
+	bIns, _ := b.Ins()
+	bOuts, _ := b.Outs()
+
+	return bIns[0], aOuts[0], aIns[0], bOuts[0]
+}
+
+func TestTransfer(t *testing.T) {
+	inA, outA, inB, outB := crossed()
+	samples := sinus(300)
+	h := DumpHeader{Channel: 2, Sample: 1, Bits: 16, Period: 20833, LoopType: LoopOff}
+
+	requested := make(chan DumpRequest, 1)
+
+	_, err := midi.ListenTo(inA, func(msg midi.Message, timestampms int32) {
+		var data []byte
+		msg.GetSysEx(&data)
+		if u, _ := ParseUniversal(data); u != nil {
+			if r, is := u.(DumpRequest); is {
+				requested <- r
+			}
+		}
+	}, midi.UseSysEx())
+
+	if err != nil {
+		t.Fatalf("ListenTo() returned error: %v", err)
+	}
+
+	var gotHeader DumpHeader
+	var got []int32
+	var receiveErr error
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		gotHeader, got, receiveErr = RequestSample(inB, outB, 2, 1, ReceiveTimeout(time.Second))
+	}()
+
+	select {
+	case r := <-requested:
+		if r.Sample != 1 {
+			t.Errorf("requested sample %v; expected 1", r.Sample)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no dump request received")
+	}
+
+	err = SendSample(inA, outA, h, samples, HandshakeTimeouts(time.Second, time.Second))
+	wg.Wait()
+
+	if err != nil {
+		t.Fatalf("SendSample() returned error: %v", err)
+	}
+
+	if receiveErr != nil {
+		t.Fatalf("RequestSample() returned error: %v", receiveErr)
+	}
+
+	h.Length = 300
+	if gotHeader != h {
+		t.Errorf("header = %v; expected %v", gotHeader, h)
+	}
+
+	if !reflect.DeepEqual(got, samples) {
+		t.Errorf("received samples differ")
+	}
+}
+
+func TestSendSampleHandshakes(t *testing.T) {
+	inA, outA, inB, outB := crossed()
+	samples := sinus(100)
+
+	err := outB.Open()
+	if err != nil {
+		t.Fatalf("Open() returned error: %v", err)
+	}
+
+	var mx, sendMx sync.Mutex
+	var packets []byte
+	nak := true
+
+	stop, err := midi.ListenTo(inB, func(msg midi.Message, timestampms int32) {
+		var data []byte
+		msg.GetSysEx(&data)
+		u, _ := ParseUniversal(data)
+
+		var reply Handshake
+
+		switch m := u.(type) {
+		case DumpHeader:
+			reply = Handshake{Channel: m.Channel, Type: HandshakeACK}
+		case DataPacket:
+			mx.Lock()
+			packets = append(packets, m.Number)
+			reply = Handshake{Channel: m.Channel, Type: HandshakeACK, Packet: m.Number}
+			if m.Number == 1 && nak {
+				nak = false
+				reply.Type = HandshakeNAK
+			}
+			if m.Number == 2 {
+				reply.Type = HandshakeCancel
+			}
+			mx.Unlock()
+		default:
+			return
+		}
+
+		// reply asynchronously, like a real device
+		go func() {
+			sendMx.Lock()
+			outB.Send(reply.SysEx())
+			sendMx.Unlock()
+		}()
+	}, midi.UseSysEx())
+	if err != nil {
+		t.Fatalf("ListenTo() returned error: %v", err)
+	}
+	defer stop()
+
+	err = SendSample(inA, outA, DumpHeader{Channel: 1, Bits: 16}, samples, HandshakeTimeouts(time.Second, time.Second))
+
+	if err != ErrCancelled {
+		t.Errorf("SendSample() returned %v; expected %v", err, ErrCancelled)
+	}
+
+	mx.Lock()
+	defer mx.Unlock()
+
+	if expected := []byte{0, 1, 1, 2}; !bytes.Equal(packets, expected) {
+		t.Errorf("sent packets = %v; expected %v", packets, expected)
+	}
+}
+
+func TestSendSampleNotAcknowledged(t *testing.T) {
+	inA, outA, inB, outB := crossed()
+
+	err := outB.Open()
+	if err != nil {
+		t.Fatalf("Open() returned error: %v", err)
+	}
+
+	var mx, sendMx sync.Mutex
+	var packets []byte
+
+	stop, err := midi.ListenTo(inB, func(msg midi.Message, timestampms int32) {
+		var data []byte
+		msg.GetSysEx(&data)
+		u, _ := ParseUniversal(data)
+
+		var reply Handshake
+
+		switch m := u.(type) {
+		case DumpHeader:
+			reply = Handshake{Channel: m.Channel, Type: HandshakeACK}
+		case DataPacket:
+			mx.Lock()
+			packets = append(packets, m.Number)
+			mx.Unlock()
+			reply = Handshake{Channel: m.Channel, Type: HandshakeNAK, Packet: m.Number}
+		default:
+			return
+		}
+
+		go func() {
+			sendMx.Lock()
+			outB.Send(reply.SysEx())
+			sendMx.Unlock()
+		}()
+	}, midi.UseSysEx())
+	if err != nil {
+		t.Fatalf("ListenTo() returned error: %v", err)
+	}
+	defer stop()
+
+	err = SendSample(inA, outA, DumpHeader{Channel: 1, Bits: 16}, sinus(100), HandshakeTimeouts(time.Second, time.Second), MaxRetries(2))
+
+	if !errors.Is(err, ErrNotAcknowledged) {
+		t.Errorf("SendSample() returned %v; expected %v", err, ErrNotAcknowledged)
+	}
+
+	mx.Lock()
+	defer mx.Unlock()
+
+	if expected := []byte{0, 0, 0}; !bytes.Equal(packets, expected) {
+		t.Errorf("sent packets = %v; expected %v", packets, expected)
+	}
+}
+
+func TestWriteWAV(t *testing.T) {
+	var bf bytes.Buffer
+	h := DumpHeader{Bits: 12, Period: 25000}
+
+	err := WriteWAV(&bf, h, []int32{-2048, 0, 1})
+	if err != nil {
+		t.Fatalf("WriteWAV() returned error: %v", err)
+	}
+
+	b := bf.Bytes()
+
+	if string(b[0:4]) != "RIFF" || string(b[8:16]) != "WAVEfmt " || string(b[36:40]) != "data" {
+		t.Fatalf("invalid WAV header: % X", b[:44])
+	}
+
+	if rate := binary.LittleEndian.Uint32(b[24:]); rate != 40000 {
+		t.Errorf("sample rate = %v; expected 40000", rate)
+	}
+
+	if bits := binary.LittleEndian.Uint16(b[34:]); bits != 16 {
+		t.Errorf("bits per sample = %v; expected 16", bits)
+	}
+
+	if data := fmt.Sprintf("% X", b[44:]); data != "00 80 00 00 10 00" {
+		t.Errorf("data = %s", data)
+	}
+}
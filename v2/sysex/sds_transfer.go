@@ -0,0 +1,349 @@
+package sysex
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/drivers"
+)
+
+var (
+	// ErrCancelled is returned, if the other side cancelled the sample dump.
+	ErrCancelled = errors.New("sample dump cancelled")
+
+	// ErrTimeout is returned, if the receiver of a sample dump got no message within the receive timeout.
+	ErrTimeout = errors.New("sample dump timed out")
+
+	// ErrNotAcknowledged is returned, if the receiver of a sample dump replied with NAK to every attempt to
+	// send a packet.
+	ErrNotAcknowledged = errors.New("sample dump packet not acknowledged")
+)
+
+// TransferOption is an option for sending and receiving sample dumps.
+type TransferOption func(*transferConfig)
+
+type transferConfig struct {
+	headerTimeout  time.Duration
+	packetTimeout  time.Duration
+	receiveTimeout time.Duration
+	maxRetries     int
+}
+
+// HandshakeTimeouts is an option to set the time the sender waits for a handshake after the dump header
+// (default: 2 seconds) and after each data packet (default: 20 milliseconds).
+// If no handshake is received in time, the sender continues (open loop).
+func HandshakeTimeouts(header, packet time.Duration) TransferOption {
+	return func(c *transferConfig) {
+		c.headerTimeout = header
+		c.packetTimeout = packet
+	}
+}
+
+// ReceiveTimeout is an option to set the time the receiver waits for the next message (default: 2 seconds).
+func ReceiveTimeout(d time.Duration) TransferOption {
+	return func(c *transferConfig) {
+		c.receiveTimeout = d
+	}
+}
+
+// MaxRetries is an option to set how often the sender resends a packet that is not acknowledged (default: 3).
+func MaxRetries(n int) TransferOption {
+	return func(c *transferConfig) {
+		c.maxRetries = n
+	}
+}
+
+func newTransferConfig(opts []TransferOption) *transferConfig {
+	c := &transferConfig{
+		headerTimeout:  2 * time.Second,
+		packetTimeout:  20 * time.Millisecond,
+		receiveTimeout: 2 * time.Second,
+		maxRetries:     3,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// received is a sample dump message that has been received.
+// For data packets with an invalid checksum, msg is nil and err is ErrChecksum.
+type received struct {
+	msg    Universal
+	err    error
+	packet byte
+}
+
+// transfer is the connection that is used by the sender and the receiver
+type transfer struct {
+	*transferConfig
+	out     drivers.Out
+	channel byte
+	ch      chan received
+	done    chan struct{}
+	stop    func()
+}
+
+func newTransfer(in drivers.In, out drivers.Out, channel byte, opts []TransferOption) (*transfer, error) {
+	t := &transfer{
+		transferConfig: newTransferConfig(opts),
+		out:            out,
+		channel:        channel,
+		ch:             make(chan received, 256),
+		done:           make(chan struct{}),
+	}
+
+	if !out.IsOpen() {
+		err := out.Open()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	stop, err := midi.ListenTo(in, t.receive, midi.UseSysEx())
+	if err != nil {
+		return nil, err
+	}
+
+	t.stop = stop
+	return t, nil
+}
+
+func (t *transfer) close() {
+	t.stop()
+	close(t.done)
+}
+
+func (t *transfer) receive(msg midi.Message, timestampms int32) {
+	var data []byte
+	if !msg.GetSysEx(&data) || len(data) < 4 || data[0] != byte(NonRealTimeID) {
+		return
+	}
+
+	if t.channel != EveryChannel && data[1] != t.channel {
+		return
+	}
+
+	u, err := ParseUniversal(data)
+	if err != nil && err != ErrChecksum {
+		return
+	}
+
+	select {
+	case t.ch <- received{msg: u, err: err, packet: data[3]}:
+	case <-t.done:
+	}
+}
+
+func (t *transfer) send(u Universal) error {
+	return t.out.Send(u.SysEx())
+}
+
+// wait waits for the next message until the timeout. If the timeout is 0, it waits forever.
+func (t *transfer) wait(timeout time.Duration) (r received, ok bool) {
+	if timeout == 0 {
+		return <-t.ch, true
+	}
+
+	select {
+	case r = <-t.ch:
+		return r, true
+	case <-time.After(timeout):
+		return r, false
+	}
+}
+
+// waitHandshake waits for a handshake for the given packet until the timeout.
+// Other messages and handshakes for other packets are ignored. After a WAIT handshake, it waits forever.
+func (t *transfer) waitHandshake(packet byte, timeout time.Duration) (h Handshake, ok bool) {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		left := time.Until(deadline)
+		if timeout == 0 {
+			left = 0
+		} else if left <= 0 {
+			return h, false
+		}
+
+		r, ok := t.wait(left)
+		if !ok {
+			return h, false
+		}
+
+		h, is := r.msg.(Handshake)
+		if !is {
+			continue
+		}
+
+		switch h.Type {
+		case HandshakeWait:
+			timeout = 0
+		case HandshakeCancel:
+			return h, true
+		default:
+			if h.Packet == packet {
+				return h, true
+			}
+		}
+	}
+}
+
+// SendSample sends the sample with the given header and signed samples via the sample dump standard.
+// It listens on the given in port for the handshakes of the receiver. If the receiver does not reply
+// in time, the dump is continued without handshakes (open loop). Packets that are not acknowledged
+// (NAK) are resent up to MaxRetries times. After a WAIT handshake the sender waits until the next handshake.
+// If the receiver cancels the dump, ErrCancelled is returned. If a packet is still not acknowledged after the
+// last retry, an error wrapping ErrNotAcknowledged is returned.
+// The Length of the header is set to the number of samples.
+func SendSample(in drivers.In, out drivers.Out, h DumpHeader, samples []int32, opts ...TransferOption) error {
+	t, err := newTransfer(in, out, h.Channel, opts)
+	if err != nil {
+		return err
+	}
+	defer t.close()
+
+	h.Length = uint32(len(samples))
+
+	err = t.sendAcknowledged(h, 0, t.headerTimeout)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range DataPackets(h, samples) {
+		err = t.sendAcknowledged(p, p.Number, t.packetTimeout)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sendAcknowledged sends the given message and resends it, if the receiver replies with NAK.
+// After the last NAK, it returns ErrNotAcknowledged.
+func (t *transfer) sendAcknowledged(u Universal, packet byte, timeout time.Duration) error {
+	for try := 0; try <= t.maxRetries; try++ {
+		err := t.send(u)
+		if err != nil {
+			return err
+		}
+
+		h, ok := t.waitHandshake(packet, timeout)
+		if !ok {
+			// open loop
+			return nil
+		}
+
+		switch h.Type {
+		case HandshakeCancel:
+			return ErrCancelled
+		case HandshakeNAK:
+			continue
+		default:
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: packet %v", ErrNotAcknowledged, packet)
+}
+
+// RequestSample sends a dump request for the given sample to the given channel and receives the sample dump.
+// See ReceiveSample.
+func RequestSample(in drivers.In, out drivers.Out, channel byte, sample uint16, opts ...TransferOption) (DumpHeader, []int32, error) {
+	t, err := newTransfer(in, out, channel, opts)
+	if err != nil {
+		return DumpHeader{}, nil, err
+	}
+	defer t.close()
+
+	err = t.send(DumpRequest{Channel: channel, Sample: sample})
+	if err != nil {
+		return DumpHeader{}, nil, err
+	}
+
+	return t.receiveSample()
+}
+
+// ReceiveSample waits for a sample dump on the given channel (EveryChannel for any channel) and returns the
+// dump header and the signed samples, which can be written with WriteWAV.
+// Each packet is acknowledged or, if the checksum is invalid, not acknowledged (NAK).
+// If no message is received within the ReceiveTimeout, the dump is cancelled and ErrTimeout is returned.
+func ReceiveSample(in drivers.In, out drivers.Out, channel byte, opts ...TransferOption) (DumpHeader, []int32, error) {
+	t, err := newTransfer(in, out, channel, opts)
+	if err != nil {
+		return DumpHeader{}, nil, err
+	}
+	defer t.close()
+
+	return t.receiveSample()
+}
+
+func (t *transfer) receiveSample() (h DumpHeader, samples []int32, err error) {
+	for {
+		r, ok := t.wait(t.receiveTimeout)
+		if !ok {
+			return h, nil, ErrTimeout
+		}
+
+		var is bool
+		if h, is = r.msg.(DumpHeader); is {
+			break
+		}
+	}
+
+	reply := func(typ, packet byte) error {
+		return t.send(Handshake{Channel: h.Channel, Type: typ, Packet: packet})
+	}
+
+	err = reply(HandshakeACK, 0)
+	if err != nil {
+		return
+	}
+
+	size := int(h.Length) * h.BytesPerWord()
+	data := make([]byte, 0, size)
+	var next byte
+
+	for len(data) < size {
+		r, ok := t.wait(t.receiveTimeout)
+		if !ok {
+			reply(HandshakeCancel, next)
+			return h, nil, ErrTimeout
+		}
+
+		if r.err == ErrChecksum {
+			err = reply(HandshakeNAK, r.packet)
+			if err != nil {
+				return
+			}
+			continue
+		}
+
+		switch m := r.msg.(type) {
+		case DataPacket:
+			switch m.Number {
+			case next:
+				data = append(data, m.Data[:]...)
+				next = (next + 1) % 128
+				err = reply(HandshakeACK, m.Number)
+			case (next + 127) % 128:
+				// the sender did not get our ACK and resent the packet
+				err = reply(HandshakeACK, m.Number)
+			default:
+				err = reply(HandshakeNAK, next)
+			}
+			if err != nil {
+				return
+			}
+		case Handshake:
+			if m.Type == HandshakeCancel {
+				return h, nil, ErrCancelled
+			}
+		}
+	}
+
+	return h, DecodeSamples(h.Bits, data[:size]), nil
+}
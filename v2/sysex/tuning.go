@@ -0,0 +1,324 @@
+package sysex
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// sub IDs of the MIDI tuning standard (sub ID 1 is 08)
+const (
+	subTuning                 = 0x08
+	subTuningDumpRequest      = 0x00
+	subBulkTuningDump         = 0x01
+	subSingleNoteTuning       = 0x02
+	subTuningDumpRequestBank  = 0x03
+	subBulkTuningDumpBank     = 0x04
+	subSingleNoteTuningBank   = 0x07
+	subScaleOctaveTuning1Byte = 0x08
+	subScaleOctaveTuning2Byte = 0x09
+)
+
+// AllChannels is the channel mask of the ScaleOctaveTuning message for all 16 MIDI channels.
+const AllChannels uint16 = 0xFFFF
+
+// NoteTuning is the frequency of a note in the format of the MIDI tuning standard: the semitone (key)
+// and the 14-bit fraction above it in units of 100/16384 cents.
+type NoteTuning struct {
+	Semitone byte
+	Fraction uint16
+}
+
+// NoTuningChange is the NoteTuning that keeps the tuning of a note unchanged.
+var NoTuningChange = NoteTuning{Semitone: 0x7F, Fraction: 0x3FFF}
+
+// NoteTuningFromHz returns the NoteTuning for the given frequency (in Hz), based on A4 (key 69) = 440 Hz.
+// Frequencies that are out of range return NoTuningChange.
+func NoteTuningFromHz(hz float64) NoteTuning {
+	if hz <= 0 {
+		return NoTuningChange
+	}
+
+	key := 69 + 12*math.Log2(hz/440)
+	semitone := math.Floor(key)
+	fraction := math.Round((key - semitone) * 0x4000)
+
+	if fraction == 0x4000 {
+		semitone++
+		fraction = 0
+	}
+
+	if semitone < 0 || semitone > 127 || (semitone == 127 && fraction == 0x3FFF) {
+		return NoTuningChange
+	}
+
+	return NoteTuning{Semitone: byte(semitone), Fraction: uint16(fraction)}
+}
+
+// Hz returns the frequency in Hz. For NoTuningChange, 0 is returned.
+func (n NoteTuning) Hz() float64 {
+	if n == NoTuningChange {
+		return 0
+	}
+	key := float64(n.Semitone) + float64(n.Fraction)/0x4000
+	return 440 * math.Pow(2, (key-69)/12)
+}
+
+func (n NoteTuning) bytes() []byte {
+	return []byte{n.Semitone & 0x7F, byte(n.Fraction>>7) & 0x7F, byte(n.Fraction) & 0x7F}
+}
+
+func noteTuningFromBytes(b []byte) NoteTuning {
+	return NoteTuning{Semitone: b[0], Fraction: uint16(b[1])<<7 | uint16(b[2])}
+}
+
+// TuningDumpRequest requests the bulk tuning dump of a tuning program (non-realtime, sub IDs 08 00 or 08 03 with bank).
+type TuningDumpRequest struct {
+	Channel byte
+	HasBank bool
+	Bank    byte
+	Program byte
+}
+
+func (t TuningDumpRequest) SysEx() []byte {
+	if t.HasBank {
+		return NonRealtime{Channel: t.Channel, SubID1: subTuning, SubID2: subTuningDumpRequestBank, Data: []byte{t.Bank, t.Program}}.SysEx()
+	}
+	return NonRealtime{Channel: t.Channel, SubID1: subTuning, SubID2: subTuningDumpRequest, Data: []byte{t.Program}}.SysEx()
+}
+
+func (t TuningDumpRequest) String() string {
+	return fmt.Sprintf("TuningDumpRequest channel: %v bank: %v program: %v", t.Channel, t.Bank, t.Program)
+}
+
+// BulkTuningDump is the tuning of all 128 notes of a tuning program (non-realtime, sub IDs 08 01 or 08 04 with bank).
+// The Name has at most 16 ASCII characters.
+type BulkTuningDump struct {
+	Channel byte
+	HasBank bool
+	Bank    byte
+	Program byte
+	Name    string
+	Notes   [128]NoteTuning
+}
+
+// Checksum returns the checksum of the dump (the XOR of all bytes between 0xF0 and the checksum).
+func (t BulkTuningDump) Checksum() byte {
+	sx := t.sysex()
+	var sum byte
+	for _, b := range sx[1:] {
+		sum ^= b
+	}
+	return sum & 0x7F
+}
+
+// sysex returns the message without checksum and 0xF7
+func (t BulkTuningDump) sysex() []byte {
+	var bf bytes.Buffer
+	sub := byte(subBulkTuningDump)
+	if t.HasBank {
+		sub = subBulkTuningDumpBank
+		bf.WriteByte(t.Bank)
+	}
+	bf.WriteByte(t.Program)
+
+	name := []byte(t.Name)
+	if len(name) > 16 {
+		name = name[:16]
+	}
+	bf.Write(name)
+	bf.Write(bytes.Repeat([]byte{' '}, 16-len(name)))
+
+	for _, n := range t.Notes {
+		bf.Write(n.bytes())
+	}
+
+	sx := NonRealtime{Channel: t.Channel, SubID1: subTuning, SubID2: sub, Data: bf.Bytes()}.SysEx()
+	return sx[:len(sx)-1]
+}
+
+func (t BulkTuningDump) SysEx() []byte {
+	sx := t.sysex()
+	return append(sx, t.Checksum(), 0xF7)
+}
+
+func (t BulkTuningDump) String() string {
+	return fmt.Sprintf("BulkTuningDump channel: %v bank: %v program: %v name: %q", t.Channel, t.Bank, t.Program, t.Name)
+}
+
+// maxNoteChanges is the maximum number of changes within a single note tuning change message.
+const maxNoteChanges = 127
+
+// NoteChange is the tuning of a single key.
+type NoteChange struct {
+	Key    byte
+	Tuning NoteTuning
+}
+
+// SingleNoteTuning changes the tuning of single keys of a tuning program
+// (realtime sub IDs 08 02, realtime or non-realtime sub IDs 08 07 with bank).
+// Non-realtime messages are always sent with bank.
+// A single message can hold up to 127 changes.
+type SingleNoteTuning struct {
+	Channel  byte
+	Realtime bool
+	HasBank  bool
+	Bank     byte
+	Program  byte
+	Changes  []NoteChange
+}
+
+// SysEx returns the system exclusive message. It returns nil, if there are more than 127 changes.
+func (t SingleNoteTuning) SysEx() []byte {
+	if len(t.Changes) > maxNoteChanges {
+		return nil
+	}
+
+	var bf bytes.Buffer
+	sub := byte(subSingleNoteTuning)
+	if t.HasBank || !t.Realtime {
+		sub = subSingleNoteTuningBank
+		bf.WriteByte(t.Bank)
+	}
+	bf.WriteByte(t.Program)
+	bf.WriteByte(byte(len(t.Changes)))
+
+	for _, c := range t.Changes {
+		bf.WriteByte(c.Key)
+		bf.Write(c.Tuning.bytes())
+	}
+
+	if t.Realtime {
+		return Realtime{Channel: t.Channel, SubID1: subTuning, SubID2: sub, Data: bf.Bytes()}.SysEx()
+	}
+	return NonRealtime{Channel: t.Channel, SubID1: subTuning, SubID2: sub, Data: bf.Bytes()}.SysEx()
+}
+
+func (t SingleNoteTuning) String() string {
+	var bf bytes.Buffer
+	fmt.Fprintf(&bf, "SingleNoteTuning channel: %v realtime: %v bank: %v program: %v", t.Channel, t.Realtime, t.Bank, t.Program)
+	for _, c := range t.Changes {
+		fmt.Fprintf(&bf, " [%v]: %0.2fHz", c.Key, c.Tuning.Hz())
+	}
+	return bf.String()
+}
+
+// ScaleOctaveTuning sets the tuning of the 12 semitones of the octave (starting with C) for the given MIDI channels
+// (realtime or non-realtime, sub IDs 08 08 for the 1-byte form and 08 09 for the 2-byte form).
+// Channels is a bit mask where bit 0 is the first MIDI channel.
+// Cents is the deviation from equal temperament, ranging from -64 to 63 cents in 1 cent steps (1-byte form)
+// or from -100 to 100 cents in steps of 100/8192 cents (2-byte form).
+type ScaleOctaveTuning struct {
+	Channel  byte
+	Realtime bool
+	TwoByte  bool
+	Channels uint16
+	Cents    [12]float64
+}
+
+func (t ScaleOctaveTuning) SysEx() []byte {
+	data := []byte{byte(t.Channels>>14) & 0x03, byte(t.Channels>>7) & 0x7F, byte(t.Channels) & 0x7F}
+	sub := byte(subScaleOctaveTuning1Byte)
+
+	if t.TwoByte {
+		sub = subScaleOctaveTuning2Byte
+		for _, c := range t.Cents {
+			v := fineTuning(c)
+			data = append(data, byte(v>>7)&0x7F, byte(v)&0x7F)
+		}
+	} else {
+		for _, c := range t.Cents {
+			v := math.Round(c) + 64
+			data = append(data, byte(math.Max(0, math.Min(127, v))))
+		}
+	}
+
+	if t.Realtime {
+		return Realtime{Channel: t.Channel, SubID1: subTuning, SubID2: sub, Data: data}.SysEx()
+	}
+	return NonRealtime{Channel: t.Channel, SubID1: subTuning, SubID2: sub, Data: data}.SysEx()
+}
+
+func (t ScaleOctaveTuning) String() string {
+	return fmt.Sprintf("ScaleOctaveTuning channel: %v realtime: %v channels: %016b cents: %v", t.Channel, t.Realtime, t.Channels, t.Cents)
+}
+
+// parseTuning parses the messages of the MIDI tuning standard. data starts with the byte after the sub ID 2.
+func parseTuning(realtime bool, channel, subID byte, data []byte) (Universal, error) {
+	wrongLength := func(name string) error {
+		return fmt.Errorf("invalid length of %s: %v bytes", name, len(data))
+	}
+
+	switch {
+	case !realtime && subID == subTuningDumpRequest:
+		if len(data) != 1 {
+			return nil, wrongLength("tuning dump request")
+		}
+		return TuningDumpRequest{Channel: channel, Program: data[0]}, nil
+	case !realtime && subID == subTuningDumpRequestBank:
+		if len(data) != 2 {
+			return nil, wrongLength("tuning dump request")
+		}
+		return TuningDumpRequest{Channel: channel, HasBank: true, Bank: data[0], Program: data[1]}, nil
+	case !realtime && (subID == subBulkTuningDump || subID == subBulkTuningDumpBank):
+		t := BulkTuningDump{Channel: channel, HasBank: subID == subBulkTuningDumpBank}
+		if t.HasBank {
+			if len(data) == 0 {
+				return nil, wrongLength("bulk tuning dump")
+			}
+			t.Bank, data = data[0], data[1:]
+		}
+		if len(data) != 1+16+128*3+1 {
+			return nil, wrongLength("bulk tuning dump")
+		}
+		t.Program = data[0]
+		t.Name = strings.TrimRight(string(data[1:17]), " ")
+		for i := range t.Notes {
+			t.Notes[i] = noteTuningFromBytes(data[17+i*3:])
+		}
+		if t.Checksum() != data[len(data)-1] {
+			return nil, ErrChecksum
+		}
+		return t, nil
+	case (realtime && subID == subSingleNoteTuning) || subID == subSingleNoteTuningBank:
+		t := SingleNoteTuning{Channel: channel, Realtime: realtime, HasBank: subID == subSingleNoteTuningBank}
+		if t.HasBank {
+			if len(data) == 0 {
+				return nil, wrongLength("single note tuning change")
+			}
+			t.Bank, data = data[0], data[1:]
+		}
+		if len(data) < 2 || len(data) != 2+int(data[1])*4 {
+			return nil, wrongLength("single note tuning change")
+		}
+		if data[1] > maxNoteChanges {
+			return nil, fmt.Errorf("invalid number of single note tuning changes: %v", data[1])
+		}
+		t.Program = data[0]
+		for data = data[2:]; len(data) > 0; data = data[4:] {
+			t.Changes = append(t.Changes, NoteChange{Key: data[0], Tuning: noteTuningFromBytes(data[1:])})
+		}
+		return t, nil
+	case subID == subScaleOctaveTuning1Byte || subID == subScaleOctaveTuning2Byte:
+		t := ScaleOctaveTuning{Channel: channel, Realtime: realtime, TwoByte: subID == subScaleOctaveTuning2Byte}
+		size := 1
+		if t.TwoByte {
+			size = 2
+		}
+		if len(data) != 3+12*size {
+			return nil, wrongLength("scale/octave tuning")
+		}
+		t.Channels = uint16(data[0]&0x03)<<14 | uint16(data[1])<<7 | uint16(data[2])
+		for i := range t.Cents {
+			if t.TwoByte {
+				t.Cents[i] = DeviceControl{Value: uint16(data[3+i*2])<<7 | uint16(data[4+i*2])}.Cents()
+			} else {
+				t.Cents[i] = float64(int(data[3+i]) - 64)
+			}
+		}
+		return t, nil
+	}
+
+	return nil, nil
+}
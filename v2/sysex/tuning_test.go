@@ -0,0 +1,97 @@
+package sysex
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestNoteTuning(t *testing.T) {
+	tests := []struct {
+		hz       float64
+		expected string
+	}{
+		{8.1758, "00 00 00"},
+		{8.2104, "00 09 2E"},
+		{440, "45 00 00"},
+		{440.0016, "45 00 01"},
+		{261.6256, "3C 00 00"},
+		{0, "7F 7F 7F"},
+		{20000, "7F 7F 7F"},
+	}
+
+	for i, test := range tests {
+		n := NoteTuningFromHz(test.hz)
+
+		if got := fmt.Sprintf("% X", n.bytes()); got != test.expected {
+			t.Errorf("[%v] NoteTuningFromHz(%v) = %s; expected %s", i, test.hz, got, test.expected)
+		}
+
+		if n != NoTuningChange && math.Abs(n.Hz()-test.hz) > 0.001 {
+			t.Errorf("[%v] Hz() = %v; expected %v", i, n.Hz(), test.hz)
+		}
+	}
+}
+
+func TestTuningMessages(t *testing.T) {
+	dump := EqualTemperament().BulkDump(0x7F, 3, "12-TET")
+	dump.Notes[127] = NoTuningChange
+
+	tests := []Universal{
+		TuningDumpRequest{Channel: 1, Program: 3},
+		TuningDumpRequest{Channel: 1, HasBank: true, Bank: 2, Program: 3},
+		dump,
+		BulkTuningDump{Channel: 1, HasBank: true, Bank: 5, Program: 7, Name: "sixteen chars ok"},
+		SingleNoteTuning{Channel: 1, Realtime: true, Program: 1,
+			Changes: []NoteChange{{Key: 60, Tuning: NoteTuning{60, 0x2000}}, {Key: 61, Tuning: NoTuningChange}}},
+		SingleNoteTuning{Channel: 1, Realtime: true, HasBank: true, Bank: 1, Program: 2,
+			Changes: []NoteChange{{Key: 60, Tuning: NoteTuning{60, 1}}}},
+		SingleNoteTuning{Channel: 1, HasBank: true, Bank: 1, Program: 2,
+			Changes: []NoteChange{{Key: 69, Tuning: NoteTuning{69, 0}}}},
+		ScaleOctaveTuning{Channel: 0x7F, Channels: AllChannels, Cents: [12]float64{-64, 0, 63, 1, -1}},
+		ScaleOctaveTuning{Channel: 0x7F, Realtime: true, TwoByte: true, Channels: 0x8001, Cents: [12]float64{-100, 0, 50, 12.5}},
+	}
+
+	for i, test := range tests {
+		sx := test.SysEx()
+
+		got, err := ParseUniversal(sx)
+		if err != nil {
+			t.Errorf("[%v] ParseUniversal(% X) returned error: %v", i, sx, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, test) {
+			t.Errorf("[%v] ParseUniversal(% X) = %v; expected %v", i, sx, got, test)
+		}
+	}
+
+	sx := dump.SysEx()
+	if len(sx) != 408 {
+		t.Errorf("len(BulkTuningDump.SysEx()) = %v; expected 408", len(sx))
+	}
+
+	sx[30] ^= 0x01
+	if _, err := ParseUniversal(sx); err != ErrChecksum {
+		t.Errorf("ParseUniversal() of corrupted dump returned %v; expected %v", err, ErrChecksum)
+	}
+
+	so := ScaleOctaveTuning{Channels: 1<<15 | 1<<7 | 1}.SysEx()
+	if got := fmt.Sprintf("% X", so[5:8]); got != "02 01 01" {
+		t.Errorf("channel mask = %s; expected 02 01 01", got)
+	}
+
+	tooMany := SingleNoteTuning{Channel: 1, Realtime: true, Program: 1, Changes: make([]NoteChange, 128)}
+	if sx := tooMany.SysEx(); sx != nil {
+		t.Errorf("SingleNoteTuning.SysEx() with 128 changes = % X; expected nil", sx)
+	}
+
+	tooMany.Changes = tooMany.Changes[:127]
+	sx = tooMany.SysEx()
+	sx = append(sx[:len(sx)-1], 0, 0, 0, 0, 0xF7)
+	sx[6] = 0x80
+	if _, err := ParseUniversal(sx); err == nil {
+		t.Errorf("ParseUniversal() of single note tuning change with 128 changes returned no error")
+	}
+}
@@ -0,0 +1,358 @@
+package sysex
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrNotUniversal is returned by ParseUniversal, if the message is no universal system exclusive message.
+var ErrNotUniversal = errors.New("no universal system exclusive message")
+
+// Universal is a decoded universal (realtime or non-realtime) system exclusive message.
+type Universal interface {
+	// SysEx returns the bytes of the message, including the 0xF0 and 0xF7
+	SysEx() []byte
+	String() string
+}
+
+// The modes of the GeneralMIDI message.
+const (
+	GMOff byte = 0x02
+	GM1On byte = 0x01
+	GM2On byte = 0x03
+)
+
+// The controls of the DeviceControl message.
+const (
+	ControlMasterVolume       byte = 0x01
+	ControlMasterBalance      byte = 0x02
+	ControlMasterFineTuning   byte = 0x03
+	ControlMasterCoarseTuning byte = 0x04
+)
+
+// sub IDs of the universal messages
+const (
+	subGeneralInformation = 0x06
+	subIdentityRequest    = 0x01
+	subIdentityReply      = 0x02
+	subGeneralMIDI        = 0x09
+	subDeviceControl      = 0x04
+	subGlobalParameter    = 0x05
+)
+
+// DeviceInquiry is the identity request (non-realtime, sub IDs 06 01).
+type DeviceInquiry struct {
+	Channel byte
+}
+
+func (d DeviceInquiry) SysEx() []byte {
+	return NonRealtime{Channel: d.Channel, SubID1: subGeneralInformation, SubID2: subIdentityRequest}.SysEx()
+}
+
+func (d DeviceInquiry) String() string {
+	return fmt.Sprintf("DeviceInquiry channel: %v", d.Channel)
+}
+
+// DeviceIdentity is the identity reply (non-realtime, sub IDs 06 02).
+// Manufacturer is either a single byte or three bytes, starting with 0x00.
+type DeviceIdentity struct {
+	Channel      byte
+	Manufacturer []byte
+	Family       [2]byte
+	Model        [2]byte
+	Version      [4]byte
+}
+
+func (d DeviceIdentity) SysEx() []byte {
+	var bf bytes.Buffer
+	bf.Write(d.Manufacturer)
+	bf.Write(d.Family[:])
+	bf.Write(d.Model[:])
+	bf.Write(d.Version[:])
+	return NonRealtime{Channel: d.Channel, SubID1: subGeneralInformation, SubID2: subIdentityReply, Data: bf.Bytes()}.SysEx()
+}
+
+func (d DeviceIdentity) String() string {
+	manu := fmt.Sprintf("% X", d.Manufacturer)
+	if len(d.Manufacturer) == 1 {
+		manu = ManufacturerID(d.Manufacturer[0]).String()
+	}
+	return fmt.Sprintf("DeviceIdentity channel: %v manufacturer: %s family: % X model: % X version: % X",
+		d.Channel, manu, d.Family, d.Model, d.Version)
+}
+
+// GeneralMIDI enables or disables the General MIDI mode (non-realtime, sub ID 09).
+// Mode is one of GM1On, GM2On and GMOff.
+type GeneralMIDI struct {
+	Channel byte
+	Mode    byte
+}
+
+func (g GeneralMIDI) SysEx() []byte {
+	return NonRealtime{Channel: g.Channel, SubID1: subGeneralMIDI, SubID2: g.Mode}.SysEx()
+}
+
+func (g GeneralMIDI) String() string {
+	var mode string
+	switch g.Mode {
+	case GM1On:
+		mode = "GM1 on"
+	case GM2On:
+		mode = "GM2 on"
+	case GMOff, 0x00:
+		mode = "GM off"
+	default:
+		mode = fmt.Sprintf("unknown (%02X)", g.Mode)
+	}
+	return fmt.Sprintf("GeneralMIDI channel: %v mode: %s", g.Channel, mode)
+}
+
+// DeviceControl sets a master volume, balance, fine tuning or coarse tuning of the device (realtime, sub ID 04).
+// Control is one of the Control... constants and Value is the 14-bit value.
+type DeviceControl struct {
+	Channel byte
+	Control byte
+	Value   uint16
+}
+
+func (d DeviceControl) SysEx() []byte {
+	return Realtime{
+		Channel: d.Channel,
+		SubID1:  subDeviceControl,
+		SubID2:  d.Control,
+		Data:    []byte{byte(d.Value & 0x7F), byte(d.Value>>7) & 0x7F},
+	}.SysEx()
+}
+
+func (d DeviceControl) String() string {
+	switch d.Control {
+	case ControlMasterVolume:
+		return fmt.Sprintf("MasterVolume channel: %v volume: %v", d.Channel, d.Value)
+	case ControlMasterBalance:
+		return fmt.Sprintf("MasterBalance channel: %v balance: %v", d.Channel, int(d.Value)-0x2000)
+	case ControlMasterFineTuning:
+		return fmt.Sprintf("MasterFineTuning channel: %v cents: %0.2f", d.Channel, d.Cents())
+	case ControlMasterCoarseTuning:
+		return fmt.Sprintf("MasterCoarseTuning channel: %v semitones: %v", d.Channel, d.Semitones())
+	default:
+		return fmt.Sprintf("DeviceControl channel: %v control: %02X value: %v", d.Channel, d.Control, d.Value)
+	}
+}
+
+// Cents returns the value of a master fine tuning as cents.
+func (d DeviceControl) Cents() float64 {
+	return float64(int(d.Value)-0x2000) * 100 / 0x2000
+}
+
+// Semitones returns the value of a master coarse tuning as semitones.
+func (d DeviceControl) Semitones() int8 {
+	return int8(int(d.Value>>7) - 0x40)
+}
+
+func fineTuning(cents float64) uint16 {
+	v := math.Round(cents*0x2000/100) + 0x2000
+	switch {
+	case v < 0:
+		return 0
+	case v > 0x3FFF:
+		return 0x3FFF
+	default:
+		return uint16(v)
+	}
+}
+
+func coarseTuning(semitones int8) uint16 {
+	switch {
+	case semitones > 63:
+		semitones = 63
+	case semitones < -64:
+		semitones = -64
+	}
+	return uint16(int(semitones)+0x40) << 7
+}
+
+// GlobalParameter is a parameter of the GlobalParameterControl message.
+// The lengths of ID and Value are defined by the IDWidth and ValueWidth of the message.
+// The Value is least significant byte first.
+type GlobalParameter struct {
+	ID    []byte
+	Value []byte
+}
+
+// GlobalParameterControl sets global parameters of a device (realtime, sub IDs 04 05).
+// SlotPaths are the MSB and LSB of each slot of the path to the parameters.
+type GlobalParameterControl struct {
+	Channel    byte
+	SlotPaths  [][2]byte
+	IDWidth    byte
+	ValueWidth byte
+	Parameters []GlobalParameter
+}
+
+func (g GlobalParameterControl) SysEx() []byte {
+	var bf bytes.Buffer
+	bf.WriteByte(byte(len(g.SlotPaths)))
+	bf.WriteByte(g.IDWidth)
+	bf.WriteByte(g.ValueWidth)
+
+	for _, sp := range g.SlotPaths {
+		bf.Write(sp[:])
+	}
+
+	for _, p := range g.Parameters {
+		bf.Write(fixedWidth(p.ID, g.IDWidth))
+		bf.Write(fixedWidth(p.Value, g.ValueWidth))
+	}
+
+	return Realtime{Channel: g.Channel, SubID1: subDeviceControl, SubID2: subGlobalParameter, Data: bf.Bytes()}.SysEx()
+}
+
+func (g GlobalParameterControl) String() string {
+	var bf bytes.Buffer
+	fmt.Fprintf(&bf, "GlobalParameterControl channel: %v slots: % X", g.Channel, g.SlotPaths)
+	for _, p := range g.Parameters {
+		fmt.Fprintf(&bf, " [% X]: % X", p.ID, p.Value)
+	}
+	return bf.String()
+}
+
+// fixedWidth returns b, cut or filled with zeros to the given width
+func fixedWidth(b []byte, width byte) []byte {
+	res := make([]byte, width)
+	copy(res, b)
+	return res
+}
+
+// ParseUniversal parses the given universal system exclusive message. The starting 0xF0 and the
+// final 0xF7 are optional.
+// Messages with known sub IDs are returned as DeviceInquiry, DeviceIdentity, GeneralMIDI, DeviceControl,
+// GlobalParameterControl or as one of the sample dump messages (DumpHeader, DumpRequest, DataPacket, Handshake,
+// LoopPoint and LoopPointRequest) or as one of the tuning messages (TuningDumpRequest, BulkTuningDump,
+// SingleNoteTuning and ScaleOctaveTuning); all other universal messages are returned as NonRealtime or Realtime.
+// If the message is no universal system exclusive message, ErrNotUniversal is returned.
+func ParseUniversal(data []byte) (Universal, error) {
+	if len(data) > 0 && data[0] == 0xF0 {
+		data = data[1:]
+	}
+	if len(data) > 0 && data[len(data)-1] == 0xF7 {
+		data = data[:len(data)-1]
+	}
+
+	if len(data) < 3 {
+		return nil, ErrNotUniversal
+	}
+
+	channel, subID1 := data[1], data[2]
+	var subID2 byte
+	var rest []byte
+
+	if len(data) > 3 {
+		subID2 = data[3]
+		rest = append([]byte(nil), data[4:]...)
+	}
+
+	switch ManufacturerID(data[0]) {
+	case NonRealTimeID:
+		return parseNonRealtime(NonRealtime{Channel: channel, SubID1: subID1, SubID2: subID2, Data: rest})
+	case RealTimeID:
+		return parseRealtime(Realtime{Channel: channel, SubID1: subID1, SubID2: subID2, Data: rest})
+	default:
+		return nil, ErrNotUniversal
+	}
+}
+
+func parseNonRealtime(n NonRealtime) (Universal, error) {
+	switch {
+	case n.SubID1 == subGeneralInformation && n.SubID2 == subIdentityRequest:
+		return DeviceInquiry{Channel: n.Channel}, nil
+	case n.SubID1 == subGeneralInformation && n.SubID2 == subIdentityReply:
+		d := DeviceIdentity{Channel: n.Channel}
+		size := 1
+		if len(n.Data) > 0 && n.Data[0] == 0x00 {
+			size = 3
+		}
+		if len(n.Data) != size+8 {
+			return nil, fmt.Errorf("invalid length of identity reply: %v bytes", len(n.Data))
+		}
+		d.Manufacturer = n.Data[:size]
+		copy(d.Family[:], n.Data[size:])
+		copy(d.Model[:], n.Data[size+2:])
+		copy(d.Version[:], n.Data[size+4:])
+		return d, nil
+	case n.SubID1 == subGeneralMIDI && len(n.Data) == 0:
+		return GeneralMIDI{Channel: n.Channel, Mode: n.SubID2}, nil
+	}
+
+	if n.SubID1 == subTuning {
+		u, err := parseTuning(false, n.Channel, n.SubID2, n.Data)
+		if err != nil || u != nil {
+			return u, err
+		}
+		return n, nil
+	}
+
+	u, err := parseSDS(n.Channel, n.SubID1, append([]byte{n.SubID2}, n.Data...))
+	if err != nil || u != nil {
+		return u, err
+	}
+	return n, nil
+}
+
+func parseRealtime(r Realtime) (Universal, error) {
+	if r.SubID1 == subTuning {
+		u, err := parseTuning(true, r.Channel, r.SubID2, r.Data)
+		if err != nil || u != nil {
+			return u, err
+		}
+		return r, nil
+	}
+
+	if r.SubID1 != subDeviceControl {
+		return r, nil
+	}
+
+	switch r.SubID2 {
+	case ControlMasterVolume, ControlMasterBalance, ControlMasterFineTuning, ControlMasterCoarseTuning:
+		if len(r.Data) != 2 {
+			return nil, fmt.Errorf("invalid length of device control: %v bytes", len(r.Data))
+		}
+		return DeviceControl{Channel: r.Channel, Control: r.SubID2, Value: uint16(r.Data[1])<<7 | uint16(r.Data[0])}, nil
+	case subGlobalParameter:
+		return parseGlobalParameterControl(r)
+	default:
+		return r, nil
+	}
+}
+
+func parseGlobalParameterControl(r Realtime) (Universal, error) {
+	if len(r.Data) < 3 {
+		return nil, fmt.Errorf("global parameter control too short")
+	}
+
+	g := GlobalParameterControl{Channel: r.Channel, IDWidth: r.Data[1], ValueWidth: r.Data[2]}
+	data := r.Data[3:]
+
+	slots := int(r.Data[0])
+	if len(data) < slots*2 {
+		return nil, fmt.Errorf("global parameter control too short for %v slot paths", slots)
+	}
+
+	for i := 0; i < slots; i++ {
+		g.SlotPaths = append(g.SlotPaths, [2]byte{data[0], data[1]})
+		data = data[2:]
+	}
+
+	size := int(g.IDWidth) + int(g.ValueWidth)
+	if len(data) > 0 && (size == 0 || len(data)%size != 0) {
+		return nil, fmt.Errorf("invalid length of global parameters: %v bytes", len(data))
+	}
+
+	for len(data) > 0 {
+		g.Parameters = append(g.Parameters, GlobalParameter{ID: data[:g.IDWidth], Value: data[g.IDWidth:size]})
+		data = data[size:]
+	}
+
+	return g, nil
+}
@@ -0,0 +1,127 @@
+package sysex
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestParseUniversal(t *testing.T) {
+	tests := []struct {
+		sysex    []byte
+		expected Universal
+		str      string
+	}{
+		{
+			IdentityRequest(0x7F),
+			DeviceInquiry{Channel: 0x7F},
+			"DeviceInquiry channel: 127",
+		},
+		{
+			IdentityReply(0x10, Roland, [2]byte{1, 2}, [2]byte{3, 4}, [4]byte{0, 1, 0, 2}),
+			DeviceIdentity{Channel: 0x10, Manufacturer: []byte{0x41}, Family: [2]byte{1, 2}, Model: [2]byte{3, 4}, Version: [4]byte{0, 1, 0, 2}},
+			"DeviceIdentity channel: 16 manufacturer: Roland family: 01 02 model: 03 04 version: 00 01 00 02",
+		},
+		{
+			[]byte{0xF0, 0x7E, 0x01, 0x06, 0x02, 0x00, 0x21, 0x09, 1, 2, 3, 4, 5, 6, 7, 8, 0xF7},
+			DeviceIdentity{Channel: 1, Manufacturer: []byte{0x00, 0x21, 0x09}, Family: [2]byte{1, 2}, Model: [2]byte{3, 4}, Version: [4]byte{5, 6, 7, 8}},
+			"DeviceIdentity channel: 1 manufacturer: 00 21 09 family: 01 02 model: 03 04 version: 05 06 07 08",
+		},
+		{
+			GMSystem(0x7F, true),
+			GeneralMIDI{Channel: 0x7F, Mode: GM1On},
+			"GeneralMIDI channel: 127 mode: GM1 on",
+		},
+		{
+			GMSystem(0x7F, false),
+			GeneralMIDI{Channel: 0x7F, Mode: GMOff},
+			"GeneralMIDI channel: 127 mode: GM off",
+		},
+		{
+			[]byte{0xF0, 0x7E, 0x7F, 0x09, 0x03, 0xF7},
+			GeneralMIDI{Channel: 0x7F, Mode: GM2On},
+			"GeneralMIDI channel: 127 mode: GM2 on",
+		},
+		{
+			MasterVolume(0x7F, 0x3FFF),
+			DeviceControl{Channel: 0x7F, Control: ControlMasterVolume, Value: 0x3FFF},
+			"MasterVolume channel: 127 volume: 16383",
+		},
+		{
+			MasterBalance(0x7F, 0x1000),
+			DeviceControl{Channel: 0x7F, Control: ControlMasterBalance, Value: 0x1000},
+			"MasterBalance channel: 127 balance: -4096",
+		},
+		{
+			MasterFineTuning(0x7F, 50),
+			DeviceControl{Channel: 0x7F, Control: ControlMasterFineTuning, Value: 0x3000},
+			"MasterFineTuning channel: 127 cents: 50.00",
+		},
+		{
+			MasterCoarseTuning(0x7F, -12),
+			DeviceControl{Channel: 0x7F, Control: ControlMasterCoarseTuning, Value: 0x34 << 7},
+			"MasterCoarseTuning channel: 127 semitones: -12",
+		},
+		{
+			// reverb type (GM2)
+			[]byte{0xF0, 0x7F, 0x7F, 0x04, 0x05, 0x01, 0x01, 0x01, 0x01, 0x01, 0x00, 0x04, 0xF7},
+			GlobalParameterControl{
+				Channel:    0x7F,
+				SlotPaths:  [][2]byte{{0x01, 0x01}},
+				IDWidth:    1,
+				ValueWidth: 1,
+				Parameters: []GlobalParameter{{ID: []byte{0x00}, Value: []byte{0x04}}},
+			},
+			"GlobalParameterControl channel: 127 slots: [01 01] [00]: 04",
+		},
+		{
+			[]byte{0xF0, 0x7E, 0x7F, 0x0A, 0x01, 0xF7},
+			NonRealtime{Channel: 0x7F, SubID1: 0x0A, SubID2: 0x01},
+			"NonRealtime channel: 127 sub IDs: 0A 01 data: ",
+		},
+		{
+			[]byte{0xF0, 0x7F, 0x00, 0x03, 0x02, 0x01, 0xF7},
+			Realtime{Channel: 0x00, SubID1: 0x03, SubID2: 0x02, Data: []byte{0x01}},
+			"Realtime channel: 0 sub IDs: 03 02 data: 01",
+		},
+	}
+
+	for i, test := range tests {
+		got, err := ParseUniversal(test.sysex)
+
+		if err != nil {
+			t.Errorf("[%v] ParseUniversal(% X) returned error: %v", i, test.sysex, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("[%v] ParseUniversal(% X) = %#v; expected %#v", i, test.sysex, got, test.expected)
+		}
+
+		if s := got.String(); s != test.str {
+			t.Errorf("[%v] String() = %q; expected %q", i, s, test.str)
+		}
+
+		if bt := got.SysEx(); fmt.Sprintf("% X", bt) != fmt.Sprintf("% X", test.sysex) {
+			t.Errorf("[%v] SysEx() = % X; expected % X", i, bt, test.sysex)
+		}
+	}
+}
+
+func TestParseUniversalErrors(t *testing.T) {
+	tests := [][]byte{
+		GMReset.SysEx(),
+		{0xF0, 0x7E, 0xF7},
+		{0xF0, 0x7E, 0x7F, 0x06, 0x02, 0x41, 0x01, 0xF7},
+		{0xF0, 0x7F, 0x7F, 0x04, 0x01, 0x00, 0xF7},
+		{0xF0, 0x7F, 0x7F, 0x04, 0x05, 0x02, 0x01, 0x01, 0x01, 0x01, 0xF7},
+		{0xF0, 0x7F, 0x7F, 0x04, 0x05, 0x00, 0x01, 0x02, 0x01, 0xF7},
+	}
+
+	for i, test := range tests {
+		_, err := ParseUniversal(test)
+		if err == nil {
+			t.Errorf("[%v] ParseUniversal(% X) must return an error", i, test)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package sysex
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// WriteWAV writes the given signed samples of a sample dump as mono PCM WAV file to the given writer.
+// The sample rate is taken from the period of the header. Samples with a resolution that is not a multiple of 8 bits
+// are left justified to the next multiple of 8 bits.
+func WriteWAV(wr io.Writer, h DumpHeader, samples []int32) error {
+	bytesPerSample := (int(h.Bits) + 7) / 8
+	shift := uint(bytesPerSample*8) - uint(h.Bits)
+	rate := uint32(math.Round(h.SampleRate()))
+	dataSize := uint32(len(samples) * bytesPerSample)
+
+	var bf bytes.Buffer
+	bf.WriteString("RIFF")
+	binary.Write(&bf, binary.LittleEndian, 36+dataSize+dataSize%2)
+	bf.WriteString("WAVE")
+
+	bf.WriteString("fmt ")
+	binary.Write(&bf, binary.LittleEndian, []uint32{16})
+	binary.Write(&bf, binary.LittleEndian, []uint16{1, 1}) // PCM, mono
+	binary.Write(&bf, binary.LittleEndian, []uint32{rate, rate * uint32(bytesPerSample)})
+	binary.Write(&bf, binary.LittleEndian, []uint16{uint16(bytesPerSample), uint16(bytesPerSample * 8)})
+
+	bf.WriteString("data")
+	binary.Write(&bf, binary.LittleEndian, dataSize)
+
+	for _, s := range samples {
+		v := uint32(s << shift)
+
+		if bytesPerSample == 1 {
+			// 8-bit WAV samples are unsigned
+			bf.WriteByte(byte(v) + 128)
+			continue
+		}
+
+		for i := 0; i < bytesPerSample; i++ {
+			bf.WriteByte(byte(v >> (8 * uint(i))))
+		}
+	}
+
+	if dataSize%2 == 1 {
+		bf.WriteByte(0)
+	}
+
+	_, err := bf.WriteTo(wr)
+	return err
+}
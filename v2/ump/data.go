@@ -0,0 +1,233 @@
+package ump
+
+import (
+	"encoding/binary"
+)
+
+// The status of SysEx7 and SysEx8 packets.
+const (
+	// SysExComplete is the status of a packet that contains a complete system exclusive message
+	SysExComplete uint8 = 0x0
+
+	// SysExStart is the status of the first packet of a system exclusive message
+	SysExStart uint8 = 0x1
+
+	// SysExContinue is the status of a packet in the middle of a system exclusive message
+	SysExContinue uint8 = 0x2
+
+	// SysExEnd is the status of the last packet of a system exclusive message
+	SysExEnd uint8 = 0x3
+)
+
+// dataPacket returns a packet of the given message type with the given status and data,
+// that starts at the third byte of the first word
+func dataPacket(mt MessageType, group, status uint8, data []byte) Packet {
+	b := make([]byte, mt.Size()*4)
+	b[0] = byte(mt)<<4 | group&0xF
+	b[1] = status<<4 | uint8(len(data))
+	copy(b[2:], data)
+
+	p := make(Packet, mt.Size())
+	for i := range p {
+		p[i] = binary.BigEndian.Uint32(b[i*4:])
+	}
+	return p
+}
+
+// splitSysEx splits the data into packets with the given maximal number of data bytes
+func splitSysEx(data []byte, max int, packet func(status uint8, data []byte) Packet) (packets []Packet) {
+	if len(data) <= max {
+		return []Packet{packet(SysExComplete, data)}
+	}
+
+	status := SysExStart
+	for len(data) > 0 {
+		n := max
+		if n >= len(data) {
+			n = len(data)
+			status = SysExEnd
+		}
+		packets = append(packets, packet(status, data[:n]))
+		data = data[n:]
+		status = SysExContinue
+	}
+	return
+}
+
+// trimSysEx removes the start and end bytes of a MIDI 1.0 system exclusive message, if there are any
+func trimSysEx(data []byte) []byte {
+	if len(data) > 0 && data[0] == 0xF0 {
+		data = data[1:]
+	}
+	if len(data) > 0 && data[len(data)-1] == 0xF7 {
+		data = data[:len(data)-1]
+	}
+	return data
+}
+
+// SysEx7 returns the 64-bit data packets (message type 0x3) for the given system exclusive data within the given group.
+// The start (0xF0) and end (0xF7) bytes of a MIDI 1.0 system exclusive message are removed, if present.
+func SysEx7(group uint8, data []byte) []Packet {
+	return splitSysEx(trimSysEx(data), 6, func(status uint8, data []byte) Packet {
+		return dataPacket(Data64MT, group, status, data)
+	})
+}
+
+// GetSysEx7 returns true if (and only if) the packet is a SysEx7Msg.
+// Then it also extracts the group, the status (SysExComplete, SysExStart, SysExContinue or SysExEnd) and the data
+// to the given arguments.
+// Only arguments that are not nil are parsed and filled.
+func (p Packet) GetSysEx7(group, status *uint8, data *[]byte) (is bool) {
+	if !p.Is(SysEx7Msg) {
+		return false
+	}
+
+	if group != nil {
+		*group = p.Group()
+	}
+
+	if status != nil {
+		*status = p.status()
+	}
+
+	if data != nil {
+		n := int(p.byte1() & 0xF)
+		if n > 6 {
+			n = 6
+		}
+		*data = p.Bytes()[2 : 2+n]
+	}
+	return true
+}
+
+// SysEx8 returns the 128-bit data packets (message type 0x5) for the given 8-bit system exclusive data
+// within the given group and stream.
+func SysEx8(group, streamID uint8, data []byte) []Packet {
+	return splitSysEx(data, 13, func(status uint8, data []byte) Packet {
+		return dataPacket(Data128MT, group, status, append([]byte{streamID}, data...))
+	})
+}
+
+// GetSysEx8 returns true if (and only if) the packet is a SysEx8Msg.
+// Then it also extracts the group, the status (SysExComplete, SysExStart, SysExContinue or SysExEnd),
+// the stream ID and the data to the given arguments.
+// Only arguments that are not nil are parsed and filled.
+func (p Packet) GetSysEx8(group, status, streamID *uint8, data *[]byte) (is bool) {
+	if !p.Is(SysEx8Msg) {
+		return false
+	}
+
+	if group != nil {
+		*group = p.Group()
+	}
+
+	if status != nil {
+		*status = p.status()
+	}
+
+	b := p.Bytes()
+
+	if streamID != nil {
+		*streamID = b[2]
+	}
+
+	if data != nil {
+		// the number of bytes includes the stream ID
+		n := int(p.byte1() & 0xF)
+		if n > 14 {
+			n = 14
+		}
+		if n < 1 {
+			n = 1
+		}
+		*data = b[3 : 2+n]
+	}
+	return true
+}
+
+// MixedDataSetHeader returns the header packet of a chunk of a mixed data set.
+func MixedDataSetHeader(group, mdsID uint8, validBytes, numChunks, chunkNo, manufacturerID, deviceID, subID1, subID2 uint16) Packet {
+	return Packet{
+		word0(Data128MT, group, 0x80|mdsID&0xF, validBytes),
+		uint32(numChunks)<<16 | uint32(chunkNo),
+		uint32(manufacturerID)<<16 | uint32(deviceID),
+		uint32(subID1)<<16 | uint32(subID2),
+	}
+}
+
+// MixedDataSetPayload returns a payload packet of a mixed data set with up to 14 bytes of data.
+func MixedDataSetPayload(group, mdsID uint8, data []byte) Packet {
+	if len(data) > 14 {
+		data = data[:14]
+	}
+
+	b := make([]byte, 16)
+	b[0] = byte(Data128MT)<<4 | group&0xF
+	b[1] = 0x90 | mdsID&0xF
+	copy(b[2:], data)
+
+	p := make(Packet, 4)
+	for i := range p {
+		p[i] = binary.BigEndian.Uint32(b[i*4:])
+	}
+	return p
+}
+
+// GetMixedDataSetHeader returns true if (and only if) the packet is a MixedDataSetHeaderMsg.
+// Then it also extracts the data to the given arguments.
+// Only arguments that are not nil are parsed and filled.
+func (p Packet) GetMixedDataSetHeader(group, mdsID *uint8, validBytes, numChunks, chunkNo, manufacturerID, deviceID, subID1, subID2 *uint16) (is bool) {
+	if !p.Is(MixedDataSetHeaderMsg) {
+		return false
+	}
+
+	fields := []struct {
+		ptr *uint16
+		val uint32
+	}{
+		{validBytes, p[0]},
+		{numChunks, p[1] >> 16},
+		{chunkNo, p[1]},
+		{manufacturerID, p[2] >> 16},
+		{deviceID, p[2]},
+		{subID1, p[3] >> 16},
+		{subID2, p[3]},
+	}
+
+	for _, f := range fields {
+		if f.ptr != nil {
+			*f.ptr = uint16(f.val)
+		}
+	}
+
+	if group != nil {
+		*group = p.Group()
+	}
+
+	if mdsID != nil {
+		*mdsID = p.byte1() & 0xF
+	}
+	return true
+}
+
+// GetMixedDataSetPayload returns true if (and only if) the packet is a MixedDataSetPayloadMsg.
+// Then it also extracts the group, the mixed data set ID and the 14 bytes of data to the given arguments.
+// Only arguments that are not nil are parsed and filled.
+func (p Packet) GetMixedDataSetPayload(group, mdsID *uint8, data *[]byte) (is bool) {
+	if !p.Is(MixedDataSetPayloadMsg) {
+		return false
+	}
+
+	if group != nil {
+		*group = p.Group()
+	}
+
+	if mdsID != nil {
+		*mdsID = p.byte1() & 0xF
+	}
+
+	if data != nil {
+		*data = p.Bytes()[2:]
+	}
+	return true
+}
@@ -0,0 +1,17 @@
+// Copyright (c) 2022 Marc René Arns. All rights reserved.
+// Use of this source code is governed by a MIT
+// license that can be found in the LICENSE file.
+
+/*
+Package ump provides the Universal MIDI Packets (UMP) of MIDI 2.0.
+
+A Packet consists of one to four 32-bit words. The first four bits define the message type,
+which determines the size of the packet. There are constructors and Get* accessors for the
+utility, system, MIDI 1.0 channel voice, MIDI 2.0 channel voice (including per-note controllers),
+data (SysEx7, SysEx8, mixed data set), flex data (tempo, time signature, key signature, text)
+and UMP stream messages, in the style of midi.Message.
+
+A Translator translates between MIDI 1.0 messages (midi.Message) and packets, following the default
+translation rules of the UMP specification.
+*/
+package ump
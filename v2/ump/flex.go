@@ -0,0 +1,258 @@
+package ump
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+)
+
+// The status banks of the flex data text messages.
+const (
+	// MetadataTextBank is the status bank of the metadata text messages
+	MetadataTextBank uint8 = 0x01
+
+	// PerformanceTextBank is the status bank of the performance text events
+	PerformanceTextBank uint8 = 0x02
+)
+
+// flexSetupBank is the status bank of the setup and performance messages (tempo, time signature etc.)
+const flexSetupBank uint8 = 0x00
+
+const (
+	flexTempo         = 0x00
+	flexTimeSignature = 0x01
+	flexKeySignature  = 0x05
+)
+
+// The status of the metadata text messages (MetadataTextBank).
+const (
+	UnknownText           uint8 = 0x00
+	ProjectName           uint8 = 0x01
+	CompositionName       uint8 = 0x02
+	ClipName              uint8 = 0x03
+	CopyrightNotice       uint8 = 0x04
+	ComposerName          uint8 = 0x05
+	LyricistName          uint8 = 0x06
+	ArrangerName          uint8 = 0x07
+	PublisherName         uint8 = 0x08
+	PrimaryPerformer      uint8 = 0x09
+	AccompanyingPerformer uint8 = 0x0A
+	RecordingDate         uint8 = 0x0B
+	RecordingLocation     uint8 = 0x0C
+)
+
+// The status of the performance text events (PerformanceTextBank).
+const (
+	Lyrics         uint8 = 0x01
+	LyricsLanguage uint8 = 0x02
+	Ruby           uint8 = 0x03
+	RubyLanguage   uint8 = 0x04
+)
+
+// The tonic notes of the key signature message.
+const (
+	TonicUnknown uint8 = 0x0
+	TonicA       uint8 = 0x1
+	TonicB       uint8 = 0x2
+	TonicC       uint8 = 0x3
+	TonicD       uint8 = 0x4
+	TonicE       uint8 = 0x5
+	TonicF       uint8 = 0x6
+	TonicG       uint8 = 0x7
+)
+
+// flexAddressGroup is the address field of flex data messages that are sent to the whole group
+const flexAddressGroup uint8 = 0x1
+
+// flexPacket returns a group addressed flex data message with the given format, status bank and status
+func flexPacket(group, format, bank, status uint8, words ...uint32) Packet {
+	p := Packet{word0(FlexDataMT, group, format<<6|flexAddressGroup<<4, uint16(bank)<<8|uint16(status)), 0, 0, 0}
+	copy(p[1:], words)
+	return p
+}
+
+// Tempo returns a set tempo message for the given beats per minute.
+// The tempo is transmitted in units of 10 nanoseconds per quarter note.
+func Tempo(group uint8, bpm float64) Packet {
+	var units uint32
+	if bpm > 0 {
+		units = uint32(math.Round(6e9 / bpm))
+	}
+	return flexPacket(group, 0, flexSetupBank, flexTempo, units)
+}
+
+// GetTempo returns true if (and only if) the packet is a TempoMsg.
+// Then it also extracts the group and the beats per minute to the given arguments.
+// Only arguments that are not nil are parsed and filled.
+func (p Packet) GetTempo(group *uint8, bpm *float64) (is bool) {
+	if !p.Is(TempoMsg) {
+		return false
+	}
+
+	if group != nil {
+		*group = p.Group()
+	}
+
+	if bpm != nil {
+		*bpm = 0
+		if p[1] > 0 {
+			*bpm = 6e9 / float64(p[1])
+		}
+	}
+	return true
+}
+
+// TimeSignature returns a set time signature message. The denominator is the note value of a beat (e.g. 4 for quarter notes)
+// and num32ndNotes the number of 1/32 notes of a beat (0 if unknown).
+func TimeSignature(group, numerator, denominator, num32ndNotes uint8) Packet {
+	var denomPower uint8
+	for denominator > 1 {
+		denominator >>= 1
+		denomPower++
+	}
+	return flexPacket(group, 0, flexSetupBank, flexTimeSignature, uint32(numerator)<<24|uint32(denomPower)<<16|uint32(num32ndNotes)<<8)
+}
+
+// GetTimeSignature returns true if (and only if) the packet is a TimeSignatureMsg.
+// Then it also extracts the numerator, the denominator (e.g. 4 for quarter notes) and
+// the number of 1/32 notes per beat to the given arguments.
+// Only arguments that are not nil are parsed and filled.
+func (p Packet) GetTimeSignature(group, numerator, denominator, num32ndNotes *uint8) (is bool) {
+	if !p.Is(TimeSignatureMsg) {
+		return false
+	}
+
+	if group != nil {
+		*group = p.Group()
+	}
+
+	if numerator != nil {
+		*numerator = uint8(p[1] >> 24)
+	}
+
+	if denominator != nil {
+		*denominator = 1 << (uint8(p[1]>>16) & 0x7)
+	}
+
+	if num32ndNotes != nil {
+		*num32ndNotes = uint8(p[1] >> 8)
+	}
+	return true
+}
+
+// KeySignature returns a set key signature message for the given number of sharps (positive)
+// or flats (negative) between -7 and 7 and the tonic note (TonicUnknown, TonicA ... TonicG).
+func KeySignature(group uint8, sharpsFlats int8, tonic uint8) Packet {
+	return flexPacket(group, 0, flexSetupBank, flexKeySignature, uint32(uint8(sharpsFlats)&0xF)<<28|uint32(tonic&0xF)<<24)
+}
+
+// GetKeySignature returns true if (and only if) the packet is a KeySignatureMsg.
+// Then it also extracts the number of sharps (positive) or flats (negative) and the tonic note to the given arguments.
+// Only arguments that are not nil are parsed and filled.
+func (p Packet) GetKeySignature(group *uint8, sharpsFlats *int8, tonic *uint8) (is bool) {
+	if !p.Is(KeySignatureMsg) {
+		return false
+	}
+
+	if group != nil {
+		*group = p.Group()
+	}
+
+	if sharpsFlats != nil {
+		// sign extension of the 4-bit value
+		*sharpsFlats = int8(uint8(p[1]>>24)&0xF0) >> 4
+	}
+
+	if tonic != nil {
+		*tonic = uint8(p[1]>>24) & 0xF
+	}
+	return true
+}
+
+// flexTextPackets splits the text into flex data messages with the given status bank and status.
+func flexTextPackets(group, bank, status uint8, text string) (packets []Packet) {
+	data := []byte(text)
+
+	var format uint8
+	for {
+		n := 12
+		if n > len(data) {
+			n = len(data)
+		}
+
+		switch {
+		case len(packets) == 0 && n == len(data):
+			format = 0x0
+		case len(packets) == 0:
+			format = 0x1
+		case n == len(data):
+			format = 0x3
+		default:
+			format = 0x2
+		}
+
+		b := make([]byte, 12)
+		copy(b, data[:n])
+
+		packets = append(packets, flexPacket(group, format, bank, status,
+			binary.BigEndian.Uint32(b), binary.BigEndian.Uint32(b[4:]), binary.BigEndian.Uint32(b[8:])))
+
+		data = data[n:]
+		if len(data) == 0 {
+			return
+		}
+	}
+}
+
+// MetadataText returns the metadata text messages (e.g. ProjectName or CopyrightNotice) for the given UTF-8 text.
+func MetadataText(group, status uint8, text string) []Packet {
+	return flexTextPackets(group, MetadataTextBank, status, text)
+}
+
+// PerformanceText returns the performance text events (e.g. Lyrics) for the given UTF-8 text.
+func PerformanceText(group, status uint8, text string) []Packet {
+	return flexTextPackets(group, PerformanceTextBank, status, text)
+}
+
+// GetFlexText returns true if (and only if) the packet is a MetadataTextMsg or PerformanceTextMsg.
+// Then it also extracts the format (0x0 complete, 0x1 start, 0x2 continue, 0x3 end), the status bank
+// (MetadataTextBank or PerformanceTextBank), the status and the text fragment to the given arguments.
+// Only arguments that are not nil are parsed and filled.
+func (p Packet) GetFlexText(format, bank, status *uint8, text *string) (is bool) {
+	if !p.IsOneOf(MetadataTextMsg, PerformanceTextMsg) {
+		return false
+	}
+
+	if format != nil {
+		*format = p.byte1() >> 6
+	}
+
+	if bank != nil {
+		*bank = p.byte2()
+	}
+
+	if status != nil {
+		*status = p.byte3()
+	}
+
+	if text != nil {
+		*text = strings.TrimRight(string(p.Bytes()[4:]), "\x00")
+	}
+	return true
+}
+
+// JoinFlexText joins the text fragments of the given MetadataTextMsg and PerformanceTextMsg packets.
+// Other packets are ignored.
+func JoinFlexText(packets []Packet) string {
+	var bf bytes.Buffer
+	var text string
+
+	for _, p := range packets {
+		if p.GetFlexText(nil, nil, nil, &text) {
+			bf.WriteString(text)
+		}
+	}
+
+	return bf.String()
+}
@@ -0,0 +1,365 @@
+package ump
+
+const (
+	// PitchCenter is the pitch bend value of the center (no bending) for PitchBend and PerNotePitchBend
+	PitchCenter uint32 = 0x80000000
+
+	// ControllerCenter is the center value of 32-bit controllers (e.g. for pan)
+	ControllerCenter uint32 = 0x80000000
+)
+
+const (
+	opRegisteredPerNoteController  = 0x0
+	opAssignablePerNoteController  = 0x1
+	opRegisteredController         = 0x2
+	opAssignableController         = 0x3
+	opRelativeRegisteredController = 0x4
+	opRelativeAssignableController = 0x5
+	opPerNotePitchBend             = 0x6
+	opNoteOff                      = 0x8
+	opNoteOn                       = 0x9
+	opPolyPressure                 = 0xA
+	opControlChange                = 0xB
+	opProgramChange                = 0xC
+	opChannelPressure              = 0xD
+	opPitchBend                    = 0xE
+	opPerNoteManagement            = 0xF
+)
+
+// midi2 returns a MIDI 2.0 channel voice message
+func midi2(group, opcode, channel, index1, index2 uint8, data uint32) Packet {
+	if channel > 15 {
+		channel = 15
+	}
+	if index1 > 127 {
+		index1 = 127
+	}
+	return Packet{
+		word0(MIDI2ChannelVoiceMT, group, opcode<<4|channel, uint16(index1)<<8|uint16(index2)),
+		data,
+	}
+}
+
+// getMIDI2 extracts the fields of a MIDI 2.0 channel voice message of the given type
+func (p Packet) getMIDI2(typ Type, group, channel, index1, index2 *uint8, data *uint32) (is bool) {
+	if !p.Is(typ) {
+		return false
+	}
+
+	if group != nil {
+		*group = p.Group()
+	}
+
+	if channel != nil {
+		*channel = p.byte1() & 0xF
+	}
+
+	if index1 != nil {
+		*index1 = p.byte2() & 0x7F
+	}
+
+	if index2 != nil {
+		*index2 = p.byte3()
+	}
+
+	if data != nil {
+		*data = p[1]
+	}
+	return true
+}
+
+// NoteOn returns a MIDI 2.0 note on message with a 16-bit velocity.
+// Other than in MIDI 1.0, a velocity of 0 does not mean note off.
+func NoteOn(group, channel, key uint8, velocity uint16) Packet {
+	return NoteOnWithAttribute(group, channel, key, velocity, 0, 0)
+}
+
+// NoteOnWithAttribute returns a MIDI 2.0 note on message with a 16-bit velocity and an attribute of the given type
+// (e.g. 0x3 for pitch 7.9).
+func NoteOnWithAttribute(group, channel, key uint8, velocity uint16, attributeType uint8, attribute uint16) Packet {
+	return midi2(group, opNoteOn, channel, key, attributeType, uint32(velocity)<<16|uint32(attribute))
+}
+
+// NoteOff returns a MIDI 2.0 note off message with a 16-bit velocity.
+func NoteOff(group, channel, key uint8, velocity uint16) Packet {
+	return NoteOffWithAttribute(group, channel, key, velocity, 0, 0)
+}
+
+// NoteOffWithAttribute returns a MIDI 2.0 note off message with a 16-bit velocity and an attribute of the given type.
+func NoteOffWithAttribute(group, channel, key uint8, velocity uint16, attributeType uint8, attribute uint16) Packet {
+	return midi2(group, opNoteOff, channel, key, attributeType, uint32(velocity)<<16|uint32(attribute))
+}
+
+// PolyPressure returns a MIDI 2.0 polyphonic pressure message with a 32-bit pressure.
+func PolyPressure(group, channel, key uint8, pressure uint32) Packet {
+	return midi2(group, opPolyPressure, channel, key, 0, pressure)
+}
+
+// ControlChange returns a MIDI 2.0 control change message with a 32-bit value.
+func ControlChange(group, channel, controller uint8, value uint32) Packet {
+	return midi2(group, opControlChange, channel, controller, 0, value)
+}
+
+// RegisteredController returns a MIDI 2.0 registered controller (RPN) message with a 32-bit value.
+func RegisteredController(group, channel, bank, index uint8, value uint32) Packet {
+	return midi2(group, opRegisteredController, channel, bank, index&0x7F, value)
+}
+
+// AssignableController returns a MIDI 2.0 assignable controller (NRPN) message with a 32-bit value.
+func AssignableController(group, channel, bank, index uint8, value uint32) Packet {
+	return midi2(group, opAssignableController, channel, bank, index&0x7F, value)
+}
+
+// RelativeRegisteredController returns a MIDI 2.0 relative registered controller message with a 32-bit signed change.
+func RelativeRegisteredController(group, channel, bank, index uint8, change int32) Packet {
+	return midi2(group, opRelativeRegisteredController, channel, bank, index&0x7F, uint32(change))
+}
+
+// RelativeAssignableController returns a MIDI 2.0 relative assignable controller message with a 32-bit signed change.
+func RelativeAssignableController(group, channel, bank, index uint8, change int32) Packet {
+	return midi2(group, opRelativeAssignableController, channel, bank, index&0x7F, uint32(change))
+}
+
+// ProgramChange returns a MIDI 2.0 program change message without bank.
+func ProgramChange(group, channel, program uint8) Packet {
+	return midi2(group, opProgramChange, channel, 0, 0, uint32(program&0x7F)<<24)
+}
+
+// ProgramChangeWithBank returns a MIDI 2.0 program change message that also selects the given bank.
+func ProgramChangeWithBank(group, channel, program, bankMSB, bankLSB uint8) Packet {
+	return midi2(group, opProgramChange, channel, 0, 0x01, uint32(program&0x7F)<<24|uint32(bankMSB&0x7F)<<8|uint32(bankLSB&0x7F))
+}
+
+// ChannelPressure returns a MIDI 2.0 channel pressure message with a 32-bit pressure.
+func ChannelPressure(group, channel uint8, pressure uint32) Packet {
+	return midi2(group, opChannelPressure, channel, 0, 0, pressure)
+}
+
+// PitchBend returns a MIDI 2.0 pitch bend message with an unsigned 32-bit value (PitchCenter is the center).
+func PitchBend(group, channel uint8, value uint32) Packet {
+	return midi2(group, opPitchBend, channel, 0, 0, value)
+}
+
+// PerNotePitchBend returns a MIDI 2.0 per-note pitch bend message with an unsigned 32-bit value (PitchCenter is the center).
+func PerNotePitchBend(group, channel, key uint8, value uint32) Packet {
+	return midi2(group, opPerNotePitchBend, channel, key, 0, value)
+}
+
+// RegisteredPerNoteController returns a MIDI 2.0 registered per-note controller message with a 32-bit value.
+func RegisteredPerNoteController(group, channel, key, index uint8, value uint32) Packet {
+	return midi2(group, opRegisteredPerNoteController, channel, key, index, value)
+}
+
+// AssignablePerNoteController returns a MIDI 2.0 assignable per-note controller message with a 32-bit value.
+func AssignablePerNoteController(group, channel, key, index uint8, value uint32) Packet {
+	return midi2(group, opAssignablePerNoteController, channel, key, index, value)
+}
+
+// PerNoteManagement returns a MIDI 2.0 per-note management message.
+// If detach is true, the per-note controllers are detached from previously received notes.
+// If reset is true, the per-note controllers are reset to their default values.
+func PerNoteManagement(group, channel, key uint8, detach, reset bool) Packet {
+	var flags uint8
+	if detach {
+		flags |= 0x02
+	}
+	if reset {
+		flags |= 0x01
+	}
+	return midi2(group, opPerNoteManagement, channel, key, flags, 0)
+}
+
+// GetNoteOn returns true if (and only if) the packet is a NoteOnMsg.
+// Then it also extracts the data to the given arguments.
+// Only arguments that are not nil are parsed and filled.
+func (p Packet) GetNoteOn(group, channel, key *uint8, velocity *uint16) (is bool) {
+	var data uint32
+	if !p.getMIDI2(NoteOnMsg, group, channel, key, nil, &data) {
+		return false
+	}
+
+	if velocity != nil {
+		*velocity = uint16(data >> 16)
+	}
+	return true
+}
+
+// GetNoteOff returns true if (and only if) the packet is a NoteOffMsg.
+// Then it also extracts the data to the given arguments.
+// Only arguments that are not nil are parsed and filled.
+func (p Packet) GetNoteOff(group, channel, key *uint8, velocity *uint16) (is bool) {
+	var data uint32
+	if !p.getMIDI2(NoteOffMsg, group, channel, key, nil, &data) {
+		return false
+	}
+
+	if velocity != nil {
+		*velocity = uint16(data >> 16)
+	}
+	return true
+}
+
+// GetNoteAttribute returns true if (and only if) the packet is a NoteOnMsg or NoteOffMsg.
+// Then it also extracts the attribute type and the attribute to the given arguments.
+// Only arguments that are not nil are parsed and filled.
+func (p Packet) GetNoteAttribute(attributeType *uint8, attribute *uint16) (is bool) {
+	if !p.Is(NoteOnMsg) && !p.Is(NoteOffMsg) {
+		return false
+	}
+
+	if attributeType != nil {
+		*attributeType = p.byte3()
+	}
+
+	if attribute != nil {
+		*attribute = uint16(p[1])
+	}
+	return true
+}
+
+// GetPolyPressure returns true if (and only if) the packet is a PolyPressureMsg.
+// Then it also extracts the data to the given arguments.
+// Only arguments that are not nil are parsed and filled.
+func (p Packet) GetPolyPressure(group, channel, key *uint8, pressure *uint32) (is bool) {
+	return p.getMIDI2(PolyPressureMsg, group, channel, key, nil, pressure)
+}
+
+// GetControlChange returns true if (and only if) the packet is a ControlChangeMsg.
+// Then it also extracts the data to the given arguments.
+// Only arguments that are not nil are parsed and filled.
+func (p Packet) GetControlChange(group, channel, controller *uint8, value *uint32) (is bool) {
+	return p.getMIDI2(ControlChangeMsg, group, channel, controller, nil, value)
+}
+
+// GetRegisteredController returns true if (and only if) the packet is a RegisteredControllerMsg.
+// Then it also extracts the data to the given arguments.
+// Only arguments that are not nil are parsed and filled.
+func (p Packet) GetRegisteredController(group, channel, bank, index *uint8, value *uint32) (is bool) {
+	return p.getMIDI2(RegisteredControllerMsg, group, channel, bank, index, value)
+}
+
+// GetAssignableController returns true if (and only if) the packet is a AssignableControllerMsg.
+// Then it also extracts the data to the given arguments.
+// Only arguments that are not nil are parsed and filled.
+func (p Packet) GetAssignableController(group, channel, bank, index *uint8, value *uint32) (is bool) {
+	return p.getMIDI2(AssignableControllerMsg, group, channel, bank, index, value)
+}
+
+// GetRelativeRegisteredController returns true if (and only if) the packet is a RelativeRegisteredControllerMsg.
+// Then it also extracts the data to the given arguments.
+// Only arguments that are not nil are parsed and filled.
+func (p Packet) GetRelativeRegisteredController(group, channel, bank, index *uint8, change *int32) (is bool) {
+	var data uint32
+	if !p.getMIDI2(RelativeRegisteredControllerMsg, group, channel, bank, index, &data) {
+		return false
+	}
+
+	if change != nil {
+		*change = int32(data)
+	}
+	return true
+}
+
+// GetRelativeAssignableController returns true if (and only if) the packet is a RelativeAssignableControllerMsg.
+// Then it also extracts the data to the given arguments.
+// Only arguments that are not nil are parsed and filled.
+func (p Packet) GetRelativeAssignableController(group, channel, bank, index *uint8, change *int32) (is bool) {
+	var data uint32
+	if !p.getMIDI2(RelativeAssignableControllerMsg, group, channel, bank, index, &data) {
+		return false
+	}
+
+	if change != nil {
+		*change = int32(data)
+	}
+	return true
+}
+
+// GetProgramChange returns true if (and only if) the packet is a ProgramChangeMsg.
+// Then it also extracts the data to the given arguments.
+// Only arguments that are not nil are parsed and filled.
+func (p Packet) GetProgramChange(group, channel, program *uint8) (is bool) {
+	var data uint32
+	if !p.getMIDI2(ProgramChangeMsg, group, channel, nil, nil, &data) {
+		return false
+	}
+
+	if program != nil {
+		*program = uint8(data>>24) & 0x7F
+	}
+	return true
+}
+
+// GetProgramChangeBank returns true if (and only if) the packet is a ProgramChangeMsg that selects a bank.
+// Then it also extracts the bank to the given arguments.
+// Only arguments that are not nil are parsed and filled.
+func (p Packet) GetProgramChangeBank(bankMSB, bankLSB *uint8) (is bool) {
+	var flags uint8
+	var data uint32
+	if !p.getMIDI2(ProgramChangeMsg, nil, nil, nil, &flags, &data) || flags&0x01 == 0 {
+		return false
+	}
+
+	if bankMSB != nil {
+		*bankMSB = uint8(data>>8) & 0x7F
+	}
+
+	if bankLSB != nil {
+		*bankLSB = uint8(data) & 0x7F
+	}
+	return true
+}
+
+// GetChannelPressure returns true if (and only if) the packet is a ChannelPressureMsg.
+// Then it also extracts the data to the given arguments.
+// Only arguments that are not nil are parsed and filled.
+func (p Packet) GetChannelPressure(group, channel *uint8, pressure *uint32) (is bool) {
+	return p.getMIDI2(ChannelPressureMsg, group, channel, nil, nil, pressure)
+}
+
+// GetPitchBend returns true if (and only if) the packet is a PitchBendMsg.
+// Then it also extracts the data to the given arguments.
+// Only arguments that are not nil are parsed and filled.
+func (p Packet) GetPitchBend(group, channel *uint8, value *uint32) (is bool) {
+	return p.getMIDI2(PitchBendMsg, group, channel, nil, nil, value)
+}
+
+// GetPerNotePitchBend returns true if (and only if) the packet is a PerNotePitchBendMsg.
+// Then it also extracts the data to the given arguments.
+// Only arguments that are not nil are parsed and filled.
+func (p Packet) GetPerNotePitchBend(group, channel, key *uint8, value *uint32) (is bool) {
+	return p.getMIDI2(PerNotePitchBendMsg, group, channel, key, nil, value)
+}
+
+// GetRegisteredPerNoteController returns true if (and only if) the packet is a RegisteredPerNoteControllerMsg.
+// Then it also extracts the data to the given arguments.
+// Only arguments that are not nil are parsed and filled.
+func (p Packet) GetRegisteredPerNoteController(group, channel, key, index *uint8, value *uint32) (is bool) {
+	return p.getMIDI2(RegisteredPerNoteControllerMsg, group, channel, key, index, value)
+}
+
+// GetAssignablePerNoteController returns true if (and only if) the packet is a AssignablePerNoteControllerMsg.
+// Then it also extracts the data to the given arguments.
+// Only arguments that are not nil are parsed and filled.
+func (p Packet) GetAssignablePerNoteController(group, channel, key, index *uint8, value *uint32) (is bool) {
+	return p.getMIDI2(AssignablePerNoteControllerMsg, group, channel, key, index, value)
+}
+
+// GetPerNoteManagement returns true if (and only if) the packet is a PerNoteManagementMsg.
+// Then it also extracts the data to the given arguments.
+// Only arguments that are not nil are parsed and filled.
+func (p Packet) GetPerNoteManagement(group, channel, key *uint8, detach, reset *bool) (is bool) {
+	var flags uint8
+	if !p.getMIDI2(PerNoteManagementMsg, group, channel, key, &flags, nil) {
+		return false
+	}
+
+	if detach != nil {
+		*detach = flags&0x02 != 0
+	}
+
+	if reset != nil {
+		*reset = flags&0x01 != 0
+	}
+	return true
+}
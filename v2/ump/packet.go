@@ -0,0 +1,302 @@
+package ump
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"gitlab.com/gomidi/midi/v2"
+)
+
+// MessageType is the message type (MT) of a packet, defined by the first 4 bits.
+type MessageType uint8
+
+const (
+	// UtilityMT is the message type of the utility messages
+	UtilityMT MessageType = 0x0
+
+	// SystemMT is the message type of the system real time and system common messages
+	SystemMT MessageType = 0x1
+
+	// MIDI1ChannelVoiceMT is the message type of the MIDI 1.0 channel voice messages
+	MIDI1ChannelVoiceMT MessageType = 0x2
+
+	// Data64MT is the message type of the 64-bit data messages (SysEx7)
+	Data64MT MessageType = 0x3
+
+	// MIDI2ChannelVoiceMT is the message type of the MIDI 2.0 channel voice messages
+	MIDI2ChannelVoiceMT MessageType = 0x4
+
+	// Data128MT is the message type of the 128-bit data messages (SysEx8 and mixed data set)
+	Data128MT MessageType = 0x5
+
+	// FlexDataMT is the message type of the flex data messages
+	FlexDataMT MessageType = 0xD
+
+	// StreamMT is the message type of the UMP stream messages
+	StreamMT MessageType = 0xF
+)
+
+var wordsPerMessageType = [16]int{1, 1, 1, 2, 2, 4, 1, 1, 2, 2, 2, 3, 3, 4, 4, 4}
+
+// Size returns the number of 32-bit words of a packet of the message type.
+func (mt MessageType) Size() int {
+	return wordsPerMessageType[mt&0xF]
+}
+
+// Packet is a Universal MIDI Packet of one to four 32-bit words.
+type Packet []uint32
+
+// MessageType returns the message type of the packet.
+func (p Packet) MessageType() MessageType {
+	if len(p) == 0 {
+		return UtilityMT
+	}
+	return MessageType(p[0] >> 28)
+}
+
+// Group returns the group of the packet. For groupless messages (utility and stream messages), 0 is returned.
+func (p Packet) Group() uint8 {
+	if len(p) == 0 {
+		return 0
+	}
+
+	switch p.MessageType() {
+	case UtilityMT, StreamMT:
+		return 0
+	default:
+		return uint8(p[0]>>24) & 0xF
+	}
+}
+
+// IsValid returns true, if the packet has the size that is defined by its message type.
+func (p Packet) IsValid() bool {
+	return len(p) > 0 && len(p) == p.MessageType().Size()
+}
+
+// Bytes returns the bytes of the packet in big endian order (as they are transmitted).
+func (p Packet) Bytes() []byte {
+	b := make([]byte, len(p)*4)
+	for i, w := range p {
+		binary.BigEndian.PutUint32(b[i*4:], w)
+	}
+	return b
+}
+
+// status returns the 4 bit status (bits 20-23) of the first word
+func (p Packet) status() uint8 {
+	return uint8(p[0]>>20) & 0xF
+}
+
+// byte1 returns the second byte of the first word (the status byte of system and MIDI 1.0 channel voice messages)
+func (p Packet) byte1() uint8 {
+	return uint8(p[0] >> 16)
+}
+
+// byte2 returns the third byte of the first word
+func (p Packet) byte2() uint8 {
+	return uint8(p[0] >> 8)
+}
+
+// byte3 returns the fourth byte of the first word
+func (p Packet) byte3() uint8 {
+	return uint8(p[0])
+}
+
+// Type returns the type of the packet.
+func (p Packet) Type() Type {
+	if !p.IsValid() {
+		return UnknownMsg
+	}
+
+	var typ Type
+	var has bool
+
+	switch p.MessageType() {
+	case UtilityMT:
+		typ, has = utilityTypes[p.status()]
+	case SystemMT:
+		typ, has = systemTypes[p.byte1()]
+	case MIDI1ChannelVoiceMT:
+		typ, has = midi1Types[p.byte1()>>4]
+	case Data64MT:
+		typ, has = SysEx7Msg, p.status() <= SysExEnd
+	case MIDI2ChannelVoiceMT:
+		typ, has = midi2Types[p.status()]
+	case Data128MT:
+		switch p.status() {
+		case 0x0, 0x1, 0x2, 0x3:
+			typ, has = SysEx8Msg, true
+		case 0x8:
+			typ, has = MixedDataSetHeaderMsg, true
+		case 0x9:
+			typ, has = MixedDataSetPayloadMsg, true
+		}
+	case FlexDataMT:
+		switch p.byte2() {
+		case flexSetupBank:
+			typ, has = flexTypes[p.byte3()]
+		case MetadataTextBank:
+			typ, has = MetadataTextMsg, true
+		case PerformanceTextBank:
+			typ, has = PerformanceTextMsg, true
+		}
+	case StreamMT:
+		typ, has = streamTypes[uint16(p[0]>>16)&0x3FF]
+	}
+
+	if !has {
+		return UnknownMsg
+	}
+	return typ
+}
+
+// Is returns true, if the packet is of the given type.
+func (p Packet) Is(t Type) bool {
+	return p.Type().Is(t)
+}
+
+// IsOneOf returns true, if the packet has one of the given types.
+func (p Packet) IsOneOf(types ...Type) bool {
+	for _, t := range types {
+		if p.Is(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// String represents the packet as a string that contains the type and its properties.
+func (p Packet) String() string {
+	var bf bytes.Buffer
+	fmt.Fprint(&bf, p.Type().String())
+
+	var group, channel, key, val8, status uint8
+	var val16 uint16
+	var val32 uint32
+	var data []byte
+	var text string
+	var msg midi.Message
+	var bpm float64
+	var sharpsFlats int8
+
+	switch {
+	case p.GetNoteOn(&group, &channel, &key, &val16):
+		fmt.Fprintf(&bf, " group: %v channel: %v key: %v velocity: %v", group, channel, key, val16)
+	case p.GetNoteOff(&group, &channel, &key, &val16):
+		fmt.Fprintf(&bf, " group: %v channel: %v key: %v velocity: %v", group, channel, key, val16)
+	case p.GetPolyPressure(&group, &channel, &key, &val32):
+		fmt.Fprintf(&bf, " group: %v channel: %v key: %v pressure: %v", group, channel, key, val32)
+	case p.GetControlChange(&group, &channel, &key, &val32):
+		fmt.Fprintf(&bf, " group: %v channel: %v controller: %v value: %v", group, channel, key, val32)
+	case p.GetChannelPressure(&group, &channel, &val32):
+		fmt.Fprintf(&bf, " group: %v channel: %v pressure: %v", group, channel, val32)
+	case p.GetPitchBend(&group, &channel, &val32):
+		fmt.Fprintf(&bf, " group: %v channel: %v pitch: %v", group, channel, val32)
+	case p.GetProgramChange(&group, &channel, &key):
+		fmt.Fprintf(&bf, " group: %v channel: %v program: %v", group, channel, key)
+	case p.GetPerNotePitchBend(&group, &channel, &key, &val32):
+		fmt.Fprintf(&bf, " group: %v channel: %v key: %v pitch: %v", group, channel, key, val32)
+	case p.GetRegisteredPerNoteController(&group, &channel, &key, &val8, &val32),
+		p.GetAssignablePerNoteController(&group, &channel, &key, &val8, &val32):
+		fmt.Fprintf(&bf, " group: %v channel: %v key: %v index: %v value: %v", group, channel, key, val8, val32)
+	case p.GetRegisteredController(&group, &channel, &key, &val8, &val32),
+		p.GetAssignableController(&group, &channel, &key, &val8, &val32):
+		fmt.Fprintf(&bf, " group: %v channel: %v bank: %v index: %v value: %v", group, channel, key, val8, val32)
+	case p.GetMIDI1(&group, &msg):
+		fmt.Fprintf(&bf, " group: %v %s", group, msg)
+	case p.GetSysEx7(&group, &status, &data):
+		fmt.Fprintf(&bf, " group: %v status: %v data: % X", group, status, data)
+	case p.GetSysEx8(&group, &status, &val8, &data):
+		fmt.Fprintf(&bf, " group: %v status: %v stream: %v data: % X", group, status, val8, data)
+	case p.GetJRTimestamp(&val16), p.GetJRClock(&val16):
+		fmt.Fprintf(&bf, " time: %v", val16)
+	case p.GetDeltaClockstampTPQ(&val16):
+		fmt.Fprintf(&bf, " ticks: %v", val16)
+	case p.GetDeltaClockstamp(&val32):
+		fmt.Fprintf(&bf, " ticks: %v", val32)
+	case p.GetTempo(&group, &bpm):
+		fmt.Fprintf(&bf, " group: %v bpm: %0.2f", group, bpm)
+	case p.GetTimeSignature(&group, &key, &val8, &status):
+		fmt.Fprintf(&bf, " group: %v numerator: %v denominator: %v 32nd notes: %v", group, key, val8, status)
+	case p.GetKeySignature(&group, &sharpsFlats, &key):
+		fmt.Fprintf(&bf, " group: %v sharps/flats: %v tonic: %v", group, sharpsFlats, key)
+	case p.GetFlexText(nil, &key, &status, &text):
+		fmt.Fprintf(&bf, " group: %v bank: %v status: %v text: %q", p.Group(), key, status, text)
+	case p.GetStreamText(&status, &text):
+		fmt.Fprintf(&bf, " status: %v text: %q", status, text)
+	default:
+		fmt.Fprintf(&bf, " % X", p.Bytes())
+	}
+
+	return bf.String()
+}
+
+// Parse splits the given words into packets.
+// An error is returned, if the last packet is incomplete.
+func Parse(words []uint32) (packets []Packet, err error) {
+	for len(words) > 0 {
+		size := MessageType(words[0] >> 28).Size()
+		if size > len(words) {
+			return packets, fmt.Errorf("incomplete packet: % X", words)
+		}
+		packets = append(packets, Packet(words[:size:size]))
+		words = words[size:]
+	}
+	return
+}
+
+// ParseBytes parses the given bytes (in big endian order) into packets.
+// An error is returned, if the last packet is incomplete.
+func ParseBytes(data []byte) ([]Packet, error) {
+	return ReadAll(bytes.NewReader(data))
+}
+
+// ReadAll reads the packets from the given reader (in big endian order) until io.EOF.
+func ReadAll(rd io.Reader) (packets []Packet, err error) {
+	for {
+		p, err := ReadPacket(rd)
+		if err == io.EOF {
+			return packets, nil
+		}
+		if err != nil {
+			return packets, err
+		}
+		packets = append(packets, p)
+	}
+}
+
+// ReadPacket reads the next packet from the given reader (in big endian order).
+// If there is no more data, io.EOF is returned. If the packet is incomplete, io.ErrUnexpectedEOF is returned.
+func ReadPacket(rd io.Reader) (Packet, error) {
+	var b [16]byte
+
+	_, err := io.ReadFull(rd, b[:4])
+	if err != nil {
+		return nil, err
+	}
+
+	size := MessageType(b[0] >> 4).Size()
+
+	if size > 1 {
+		_, err = io.ReadFull(rd, b[4:size*4])
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	p := make(Packet, size)
+	for i := range p {
+		p[i] = binary.BigEndian.Uint32(b[i*4:])
+	}
+	return p, nil
+}
+
+// word0 returns the first word for the given message type, group, status and the lower 16 bits
+func word0(mt MessageType, group, status uint8, lower uint16) uint32 {
+	return uint32(mt&0xF)<<28 | uint32(group&0xF)<<24 | uint32(status)<<16 | uint32(lower)
+}
@@ -0,0 +1,293 @@
+package ump
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"gitlab.com/gomidi/midi/v2"
+)
+
+func TestPacketString(t *testing.T) {
+	tests := []struct {
+		packet   Packet
+		expected string
+	}{
+		{
+			NoteOn(1, 2, 60, 0x8000),
+			"NoteOn group: 1 channel: 2 key: 60 velocity: 32768",
+		},
+		{
+			NoteOff(0, 0, 61, 0),
+			"NoteOff group: 0 channel: 0 key: 61 velocity: 0",
+		},
+		{
+			ControlChange(0, 3, 7, 0x80000000),
+			"ControlChange group: 0 channel: 3 controller: 7 value: 2147483648",
+		},
+		{
+			PitchBend(2, 1, PitchCenter),
+			"PitchBend group: 2 channel: 1 pitch: 2147483648",
+		},
+		{
+			RegisteredController(0, 0, 0, 1, 5),
+			"RegisteredController group: 0 channel: 0 bank: 0 index: 1 value: 5",
+		},
+		{
+			MIDI1(3, midi.NoteOn(1, 60, 100)),
+			"MIDI1NoteOn group: 3 NoteOn channel: 1 key: 60 velocity: 100",
+		},
+		{
+			MIDI1(0, midi.Start()),
+			"Start group: 0 Start",
+		},
+		{
+			JRTimestamp(1000),
+			"JRTimestamp time: 1000",
+		},
+		{
+			DeltaClockstamp(480),
+			"DeltaClockstamp ticks: 480",
+		},
+		{
+			Packet{0x40700000, 0},
+			"Unknown 40 70 00 00 00 00 00 00",
+		},
+	}
+
+	for i, test := range tests {
+		got := test.packet.String()
+
+		if got != test.expected {
+			t.Errorf("[%v] %#v.String() = %q; wanted %q", i, test.packet, got, test.expected)
+		}
+	}
+}
+
+func TestMIDI2Values(t *testing.T) {
+	var group, channel, key, bankMSB, bankLSB uint8
+	var velocity uint16
+
+	p := NoteOnWithAttribute(5, 9, 64, 0x1234, 3, 0x0200)
+
+	if !p.GetNoteOn(&group, &channel, &key, &velocity) {
+		t.Fatalf("%s is no NoteOn", p)
+	}
+
+	if group != 5 || channel != 9 || key != 64 || velocity != 0x1234 {
+		t.Errorf("GetNoteOn() = %v, %v, %v, %v", group, channel, key, velocity)
+	}
+
+	var attrType uint8
+	var attr uint16
+	if !p.GetNoteAttribute(&attrType, &attr) || attrType != 3 || attr != 0x0200 {
+		t.Errorf("GetNoteAttribute() = %v, %v", attrType, attr)
+	}
+
+	p = ProgramChangeWithBank(0, 1, 10, 2, 3)
+	if !p.GetProgramChange(nil, &channel, &key) || channel != 1 || key != 10 {
+		t.Errorf("GetProgramChange() = %v, %v", channel, key)
+	}
+
+	if !p.GetProgramChangeBank(&bankMSB, &bankLSB) || bankMSB != 2 || bankLSB != 3 {
+		t.Errorf("GetProgramChangeBank() = %v, %v", bankMSB, bankLSB)
+	}
+
+	if ProgramChange(0, 1, 10).GetProgramChangeBank(nil, nil) {
+		t.Errorf("ProgramChange without bank must not have a bank")
+	}
+
+	var change int32
+	p = RelativeAssignableController(0, 0, 1, 2, -300)
+	if !p.GetRelativeAssignableController(nil, nil, nil, nil, &change) || change != -300 {
+		t.Errorf("GetRelativeAssignableController() = %v", change)
+	}
+
+	var detach, reset bool
+	p = PerNoteManagement(0, 0, 60, false, true)
+	if !p.GetPerNoteManagement(nil, nil, nil, &detach, &reset) || detach || !reset {
+		t.Errorf("GetPerNoteManagement() = %v, %v", detach, reset)
+	}
+}
+
+func TestParseBytes(t *testing.T) {
+	packets := []Packet{
+		NOOP(),
+		MIDI1(0, midi.NoteOn(0, 60, 100)),
+		NoteOn(0, 0, 60, 0xFFFF),
+		EndOfClip(),
+	}
+
+	var bf bytes.Buffer
+	for _, p := range packets {
+		bf.Write(p.Bytes())
+	}
+
+	got, err := ParseBytes(bf.Bytes())
+	if err != nil {
+		t.Fatalf("ParseBytes() returned error: %v", err)
+	}
+
+	if !reflect.DeepEqual(got, packets) {
+		t.Errorf("ParseBytes() = %v; wanted %v", got, packets)
+	}
+
+	_, err = ParseBytes(bf.Bytes()[:bf.Len()-2])
+	if err == nil {
+		t.Errorf("ParseBytes() on incomplete data must return an error")
+	}
+
+	var words []uint32
+	for _, p := range packets {
+		words = append(words, p...)
+	}
+
+	got, err = Parse(words)
+	if err != nil {
+		t.Fatalf("Parse() returned error: %v", err)
+	}
+
+	if !reflect.DeepEqual(got, packets) {
+		t.Errorf("Parse() = %v; wanted %v", got, packets)
+	}
+}
+
+func TestSysEx(t *testing.T) {
+	tests := []struct {
+		data     []byte
+		statuses []uint8
+	}{
+		{
+			[]byte{0xF0, 0x7E, 0x7F, 0x06, 0x01, 0xF7},
+			[]uint8{SysExComplete},
+		},
+		{
+			[]byte{1, 2, 3, 4, 5, 6},
+			[]uint8{SysExComplete},
+		},
+		{
+			[]byte{1, 2, 3, 4, 5, 6, 7},
+			[]uint8{SysExStart, SysExEnd},
+		},
+		{
+			[]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13},
+			[]uint8{SysExStart, SysExContinue, SysExEnd},
+		},
+	}
+
+	for i, test := range tests {
+		packets := SysEx7(2, test.data)
+
+		var statuses []uint8
+		var data []byte
+
+		for _, p := range packets {
+			var group, status uint8
+			var d []byte
+			if !p.GetSysEx7(&group, &status, &d) {
+				t.Fatalf("[%v] %s is no SysEx7", i, p)
+			}
+			if group != 2 {
+				t.Errorf("[%v] group = %v; wanted 2", i, group)
+			}
+			statuses = append(statuses, status)
+			data = append(data, d...)
+		}
+
+		if !reflect.DeepEqual(statuses, test.statuses) {
+			t.Errorf("[%v] statuses = %v; wanted %v", i, statuses, test.statuses)
+		}
+
+		if !bytes.Equal(data, trimSysEx(test.data)) {
+			t.Errorf("[%v] data = % X; wanted % X", i, data, trimSysEx(test.data))
+		}
+	}
+
+	data := []byte("a longer message for eight bit system exclusive")
+	var got []byte
+	for _, p := range SysEx8(1, 7, data) {
+		var streamID uint8
+		var d []byte
+		if !p.GetSysEx8(nil, nil, &streamID, &d) || streamID != 7 {
+			t.Fatalf("%s is no SysEx8 of stream 7", p)
+		}
+		got = append(got, d...)
+	}
+
+	if !bytes.Equal(got, data) {
+		t.Errorf("SysEx8 data = %q; wanted %q", got, data)
+	}
+}
+
+func TestStream(t *testing.T) {
+	name := "gomidi endpoint with a long name"
+
+	if got := JoinStreamText(EndpointName(name)); got != name {
+		t.Errorf("JoinStreamText(EndpointName(%q)) = %q", name, got)
+	}
+
+	info := FunctionBlockInfo{
+		Number:           3,
+		Active:           true,
+		UIHint:           0x3,
+		MIDI1:            0x1,
+		Direction:        0x2,
+		FirstGroup:       1,
+		NumGroups:        2,
+		MIDICIVersion:    1,
+		MaxSysEx8Streams: 4,
+	}
+
+	var got FunctionBlockInfo
+	if !info.Packet().GetFunctionBlockInfo(&got) {
+		t.Fatalf("%s is no FunctionBlockInfo", info.Packet())
+	}
+
+	if got != info {
+		t.Errorf("GetFunctionBlockInfo() = %+v; wanted %+v", got, info)
+	}
+
+	var protocol uint8
+	var rx, tx bool
+	if !StreamConfigRequest(ProtocolMIDI2, true, false).GetStreamConfig(&protocol, &rx, &tx) {
+		t.Fatalf("StreamConfigRequest is no stream configuration")
+	}
+
+	if protocol != ProtocolMIDI2 || !rx || tx {
+		t.Errorf("GetStreamConfig() = %v, %v, %v", protocol, rx, tx)
+	}
+}
+
+func TestFlexData(t *testing.T) {
+	var bpm float64
+	if !Tempo(1, 120).GetTempo(nil, &bpm) || bpm != 120 {
+		t.Errorf("GetTempo() = %v; wanted 120", bpm)
+	}
+
+	var num, denom, n32 uint8
+	if !TimeSignature(0, 6, 8, 8).GetTimeSignature(nil, &num, &denom, &n32) || num != 6 || denom != 8 || n32 != 8 {
+		t.Errorf("GetTimeSignature() = %v, %v, %v", num, denom, n32)
+	}
+
+	var sf int8
+	var tonic uint8
+	if !KeySignature(0, -4, TonicF).GetKeySignature(nil, &sf, &tonic) || sf != -4 || tonic != TonicF {
+		t.Errorf("GetKeySignature() = %v, %v", sf, tonic)
+	}
+
+	text := "a lyric that needs more than one packet"
+	packets := PerformanceText(2, Lyrics, text)
+
+	if len(packets) != 4 {
+		t.Errorf("len(PerformanceText()) = %v; wanted 4", len(packets))
+	}
+
+	var bank, status uint8
+	if !packets[0].GetFlexText(nil, &bank, &status, nil) || bank != PerformanceTextBank || status != Lyrics || packets[0].Group() != 2 {
+		t.Errorf("GetFlexText() = %v, %v", bank, status)
+	}
+
+	if got := JoinFlexText(packets); got != text {
+		t.Errorf("JoinFlexText() = %q; wanted %q", got, text)
+	}
+}
@@ -0,0 +1,43 @@
+package ump
+
+// ScaleUp scales the given value with srcBits bits to a value with dstBits bits, following the
+// min-center-max scaling of the MIDI 2.0 specification: the minimum, the center and the maximum are
+// kept and the values in between are spread evenly.
+func ScaleUp(value uint32, srcBits, dstBits uint8) uint32 {
+	if srcBits >= dstBits {
+		return ScaleDown(value, srcBits, dstBits)
+	}
+
+	scaleBits := dstBits - srcBits
+	shifted := value << scaleBits
+	center := uint32(1) << (srcBits - 1)
+
+	if value <= center {
+		return shifted
+	}
+
+	// expand the bits below the highest bit into the lower bits
+	repeatBits := srcBits - 1
+	repeatValue := value & (1<<repeatBits - 1)
+
+	if scaleBits > repeatBits {
+		repeatValue <<= scaleBits - repeatBits
+	} else {
+		repeatValue >>= repeatBits - scaleBits
+	}
+
+	for repeatValue != 0 {
+		shifted |= repeatValue
+		repeatValue >>= repeatBits
+	}
+
+	return shifted
+}
+
+// ScaleDown scales the given value with srcBits bits to a value with dstBits bits by dropping the lower bits.
+func ScaleDown(value uint32, srcBits, dstBits uint8) uint32 {
+	if srcBits <= dstBits {
+		return value
+	}
+	return value >> (srcBits - dstBits)
+}
@@ -0,0 +1,393 @@
+package ump
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+)
+
+// The protocols of a stream configuration.
+const (
+	// ProtocolMIDI1 is the MIDI 1.0 protocol (MIDI 1.0 channel voice messages)
+	ProtocolMIDI1 uint8 = 0x01
+
+	// ProtocolMIDI2 is the MIDI 2.0 protocol (MIDI 2.0 channel voice messages)
+	ProtocolMIDI2 uint8 = 0x02
+)
+
+// The filter bits of an endpoint discovery message.
+const (
+	FilterEndpointInfo      uint8 = 0x01
+	FilterDeviceIdentity    uint8 = 0x02
+	FilterEndpointName      uint8 = 0x04
+	FilterProductInstanceID uint8 = 0x08
+	FilterStreamConfig      uint8 = 0x10
+)
+
+// The filter bits of a function block discovery message.
+const (
+	FilterFunctionBlockInfo uint8 = 0x01
+	FilterFunctionBlockName uint8 = 0x02
+)
+
+// AllFunctionBlocks is the function block number for the discovery of all function blocks
+const AllFunctionBlocks uint8 = 0xFF
+
+const (
+	streamEndpointDiscovery      = 0x000
+	streamEndpointInfo           = 0x001
+	streamDeviceIdentity         = 0x002
+	streamEndpointName           = 0x003
+	streamProductInstanceID      = 0x004
+	streamConfigRequest          = 0x005
+	streamConfigNotification     = 0x006
+	streamFunctionBlockDiscovery = 0x010
+	streamFunctionBlockInfo      = 0x011
+	streamFunctionBlockName      = 0x012
+	streamStartOfClip            = 0x020
+	streamEndOfClip              = 0x021
+)
+
+// streamFormatComplete is the format of a complete stream message (not split into multiple packets)
+const streamFormatComplete uint8 = 0x0
+
+// streamPacket returns a UMP stream message with the given format, status and data
+func streamPacket(format uint8, status uint16, data uint16, words ...uint32) Packet {
+	p := Packet{uint32(StreamMT)<<28 | uint32(format&0x3)<<26 | uint32(status&0x3FF)<<16 | uint32(data), 0, 0, 0}
+	copy(p[1:], words)
+	return p
+}
+
+// EndpointDiscovery returns a message to discover the properties of an endpoint.
+// The filter is a combination of FilterEndpointInfo, FilterDeviceIdentity, FilterEndpointName,
+// FilterProductInstanceID and FilterStreamConfig.
+func EndpointDiscovery(versionMajor, versionMinor, filter uint8) Packet {
+	return streamPacket(streamFormatComplete, streamEndpointDiscovery, uint16(versionMajor)<<8|uint16(versionMinor), uint32(filter))
+}
+
+// GetEndpointDiscovery returns true if (and only if) the packet is a EndpointDiscoveryMsg.
+// Then it also extracts the data to the given arguments.
+// Only arguments that are not nil are parsed and filled.
+func (p Packet) GetEndpointDiscovery(versionMajor, versionMinor, filter *uint8) (is bool) {
+	if !p.Is(EndpointDiscoveryMsg) {
+		return false
+	}
+
+	if versionMajor != nil {
+		*versionMajor = p.byte2()
+	}
+
+	if versionMinor != nil {
+		*versionMinor = p.byte3()
+	}
+
+	if filter != nil {
+		*filter = uint8(p[1])
+	}
+	return true
+}
+
+// EndpointInfo are the properties of an endpoint, as reported by an endpoint info notification.
+type EndpointInfo struct {
+	VersionMajor         uint8
+	VersionMinor         uint8
+	StaticFunctionBlocks bool
+	NumFunctionBlocks    uint8
+	MIDI2                bool
+	MIDI1                bool
+	ReceiveJR            bool
+	TransmitJR           bool
+}
+
+// Packet returns the endpoint info notification.
+func (e EndpointInfo) Packet() Packet {
+	var w uint32 = uint32(e.NumFunctionBlocks&0x7F) << 24
+	w |= boolBit(e.StaticFunctionBlocks, 31) | boolBit(e.MIDI2, 9) | boolBit(e.MIDI1, 8)
+	w |= boolBit(e.ReceiveJR, 1) | boolBit(e.TransmitJR, 0)
+	return streamPacket(streamFormatComplete, streamEndpointInfo, uint16(e.VersionMajor)<<8|uint16(e.VersionMinor), w)
+}
+
+// GetEndpointInfo returns true if (and only if) the packet is a EndpointInfoMsg.
+// Then it also extracts the info to the given argument.
+// Only arguments that are not nil are parsed and filled.
+func (p Packet) GetEndpointInfo(info *EndpointInfo) (is bool) {
+	if !p.Is(EndpointInfoMsg) {
+		return false
+	}
+
+	if info != nil {
+		w := p[1]
+		*info = EndpointInfo{
+			VersionMajor:         p.byte2(),
+			VersionMinor:         p.byte3(),
+			StaticFunctionBlocks: w&(1<<31) != 0,
+			NumFunctionBlocks:    uint8(w>>24) & 0x7F,
+			MIDI2:                w&(1<<9) != 0,
+			MIDI1:                w&(1<<8) != 0,
+			ReceiveJR:            w&(1<<1) != 0,
+			TransmitJR:           w&1 != 0,
+		}
+	}
+	return true
+}
+
+// DeviceIdentity is the identity of a device, as reported by a device identity notification.
+type DeviceIdentity struct {
+	// Manufacturer is the system exclusive ID of the manufacturer (for one byte IDs, the first two bytes are 0)
+	Manufacturer     [3]byte
+	Family           uint16
+	Model            uint16
+	SoftwareRevision [4]byte
+}
+
+// Packet returns the device identity notification.
+func (d DeviceIdentity) Packet() Packet {
+	return streamPacket(streamFormatComplete, streamDeviceIdentity, 0,
+		0,
+		uint32(d.Manufacturer[0]&0x7F)<<16|uint32(d.Manufacturer[1]&0x7F)<<8|uint32(d.Manufacturer[2]&0x7F),
+		uint32(d.Family&0x7F)<<24|uint32(d.Family>>7&0x7F)<<16|uint32(d.Model&0x7F)<<8|uint32(d.Model>>7&0x7F),
+		binary.BigEndian.Uint32(d.SoftwareRevision[:]),
+	)
+}
+
+// GetDeviceIdentity returns true if (and only if) the packet is a DeviceIdentityMsg.
+// Then it also extracts the identity to the given argument.
+// Only arguments that are not nil are parsed and filled.
+func (p Packet) GetDeviceIdentity(id *DeviceIdentity) (is bool) {
+	if !p.Is(DeviceIdentityMsg) {
+		return false
+	}
+
+	if id != nil {
+		b := p.Bytes()
+		id.Manufacturer = [3]byte{b[5], b[6], b[7]}
+		id.Family = uint16(b[8]) | uint16(b[9])<<7
+		id.Model = uint16(b[10]) | uint16(b[11])<<7
+		copy(id.SoftwareRevision[:], b[12:16])
+	}
+	return true
+}
+
+// textPackets splits the text into stream messages with the given status.
+// The first bytes of the first word are filled with the given prefix.
+func textPackets(status uint16, prefix []byte, text string) (packets []Packet) {
+	max := 14 - len(prefix)
+	data := []byte(text)
+
+	var format uint8
+	for {
+		n := max
+		if n > len(data) {
+			n = len(data)
+		}
+
+		switch {
+		case len(packets) == 0 && n == len(data):
+			format = 0x0
+		case len(packets) == 0:
+			format = 0x1
+		case n == len(data):
+			format = 0x3
+		default:
+			format = 0x2
+		}
+
+		b := make([]byte, 16)
+		copy(b[2:], prefix)
+		copy(b[2+len(prefix):], data[:n])
+
+		p := streamPacket(format, status, binary.BigEndian.Uint16(b[2:4]),
+			binary.BigEndian.Uint32(b[4:]), binary.BigEndian.Uint32(b[8:]), binary.BigEndian.Uint32(b[12:]))
+		packets = append(packets, p)
+
+		data = data[n:]
+		if len(data) == 0 {
+			return
+		}
+	}
+}
+
+// EndpointName returns the endpoint name notifications for the given UTF-8 name (up to 98 bytes).
+func EndpointName(name string) []Packet {
+	return textPackets(streamEndpointName, nil, name)
+}
+
+// ProductInstanceID returns the product instance ID notifications for the given ID (up to 42 bytes).
+func ProductInstanceID(id string) []Packet {
+	return textPackets(streamProductInstanceID, nil, id)
+}
+
+// FunctionBlockName returns the function block name notifications for the given function block and UTF-8 name (up to 91 bytes).
+func FunctionBlockName(functionBlock uint8, name string) []Packet {
+	return textPackets(streamFunctionBlockName, []byte{functionBlock}, name)
+}
+
+// GetStreamText returns true if (and only if) the packet is a EndpointNameMsg, ProductInstanceIDMsg or FunctionBlockNameMsg.
+// Then it also extracts the format (0x0 complete, 0x1 start, 0x2 continue, 0x3 end) and the text fragment to the given arguments.
+// Only arguments that are not nil are parsed and filled.
+func (p Packet) GetStreamText(format *uint8, text *string) (is bool) {
+	if !p.IsOneOf(EndpointNameMsg, ProductInstanceIDMsg, FunctionBlockNameMsg) {
+		return false
+	}
+
+	if format != nil {
+		*format = uint8(p[0]>>26) & 0x3
+	}
+
+	if text != nil {
+		b := p.Bytes()[2:]
+		if p.Is(FunctionBlockNameMsg) {
+			b = b[1:]
+		}
+		*text = strings.TrimRight(string(b), "\x00")
+	}
+	return true
+}
+
+// JoinStreamText joins the text fragments of the given EndpointNameMsg, ProductInstanceIDMsg or FunctionBlockNameMsg packets.
+// Other packets are ignored.
+func JoinStreamText(packets []Packet) string {
+	var bf bytes.Buffer
+	var text string
+
+	for _, p := range packets {
+		if p.GetStreamText(nil, &text) {
+			bf.WriteString(text)
+		}
+	}
+
+	return bf.String()
+}
+
+// StreamConfigRequest returns a message to request the given protocol (ProtocolMIDI1 or ProtocolMIDI2) and
+// jitter reduction timestamps.
+func StreamConfigRequest(protocol uint8, receiveJR, transmitJR bool) Packet {
+	return streamPacket(streamFormatComplete, streamConfigRequest, streamConfigData(protocol, receiveJR, transmitJR))
+}
+
+// StreamConfigNotification returns a message to notify about the current protocol and jitter reduction timestamps.
+func StreamConfigNotification(protocol uint8, receiveJR, transmitJR bool) Packet {
+	return streamPacket(streamFormatComplete, streamConfigNotification, streamConfigData(protocol, receiveJR, transmitJR))
+}
+
+func streamConfigData(protocol uint8, receiveJR, transmitJR bool) uint16 {
+	return uint16(protocol)<<8 | uint16(boolBit(receiveJR, 1)|boolBit(transmitJR, 0))
+}
+
+// GetStreamConfig returns true if (and only if) the packet is a StreamConfigRequestMsg or a StreamConfigNotificationMsg.
+// Then it also extracts the data to the given arguments.
+// Only arguments that are not nil are parsed and filled.
+func (p Packet) GetStreamConfig(protocol *uint8, receiveJR, transmitJR *bool) (is bool) {
+	if !p.IsOneOf(StreamConfigRequestMsg, StreamConfigNotificationMsg) {
+		return false
+	}
+
+	if protocol != nil {
+		*protocol = p.byte2()
+	}
+
+	if receiveJR != nil {
+		*receiveJR = p.byte3()&0x02 != 0
+	}
+
+	if transmitJR != nil {
+		*transmitJR = p.byte3()&0x01 != 0
+	}
+	return true
+}
+
+// FunctionBlockDiscovery returns a message to discover the given function block (AllFunctionBlocks for all).
+// The filter is a combination of FilterFunctionBlockInfo and FilterFunctionBlockName.
+func FunctionBlockDiscovery(functionBlock, filter uint8) Packet {
+	return streamPacket(streamFormatComplete, streamFunctionBlockDiscovery, uint16(functionBlock)<<8|uint16(filter))
+}
+
+// GetFunctionBlockDiscovery returns true if (and only if) the packet is a FunctionBlockDiscoveryMsg.
+// Then it also extracts the data to the given arguments.
+// Only arguments that are not nil are parsed and filled.
+func (p Packet) GetFunctionBlockDiscovery(functionBlock, filter *uint8) (is bool) {
+	if !p.Is(FunctionBlockDiscoveryMsg) {
+		return false
+	}
+
+	if functionBlock != nil {
+		*functionBlock = p.byte2()
+	}
+
+	if filter != nil {
+		*filter = p.byte3()
+	}
+	return true
+}
+
+// FunctionBlockInfo are the properties of a function block, as reported by a function block info notification.
+type FunctionBlockInfo struct {
+	Number uint8
+	Active bool
+
+	// UIHint is 0x1 for a receiver, 0x2 for a sender and 0x3 for both
+	UIHint uint8
+
+	// MIDI1 is 0x0 for no MIDI 1.0, 0x1 for MIDI 1.0 and 0x2 for MIDI 1.0 restricted to 31.25kbps
+	MIDI1 uint8
+
+	// Direction is 0x1 for input, 0x2 for output and 0x3 for bidirectional
+	Direction uint8
+
+	FirstGroup       uint8
+	NumGroups        uint8
+	MIDICIVersion    uint8
+	MaxSysEx8Streams uint8
+}
+
+// Packet returns the function block info notification.
+func (f FunctionBlockInfo) Packet() Packet {
+	data := uint16(f.Number&0x7F)<<8 | uint16(f.UIHint&0x3)<<4 | uint16(f.MIDI1&0x3)<<2 | uint16(f.Direction&0x3)
+	if f.Active {
+		data |= 0x8000
+	}
+	return streamPacket(streamFormatComplete, streamFunctionBlockInfo, data,
+		uint32(f.FirstGroup)<<24|uint32(f.NumGroups)<<16|uint32(f.MIDICIVersion)<<8|uint32(f.MaxSysEx8Streams))
+}
+
+// GetFunctionBlockInfo returns true if (and only if) the packet is a FunctionBlockInfoMsg.
+// Then it also extracts the info to the given argument.
+// Only arguments that are not nil are parsed and filled.
+func (p Packet) GetFunctionBlockInfo(info *FunctionBlockInfo) (is bool) {
+	if !p.Is(FunctionBlockInfoMsg) {
+		return false
+	}
+
+	if info != nil {
+		b := p.Bytes()
+		*info = FunctionBlockInfo{
+			Number:           b[2] & 0x7F,
+			Active:           b[2]&0x80 != 0,
+			UIHint:           b[3] >> 4 & 0x3,
+			MIDI1:            b[3] >> 2 & 0x3,
+			Direction:        b[3] & 0x3,
+			FirstGroup:       b[4],
+			NumGroups:        b[5],
+			MIDICIVersion:    b[6],
+			MaxSysEx8Streams: b[7],
+		}
+	}
+	return true
+}
+
+// StartOfClip returns the message that marks the start of a clip (of a MIDI clip file).
+func StartOfClip() Packet {
+	return streamPacket(streamFormatComplete, streamStartOfClip, 0)
+}
+
+// EndOfClip returns the message that marks the end of a clip (of a MIDI clip file).
+func EndOfClip() Packet {
+	return streamPacket(streamFormatComplete, streamEndOfClip, 0)
+}
+
+func boolBit(b bool, bit uint) uint32 {
+	if b {
+		return 1 << bit
+	}
+	return 0
+}
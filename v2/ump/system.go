@@ -0,0 +1,63 @@
+package ump
+
+import (
+	"gitlab.com/gomidi/midi/v2"
+)
+
+// MIDI1 returns the packet for the given MIDI 1.0 channel, system common or system real time message
+// within the given group (message type 0x1 or 0x2).
+// For system exclusive messages, see SysEx7. For invalid messages, nil is returned.
+func MIDI1(group uint8, msg midi.Message) Packet {
+	if len(msg) == 0 || len(msg) > 3 || len(msg) != midi1Length(msg[0]) {
+		return nil
+	}
+
+	mt := SystemMT
+	if msg[0] < 0xF0 {
+		mt = MIDI1ChannelVoiceMT
+	}
+
+	var b [3]byte
+	copy(b[:], msg)
+	return Packet{word0(mt, group, b[0], uint16(b[1])<<8|uint16(b[2]))}
+}
+
+// midi1Length returns the length of the MIDI 1.0 message with the given status byte (0 for sysex and invalid status bytes)
+func midi1Length(status byte) int {
+	switch {
+	case status < 0x80:
+		return 0
+	case status < 0xF0:
+		if typ := status >> 4; typ == 0xC || typ == 0xD {
+			return 2
+		}
+		return 3
+	case status == 0xF1 || status == 0xF3:
+		return 2
+	case status == 0xF2:
+		return 3
+	case status == 0xF6 || status >= 0xF8:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// GetMIDI1 returns true if (and only if) the packet is a SystemMsg or a MIDI1ChannelVoiceMsg.
+// Then it also extracts the group and the MIDI 1.0 message to the given arguments.
+// Only arguments that are not nil are parsed and filled.
+func (p Packet) GetMIDI1(group *uint8, msg *midi.Message) (is bool) {
+	if !p.Is(SystemMsg) && !p.Is(MIDI1ChannelVoiceMsg) {
+		return false
+	}
+
+	if group != nil {
+		*group = p.Group()
+	}
+
+	if msg != nil {
+		b := []byte{p.byte1(), p.byte2(), p.byte3()}
+		*msg = midi.Message(b[:midi1Length(b[0])])
+	}
+	return true
+}
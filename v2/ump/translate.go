@@ -0,0 +1,235 @@
+package ump
+
+import (
+	"gitlab.com/gomidi/midi/v2"
+)
+
+// FromMIDI1 returns the packets for the given MIDI 1.0 message within the given group, using the MIDI 1.0 protocol
+// (message type 0x2 for channel voice messages). This translation is lossless.
+// System exclusive messages are split into multiple SysEx7 packets.
+func FromMIDI1(group uint8, msg midi.Message) []Packet {
+	if len(msg) > 0 && msg[0] == 0xF0 {
+		return SysEx7(group, msg)
+	}
+
+	p := MIDI1(group, msg)
+	if p == nil {
+		return nil
+	}
+	return []Packet{p}
+}
+
+// Translator translates between MIDI 1.0 messages and packets using the MIDI 2.0 protocol, following the
+// default translation rules of the UMP specification.
+// It keeps the state that is needed for the translation (bank select, RPN and NRPN selection,
+// system exclusive messages that are split into multiple packets), therefore a Translator should be used for
+// a single stream of messages. It is not safe for concurrent use.
+type Translator struct {
+	channels [16][16]channelState
+	sysex    [16][]byte
+	inSysEx  [16]bool
+}
+
+type channelState struct {
+	bankMSB  uint8
+	bankLSB  uint8
+	hasBank  bool
+	paramMSB uint8
+	paramLSB uint8
+	param    uint8
+	dataMSB  uint8
+}
+
+const (
+	paramNone = iota
+	paramRegistered
+	paramAssignable
+)
+
+// ToMIDI2 translates the given MIDI 1.0 message within the given group to packets using the MIDI 2.0 protocol.
+// Channel voice messages are translated to MIDI 2.0 channel voice messages with upscaled values.
+// Bank select messages are combined with the following program change, RPN and NRPN data entry messages are
+// translated to registered and assignable controller messages. Therefore some messages return no packets.
+// A note on with velocity 0 is translated to a note off with velocity 0x8000.
+// System messages are translated to system packets and system exclusive messages to SysEx7 packets.
+func (t *Translator) ToMIDI2(group uint8, msg midi.Message) []Packet {
+	group &= 0xF
+
+	var channel, key, val, controller uint8
+	var rel int16
+	var abs uint16
+
+	switch {
+	case msg.GetNoteStart(&channel, &key, &val):
+		return []Packet{NoteOn(group, channel, key, uint16(ScaleUp(uint32(val), 7, 16)))}
+	case msg.GetNoteOn(&channel, &key, nil):
+		// note on with velocity 0
+		return []Packet{NoteOff(group, channel, key, 0x8000)}
+	case msg.GetNoteOff(&channel, &key, &val):
+		return []Packet{NoteOff(group, channel, key, uint16(ScaleUp(uint32(val), 7, 16)))}
+	case msg.GetPolyAfterTouch(&channel, &key, &val):
+		return []Packet{PolyPressure(group, channel, key, ScaleUp(uint32(val), 7, 32))}
+	case msg.GetAfterTouch(&channel, &val):
+		return []Packet{ChannelPressure(group, channel, ScaleUp(uint32(val), 7, 32))}
+	case msg.GetPitchBend(&channel, &rel, &abs):
+		return []Packet{PitchBend(group, channel, ScaleUp(uint32(abs), 14, 32))}
+	case msg.GetProgramChange(&channel, &val):
+		st := &t.channels[group][channel]
+		if st.hasBank {
+			return []Packet{ProgramChangeWithBank(group, channel, val, st.bankMSB, st.bankLSB)}
+		}
+		return []Packet{ProgramChange(group, channel, val)}
+	case msg.GetControlChange(&channel, &controller, &val):
+		return t.controlChange(group, channel, controller, val)
+	case len(msg) > 0 && msg[0] == 0xF0:
+		return SysEx7(group, msg)
+	default:
+		p := MIDI1(group, msg)
+		if p == nil {
+			return nil
+		}
+		return []Packet{p}
+	}
+}
+
+func (t *Translator) controlChange(group, channel, controller, val uint8) []Packet {
+	st := &t.channels[group][channel]
+
+	switch controller {
+	case 0:
+		st.bankMSB, st.hasBank = val, true
+		return nil
+	case 32:
+		st.bankLSB = val
+		return nil
+	case 101, 100:
+		if controller == 101 {
+			st.paramMSB = val
+		} else {
+			st.paramLSB = val
+		}
+		st.param = paramRegistered
+		if st.paramMSB == 127 && st.paramLSB == 127 {
+			// RPN null
+			st.param = paramNone
+		}
+		return nil
+	case 99, 98:
+		if controller == 99 {
+			st.paramMSB = val
+		} else {
+			st.paramLSB = val
+		}
+		st.param = paramAssignable
+		return nil
+	case 6, 38:
+		if st.param == paramNone {
+			break
+		}
+
+		lsb := uint8(0)
+		if controller == 6 {
+			st.dataMSB = val
+		} else {
+			lsb = val
+		}
+
+		value := ScaleUp(uint32(st.dataMSB)<<7|uint32(lsb), 14, 32)
+
+		if st.param == paramRegistered {
+			return []Packet{RegisteredController(group, channel, st.paramMSB, st.paramLSB, value)}
+		}
+		return []Packet{AssignableController(group, channel, st.paramMSB, st.paramLSB, value)}
+	}
+
+	return []Packet{ControlChange(group, channel, controller, ScaleUp(uint32(val), 7, 32))}
+}
+
+// ToMIDI1 translates the given packet to MIDI 1.0 messages.
+// MIDI 2.0 channel voice messages are translated with downscaled values. A program change with bank
+// is translated to bank select messages followed by the program change, registered and assignable controllers
+// are translated to RPN and NRPN messages. A MIDI 2.0 note on with a velocity that would be 0 is sent with velocity 1.
+// The per-note and relative controllers and the per-note management have no MIDI 1.0 equivalent and
+// return no messages.
+// SysEx7 packets are collected until the system exclusive message is complete.
+// Other packets return no messages.
+func (t *Translator) ToMIDI1(p Packet) []midi.Message {
+	var group, channel, key, index, status uint8
+	var vel16 uint16
+	var val uint32
+	var msg midi.Message
+	var data []byte
+
+	switch {
+	case p.GetMIDI1(nil, &msg):
+		return []midi.Message{msg}
+	case p.GetSysEx7(&group, &status, &data):
+		return t.sysEx7(group, status, data)
+	case p.GetNoteOn(nil, &channel, &key, &vel16):
+		vel := uint8(ScaleDown(uint32(vel16), 16, 7))
+		if vel == 0 {
+			vel = 1
+		}
+		return []midi.Message{midi.NoteOn(channel, key, vel)}
+	case p.GetNoteOff(nil, &channel, &key, &vel16):
+		return []midi.Message{midi.NoteOffVelocity(channel, key, uint8(ScaleDown(uint32(vel16), 16, 7)))}
+	case p.GetPolyPressure(nil, &channel, &key, &val):
+		return []midi.Message{midi.PolyAfterTouch(channel, key, uint8(ScaleDown(val, 32, 7)))}
+	case p.GetControlChange(nil, &channel, &key, &val):
+		return []midi.Message{midi.ControlChange(channel, key, uint8(ScaleDown(val, 32, 7)))}
+	case p.GetChannelPressure(nil, &channel, &val):
+		return []midi.Message{midi.AfterTouch(channel, uint8(ScaleDown(val, 32, 7)))}
+	case p.GetPitchBend(nil, &channel, &val):
+		v := ScaleDown(val, 32, 14)
+		return []midi.Message{midi.Pitchbend(channel, int16(v)-8192)}
+	case p.GetProgramChange(nil, &channel, &key):
+		var res []midi.Message
+		var msb, lsb uint8
+		if p.GetProgramChangeBank(&msb, &lsb) {
+			res = append(res, midi.ControlChange(channel, 0, msb), midi.ControlChange(channel, 32, lsb))
+		}
+		return append(res, midi.ProgramChange(channel, key))
+	case p.GetRegisteredController(nil, &channel, &key, &index, &val):
+		return parameterMessages(channel, 101, 100, key, index, val)
+	case p.GetAssignableController(nil, &channel, &key, &index, &val):
+		return parameterMessages(channel, 99, 98, key, index, val)
+	default:
+		return nil
+	}
+}
+
+// parameterMessages returns the MIDI 1.0 messages to set the given RPN or NRPN to the given 32-bit value
+func parameterMessages(channel, ccMSB, ccLSB, msb, lsb uint8, val uint32) []midi.Message {
+	v := ScaleDown(val, 32, 14)
+	return []midi.Message{
+		midi.ControlChange(channel, ccMSB, msb),
+		midi.ControlChange(channel, ccLSB, lsb),
+		midi.ControlChange(channel, 6, uint8(v>>7)),
+		midi.ControlChange(channel, 38, uint8(v&0x7F)),
+	}
+}
+
+func (t *Translator) sysEx7(group, status uint8, data []byte) []midi.Message {
+	switch status {
+	case SysExComplete:
+		t.inSysEx[group] = false
+		t.sysex[group] = nil
+		return []midi.Message{midi.SysEx(data)}
+	case SysExStart:
+		t.inSysEx[group] = true
+		t.sysex[group] = append([]byte(nil), data...)
+	case SysExContinue:
+		if t.inSysEx[group] {
+			t.sysex[group] = append(t.sysex[group], data...)
+		}
+	case SysExEnd:
+		if !t.inSysEx[group] {
+			return nil
+		}
+		msg := midi.SysEx(append(t.sysex[group], data...))
+		t.inSysEx[group] = false
+		t.sysex[group] = nil
+		return []midi.Message{msg}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package ump
+
+import (
+	"reflect"
+	"testing"
+
+	"gitlab.com/gomidi/midi/v2"
+)
+
+func TestScale(t *testing.T) {
+	tests := []struct {
+		value    uint32
+		src, dst uint8
+		expected uint32
+	}{
+		{0, 7, 16, 0},
+		{64, 7, 16, 0x8000},
+		{127, 7, 16, 0xFFFF},
+		{1, 7, 16, 0x0200},
+		{0, 7, 32, 0},
+		{64, 7, 32, 0x80000000},
+		{127, 7, 32, 0xFFFFFFFF},
+		{8192, 14, 32, PitchCenter},
+		{16383, 14, 32, 0xFFFFFFFF},
+		{0xFFFF, 16, 7, 127},
+		{0x8000, 16, 7, 64},
+		{0xFFFFFFFF, 32, 14, 16383},
+	}
+
+	for i, test := range tests {
+		got := ScaleUp(test.value, test.src, test.dst)
+
+		if got != test.expected {
+			t.Errorf("[%v] ScaleUp(%v, %v, %v) = %X; wanted %X", i, test.value, test.src, test.dst, got, test.expected)
+		}
+	}
+}
+
+func TestTranslateToMIDI2(t *testing.T) {
+	tests := []struct {
+		in       []midi.Message
+		expected []Packet
+	}{
+		{
+			[]midi.Message{midi.NoteOn(1, 60, 127), midi.NoteOn(1, 60, 0), midi.NoteOffVelocity(1, 61, 64)},
+			[]Packet{NoteOn(0, 1, 60, 0xFFFF), NoteOff(0, 1, 60, 0x8000), NoteOff(0, 1, 61, 0x8000)},
+		},
+		{
+			[]midi.Message{midi.ControlChange(2, 7, 64), midi.Pitchbend(2, 0), midi.AfterTouch(2, 0)},
+			[]Packet{ControlChange(0, 2, 7, 0x80000000), PitchBend(0, 2, PitchCenter), ChannelPressure(0, 2, 0)},
+		},
+		{
+			[]midi.Message{midi.ControlChange(0, 0, 1), midi.ControlChange(0, 32, 2), midi.ProgramChange(0, 5)},
+			[]Packet{ProgramChangeWithBank(0, 0, 5, 1, 2)},
+		},
+		{
+			[]midi.Message{midi.ControlChange(0, 101, 0), midi.ControlChange(0, 100, 0), midi.ControlChange(0, 6, 64)},
+			[]Packet{RegisteredController(0, 0, 0, 0, 0x80000000)},
+		},
+		{
+			[]midi.Message{midi.ControlChange(0, 99, 1), midi.ControlChange(0, 98, 2), midi.ControlChange(0, 6, 0)},
+			[]Packet{AssignableController(0, 0, 1, 2, 0)},
+		},
+		{
+			[]midi.Message{midi.ControlChange(0, 101, 127), midi.ControlChange(0, 100, 127), midi.ControlChange(0, 6, 0)},
+			[]Packet{ControlChange(0, 0, 6, 0)},
+		},
+		{
+			[]midi.Message{midi.TimingClock(), midi.SysEx([]byte{0x7E, 0x7F, 0x06, 0x01})},
+			[]Packet{MIDI1(0, midi.TimingClock()), SysEx7(0, []byte{0x7E, 0x7F, 0x06, 0x01})[0]},
+		},
+	}
+
+	for i, test := range tests {
+		var tr Translator
+		var got []Packet
+
+		for _, msg := range test.in {
+			got = append(got, tr.ToMIDI2(0, msg)...)
+		}
+
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("[%v] ToMIDI2() = %v; wanted %v", i, got, test.expected)
+		}
+	}
+}
+
+func TestTranslateToMIDI1(t *testing.T) {
+	sysex := []byte{0x41, 0x10, 0x42, 0x12, 0x40, 0x00, 0x7F, 0x00, 0x41}
+
+	tests := []struct {
+		in       []Packet
+		expected []midi.Message
+	}{
+		{
+			[]Packet{NoteOn(0, 1, 60, 0xFFFF), NoteOn(0, 1, 61, 1), NoteOff(0, 1, 60, 0x8000)},
+			[]midi.Message{midi.NoteOn(1, 60, 127), midi.NoteOn(1, 61, 1), midi.NoteOffVelocity(1, 60, 64)},
+		},
+		{
+			[]Packet{ControlChange(0, 2, 7, 0x80000000), PitchBend(0, 2, PitchCenter), PolyPressure(0, 2, 60, 0xFFFFFFFF)},
+			[]midi.Message{midi.ControlChange(2, 7, 64), midi.Pitchbend(2, 0), midi.PolyAfterTouch(2, 60, 127)},
+		},
+		{
+			[]Packet{ProgramChangeWithBank(0, 3, 5, 1, 2)},
+			[]midi.Message{midi.ControlChange(3, 0, 1), midi.ControlChange(3, 32, 2), midi.ProgramChange(3, 5)},
+		},
+		{
+			[]Packet{RegisteredController(0, 0, 0, 1, 0x80000000)},
+			[]midi.Message{midi.ControlChange(0, 101, 0), midi.ControlChange(0, 100, 1), midi.ControlChange(0, 6, 64), midi.ControlChange(0, 38, 0)},
+		},
+		{
+			[]Packet{PerNotePitchBend(0, 0, 60, PitchCenter), MIDI1(0, midi.Start())},
+			[]midi.Message{midi.Start()},
+		},
+		{
+			SysEx7(0, sysex),
+			[]midi.Message{midi.SysEx(sysex)},
+		},
+	}
+
+	for i, test := range tests {
+		var tr Translator
+		var got []midi.Message
+
+		for _, p := range test.in {
+			got = append(got, tr.ToMIDI1(p)...)
+		}
+
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("[%v] ToMIDI1() = %v; wanted %v", i, got, test.expected)
+		}
+	}
+}
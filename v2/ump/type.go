@@ -0,0 +1,276 @@
+package ump
+
+// Type is the type of a packet
+type Type int
+
+// Is returns true, if the type correspond to the given type.
+// The message types (e.g. MIDI2ChannelVoiceMsg) match all the types of their category.
+func (t Type) Is(checker Type) bool {
+	if t == checker {
+		return true
+	}
+
+	switch checker {
+	case UtilityMsg:
+		return t > UtilityMsg && t < SystemMsg
+	case SystemMsg:
+		return t > SystemMsg && t < MIDI1ChannelVoiceMsg
+	case MIDI1ChannelVoiceMsg:
+		return t > MIDI1ChannelVoiceMsg && t < Data64Msg
+	case Data64Msg:
+		return t > Data64Msg && t < MIDI2ChannelVoiceMsg
+	case MIDI2ChannelVoiceMsg:
+		return t > MIDI2ChannelVoiceMsg && t < Data128Msg
+	case Data128Msg:
+		return t > Data128Msg && t < FlexDataMsg
+	case FlexDataMsg:
+		return t > FlexDataMsg && t < StreamMsg
+	case StreamMsg:
+		return t > StreamMsg && t <= EndOfClipMsg
+	default:
+		return false
+	}
+}
+
+// String returns the name of the type.
+func (t Type) String() string {
+	if s, has := typeNames[t]; has {
+		return s
+	}
+	return "unknown"
+}
+
+const (
+	// UnknownMsg is an invalid or unknown packet
+	UnknownMsg Type = iota
+
+	// UtilityMsg is a utility message (message type 0x0)
+	UtilityMsg
+	NOOPMsg
+	JRClockMsg
+	JRTimestampMsg
+	DeltaClockstampTPQMsg
+	DeltaClockstampMsg
+
+	// SystemMsg is a system real time or system common message (message type 0x1)
+	SystemMsg
+	MTCMsg
+	SongPositionMsg
+	SongSelectMsg
+	TuneRequestMsg
+	TimingClockMsg
+	StartMsg
+	ContinueMsg
+	StopMsg
+	ActiveSensingMsg
+	ResetMsg
+
+	// MIDI1ChannelVoiceMsg is a MIDI 1.0 channel voice message (message type 0x2)
+	MIDI1ChannelVoiceMsg
+	MIDI1NoteOffMsg
+	MIDI1NoteOnMsg
+	MIDI1PolyPressureMsg
+	MIDI1ControlChangeMsg
+	MIDI1ProgramChangeMsg
+	MIDI1ChannelPressureMsg
+	MIDI1PitchBendMsg
+
+	// Data64Msg is a 64-bit data message (message type 0x3)
+	Data64Msg
+	SysEx7Msg
+
+	// MIDI2ChannelVoiceMsg is a MIDI 2.0 channel voice message (message type 0x4)
+	MIDI2ChannelVoiceMsg
+	RegisteredPerNoteControllerMsg
+	AssignablePerNoteControllerMsg
+	RegisteredControllerMsg
+	AssignableControllerMsg
+	RelativeRegisteredControllerMsg
+	RelativeAssignableControllerMsg
+	PerNotePitchBendMsg
+	NoteOffMsg
+	NoteOnMsg
+	PolyPressureMsg
+	ControlChangeMsg
+	ProgramChangeMsg
+	ChannelPressureMsg
+	PitchBendMsg
+	PerNoteManagementMsg
+
+	// Data128Msg is a 128-bit data message (message type 0x5)
+	Data128Msg
+	SysEx8Msg
+	MixedDataSetHeaderMsg
+	MixedDataSetPayloadMsg
+
+	// FlexDataMsg is a flex data message (message type 0xD)
+	FlexDataMsg
+	TempoMsg
+	TimeSignatureMsg
+	KeySignatureMsg
+	MetadataTextMsg
+	PerformanceTextMsg
+
+	// StreamMsg is a UMP stream message (message type 0xF)
+	StreamMsg
+	EndpointDiscoveryMsg
+	EndpointInfoMsg
+	DeviceIdentityMsg
+	EndpointNameMsg
+	ProductInstanceIDMsg
+	StreamConfigRequestMsg
+	StreamConfigNotificationMsg
+	FunctionBlockDiscoveryMsg
+	FunctionBlockInfoMsg
+	FunctionBlockNameMsg
+	StartOfClipMsg
+	EndOfClipMsg
+)
+
+var typeNames = map[Type]string{
+	UnknownMsg: "Unknown",
+
+	UtilityMsg:            "Utility",
+	NOOPMsg:               "NOOP",
+	JRClockMsg:            "JRClock",
+	JRTimestampMsg:        "JRTimestamp",
+	DeltaClockstampTPQMsg: "DeltaClockstampTPQ",
+	DeltaClockstampMsg:    "DeltaClockstamp",
+
+	SystemMsg:        "System",
+	MTCMsg:           "MTC",
+	SongPositionMsg:  "SongPosition",
+	SongSelectMsg:    "SongSelect",
+	TuneRequestMsg:   "TuneRequest",
+	TimingClockMsg:   "TimingClock",
+	StartMsg:         "Start",
+	ContinueMsg:      "Continue",
+	StopMsg:          "Stop",
+	ActiveSensingMsg: "ActiveSensing",
+	ResetMsg:         "Reset",
+
+	MIDI1ChannelVoiceMsg:    "MIDI1ChannelVoice",
+	MIDI1NoteOffMsg:         "MIDI1NoteOff",
+	MIDI1NoteOnMsg:          "MIDI1NoteOn",
+	MIDI1PolyPressureMsg:    "MIDI1PolyPressure",
+	MIDI1ControlChangeMsg:   "MIDI1ControlChange",
+	MIDI1ProgramChangeMsg:   "MIDI1ProgramChange",
+	MIDI1ChannelPressureMsg: "MIDI1ChannelPressure",
+	MIDI1PitchBendMsg:       "MIDI1PitchBend",
+
+	Data64Msg: "Data64",
+	SysEx7Msg: "SysEx7",
+
+	MIDI2ChannelVoiceMsg:            "MIDI2ChannelVoice",
+	RegisteredPerNoteControllerMsg:  "RegisteredPerNoteController",
+	AssignablePerNoteControllerMsg:  "AssignablePerNoteController",
+	RegisteredControllerMsg:         "RegisteredController",
+	AssignableControllerMsg:         "AssignableController",
+	RelativeRegisteredControllerMsg: "RelativeRegisteredController",
+	RelativeAssignableControllerMsg: "RelativeAssignableController",
+	PerNotePitchBendMsg:             "PerNotePitchBend",
+	NoteOffMsg:                      "NoteOff",
+	NoteOnMsg:                       "NoteOn",
+	PolyPressureMsg:                 "PolyPressure",
+	ControlChangeMsg:                "ControlChange",
+	ProgramChangeMsg:                "ProgramChange",
+	ChannelPressureMsg:              "ChannelPressure",
+	PitchBendMsg:                    "PitchBend",
+	PerNoteManagementMsg:            "PerNoteManagement",
+
+	Data128Msg:             "Data128",
+	SysEx8Msg:              "SysEx8",
+	MixedDataSetHeaderMsg:  "MixedDataSetHeader",
+	MixedDataSetPayloadMsg: "MixedDataSetPayload",
+
+	FlexDataMsg:        "FlexData",
+	TempoMsg:           "Tempo",
+	TimeSignatureMsg:   "TimeSignature",
+	KeySignatureMsg:    "KeySignature",
+	MetadataTextMsg:    "MetadataText",
+	PerformanceTextMsg: "PerformanceText",
+
+	StreamMsg:                   "Stream",
+	EndpointDiscoveryMsg:        "EndpointDiscovery",
+	EndpointInfoMsg:             "EndpointInfo",
+	DeviceIdentityMsg:           "DeviceIdentity",
+	EndpointNameMsg:             "EndpointName",
+	ProductInstanceIDMsg:        "ProductInstanceID",
+	StreamConfigRequestMsg:      "StreamConfigRequest",
+	StreamConfigNotificationMsg: "StreamConfigNotification",
+	FunctionBlockDiscoveryMsg:   "FunctionBlockDiscovery",
+	FunctionBlockInfoMsg:        "FunctionBlockInfo",
+	FunctionBlockNameMsg:        "FunctionBlockName",
+	StartOfClipMsg:              "StartOfClip",
+	EndOfClipMsg:                "EndOfClip",
+}
+
+var utilityTypes = map[uint8]Type{
+	0x0: NOOPMsg,
+	0x1: JRClockMsg,
+	0x2: JRTimestampMsg,
+	0x3: DeltaClockstampTPQMsg,
+	0x4: DeltaClockstampMsg,
+}
+
+var systemTypes = map[uint8]Type{
+	0xF1: MTCMsg,
+	0xF2: SongPositionMsg,
+	0xF3: SongSelectMsg,
+	0xF6: TuneRequestMsg,
+	0xF8: TimingClockMsg,
+	0xFA: StartMsg,
+	0xFB: ContinueMsg,
+	0xFC: StopMsg,
+	0xFE: ActiveSensingMsg,
+	0xFF: ResetMsg,
+}
+
+var midi1Types = map[uint8]Type{
+	0x8: MIDI1NoteOffMsg,
+	0x9: MIDI1NoteOnMsg,
+	0xA: MIDI1PolyPressureMsg,
+	0xB: MIDI1ControlChangeMsg,
+	0xC: MIDI1ProgramChangeMsg,
+	0xD: MIDI1ChannelPressureMsg,
+	0xE: MIDI1PitchBendMsg,
+}
+
+var midi2Types = map[uint8]Type{
+	0x0: RegisteredPerNoteControllerMsg,
+	0x1: AssignablePerNoteControllerMsg,
+	0x2: RegisteredControllerMsg,
+	0x3: AssignableControllerMsg,
+	0x4: RelativeRegisteredControllerMsg,
+	0x5: RelativeAssignableControllerMsg,
+	0x6: PerNotePitchBendMsg,
+	0x8: NoteOffMsg,
+	0x9: NoteOnMsg,
+	0xA: PolyPressureMsg,
+	0xB: ControlChangeMsg,
+	0xC: ProgramChangeMsg,
+	0xD: ChannelPressureMsg,
+	0xE: PitchBendMsg,
+	0xF: PerNoteManagementMsg,
+}
+
+var flexTypes = map[uint8]Type{
+	0x00: TempoMsg,
+	0x01: TimeSignatureMsg,
+	0x05: KeySignatureMsg,
+}
+
+var streamTypes = map[uint16]Type{
+	0x000: EndpointDiscoveryMsg,
+	0x001: EndpointInfoMsg,
+	0x002: DeviceIdentityMsg,
+	0x003: EndpointNameMsg,
+	0x004: ProductInstanceIDMsg,
+	0x005: StreamConfigRequestMsg,
+	0x006: StreamConfigNotificationMsg,
+	0x010: FunctionBlockDiscoveryMsg,
+	0x011: FunctionBlockInfoMsg,
+	0x012: FunctionBlockNameMsg,
+	0x020: StartOfClipMsg,
+	0x021: EndOfClipMsg,
+}
@@ -0,0 +1,89 @@
+package ump
+
+// NOOP returns a utility message that does nothing.
+func NOOP() Packet {
+	return Packet{word0(UtilityMT, 0, 0x00, 0)}
+}
+
+// JRClock returns a jitter reduction clock message with the given sender clock time
+// (in units of 1/31250 seconds).
+func JRClock(time uint16) Packet {
+	return Packet{word0(UtilityMT, 0, 0x10, time)}
+}
+
+// JRTimestamp returns a jitter reduction timestamp message with the given sender clock time
+// (in units of 1/31250 seconds).
+func JRTimestamp(time uint16) Packet {
+	return Packet{word0(UtilityMT, 0, 0x20, time)}
+}
+
+// DeltaClockstampTPQ returns a message that defines the number of ticks per quarter note of the
+// following delta clockstamps.
+func DeltaClockstampTPQ(ticksPerQuarterNote uint16) Packet {
+	return Packet{word0(UtilityMT, 0, 0x30, ticksPerQuarterNote)}
+}
+
+// DeltaClockstamp returns a message with the number of ticks since the last event (20-bit).
+// If ticks is > 0xFFFFF, it will be set to 0xFFFFF.
+func DeltaClockstamp(ticks uint32) Packet {
+	if ticks > 0xFFFFF {
+		ticks = 0xFFFFF
+	}
+	return Packet{uint32(UtilityMT)<<28 | 0x4<<20 | ticks}
+}
+
+// GetJRClock returns true if (and only if) the packet is a JRClockMsg.
+// Then it also extracts the time to the given argument.
+// Only arguments that are not nil are parsed and filled.
+func (p Packet) GetJRClock(time *uint16) (is bool) {
+	if !p.Is(JRClockMsg) {
+		return false
+	}
+
+	if time != nil {
+		*time = uint16(p[0])
+	}
+	return true
+}
+
+// GetJRTimestamp returns true if (and only if) the packet is a JRTimestampMsg.
+// Then it also extracts the time to the given argument.
+// Only arguments that are not nil are parsed and filled.
+func (p Packet) GetJRTimestamp(time *uint16) (is bool) {
+	if !p.Is(JRTimestampMsg) {
+		return false
+	}
+
+	if time != nil {
+		*time = uint16(p[0])
+	}
+	return true
+}
+
+// GetDeltaClockstampTPQ returns true if (and only if) the packet is a DeltaClockstampTPQMsg.
+// Then it also extracts the ticks per quarter note to the given argument.
+// Only arguments that are not nil are parsed and filled.
+func (p Packet) GetDeltaClockstampTPQ(ticksPerQuarterNote *uint16) (is bool) {
+	if !p.Is(DeltaClockstampTPQMsg) {
+		return false
+	}
+
+	if ticksPerQuarterNote != nil {
+		*ticksPerQuarterNote = uint16(p[0])
+	}
+	return true
+}
+
+// GetDeltaClockstamp returns true if (and only if) the packet is a DeltaClockstampMsg.
+// Then it also extracts the ticks to the given argument.
+// Only arguments that are not nil are parsed and filled.
+func (p Packet) GetDeltaClockstamp(ticks *uint32) (is bool) {
+	if !p.Is(DeltaClockstampMsg) {
+		return false
+	}
+
+	if ticks != nil {
+		*ticks = p[0] & 0xFFFFF
+	}
+	return true
+}